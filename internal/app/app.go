@@ -18,18 +18,22 @@ import (
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/llm/agent"
 	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/prompthistory"
 	"github.com/charmbracelet/crush/internal/pubsub"
 
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
 )
 
 type App struct {
 	Sessions    session.Service
 	Messages    message.Service
 	History     history.Service
+	Todos       todo.Service
+	Prompts     prompthistory.Service
 	Permissions permission.Service
 
 	CoderAgent agent.Service
@@ -45,8 +49,11 @@ type App struct {
 
 	serviceEventsWG *sync.WaitGroup
 	eventsCtx       context.Context
-	events          chan tea.Msg
-	tuiWG           *sync.WaitGroup
+	// cancelAgentEvents stops forwarding the events of the current coder
+	// agent, when it's replaced.
+	cancelAgentEvents context.CancelFunc
+	events            chan tea.Msg
+	tuiWG             *sync.WaitGroup
 
 	// global context and cleanup functions
 	globalCtx    context.Context
@@ -59,6 +66,7 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	sessions := session.NewService(q)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
+	prompts := prompthistory.NewService(q, conn, cfg.WorkingDir(), cfg.Options.PromptHistory.Disabled, cfg.Options.PromptHistory.ExcludePatterns)
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	allowedTools := []string{}
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
@@ -69,6 +77,8 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
+		Todos:       todo.NewService(q),
+		Prompts:     prompts,
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
 		LSPClients:  make(map[string]*lsp.Client),
 
@@ -105,8 +115,9 @@ func (app *App) Config() *config.Config {
 }
 
 // RunNonInteractive handles the execution flow when a prompt is provided via
-// CLI flag.
-func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool) error {
+// CLI flag. When plan is set the agent runs in plan mode and only answers with
+// a plan.
+func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet, plan bool) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -144,10 +155,16 @@ func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool
 		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
 	}
 	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+	if err := app.Prompts.Add(ctx, sess.ID, prompt); err != nil {
+		slog.Error("Failed to save the prompt to the history", "error", err)
+	}
 
 	// Automatically approve all permission requests for this non-interactive session
 	app.Permissions.AutoApproveSession(sess.ID)
 
+	// In plan mode the agent only investigates and prints its plan.
+	app.CoderAgent.SetPlanMode(sess.ID, plan)
+
 	done, err := app.CoderAgent.Run(ctx, sess.ID, prompt)
 	if err != nil {
 		return fmt.Errorf("failed to start agent processing stream: %w", err)
@@ -207,6 +224,7 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "todos", app.Todos.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", agent.SubscribeMCPEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
 	cleanupFunc := func() {
@@ -252,29 +270,58 @@ func setupSubscriber[T any](
 }
 
 func (app *App) InitCoderAgent() error {
-	coderAgentCfg := app.config.Agents["coder"]
+	coderAgentCfg := app.config.PrimaryAgent()
 	if coderAgentCfg.ID == "" {
 		return fmt.Errorf("coder agent configuration is missing")
 	}
-	var err error
-	app.CoderAgent, err = agent.NewAgent(
+	coderAgent, err := agent.NewAgent(
 		app.globalCtx,
 		coderAgentCfg,
 		app.Permissions,
 		app.Sessions,
 		app.Messages,
 		app.History,
+		app.Todos,
 		app.LSPClients,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	if app.CoderAgent == nil {
+		// Add MCP client cleanup to shutdown process
+		app.cleanupFuncs = append(app.cleanupFuncs, agent.CloseMCPClients)
+	} else {
+		// Sessions stay in plan mode across agents.
+		for _, sessionID := range app.CoderAgent.PlanModeSessions() {
+			coderAgent.SetPlanMode(sessionID, true)
+		}
+	}
+	if app.cancelAgentEvents != nil {
+		app.cancelAgentEvents()
+	}
+	app.CoderAgent = coderAgent
 
-	// Add MCP client cleanup to shutdown process
-	app.cleanupFuncs = append(app.cleanupFuncs, agent.CloseMCPClients)
+	ctx, cancel := context.WithCancel(app.eventsCtx)
+	app.cancelAgentEvents = cancel
+	setupSubscriber(ctx, app.serviceEventsWG, "coderAgent", app.CoderAgent.Subscribe, app.events)
+	return nil
+}
 
-	setupSubscriber(app.eventsCtx, app.serviceEventsWG, "coderAgent", app.CoderAgent.Subscribe, app.events)
+// SetPrimaryAgent replaces the agent used for the main conversation with the
+// agent with the given ID.
+func (app *App) SetPrimaryAgent(id string) error {
+	if app.CoderAgent != nil && app.CoderAgent.IsBusy() {
+		return agent.ErrSessionBusy
+	}
+	previous := app.config.PrimaryAgent().ID
+	if err := app.config.SetPrimaryAgent(id); err != nil {
+		return err
+	}
+	if err := app.InitCoderAgent(); err != nil {
+		_ = app.config.SetPrimaryAgent(previous)
+		return fmt.Errorf("failed to switch to agent %s: %w", id, err)
+	}
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +41,12 @@ var exportCmd = &cobra.Command{
 			return fmt.Errorf("failed to get messages: %w", err)
 		}
 
+		// Get the todos
+		todos, err := app.Todos.List(cmd.Context(), sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get todos: %w", err)
+		}
+
 		// Create output filename
 		filename := fmt.Sprintf("session_%s_%s.%s", 
 			sessionID, 
@@ -49,16 +56,16 @@ var exportCmd = &cobra.Command{
 		// Export based on format
 		switch format {
 		case "json":
-			return ExportAsJSON(filename, session, messages)
+			return ExportAsJSON(filename, session, messages, todos)
 		case "markdown":
-			return exportAsMarkdown(filename, session, messages)
+			return exportAsMarkdown(filename, session, messages, todos)
 		default:
 			return fmt.Errorf("unsupported format: %s", format)
 		}
 	},
 }
 
-func exportAsMarkdown(filename string, session session.Session, messages []message.Message) error {
+func exportAsMarkdown(filename string, session session.Session, messages []message.Message, todos []todo.Todo) error {
 	var content strings.Builder
 	
 	// Add session header
@@ -70,6 +77,13 @@ func exportAsMarkdown(filename string, session session.Session, messages []messa
 	content.WriteString(fmt.Sprintf("**Completion Tokens:** %d\n\n", session.CompletionTokens))
 	content.WriteString(fmt.Sprintf("**Cost:** $%.4f\n\n", session.Cost))
 	
+	// Add todos
+	if len(todos) > 0 {
+		content.WriteString("## Todos\n\n")
+		content.WriteString(todo.Markdown(todos, false))
+		content.WriteString("\n")
+	}
+
 	// Add conversation
 	content.WriteString("## Conversation\n\n")
 	
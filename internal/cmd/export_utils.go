@@ -7,13 +7,15 @@ import (
 
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
 )
 
 // ExportAsJSON exports session data to a JSON file
-func ExportAsJSON(filename string, session session.Session, messages []message.Message) error {
+func ExportAsJSON(filename string, session session.Session, messages []message.Message, todos []todo.Todo) error {
 	data := map[string]interface{}{
 		"session":  session,
 		"messages": messages,
+		"todos":    todos,
 	}
 	
 	file, err := os.Create(filename)
@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/agent"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/mcpauth"
+	"github.com/charmbracelet/crush/internal/mcpserver"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers",
+	Long:  `Manage the MCP servers configured for Crush.`,
+}
+
+var mcpListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List MCP servers and their state",
+	Long:  `Start every configured MCP server and show whether it connected, how many tools it has, and why it failed if it did.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if len(cfg.MCP) == 0 {
+			fmt.Println("No MCP servers configured.")
+			return nil
+		}
+
+		startMCPClients(cmd, cfg)
+		defer agent.CloseMCPClients()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tSTATE\tTOOLS\tERROR\t")
+		for _, m := range cfg.MCP.Sorted() {
+			state, _ := agent.GetMCPState(m.Name)
+			var errMsg string
+			switch {
+			case state.State == agent.MCPStateAuthRequired:
+				errMsg = fmt.Sprintf("run crush mcp login %s", m.Name)
+			case state.Error != nil:
+				errMsg = state.Error.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t\n", m.Name, m.MCP.Type, state.State, state.ToolCount, errMsg)
+		}
+		return w.Flush()
+	},
+}
+
+var mcpToolsCmd = &cobra.Command{
+	Use:   "tools <name>",
+	Short: "Show the tools of an MCP server",
+	Long:  `Start an MCP server and print its tools, with their full input schemas, as JSON.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := connectMCPServer(cmd, name); err != nil {
+			return err
+		}
+		defer agent.CloseMCPClients()
+
+		b, err := json.MarshalIndent(agent.MCPServerTools(name), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal tools: %w", err)
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var mcpCallCmd = &cobra.Command{
+	Use:   "call <name> <tool> [json-arguments]",
+	Short: "Call a tool of an MCP server",
+	Long: `Start an MCP server and call one of its tools with the given JSON arguments.
+Text content is printed as is, other content as JSON.`,
+	Example: `crush mcp call github search_issues '{"query": "is:open label:bug"}'`,
+	Args:    cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, tool := args[0], args[1]
+		input := "{}"
+		if len(args) == 3 {
+			input = args[2]
+		}
+		var toolArgs map[string]any
+		if err := json.Unmarshal([]byte(input), &toolArgs); err != nil {
+			return fmt.Errorf("invalid arguments: %w", err)
+		}
+
+		if err := connectMCPServer(cmd, name); err != nil {
+			return err
+		}
+		defer agent.CloseMCPClients()
+
+		result, err := agent.CallMCPTool(cmd.Context(), name, tool, toolArgs)
+		if err != nil {
+			return fmt.Errorf("failed to call %s: %w", tool, err)
+		}
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				fmt.Println(text.Text)
+				continue
+			}
+			b, err := json.MarshalIndent(content, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal content: %w", err)
+			}
+			fmt.Println(string(b))
+		}
+		if result.IsError {
+			return fmt.Errorf("tool %s returned an error", tool)
+		}
+		return nil
+	},
+}
+
+var mcpAddCmd = &cobra.Command{
+	Use:   "add <name> [-- command [args...]]",
+	Short: "Add an MCP server to the configuration",
+	Long: `Add an MCP server to the configuration after checking that the entry is valid.
+Stdio servers take their command after --, HTTP and SSE servers take a --url.`,
+	Example: `crush mcp add filesystem -- npx -y @modelcontextprotocol/server-filesystem .
+crush mcp add github --type http --url https://api.githubcopilot.com/mcp/ --header 'Authorization=Bearer $GITHUB_TOKEN'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := config.ValidateMCPName(name); err != nil {
+			return err
+		}
+
+		mcpType, _ := cmd.Flags().GetString("type")
+		url, _ := cmd.Flags().GetString("url")
+		headers, _ := cmd.Flags().GetStringToString("header")
+		env, _ := cmd.Flags().GetStringToString("env")
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		disabled, _ := cmd.Flags().GetBool("disabled")
+		force, _ := cmd.Flags().GetBool("force")
+
+		// Servers with a URL are HTTP servers unless told otherwise.
+		defaultType := config.MCPStdio
+		if url != "" {
+			defaultType = config.MCPHttp
+		}
+		m := config.MCPConfig{
+			Type:     cmp.Or(config.MCPType(mcpType), defaultType),
+			URL:      url,
+			Headers:  headers,
+			Env:      env,
+			Timeout:  timeout,
+			Disabled: disabled,
+		}
+		if len(args) > 1 {
+			m.Command = args[1]
+			m.Args = args[2:]
+		}
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid mcp %s: %w", name, err)
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if _, exists := cfg.MCP[name]; exists && !force {
+			return fmt.Errorf("mcp '%s' already exists, use --force to replace it", name)
+		}
+		if err := cfg.SetConfigField("mcp."+name, m); err != nil {
+			return fmt.Errorf("failed to save mcp %s: %w", name, err)
+		}
+
+		fmt.Printf("Added MCP server %s. Check it with: crush mcp list\n", name)
+		return nil
+	},
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve Crush's tools and sessions over MCP",
+	Long: `Run Crush as an MCP server so that other agents and editors can use its tools and read its sessions.
+It serves a single client over stdio by default, or any number of clients over streamable HTTP with --http.
+Tool calls follow the permission settings of the configuration; calls that would ask for permission are denied unless --yolo is set.`,
+	Example: `crush mcp serve
+crush mcp serve --http 127.0.0.1:8765`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		httpAddr, _ := cmd.Flags().GetString("http")
+		ctx := cmd.Context()
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		cfg := app.Config()
+		serverTools := agent.CoreTools(cfg.WorkingDir(), app.Permissions, app.History, app.Todos, app.LSPClients)
+		// LSP clients start in the background, so they might not be there
+		// yet.
+		if len(app.LSPClients) == 0 && len(cfg.LSP) > 0 {
+			serverTools = append(serverTools, tools.NewDiagnosticsTool(app.LSPClients))
+		}
+		srv := mcpserver.New(ctx, app.Sessions, app.Messages, app.Permissions, serverTools)
+
+		if httpAddr == "" {
+			return srv.ServeStdio(ctx, os.Stdin, os.Stdout)
+		}
+
+		httpServer := &http.Server{
+			Addr:              httpAddr,
+			Handler:           srv.HTTPHandler("/mcp"),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			_ = httpServer.Close()
+		}()
+		fmt.Fprintf(os.Stderr, "Serving MCP at http://%s/mcp\n", httpAddr)
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve mcp: %w", err)
+		}
+		return nil
+	},
+}
+
+var mcpLoginCmd = &cobra.Command{
+	Use:   "login <name>",
+	Short: "Log in to a remote MCP server",
+	Long: `Authorize Crush to access an HTTP or SSE MCP server with OAuth.
+The authorization page opens in your browser, and the token is stored in the data directory and refreshed automatically.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		cfg, m, err := loadMCPConfig(cmd, name)
+		if err != nil {
+			return err
+		}
+
+		err = mcpauth.Login(cmd.Context(), cfg.Options.DataDirectory, name, m, func(u string) error {
+			fmt.Printf("Opening the authorization page of %s in your browser. If it does not open, visit:\n\n  %s\n\n", name, u)
+			_ = mcpauth.OpenBrowser(u)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to log in to %s: %w", name, err)
+		}
+
+		fmt.Printf("Logged in to %s. Restart it from the command palette or restart Crush to use it.\n", name)
+		return nil
+	},
+}
+
+var mcpLogoutCmd = &cobra.Command{
+	Use:   "logout <name>",
+	Short: "Log out of a remote MCP server",
+	Long:  `Delete the OAuth credentials stored for an MCP server.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		cfg, _, err := loadMCPConfig(cmd, name)
+		if err != nil {
+			return err
+		}
+		if err := mcpauth.Logout(cfg.Options.DataDirectory, name); err != nil {
+			return fmt.Errorf("failed to log out of %s: %w", name, err)
+		}
+		fmt.Printf("Logged out of %s.\n", name)
+		return nil
+	},
+}
+
+// loadConfig loads the configuration of the working directory.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return config.Init(cwd, debug)
+}
+
+// loadMCPConfig loads the configuration and returns the named MCP server.
+func loadMCPConfig(cmd *cobra.Command, name string) (*config.Config, config.MCPConfig, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, config.MCPConfig{}, err
+	}
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return nil, config.MCPConfig{}, fmt.Errorf("mcp '%s' not found", name)
+	}
+	return cfg, m, nil
+}
+
+// startMCPClients starts the MCP servers of the configuration and waits for
+// them to connect or fail.
+func startMCPClients(cmd *cobra.Command, cfg *config.Config) {
+	permissions := permission.NewPermissionService(cfg.WorkingDir(), false, nil)
+	agent.InitMCPClients(cmd.Context(), permissions, cfg)
+}
+
+// connectMCPServer starts only the named MCP server and returns an error
+// explaining why if it does not connect. The caller closes the client once
+// it is done with it.
+func connectMCPServer(cmd *cobra.Command, name string) error {
+	cfg, m, err := loadMCPConfig(cmd, name)
+	if err != nil {
+		return err
+	}
+	if m.Disabled {
+		return fmt.Errorf("mcp '%s' is disabled", name)
+	}
+
+	single := *cfg
+	single.MCP = config.MCPs{name: m}
+	startMCPClients(cmd, &single)
+
+	state, _ := agent.GetMCPState(name)
+	if state.State == agent.MCPStateConnected {
+		return nil
+	}
+	agent.CloseMCPClients()
+	if state.State == agent.MCPStateAuthRequired {
+		return fmt.Errorf("mcp '%s' requires authorization, run: crush mcp login %s", name, name)
+	}
+	return fmt.Errorf("mcp '%s' failed to connect: %w", name, cmp.Or(state.Error, errors.New(state.State.String())))
+}
+
+func init() {
+	mcpAddCmd.Flags().String("type", "", "Type of the server: stdio, http or sse (http when --url is set, stdio otherwise)")
+	mcpAddCmd.Flags().String("url", "", "URL of an http or sse server")
+	mcpAddCmd.Flags().StringToString("header", nil, "HTTP header to send, as key=value (repeatable)")
+	mcpAddCmd.Flags().StringToString("env", nil, "Environment variable of a stdio server, as key=value (repeatable)")
+	mcpAddCmd.Flags().Int("timeout", 0, "Timeout in seconds for the connection")
+	mcpAddCmd.Flags().Bool("disabled", false, "Add the server disabled")
+	mcpAddCmd.Flags().Bool("force", false, "Replace an existing server with the same name")
+
+	mcpServeCmd.Flags().String("http", "", "Serve over streamable HTTP at this address instead of stdio")
+	mcpServeCmd.Flags().BoolP("yolo", "y", false, "Allow every tool call without asking (dangerous mode)")
+
+	mcpCmd.AddCommand(mcpListCmd)
+	mcpCmd.AddCommand(mcpToolsCmd)
+	mcpCmd.AddCommand(mcpCallCmd)
+	mcpCmd.AddCommand(mcpAddCmd)
+	mcpCmd.AddCommand(mcpServeCmd)
+	mcpCmd.AddCommand(mcpLoginCmd)
+	mcpCmd.AddCommand(mcpLogoutCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
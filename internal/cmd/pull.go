@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/ollama"
+	"github.com/spf13/cobra"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <model>",
+	Short: "Pull a model into Ollama",
+	Long: `Download a model into the Ollama server of a provider of type "ollama", showing the progress.
+The model is available to Crush the next time it starts.`,
+	Example: `
+# Pull a model into the Ollama provider, or into OLLAMA_HOST without one
+crush providers pull qwen3:8b
+
+# Pull a model into a given provider
+crush providers pull --provider gpu-box gpt-oss:20b
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		providerID, _ := cmd.Flags().GetString("provider")
+		baseURL, headers, err := ollamaEndpoint(cfg, providerID)
+		if err != nil {
+			return err
+		}
+
+		model := args[0]
+		out := cmd.ErrOrStderr()
+		client := ollama.NewClient(baseURL, nil, headers)
+		status := ""
+		err = client.Pull(cmd.Context(), model, func(p ollama.PullProgress) {
+			if p.Status != status {
+				if status != "" {
+					fmt.Fprintln(out)
+				}
+				status = p.Status
+			}
+			if p.Total > 0 {
+				fmt.Fprintf(out, "\r%s: %3d%% (%.1f/%.1f MB)", p.Status,
+					p.Completed*100/p.Total, float64(p.Completed)/1e6, float64(p.Total)/1e6)
+				return
+			}
+			fmt.Fprintf(out, "\r%s", p.Status)
+		})
+		if status != "" {
+			fmt.Fprintln(out)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to pull %s: %w", model, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Pulled %s from %s\n", model, baseURL)
+		return nil
+	},
+}
+
+// ollamaEndpoint returns the endpoint of the given Ollama provider and the
+// headers its requests need. Without a provider given, there must be at most
+// one Ollama provider; without any it falls back to OLLAMA_HOST.
+func ollamaEndpoint(cfg *config.Config, providerID string) (string, map[string]string, error) {
+	var ids []string
+	for id, p := range cfg.Providers.Seq2() {
+		if p.Type == config.TypeOllama && (providerID == "" || id == providerID) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	switch {
+	case len(ids) == 0 && providerID != "":
+		return "", nil, fmt.Errorf("ollama provider '%s' not found", providerID)
+	case len(ids) == 0:
+		return ollama.Host(os.Getenv("OLLAMA_HOST")), nil, nil
+	case len(ids) > 1:
+		return "", nil, fmt.Errorf("there are several ollama providers, choose one with --provider: %s", strings.Join(ids, ", "))
+	}
+
+	p, _ := cfg.Providers.Get(ids[0])
+	baseURL, err := cfg.Resolve(p.BaseURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve the endpoint of %s: %w", p.ID, err)
+	}
+	apiKey, err := cfg.Resolve(p.APIKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve the API key of %s: %w", p.ID, err)
+	}
+	headers := make(map[string]string, len(p.ExtraHeaders))
+	for key, value := range p.ExtraHeaders {
+		if headers[key], err = cfg.Resolve(value); err != nil {
+			return "", nil, fmt.Errorf("failed to resolve header %s of %s: %w", key, p.ID, err)
+		}
+	}
+	return baseURL, ollama.Headers(apiKey, headers), nil
+}
+
+func init() {
+	pullCmd.Flags().String("provider", "", "ID of the Ollama provider to pull into")
+	providersCmd.AddCommand(pullCmd)
+}
@@ -22,9 +22,13 @@ echo "What is this code doing?" | crush run
 
 # Run with quiet mode (no spinner)
 crush run -q "Generate a README for this project"
+
+# Only plan the change, without modifying any files
+crush run --plan "Add a --verbose flag to the CLI"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		plan, _ := cmd.Flags().GetBool("plan")
 
 		app, err := setupApp(cmd)
 		if err != nil {
@@ -49,10 +53,11 @@ crush run -q "Generate a README for this project"
 		}
 
 		// Run non-interactive flow using the App method
-		return app.RunNonInteractive(cmd.Context(), prompt, quiet)
+		return app.RunNonInteractive(cmd.Context(), prompt, quiet, plan)
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().Bool("plan", false, "Answer with a plan without modifying any files")
 }
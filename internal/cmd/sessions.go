@@ -187,11 +187,18 @@ var exportAllSessionsCmd = &cobra.Command{
 				continue
 			}
 
+			// Get the todos
+			todos, err := app.Todos.List(cmd.Context(), session.ID)
+			if err != nil {
+				fmt.Printf("Failed to get todos for session '%s' (%s): %v\n", session.Title, session.ID, err)
+				continue
+			}
+
 			// Create filename
 			filename := fmt.Sprintf("session_%s_%s.json", session.ID, time.Now().Format("20060102_150405"))
 
 			// Export session
-			err = ExportAsJSON(filename, session, messages)
+			err = ExportAsJSON(filename, session, messages, todos)
 			if err != nil {
 				fmt.Printf("Failed to export session '%s' (%s): %v\n", session.Title, session.ID, err)
 			} else {
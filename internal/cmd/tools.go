@@ -42,6 +42,7 @@ var listToolsCmd = &cobra.Command{
 			{"grep", "Search for patterns in files"},
 			{"ls", "List directory contents"},
 			{"sourcegraph", "Search code with Sourcegraph"},
+			{"todos", "Track the steps of a task in a checklist"},
 			{"diagnostics", "Get LSP diagnostics for files"},
 			{"agent", "Launch a new agent with a subset of tools"},
 			{"debugger", "Launch a specialized debugging agent"},
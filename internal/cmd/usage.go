@@ -27,12 +27,17 @@ var usageCmd = &cobra.Command{
 
 		var totalPromptTokens, totalCompletionTokens int64
 		var totalCost float64
+		var cacheReadTokens, cacheCreationTokens int64
+		var cacheSavings float64
 
 		// Calculate totals
 		for _, session := range sessions {
 			totalPromptTokens += session.PromptTokens
 			totalCompletionTokens += session.CompletionTokens
 			totalCost += session.Cost
+			cacheReadTokens += session.CacheReadTokens
+			cacheCreationTokens += session.CacheCreationTokens
+			cacheSavings += session.CacheSavings
 		}
 
 		// Create a new tabwriter
@@ -42,6 +47,9 @@ var usageCmd = &cobra.Command{
 		fmt.Fprintf(w, "Total Prompt Tokens\t%d\t\n", totalPromptTokens)
 		fmt.Fprintf(w, "Total Completion Tokens\t%d\t\n", totalCompletionTokens)
 		fmt.Fprintf(w, "Total Cost\t$%.4f\t\n", totalCost)
+		fmt.Fprintf(w, "Cache Read Tokens\t%d\t\n", cacheReadTokens)
+		fmt.Fprintf(w, "Cache Write Tokens\t%d\t\n", cacheCreationTokens)
+		fmt.Fprintf(w, "Cache Savings\t$%.4f\t\n", cacheSavings)
 
 		// Flush the tabwriter's buffer to ensure all output is printed
 		return w.Flush()
@@ -2,11 +2,13 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/ollama"
 	"github.com/tidwall/sjson"
 )
 
@@ -64,6 +67,47 @@ type SelectedModel struct {
 
 	// Used by anthropic models that can reason to indicate if the model should think.
 	Think bool `json:"think,omitempty" jsonschema:"description=Enable thinking mode for Anthropic models that support reasoning"`
+
+	// The API used to talk to openai providers, overriding the one of the
+	// provider.
+	API string `json:"api,omitempty" jsonschema:"description=API used for this model with OpenAI providers (overrides the provider setting),enum=chat_completions,enum=responses"`
+}
+
+// TypeOllama is the type of providers that serve local models with Ollama.
+// Their models are discovered from the server.
+const TypeOllama catwalk.Type = "ollama"
+
+// TypeMock is the type of providers that answer from a YAML script instead
+// of a model, for tests and demos.
+const TypeMock catwalk.Type = "mock"
+
+// APIs used to talk to openai providers.
+const (
+	APIChatCompletions = "chat_completions"
+	APIResponses       = "responses"
+)
+
+// Cassette modes.
+const (
+	CassetteModeReplay = "replay"
+	CassetteModeRecord = "record"
+	CassetteModeAuto   = "auto"
+)
+
+// CassetteConfig makes a provider record its exchanges to a file and replay
+// them, so that tests can run without network access or API keys.
+type CassetteConfig struct {
+	// Path of the cassette file.
+	Path string `json:"path" jsonschema:"required,description=Path of the cassette file,example=testdata/cassettes/tools.json"`
+	// Replay fails on unknown requests; record calls the provider and
+	// overwrites the cassette; auto replays what it can and records the rest.
+	Mode string `json:"mode,omitempty" jsonschema:"description=Whether to replay or record exchanges,enum=replay,enum=record,enum=auto,default=replay"`
+}
+
+// ReplayOnly reports whether the provider only replays the cassette, in which
+// case it's never called and needs no API key.
+func (c *CassetteConfig) ReplayOnly() bool {
+	return c != nil && (c.Mode == "" || c.Mode == CassetteModeReplay)
 }
 
 type ProviderConfig struct {
@@ -74,7 +118,7 @@ type ProviderConfig struct {
 	// The provider's API endpoint.
 	BaseURL string `json:"base_url,omitempty" jsonschema:"description=Base URL for the provider's API,format=uri,example=https://api.openai.com/v1"`
 	// The provider type, e.g. "openai", "anthropic", etc. if empty it defaults to openai.
-	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,default=openai"`
+	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,enum=ollama,default=openai"`
 	// The provider's API key.
 	APIKey string `json:"api_key,omitempty" jsonschema:"description=API key for authentication with the provider,example=$OPENAI_API_KEY"`
 	// Marks the provider as disabled.
@@ -83,6 +127,19 @@ type ProviderConfig struct {
 	// Custom system prompt prefix.
 	SystemPromptPrefix string `json:"system_prompt_prefix,omitempty" jsonschema:"description=Custom prefix to add to system prompts for this provider"`
 
+	// The API used to talk to openai providers, Chat Completions unless set.
+	API string `json:"api,omitempty" jsonschema:"description=API used for OpenAI providers,enum=chat_completions,enum=responses,default=chat_completions"`
+
+	// How long Ollama keeps the model loaded after a request, e.g. "5m" or
+	// "-1" to keep it loaded. Only used by ollama providers.
+	KeepAlive string `json:"keep_alive,omitempty" jsonschema:"description=How long Ollama keeps the model in memory after a request (ollama providers only),example=30m"`
+
+	// Path of the YAML script that mock providers answer from.
+	Script string `json:"script,omitempty" jsonschema:"description=Path of the YAML script answering requests (mock providers only),example=testdata/script.yaml"`
+
+	// Records the exchanges with the provider to replay them offline.
+	Cassette *CassetteConfig `json:"cassette,omitempty" jsonschema:"description=Record and replay the exchanges with this provider"`
+
 	// Extra headers to send with each request to the provider.
 	ExtraHeaders map[string]string `json:"extra_headers,omitempty" jsonschema:"description=Additional HTTP headers to send with requests"`
 	// Extra body
@@ -114,6 +171,56 @@ type MCPConfig struct {
 
 	// TODO: maybe make it possible to get the value from the env
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	OAuth *MCPOAuthConfig `json:"oauth,omitempty" jsonschema:"description=OAuth settings for HTTP/SSE MCP servers that require authorization"`
+}
+
+// MCPOAuthConfig configures the OAuth authorization of a remote MCP server.
+// Servers that support dynamic client registration need no configuration.
+type MCPOAuthConfig struct {
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=Client ID registered with the authorization server (registered dynamically when empty)"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=Client secret for confidential clients"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=Scopes to request"`
+	RedirectPort int      `json:"redirect_port,omitempty" jsonschema:"description=Port of the local redirect listener (random when empty),example=8976"`
+}
+
+var mcpNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateMCPName reports whether name can be used for an MCP server. Names
+// end up in tool names, so only letters, digits, dashes and underscores are
+// allowed.
+func ValidateMCPName(name string) error {
+	if !mcpNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid mcp name %q: only letters, digits, '-' and '_' are allowed", name)
+	}
+	return nil
+}
+
+// Validate checks that the configuration has what its type of server needs.
+func (m MCPConfig) Validate() error {
+	switch m.Type {
+	case MCPStdio:
+		if m.Command == "" {
+			return errors.New("stdio mcp requires a command")
+		}
+		if m.URL != "" {
+			return errors.New("stdio mcp does not take a url")
+		}
+	case MCPHttp, MCPSse:
+		if m.Command != "" || len(m.Args) > 0 {
+			return fmt.Errorf("%s mcp does not take a command", m.Type)
+		}
+		u, err := url.Parse(m.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s mcp requires an http or https url, got %q", m.Type, m.URL)
+		}
+	default:
+		return fmt.Errorf("unsupported mcp type %q", m.Type)
+	}
+	if m.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
 }
 
 type LSPConfig struct {
@@ -125,23 +232,143 @@ type LSPConfig struct {
 }
 
 type TUIOptions struct {
-	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
-	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	CompactMode   bool                 `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
+	DiffMode      string               `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	Notifications *NotificationOptions `json:"notifications,omitempty" jsonschema:"description=Notifications when an agent turn finishes or waits for a permission"`
+	Images        ImageMode            `json:"images,omitempty" jsonschema:"description=How images are shown in the chat: detected from the terminal or forced to a graphics protocol,enum=auto,enum=kitty,enum=iterm2,enum=sixel,enum=halfblocks,enum=off,default=auto"`
 	// Here we can add themes later or any TUI related options
 }
 
+// NotificationOptions controls the notifications sent when an agent turn
+// finishes, fails or waits for a permission. They are only sent when the
+// terminal isn't focused, or when the turn ran for MinDuration seconds.
+type NotificationOptions struct {
+	Disabled        bool     `json:"disabled,omitempty" jsonschema:"description=Disable notifications,default=false"`
+	DisableTerminal bool     `json:"disable_terminal,omitempty" jsonschema:"description=Do not send OSC 9 and OSC 777 terminal notifications,default=false"`
+	DisableBell     bool     `json:"disable_bell,omitempty" jsonschema:"description=Do not ring the terminal bell,default=false"`
+	Command         []string `json:"command,omitempty" jsonschema:"description=Command run for desktop notifications where {title} and {message} are replaced in the arguments,example=notify-send,example={title},example={message}"`
+	MinDuration     int      `json:"min_duration,omitempty" jsonschema:"description=Seconds a turn must run to notify while the terminal is focused,default=30,minimum=1"`
+}
+
+// ImageMode is how images of messages and tool results are shown in the chat.
+type ImageMode string
+
+const (
+	// ImageModeAuto uses the best graphics protocol the terminal supports.
+	ImageModeAuto ImageMode = "auto"
+	// ImageModeKitty, ImageModeITerm2 and ImageModeSixel force a graphics
+	// protocol.
+	ImageModeKitty  ImageMode = "kitty"
+	ImageModeITerm2 ImageMode = "iterm2"
+	ImageModeSixel  ImageMode = "sixel"
+	// ImageModeHalfBlocks draws images with colored half blocks, which works
+	// in any terminal with true colors.
+	ImageModeHalfBlocks ImageMode = "halfblocks"
+	// ImageModeOff doesn't show images, only their names.
+	ImageModeOff ImageMode = "off"
+)
+
+type CompactionStrategy string
+
+const (
+	// CompactionStrategySummarize replaces the whole history with a summary.
+	CompactionStrategySummarize CompactionStrategy = "summarize"
+	// CompactionStrategySummarizeOldest summarizes everything but the most
+	// recent turns, which are kept verbatim.
+	CompactionStrategySummarizeOldest CompactionStrategy = "summarize_oldest"
+	// CompactionStrategyDropToolResults replaces old tool results with a
+	// placeholder.
+	CompactionStrategyDropToolResults CompactionStrategy = "drop_tool_results"
+	// CompactionStrategyTruncateToolResults shortens large old tool results.
+	CompactionStrategyTruncateToolResults CompactionStrategy = "truncate_tool_results"
+)
+
+const (
+	defaultCompactionThreshold     = 95
+	defaultCompactionKeepTurns     = 4
+	defaultCompactionMaxToolResult = 2000
+
+	defaultNotificationMinDuration = 30
+)
+
+type CompactionOptions struct {
+	Threshold           float64            `json:"threshold,omitempty" jsonschema:"description=Percentage of the model context window at which the conversation is compacted,minimum=10,maximum=100,default=95,example=80"`
+	Strategy            CompactionStrategy `json:"strategy,omitempty" jsonschema:"description=How the conversation is compacted once the threshold is reached,enum=summarize,enum=summarize_oldest,enum=drop_tool_results,enum=truncate_tool_results,default=summarize"`
+	KeepRecentTurns     int                `json:"keep_recent_turns,omitempty" jsonschema:"description=Number of recent turns kept verbatim by the partial strategies,minimum=1,default=4"`
+	MaxToolResultLength int                `json:"max_tool_result_length,omitempty" jsonschema:"description=Maximum length in characters of a tool result when using the truncate_tool_results strategy,minimum=100,default=2000"`
+}
+
+// ThresholdTokens returns the token count at which compaction kicks in for a
+// model with the given context window.
+func (c *CompactionOptions) ThresholdTokens(contextWindow int64) int64 {
+	return int64(float64(contextWindow) * c.Threshold / 100)
+}
+
+// CacheBreakpoint is a part of the prompt marked for caching.
+type CacheBreakpoint string
+
+const (
+	CacheBreakpointSystem   CacheBreakpoint = "system"
+	CacheBreakpointTools    CacheBreakpoint = "tools"
+	CacheBreakpointMessages CacheBreakpoint = "messages"
+)
+
+const (
+	defaultCacheMessages = 2
+	defaultCacheTTL      = "5m"
+)
+
+// CacheOptions controls prompt caching. Anthropic models cache the prompt up
+// to each breakpoint; Gemini models cache the system prompt and the tools as
+// cached content. Other providers cache on their own and only report it.
+type CacheOptions struct {
+	Disabled    bool              `json:"disabled,omitempty" jsonschema:"description=Disable prompt caching,default=false"`
+	Breakpoints []CacheBreakpoint `json:"breakpoints,omitempty" jsonschema:"description=Parts of the prompt to cache,enum=system,enum=tools,enum=messages,default=system,default=tools,default=messages"`
+	Messages    int               `json:"messages,omitempty" jsonschema:"description=Number of recent messages marked for caching with the messages breakpoint,minimum=1,maximum=4,default=2"`
+	TTL         string            `json:"ttl,omitempty" jsonschema:"description=How long Gemini keeps cached content,default=5m,example=1h"`
+}
+
+// Caches reports whether the part of the prompt is cached.
+func (c *CacheOptions) Caches(breakpoint CacheBreakpoint) bool {
+	return c != nil && !c.Disabled && slices.Contains(c.Breakpoints, breakpoint)
+}
+
+// DefaultPromptHistoryExcludePatterns match prompts that look like they
+// contain secrets: API keys, tokens, private keys and passwords.
+var DefaultPromptHistoryExcludePatterns = []string{
+	`\b(sk|pk|rk)-[A-Za-z0-9_-]{20,}`,
+	`\bgh[pousr]_[A-Za-z0-9]{20,}`,
+	`\bgithub_pat_[A-Za-z0-9_]{20,}`,
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,
+	`\bAKIA[0-9A-Z]{16}\b`,
+	`\bAIza[0-9A-Za-z_-]{35}`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	`(?i)\b(api[_-]?key|secret|token|password|passwd)\b\s*[:=]\s*\S+`,
+}
+
+// PromptHistoryOptions controls the history of the prompts sent from the
+// editor and with crush run, which is kept per project.
+type PromptHistoryOptions struct {
+	Disabled        bool     `json:"disabled,omitempty" jsonschema:"description=Disable saving prompts to the history,default=false"`
+	ExcludePatterns []string `json:"exclude_patterns,omitempty" jsonschema:"description=Regular expressions of prompts that are never saved in addition to the default patterns of API keys and tokens and passwords,example=(?i)password"`
+}
+
 type Permissions struct {
 	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
 	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
 }
 
 type Options struct {
-	ContextPaths         []string    `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
-	TUI                  *TUIOptions `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
-	Debug                bool        `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
-	DebugLSP             bool        `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
-	DisableAutoSummarize bool        `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
-	DataDirectory        string      `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
+	ContextPaths         []string              `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
+	TUI                  *TUIOptions           `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
+	Debug                bool                  `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
+	DebugLSP             bool                  `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	DisableAutoSummarize bool                  `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	DataDirectory        string                `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
+	Compaction           *CompactionOptions    `json:"compaction,omitempty" jsonschema:"description=Automatic conversation compaction options"`
+	Cache                *CacheOptions         `json:"cache,omitempty" jsonschema:"description=Prompt caching options"`
+	PromptHistory        *PromptHistoryOptions `json:"prompt_history,omitempty" jsonschema:"description=Prompt history options"`
+	PrimaryAgent         string                `json:"primary_agent,omitempty" jsonschema:"description=ID of the agent used for the main conversation,default=coder"`
 }
 
 type MCPs map[string]MCPConfig
@@ -217,31 +444,60 @@ func (m MCPConfig) ResolvedHeaders() map[string]string {
 	return m.Headers
 }
 
+type HookEvent string
+
+const (
+	// HookPreToolUse runs before a tool call, it can block the call or
+	// change its input.
+	HookPreToolUse HookEvent = "pre_tool_use"
+	// HookPostToolUse runs after a tool call with its result.
+	HookPostToolUse HookEvent = "post_tool_use"
+	// HookFinish runs when the agent finishes its turn.
+	HookFinish HookEvent = "finish"
+	// HookPermissionRequest runs when a tool asks for permission, it can
+	// allow or deny the request.
+	HookPermissionRequest HookEvent = "permission_request"
+)
+
+type Hook struct {
+	Command string `json:"command" jsonschema:"required,description=Shell command to run (it receives the event as JSON on stdin),example=./scripts/check-policy.sh"`
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regular expression matched against the tool name for tool and permission events (all tools when empty),example=edit|write"`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for the command,default=60,minimum=1"`
+}
+
+type Hooks map[HookEvent][]Hook
+
 type Agent struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	// This is the id of the system prompt used by the agent
-	Disabled bool `json:"disabled,omitempty"`
+	ID          string `json:"id,omitempty" jsonschema:"description=Unique identifier for the agent (defaults to its key in the agents config)"`
+	Name        string `json:"name,omitempty" jsonschema:"description=Human-readable name of the agent,example=Reviewer"`
+	Description string `json:"description,omitempty" jsonschema:"description=What the agent does (shown to the model that can call it as a sub-agent),example=Reviews the current changes and reports problems"`
+	Disabled    bool   `json:"disabled,omitempty" jsonschema:"description=Whether this agent is disabled,default=false"`
+
+	// Path to a file with the system prompt of the agent, relative to the
+	// working directory. Only used by user-defined agents.
+	Prompt string `json:"prompt,omitempty" jsonschema:"description=Path to a markdown file with the system prompt of the agent (relative to the working directory),example=.crush/agents/reviewer.md"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	Model SelectedModelType `json:"model,omitempty" jsonschema:"description=The model type to use for this agent,enum=large,enum=small,default=large"`
+
+	// An explicit model for the agent, takes precedence over Model.
+	SelectedModel *SelectedModel `json:"selected_model,omitempty" jsonschema:"description=An explicit model for this agent that takes precedence over the model type"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
-	AllowedTools []string `json:"allowed_tools,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=Tools available to the agent (all tools when not set),example=view,example=grep"`
 
 	// this tells us which MCPs are available for this agent
 	//  if this is empty all mcps are available
 	//  the string array is the list of tools from the AllowedMCP the agent has available
 	//  if the string array is nil, all tools from the AllowedMCP are available
-	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty"`
+	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty" jsonschema:"description=MCP servers available to the agent with an optional list of their tools"`
 
 	// The list of LSPs that this agent can use
 	//  if this is nil, all LSPs are available
-	AllowedLSP []string `json:"allowed_lsp,omitempty"`
+	AllowedLSP []string `json:"allowed_lsp,omitempty" jsonschema:"description=LSP servers available to the agent"`
 
 	// Overrides the context paths for this agent
-	ContextPaths []string `json:"context_paths,omitempty"`
+	ContextPaths []string `json:"context_paths,omitempty" jsonschema:"description=Overrides the context paths for this agent"`
 }
 
 // Config holds the configuration for crush.
@@ -262,10 +518,14 @@ type Config struct {
 
 	Permissions *Permissions `json:"permissions,omitempty" jsonschema:"description=Permission settings for tool usage"`
 
+	// User-defined agents, the built-in coder and task agents are added by
+	// SetupAgents.
+	Agents map[string]Agent `json:"agents,omitempty" jsonschema:"description=User-defined agents that can be used as sub-agents or as the primary agent"`
+
+	Hooks Hooks `json:"hooks,omitempty" jsonschema:"description=Shell commands to run on agent lifecycle events"`
+
 	// Internal
 	workingDir string `json:"-"`
-	// TODO: most likely remove this concept when I come back to it
-	Agents map[string]Agent `json:"-"`
 	// TODO: find a better way to do this this should probably not be part of the config
 	resolver       VariableResolver
 	dataConfigDir  string             `json:"-"`
@@ -302,8 +562,37 @@ func (c *Config) GetModel(provider, model string) *catwalk.Model {
 	return nil
 }
 
-func (c *Config) GetProviderForModel(modelType SelectedModelType) *ProviderConfig {
+// agentModelPrefix starts the model type of agents with an explicit model.
+const agentModelPrefix = "agent:"
+
+// selectedModel returns the model selected for a model type. The model types
+// of agents with an explicit model resolve to the model of the agent.
+func (c *Config) selectedModel(modelType SelectedModelType) (SelectedModel, bool) {
+	if id, ok := strings.CutPrefix(string(modelType), agentModelPrefix); ok {
+		if agent, ok := c.Agents[id]; ok && agent.SelectedModel != nil {
+			return *agent.SelectedModel, true
+		}
+		return SelectedModel{}, false
+	}
 	model, ok := c.Models[modelType]
+	return model, ok
+}
+
+// SetModelConfig changes the model selected for a model type for the current
+// run, on the agent when it's the model type of an agent.
+func (c *Config) SetModelConfig(modelType SelectedModelType, model SelectedModel) {
+	if id, ok := strings.CutPrefix(string(modelType), agentModelPrefix); ok {
+		if agent, ok := c.Agents[id]; ok {
+			agent.SelectedModel = &model
+			c.Agents[id] = agent
+		}
+		return
+	}
+	c.Models[modelType] = model
+}
+
+func (c *Config) GetProviderForModel(modelType SelectedModelType) *ProviderConfig {
+	model, ok := c.selectedModel(modelType)
 	if !ok {
 		return nil
 	}
@@ -314,13 +603,36 @@ func (c *Config) GetProviderForModel(modelType SelectedModelType) *ProviderConfi
 }
 
 func (c *Config) GetModelByType(modelType SelectedModelType) *catwalk.Model {
-	model, ok := c.Models[modelType]
+	model, ok := c.selectedModel(modelType)
 	if !ok {
 		return nil
 	}
 	return c.GetModel(model.Provider, model.Model)
 }
 
+// ModelConfig returns the selected model configuration for the given model
+// type, falling back to the large model.
+func (c *Config) ModelConfig(modelType SelectedModelType) SelectedModel {
+	if model, ok := c.selectedModel(modelType); ok {
+		return model
+	}
+	return c.Models[SelectedModelTypeLarge]
+}
+
+// ModelAPI returns the API used to talk to the model of the given type: the
+// one set on the selected model, else the one of its provider, else Chat
+// Completions. It only matters for openai providers.
+func (c *Config) ModelAPI(modelType SelectedModelType) string {
+	model := c.ModelConfig(modelType)
+	if model.API != "" {
+		return model.API
+	}
+	if providerConfig, ok := c.Providers.Get(model.Provider); ok && providerConfig.API != "" {
+		return providerConfig.API
+	}
+	return APIChatCompletions
+}
+
 func (c *Config) LargeModel() *catwalk.Model {
 	model, ok := c.Models[SelectedModelTypeLarge]
 	if !ok {
@@ -452,9 +764,72 @@ func (c *Config) SetupAgents() {
 			AllowedLSP: []string{},
 		},
 	}
+	for id, agent := range c.Agents {
+		if _, ok := agents[id]; ok {
+			slog.Warn("Ignoring user-defined agent with a reserved ID", "agent", id)
+			continue
+		}
+		agent.ID = id
+		if agent.Name == "" {
+			agent.Name = id
+		}
+		if agent.Model == "" {
+			agent.Model = SelectedModelTypeLarge
+		}
+		if agent.SelectedModel != nil {
+			// Agents with an explicit model get their own model type, which
+			// resolves to the model of the agent, so that they can be used
+			// everywhere a model type is expected.
+			agent.Model = SelectedModelType(agentModelPrefix + id)
+		}
+		if agent.ContextPaths == nil {
+			agent.ContextPaths = c.Options.ContextPaths
+		}
+		agents[id] = agent
+	}
 	c.Agents = agents
 }
 
+// UserAgents returns the enabled user-defined agents sorted by ID.
+func (c *Config) UserAgents() []Agent {
+	var agents []Agent
+	for id, agent := range c.Agents {
+		if id == "coder" || id == "task" || agent.Disabled {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	slices.SortFunc(agents, func(a, b Agent) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return agents
+}
+
+// PrimaryAgent returns the agent used for the main conversation, which is the
+// coder agent unless another one was selected.
+func (c *Config) PrimaryAgent() Agent {
+	if c.Options != nil && c.Options.PrimaryAgent != "" && c.Options.PrimaryAgent != "task" {
+		if agent, ok := c.Agents[c.Options.PrimaryAgent]; ok && !agent.Disabled {
+			return agent
+		}
+	}
+	return c.Agents["coder"]
+}
+
+// SetPrimaryAgent selects the agent used for the main conversation. The
+// selection only lasts for the current run, use options.primary_agent to
+// change the default.
+func (c *Config) SetPrimaryAgent(id string) error {
+	if id != "coder" && !slices.ContainsFunc(c.UserAgents(), func(a Agent) bool { return a.ID == id }) {
+		return fmt.Errorf("agent %s not found", id)
+	}
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	c.Options.PrimaryAgent = id
+	return nil
+}
+
 func (c *Config) Resolver() VariableResolver {
 	return c.resolver
 }
@@ -485,6 +860,12 @@ func (c *ProviderConfig) TestConnection(resolver VariableResolver) error {
 			baseURL = "https://generativelanguage.googleapis.com"
 		}
 		testURL = baseURL + "/v1beta/models?key=" + url.QueryEscape(apiKey)
+	case TypeOllama:
+		baseURL, _ := resolver.ResolveValue(c.BaseURL)
+		if baseURL == "" {
+			baseURL = ollama.DefaultHost
+		}
+		testURL = baseURL + "/api/tags"
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		mcp     MCPConfig
+		wantErr string
+	}{
+		"stdio":             {mcp: MCPConfig{Type: MCPStdio, Command: "npx"}},
+		"http":              {mcp: MCPConfig{Type: MCPHttp, URL: "https://example.com/mcp"}},
+		"sse":               {mcp: MCPConfig{Type: MCPSse, URL: "http://localhost:3000/sse"}},
+		"stdio without cmd": {mcp: MCPConfig{Type: MCPStdio}, wantErr: "requires a command"},
+		"stdio with url":    {mcp: MCPConfig{Type: MCPStdio, Command: "npx", URL: "http://x"}, wantErr: "does not take a url"},
+		"http without url":  {mcp: MCPConfig{Type: MCPHttp}, wantErr: "requires an http or https url"},
+		"http with bad url": {mcp: MCPConfig{Type: MCPHttp, URL: "ftp://example.com"}, wantErr: "requires an http or https url"},
+		"http with command": {mcp: MCPConfig{Type: MCPHttp, URL: "https://example.com", Command: "npx"}, wantErr: "does not take a command"},
+		"unknown type":      {mcp: MCPConfig{Type: "grpc"}, wantErr: "unsupported mcp type"},
+		"negative timeout":  {mcp: MCPConfig{Type: MCPStdio, Command: "npx", Timeout: -1}, wantErr: "timeout"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.mcp.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMCPName(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateMCPName("github_2-beta"))
+	require.Error(t, ValidateMCPName(""))
+	require.Error(t, ValidateMCPName("my.server"))
+	require.Error(t, ValidateMCPName("my server"))
+}
+
+func TestConfig_ModelAPI(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeLarge: {Model: "gpt-5", Provider: "openai"},
+			SelectedModelTypeSmall: {Model: "gpt-5-mini", Provider: "openai", API: APIChatCompletions},
+		},
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"openai": {API: APIResponses},
+		}),
+	}
+	require.Equal(t, APIResponses, cfg.ModelAPI(SelectedModelTypeLarge))
+	require.Equal(t, APIChatCompletions, cfg.ModelAPI(SelectedModelTypeSmall))
+
+	cfg.Providers = csync.NewMap[string, ProviderConfig]()
+	require.Equal(t, APIChatCompletions, cfg.ModelAPI(SelectedModelTypeLarge))
+}
+
+func TestCacheOptions_Caches(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{}
+	cfg.setDefaults("/tmp")
+	cache := cfg.Options.Cache
+	require.True(t, cache.Caches(CacheBreakpointSystem))
+	require.True(t, cache.Caches(CacheBreakpointTools))
+	require.True(t, cache.Caches(CacheBreakpointMessages))
+	require.Equal(t, 2, cache.Messages)
+
+	cache.Breakpoints = []CacheBreakpoint{CacheBreakpointSystem}
+	require.True(t, cache.Caches(CacheBreakpointSystem))
+	require.False(t, cache.Caches(CacheBreakpointMessages))
+
+	cache.Disabled = true
+	require.False(t, cache.Caches(CacheBreakpointSystem))
+
+	var unset *CacheOptions
+	require.False(t, unset.Caches(CacheBreakpointSystem))
+}
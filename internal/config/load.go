@@ -2,6 +2,7 @@ package config
 
 import (
 	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,11 +15,13 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
 	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/ollama"
 )
 
 const defaultCatwalkURL = "https://catwalk.charm.sh"
@@ -69,7 +72,13 @@ func Load(workingDir string, debug bool) (*Config, error) {
 	// Load known providers, this loads the config from catwalk
 	providers, err := Providers()
 	if err != nil || len(providers) == 0 {
-		return nil, fmt.Errorf("failed to load providers: %w", err)
+		// Without network access the known providers cannot be fetched, which
+		// is fine as long as there are custom providers, like local models.
+		if cfg.Providers.Len() == 0 {
+			return nil, fmt.Errorf("failed to load providers: %w", err)
+		}
+		slog.Warn("Failed to load known providers, using only the configured ones", "error", err)
+		providers = nil
 	}
 	cfg.knownProviders = providers
 
@@ -189,6 +198,9 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			ExtraBody:          config.ExtraBody,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
+			API:                config.API,
+			Script:             config.Script,
+			Cassette:           config.Cassette,
 		}
 
 		switch p.ID {
@@ -232,9 +244,10 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 				}
 			}
 		default:
-			// if the provider api or endpoint are missing we skip them
+			// if the provider api or endpoint are missing we skip them, unless
+			// it only replays a cassette
 			v, err := resolver.ResolveValue(p.APIKey)
-			if v == "" || err != nil {
+			if (v == "" || err != nil) && !config.Cassette.ReplayOnly() {
 				if configExists {
 					slog.Warn("Skipping provider due to missing API key", "provider", p.ID)
 					c.Providers.Del(string(p.ID))
@@ -266,7 +279,18 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			c.Providers.Del(id)
 			continue
 		}
-		if providerConfig.APIKey == "" {
+		if providerConfig.Type == TypeMock {
+			if providerConfig.Script == "" || len(providerConfig.Models) == 0 {
+				slog.Warn("Skipping mock provider without a script or models", "provider", id)
+				c.Providers.Del(id)
+				continue
+			}
+			c.Providers.Set(id, providerConfig)
+			continue
+		}
+		if providerConfig.Type == TypeOllama {
+			providerConfig = configureOllamaProvider(env, resolver, providerConfig)
+		} else if providerConfig.APIKey == "" {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		if providerConfig.BaseURL == "" {
@@ -279,14 +303,14 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			c.Providers.Del(id)
 			continue
 		}
-		if providerConfig.Type != catwalk.TypeOpenAI && providerConfig.Type != catwalk.TypeAnthropic {
+		if !slices.Contains([]catwalk.Type{catwalk.TypeOpenAI, catwalk.TypeAnthropic, TypeOllama}, providerConfig.Type) {
 			slog.Warn("Skipping custom provider because the provider type is not supported", "provider", id, "type", providerConfig.Type)
 			c.Providers.Del(id)
 			continue
 		}
 
 		apiKey, err := resolver.ResolveValue(providerConfig.APIKey)
-		if apiKey == "" || err != nil {
+		if (apiKey == "" || err != nil) && providerConfig.Type != TypeOllama {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
@@ -301,6 +325,53 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 	return nil
 }
 
+// ollamaDiscoveryTimeout bounds the time spent asking Ollama for its models
+// when the configuration is loaded. Ollama usually runs nearby, so an
+// unreachable server shouldn't hold up the start for long.
+const ollamaDiscoveryTimeout = 2 * time.Second
+
+// configureOllamaProvider defaults the endpoint of an Ollama provider and
+// adds the models available on the server. Models listed in the
+// configuration take precedence over the discovered ones.
+func configureOllamaProvider(env env.Env, resolver VariableResolver, providerConfig ProviderConfig) ProviderConfig {
+	if providerConfig.BaseURL == "" {
+		providerConfig.BaseURL = ollama.Host(env.Get("OLLAMA_HOST"))
+	}
+	baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
+	if err != nil || baseURL == "" {
+		return providerConfig
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaDiscoveryTimeout)
+	defer cancel()
+	apiKey, _ := resolver.ResolveValue(providerConfig.APIKey)
+	headers := make(map[string]string, len(providerConfig.ExtraHeaders))
+	for key, value := range providerConfig.ExtraHeaders {
+		if resolved, err := resolver.ResolveValue(value); err == nil {
+			value = resolved
+		}
+		headers[key] = value
+	}
+	client := ollama.NewClient(baseURL, nil, ollama.Headers(apiKey, headers))
+	discovered, err := ollama.DiscoverModels(ctx, client)
+	if err != nil {
+		slog.Warn("Failed to discover ollama models", "provider", providerConfig.ID, "error", err)
+		return providerConfig
+	}
+	if len(discovered) == 0 && len(providerConfig.Models) == 0 {
+		slog.Warn("Ollama has no models, pull one with: crush providers pull <model>", "provider", providerConfig.ID)
+	}
+
+	models := slices.Clone(providerConfig.Models)
+	for _, model := range discovered {
+		if !slices.ContainsFunc(models, func(m catwalk.Model) bool { return m.ID == model.ID }) {
+			models = append(models, model)
+		}
+	}
+	providerConfig.Models = models
+	return providerConfig
+}
+
 func (c *Config) setDefaults(workingDir string) {
 	c.workingDir = workingDir
 	if c.Options == nil {
@@ -309,9 +380,51 @@ func (c *Config) setDefaults(workingDir string) {
 	if c.Options.TUI == nil {
 		c.Options.TUI = &TUIOptions{}
 	}
+	if c.Options.TUI.Notifications == nil {
+		c.Options.TUI.Notifications = &NotificationOptions{}
+	}
+	if c.Options.TUI.Notifications.MinDuration <= 0 {
+		c.Options.TUI.Notifications.MinDuration = defaultNotificationMinDuration
+	}
+	if c.Options.TUI.Images == "" {
+		c.Options.TUI.Images = ImageModeAuto
+	}
 	if c.Options.ContextPaths == nil {
 		c.Options.ContextPaths = []string{}
 	}
+	if c.Options.Compaction == nil {
+		c.Options.Compaction = &CompactionOptions{}
+	}
+	if c.Options.Compaction.Threshold <= 0 || c.Options.Compaction.Threshold > 100 {
+		c.Options.Compaction.Threshold = defaultCompactionThreshold
+	}
+	if c.Options.Compaction.Strategy == "" {
+		c.Options.Compaction.Strategy = CompactionStrategySummarize
+	}
+	if c.Options.Compaction.KeepRecentTurns <= 0 {
+		c.Options.Compaction.KeepRecentTurns = defaultCompactionKeepTurns
+	}
+	if c.Options.Compaction.MaxToolResultLength <= 0 {
+		c.Options.Compaction.MaxToolResultLength = defaultCompactionMaxToolResult
+	}
+	if c.Options.Cache == nil {
+		c.Options.Cache = &CacheOptions{}
+	}
+	if len(c.Options.Cache.Breakpoints) == 0 {
+		c.Options.Cache.Breakpoints = []CacheBreakpoint{CacheBreakpointSystem, CacheBreakpointTools, CacheBreakpointMessages}
+	}
+	if c.Options.Cache.Messages <= 0 {
+		c.Options.Cache.Messages = defaultCacheMessages
+	}
+	if c.Options.Cache.TTL == "" {
+		c.Options.Cache.TTL = defaultCacheTTL
+	}
+	if c.Options.PromptHistory == nil {
+		c.Options.PromptHistory = &PromptHistoryOptions{}
+	}
+	// Patterns from the config add to the defaults, which can't be turned
+	// off one by one.
+	c.Options.PromptHistory.ExcludePatterns = slices.Concat(DefaultPromptHistoryExcludePatterns, c.Options.PromptHistory.ExcludePatterns)
 	if c.Options.DataDirectory == "" {
 		c.Options.DataDirectory = filepath.Join(workingDir, defaultDataDirectory)
 	}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -49,12 +53,26 @@ func TestConfig_setDefaults(t *testing.T) {
 	require.NotNil(t, cfg.LSP)
 	require.NotNil(t, cfg.MCP)
 	require.Equal(t, filepath.Join("/tmp", ".crush"), cfg.Options.DataDirectory)
+	require.NotNil(t, cfg.Options.Compaction)
+	require.Equal(t, float64(defaultCompactionThreshold), cfg.Options.Compaction.Threshold)
+	require.Equal(t, CompactionStrategySummarize, cfg.Options.Compaction.Strategy)
 	for _, path := range defaultContextPaths {
 		require.Contains(t, cfg.Options.ContextPaths, path)
 	}
+	require.Equal(t, DefaultPromptHistoryExcludePatterns, cfg.Options.PromptHistory.ExcludePatterns)
 	require.Equal(t, "/tmp", cfg.workingDir)
 }
 
+func TestConfig_setDefaultsPromptHistoryExcludePatterns(t *testing.T) {
+	cfg := &Config{Options: &Options{PromptHistory: &PromptHistoryOptions{
+		ExcludePatterns: []string{"internal-[0-9]+"},
+	}}}
+
+	cfg.setDefaults("/tmp")
+
+	require.Equal(t, append(slices.Clone(DefaultPromptHistoryExcludePatterns), "internal-[0-9]+"), cfg.Options.PromptHistory.ExcludePatterns)
+}
+
 func TestConfig_configureProviders(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -668,6 +686,138 @@ func TestConfig_configureProvidersCustomProviderValidation(t *testing.T) {
 	})
 }
 
+func TestConfig_configureProvidersOllama(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"name":"qwen3:8b","model":"qwen3:8b"},{"name":"llava:7b","model":"llava:7b"}]}`)
+	})
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model_info":{"qwen3.context_length":40960},"capabilities":["completion","tools"]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	t.Run("models are discovered", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"local": {
+					Type: TypeOllama,
+					Models: []catwalk.Model{{
+						ID:            "llava:7b",
+						Name:          "LLaVA",
+						ContextWindow: 2048,
+					}},
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp")
+
+		env := env.NewFromMap(map[string]string{
+			"OLLAMA_HOST": srv.URL,
+		})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		provider, exists := cfg.Providers.Get("local")
+		require.True(t, exists)
+		require.Equal(t, srv.URL, provider.BaseURL)
+		require.Equal(t, []catwalk.Model{
+			{ID: "llava:7b", Name: "LLaVA", ContextWindow: 2048},
+			{ID: "qwen3:8b", Name: "qwen3:8b", ContextWindow: 40960, DefaultMaxTokens: 8192},
+		}, provider.Models)
+	})
+
+	t.Run("unreachable server without models is removed", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"local": {
+					Type:    TypeOllama,
+					BaseURL: "http://127.0.0.1:1",
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp")
+
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		_, exists := cfg.Providers.Get("local")
+		require.False(t, exists)
+	})
+}
+
+func TestConfig_configureProvidersMock(t *testing.T) {
+	cfg := &Config{
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"scripted": {
+				Type:   TypeMock,
+				Script: "script.yaml",
+				Models: []catwalk.Model{{ID: "mock"}},
+			},
+			"no-script": {
+				Type:   TypeMock,
+				Models: []catwalk.Model{{ID: "mock"}},
+			},
+		}),
+	}
+	cfg.setDefaults("/tmp")
+
+	env := env.NewFromMap(map[string]string{})
+	resolver := NewEnvironmentVariableResolver(env)
+	err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, cfg.Providers.Len())
+	_, exists := cfg.Providers.Get("scripted")
+	require.True(t, exists)
+}
+
+func TestConfig_configureProvidersKnownWithCassette(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:          catwalk.InferenceProviderAnthropic,
+			APIKey:      "$ANTHROPIC_API_KEY",
+			APIEndpoint: "https://api.anthropic.com/v1",
+			Type:        catwalk.TypeAnthropic,
+			Models:      []catwalk.Model{{ID: "claude"}},
+		},
+		{
+			ID:          catwalk.InferenceProviderOpenAI,
+			APIKey:      "$OPENAI_API_KEY",
+			APIEndpoint: "https://api.openai.com/v1",
+			Type:        catwalk.TypeOpenAI,
+			Models:      []catwalk.Model{{ID: "gpt"}},
+		},
+	}
+
+	cfg := &Config{
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"anthropic": {
+				Cassette: &CassetteConfig{Path: "testdata/session.json"},
+			},
+			// Recording calls the provider, so it still needs a key.
+			"openai": {
+				Cassette: &CassetteConfig{Path: "testdata/session.json", Mode: CassetteModeRecord},
+			},
+		}),
+	}
+	cfg.setDefaults("/tmp")
+
+	env := env.NewFromMap(map[string]string{})
+	resolver := NewEnvironmentVariableResolver(env)
+	err := cfg.configureProviders(env, resolver, knownProviders)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, cfg.Providers.Len())
+	pc, ok := cfg.Providers.Get("anthropic")
+	require.True(t, ok)
+	require.Equal(t, &CassetteConfig{Path: "testdata/session.json"}, pc.Cassette)
+	require.Equal(t, catwalk.TypeAnthropic, pc.Type)
+}
+
 func TestConfig_configureProvidersEnhancedCredentialValidation(t *testing.T) {
 	t.Run("VertexAI provider removed when credentials missing with existing config", func(t *testing.T) {
 		knownProviders := []catwalk.Provider{
@@ -1184,3 +1334,49 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestConfig_SetupAgents(t *testing.T) {
+	cfg, err := loadFromReaders([]io.Reader{strings.NewReader(`{
+		"agents": {
+			"reviewer": {"prompt": "reviewer.md", "model": "small", "allowed_tools": ["view", "grep"]},
+			"test-writer": {"name": "Test Writer", "selected_model": {"model": "gpt-4o", "provider": "openai"}},
+			"off": {"disabled": true},
+			"coder": {"prompt": "coder.md"}
+		}
+	}`)})
+	require.NoError(t, err)
+	cfg.setDefaults("/tmp")
+	cfg.SetupAgents()
+
+	coder := cfg.Agents["coder"]
+	require.Empty(t, coder.Prompt)
+	require.Equal(t, "Coder", coder.Name)
+
+	reviewer := cfg.Agents["reviewer"]
+	require.Equal(t, "reviewer", reviewer.ID)
+	require.Equal(t, "reviewer", reviewer.Name)
+	require.Equal(t, SelectedModelTypeSmall, reviewer.Model)
+	require.Equal(t, []string{"view", "grep"}, reviewer.AllowedTools)
+	require.Equal(t, cfg.Options.ContextPaths, reviewer.ContextPaths)
+
+	testWriter := cfg.Agents["test-writer"]
+	require.Equal(t, SelectedModelType("agent:test-writer"), testWriter.Model)
+	require.Equal(t, "gpt-4o", cfg.ModelConfig(testWriter.Model).Model)
+	require.NotContains(t, cfg.Models, testWriter.Model)
+	cfg.SetModelConfig(testWriter.Model, SelectedModel{Model: "gpt-4o", Provider: "openai", Think: true})
+	require.True(t, cfg.Agents["test-writer"].SelectedModel.Think)
+	require.NotContains(t, cfg.Models, testWriter.Model)
+
+	var ids []string
+	for _, agent := range cfg.UserAgents() {
+		ids = append(ids, agent.ID)
+	}
+	require.Equal(t, []string{"reviewer", "test-writer"}, ids)
+
+	require.Equal(t, "coder", cfg.PrimaryAgent().ID)
+	require.NoError(t, cfg.SetPrimaryAgent("reviewer"))
+	require.Equal(t, "reviewer", cfg.PrimaryAgent().ID)
+	require.Error(t, cfg.SetPrimaryAgent("off"))
+	require.Error(t, cfg.SetPrimaryAgent("task"))
+	require.Equal(t, "reviewer", cfg.PrimaryAgent().ID)
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- is_new marks the initial version of a file that didn't exist before the
+-- session, which is empty like the version of an existing empty file.
+ALTER TABLE files ADD COLUMN is_new INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN is_new;
+-- +goose StatementEnd
@@ -16,29 +16,57 @@ type File struct {
 	Version   int64  `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	IsNew     int64  `json:"is_new"`
 }
 
 type Message struct {
-	ID         string         `json:"id"`
-	SessionID  string         `json:"session_id"`
-	Role       string         `json:"role"`
-	Parts      string         `json:"parts"`
-	Model      sql.NullString `json:"model"`
-	CreatedAt  int64          `json:"created_at"`
-	UpdatedAt  int64          `json:"updated_at"`
-	FinishedAt sql.NullInt64  `json:"finished_at"`
-	Provider   sql.NullString `json:"provider"`
+	ID                  string         `json:"id"`
+	SessionID           string         `json:"session_id"`
+	Role                string         `json:"role"`
+	Parts               string         `json:"parts"`
+	Model               sql.NullString `json:"model"`
+	CreatedAt           int64          `json:"created_at"`
+	UpdatedAt           int64          `json:"updated_at"`
+	FinishedAt          sql.NullInt64  `json:"finished_at"`
+	Provider            sql.NullString `json:"provider"`
+	InputTokens         int64          `json:"input_tokens"`
+	OutputTokens        int64          `json:"output_tokens"`
+	CacheReadTokens     int64          `json:"cache_read_tokens"`
+	CacheCreationTokens int64          `json:"cache_creation_tokens"`
+}
+
+type Prompt struct {
+	ID        string `json:"id"`
+	Project   string `json:"project"`
+	SessionID string `json:"session_id"`
+	Content   string `json:"content"`
+	CreatedAt int64  `json:"created_at"`
 }
 
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
+	ID                   string         `json:"id"`
+	ParentSessionID      sql.NullString `json:"parent_session_id"`
+	Title                string         `json:"title"`
+	MessageCount         int64          `json:"message_count"`
+	PromptTokens         int64          `json:"prompt_tokens"`
+	CompletionTokens     int64          `json:"completion_tokens"`
+	Cost                 float64        `json:"cost"`
+	UpdatedAt            int64          `json:"updated_at"`
+	CreatedAt            int64          `json:"created_at"`
+	SummaryMessageID     sql.NullString `json:"summary_message_id"`
+	SummaryKeepMessageID sql.NullString `json:"summary_keep_message_id"`
+	TotalPromptTokens    int64          `json:"total_prompt_tokens"`
+	CacheReadTokens      int64          `json:"cache_read_tokens"`
+	CacheCreationTokens  int64          `json:"cache_creation_tokens"`
+	CacheSavings         float64        `json:"cache_savings"`
+}
+
+type Todo struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Content   string `json:"content"`
+	Status    string `json:"status"`
+	Position  int64  `json:"position"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
 }
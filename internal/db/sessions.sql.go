@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, summary_keep_message_id, total_prompt_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 `
 
 type CreateSessionParams struct {
@@ -68,6 +68,11 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.SummaryKeepMessageID,
+		&i.TotalPromptTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
@@ -83,7 +88,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, summary_keep_message_id, total_prompt_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -102,12 +107,17 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.SummaryKeepMessageID,
+		&i.TotalPromptTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, summary_keep_message_id, total_prompt_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC
@@ -133,6 +143,11 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.UpdatedAt,
 			&i.CreatedAt,
 			&i.SummaryMessageID,
+			&i.SummaryKeepMessageID,
+			&i.TotalPromptTokens,
+			&i.CacheReadTokens,
+			&i.CacheCreationTokens,
+			&i.CacheSavings,
 		); err != nil {
 			return nil, err
 		}
@@ -154,18 +169,28 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    summary_keep_message_id = ?,
+    cost = ?,
+    total_prompt_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?,
+    cache_savings = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, summary_keep_message_id, total_prompt_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	ID               string         `json:"id"`
+	Title                string         `json:"title"`
+	PromptTokens         int64          `json:"prompt_tokens"`
+	CompletionTokens     int64          `json:"completion_tokens"`
+	SummaryMessageID     sql.NullString `json:"summary_message_id"`
+	SummaryKeepMessageID sql.NullString `json:"summary_keep_message_id"`
+	Cost                 float64        `json:"cost"`
+	TotalPromptTokens    int64          `json:"total_prompt_tokens"`
+	CacheReadTokens      int64          `json:"cache_read_tokens"`
+	CacheCreationTokens  int64          `json:"cache_creation_tokens"`
+	CacheSavings         float64        `json:"cache_savings"`
+	ID                   string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -174,7 +199,12 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.SummaryMessageID,
+		arg.SummaryKeepMessageID,
 		arg.Cost,
+		arg.TotalPromptTokens,
+		arg.CacheReadTokens,
+		arg.CacheCreationTokens,
+		arg.CacheSavings,
 		arg.ID,
 	)
 	var i Session
@@ -189,6 +219,11 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.SummaryKeepMessageID,
+		&i.TotalPromptTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
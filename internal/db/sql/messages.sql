@@ -29,6 +29,10 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    input_tokens = ?,
+    output_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
@@ -0,0 +1,32 @@
+-- name: CreatePrompt :one
+INSERT INTO prompts (
+    id,
+    project,
+    session_id,
+    content,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: ListPrompts :many
+SELECT *
+FROM prompts
+WHERE project = ?
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?;
+
+-- name: DeletePromptsByContent :exec
+DELETE FROM prompts
+WHERE project = ? AND content = ?;
+
+-- name: PrunePrompts :exec
+DELETE FROM prompts
+WHERE id IN (
+    SELECT old.id
+    FROM prompts AS old
+    WHERE old.project = ?
+    ORDER BY old.created_at DESC, old.rowid DESC
+    LIMIT -1 OFFSET ?
+);
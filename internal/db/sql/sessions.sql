@@ -41,7 +41,12 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    summary_keep_message_id = ?,
+    cost = ?,
+    total_prompt_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?,
+    cache_savings = ?
 WHERE id = ?
 RETURNING *;
 
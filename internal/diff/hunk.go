@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// Hunks returns the hunks of the diff between two file contents as a diff
+// view shows them: with Unix line endings, tabs expanded to tabWidth spaces
+// and the default number of context lines.
+func Hunks(beforeContent, afterContent string, tabWidth int) ([]*udiff.Hunk, error) {
+	before := displayed(beforeContent, tabWidth)
+	after := displayed(afterContent, tabWidth)
+	edits := udiff.Strings(before, after)
+	unified, err := udiff.ToUnifiedDiff("before", "after", before, edits, udiff.DefaultContextLines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute diff: %w", err)
+	}
+	return unified.Hunks, nil
+}
+
+// RevertHunk returns afterContent with the hunk at index of its diff from
+// beforeContent undone, leaving the other hunks as they are. The hunks are
+// those of [Hunks], while the lines put back keep their own line endings and
+// indentation.
+func RevertHunk(beforeContent, afterContent string, index, tabWidth int) (string, error) {
+	hunks, err := Hunks(beforeContent, afterContent, tabWidth)
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || index >= len(hunks) {
+		return "", fmt.Errorf("hunk %d out of range, the diff has %d hunks", index+1, len(hunks))
+	}
+	hunk := hunks[index]
+
+	// Showing the contents doesn't add or remove lines, so the lines of the
+	// hunk are at the same positions in the raw contents.
+	beforeLines := splitLines(beforeContent)
+	afterLines := splitLines(afterContent)
+	start := hunk.ToLine - 1
+	beforeLine, afterLine := hunk.FromLine-1, start
+	if start < 0 {
+		return "", fmt.Errorf("hunk %d doesn't match the content", index+1)
+	}
+	var reverted []string
+	for _, line := range hunk.Lines {
+		switch line.Kind {
+		case udiff.Equal:
+			if afterLine >= len(afterLines) {
+				return "", fmt.Errorf("hunk %d doesn't match the content", index+1)
+			}
+			reverted = append(reverted, afterLines[afterLine])
+			beforeLine++
+			afterLine++
+		case udiff.Delete:
+			if beforeLine < 0 || beforeLine >= len(beforeLines) {
+				return "", fmt.Errorf("hunk %d doesn't match the content", index+1)
+			}
+			reverted = append(reverted, beforeLines[beforeLine])
+			beforeLine++
+		case udiff.Insert:
+			afterLine++
+		}
+	}
+	if afterLine > len(afterLines) {
+		return "", fmt.Errorf("hunk %d doesn't match the content", index+1)
+	}
+
+	var b strings.Builder
+	for _, line := range afterLines[:start] {
+		b.WriteString(line)
+	}
+	for _, line := range reverted {
+		b.WriteString(line)
+	}
+	for _, line := range afterLines[afterLine:] {
+		b.WriteString(line)
+	}
+	return b.String(), nil
+}
+
+// displayed returns content as a diff view shows it.
+func displayed(content string, tabWidth int) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\t", strings.Repeat(" ", tabWidth))
+}
+
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
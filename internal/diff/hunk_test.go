@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertHunk(t *testing.T) {
+	t.Parallel()
+
+	numbered := func(n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("line %02d", i+1)
+		}
+		return lines
+	}
+	join := func(lines []string) string {
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	before := numbered(30)
+	after := numbered(30)
+	after[2] = "changed near the top"
+	after = append(after[:15], append([]string{"inserted"}, after[15:]...)...)
+	after = after[:len(after)-1]
+
+	hunks, err := Hunks(join(before), join(after), 4)
+	require.NoError(t, err)
+	require.Len(t, hunks, 3)
+
+	tests := []struct {
+		name  string
+		index int
+		want  func() []string
+	}{
+		{
+			name:  "change",
+			index: 0,
+			want: func() []string {
+				lines := numbered(30)
+				lines = append(lines[:15], append([]string{"inserted"}, lines[15:]...)...)
+				return lines[:len(lines)-1]
+			},
+		},
+		{
+			name:  "insertion",
+			index: 1,
+			want: func() []string {
+				lines := numbered(29)
+				lines[2] = "changed near the top"
+				return lines
+			},
+		},
+		{
+			name:  "deletion",
+			index: 2,
+			want: func() []string {
+				lines := numbered(30)
+				lines[2] = "changed near the top"
+				return append(lines[:15], append([]string{"inserted"}, lines[15:]...)...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := RevertHunk(join(before), join(after), tt.index, 4)
+			require.NoError(t, err)
+			require.Equal(t, join(tt.want()), got)
+		})
+	}
+
+	t.Run("every hunk", func(t *testing.T) {
+		t.Parallel()
+		content := join(after)
+		for range hunks {
+			var err error
+			content, err = RevertHunk(join(before), content, 0, 4)
+			require.NoError(t, err)
+		}
+		require.Equal(t, join(before), content)
+	})
+
+	t.Run("missing newline", func(t *testing.T) {
+		t.Parallel()
+		got, err := RevertHunk("a\nb", "a\nb\nc\n", 0, 4)
+		require.NoError(t, err)
+		require.Equal(t, "a\nb", got)
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		t.Parallel()
+		got, err := RevertHunk("", "a\nb\n", 0, 4)
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("windows line endings", func(t *testing.T) {
+		t.Parallel()
+		crlf := func(lines []string) string {
+			return strings.Join(lines, "\r\n") + "\r\n"
+		}
+		got, err := RevertHunk(crlf(before), crlf(after), 1, 4)
+		require.NoError(t, err)
+		want := numbered(29)
+		want[2] = "changed near the top"
+		require.Equal(t, crlf(want), got)
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+		t.Parallel()
+		// The indentation changes from a tab to spaces, which a diff view
+		// doesn't show, so the only hunk is the changed line.
+		tabbed := "func f() {\n\treturn 1\n}\n"
+		spaced := "func f() {\n    return 2\n}\n"
+		hunks, err := Hunks(tabbed, spaced, 4)
+		require.NoError(t, err)
+		require.Len(t, hunks, 1)
+		got, err := RevertHunk(tabbed, spaced, 0, 4)
+		require.NoError(t, err)
+		require.Equal(t, tabbed, got)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		t.Parallel()
+		_, err := RevertHunk(join(before), join(after), 3, 4)
+		require.Error(t, err)
+	})
+}
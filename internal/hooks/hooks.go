@@ -0,0 +1,236 @@
+// Package hooks runs the user-configured shell commands on agent lifecycle
+// events.
+//
+// A hook receives the event as JSON on stdin. It can answer with a JSON
+// Output on stdout, or exit with status 2 to block the tool call or
+// permission request, in which case its stderr is used as the reason. For
+// the other events, the reason is handed back to the agent. Any other
+// non-zero exit status is logged and otherwise ignored.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+
+	// blockExitCode is the exit status a hook uses to block.
+	blockExitCode = 2
+)
+
+type Decision string
+
+const (
+	// DecisionBlock blocks a tool call or denies a permission request.
+	DecisionBlock Decision = "block"
+	// DecisionDeny is an alias of DecisionBlock for permission requests.
+	DecisionDeny Decision = "deny"
+	// DecisionAllow grants a permission request without asking the user.
+	DecisionAllow Decision = "allow"
+)
+
+// Input is the JSON payload a hook receives on stdin.
+type Input struct {
+	Event        config.HookEvent `json:"event"`
+	SessionID    string           `json:"session_id,omitempty"`
+	Agent        string           `json:"agent,omitempty"`
+	WorkingDir   string           `json:"cwd"`
+	ToolName     string           `json:"tool_name,omitempty"`
+	ToolCallID   string           `json:"tool_call_id,omitempty"`
+	ToolInput    json.RawMessage  `json:"tool_input,omitempty"`
+	ToolResponse *ToolResponse    `json:"tool_response,omitempty"`
+	Permission   *Permission      `json:"permission,omitempty"`
+	Message      string           `json:"message,omitempty"`
+	FinishReason string           `json:"finish_reason,omitempty"`
+}
+
+// ToolResponse is the result of a tool call, sent with post_tool_use.
+type ToolResponse struct {
+	Content string `json:"content"`
+	IsError bool   `json:"is_error"`
+}
+
+// Permission describes a permission request, sent with permission_request.
+type Permission struct {
+	Action      string `json:"action"`
+	Description string `json:"description"`
+	Path        string `json:"path"`
+}
+
+// Output is the JSON a hook can print on stdout.
+type Output struct {
+	Decision Decision `json:"decision,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	// Replaces the input of the tool call, only used by pre_tool_use.
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+	// A message added to the conversation.
+	Message string `json:"message,omitempty"`
+}
+
+// Result combines the outputs of the hooks that ran for an event.
+type Result struct {
+	Decision  Decision
+	Reason    string
+	ToolInput json.RawMessage
+	Messages  []string
+}
+
+// Blocked reports whether a hook blocked the tool call or denied the
+// permission request.
+func (r Result) Blocked() bool {
+	return r.Decision == DecisionBlock || r.Decision == DecisionDeny
+}
+
+// Feedback returns the text the hooks want to add to the conversation.
+func (r Result) Feedback() string {
+	feedback := r.Messages
+	if r.Blocked() && r.Reason != "" {
+		feedback = append(feedback, r.Reason)
+	}
+	return strings.Join(feedback, "\n")
+}
+
+type collectorKey struct{}
+
+// Collector gathers the feedback of hooks that run deep in a call, such as
+// the permission_request hooks of a tool, for the caller to add it to the
+// conversation.
+type Collector struct {
+	mu       sync.Mutex
+	feedback []string
+}
+
+// WithCollector returns a context whose hook feedback is gathered in the
+// returned collector.
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	c := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, c), c
+}
+
+// Collect adds feedback to the collector of the context, if it has one.
+func Collect(ctx context.Context, feedback string) {
+	c, ok := ctx.Value(collectorKey{}).(*Collector)
+	if !ok || feedback == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.feedback = append(c.feedback, feedback)
+}
+
+// Feedback returns the feedback collected so far.
+func (c *Collector) Feedback() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.feedback, "\n")
+}
+
+// RawJSON returns s as a raw JSON value, or nil if it is not valid JSON.
+func RawJSON(s string) json.RawMessage {
+	if !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+// Run runs the hooks configured for the input's event. Hooks run one after
+// the other and the first one that blocks stops the rest.
+func Run(ctx context.Context, input Input) Result {
+	cfg := config.Get()
+	if cfg == nil || len(cfg.Hooks[input.Event]) == 0 {
+		return Result{}
+	}
+	input.WorkingDir = cfg.WorkingDir()
+	return run(ctx, cfg.Hooks[input.Event], input)
+}
+
+func run(ctx context.Context, hooks []config.Hook, input Input) Result {
+	var result Result
+	for _, hook := range hooks {
+		if !matches(hook.Matcher, input.ToolName) {
+			continue
+		}
+		out, err := runHook(ctx, hook, input)
+		if err != nil {
+			slog.Error("Hook failed", "event", input.Event, "command", hook.Command, "error", err)
+			continue
+		}
+		if out.ToolInput != nil && json.Valid(out.ToolInput) {
+			input.ToolInput = out.ToolInput
+			result.ToolInput = out.ToolInput
+		}
+		if out.Message != "" {
+			result.Messages = append(result.Messages, out.Message)
+		}
+		if out.Decision != "" {
+			result.Decision = out.Decision
+			result.Reason = out.Reason
+		}
+		if result.Blocked() {
+			break
+		}
+	}
+	return result
+}
+
+// matches reports whether the hook applies to the tool. Hooks without a
+// matcher, and events without a tool, always match.
+func matches(matcher, toolName string) bool {
+	if matcher == "" || toolName == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + matcher + ")$")
+	if err != nil {
+		slog.Error("Invalid hook matcher", "matcher", matcher, "error", err)
+		return false
+	}
+	return re.MatchString(toolName)
+}
+
+func runHook(ctx context.Context, hook config.Hook, input Input) (Output, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return Output{}, fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{WorkingDir: input.WorkingDir})
+	stdout, stderr, err := sh.ExecWithStdin(ctx, hook.Command, bytes.NewReader(payload))
+	if exitCode := shell.ExitCode(err); exitCode == blockExitCode {
+		reason := strings.TrimSpace(stderr)
+		if reason == "" {
+			reason = strings.TrimSpace(stdout)
+		}
+		return Output{Decision: DecisionBlock, Reason: reason}, nil
+	} else if err != nil {
+		return Output{}, fmt.Errorf("hook exited with status %d: %w", exitCode, err)
+	}
+
+	stdout = strings.TrimSpace(stdout)
+	if !strings.HasPrefix(stdout, "{") {
+		return Output{}, nil
+	}
+	var out Output
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		return Output{}, fmt.Errorf("failed to decode hook output: %w", err)
+	}
+	return out, nil
+}
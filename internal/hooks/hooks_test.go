@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	input := Input{
+		Event:      config.HookPreToolUse,
+		WorkingDir: t.TempDir(),
+		ToolName:   "bash",
+		ToolInput:  RawJSON(`{"command":"rm -rf /"}`),
+	}
+
+	t.Run("reads the event from stdin", func(t *testing.T) {
+		t.Parallel()
+		result := run(t.Context(), []config.Hook{{
+			Command: `read -r line; case "$line" in *'"tool_name":"bash"'*) echo '{"message":"saw bash"}';; esac`,
+		}}, input)
+		require.Equal(t, []string{"saw bash"}, result.Messages)
+		require.False(t, result.Blocked())
+	})
+
+	t.Run("blocks with exit status 2", func(t *testing.T) {
+		t.Parallel()
+		result := run(t.Context(), []config.Hook{
+			{Command: `echo "not allowed" >&2; exit 2`},
+			{Command: `echo '{"message":"never runs"}'`},
+		}, input)
+		require.True(t, result.Blocked())
+		require.Equal(t, "not allowed", result.Reason)
+		require.Equal(t, "not allowed", result.Feedback())
+	})
+
+	t.Run("changes the tool input", func(t *testing.T) {
+		t.Parallel()
+		result := run(t.Context(), []config.Hook{
+			{Command: `echo '{"tool_input":{"command":"ls"}}'`},
+			{Command: `read -r line; case "$line" in *'"command":"ls"'*) echo '{"message":"changed"}';; esac`},
+		}, input)
+		require.JSONEq(t, `{"command":"ls"}`, string(result.ToolInput))
+		require.Equal(t, []string{"changed"}, result.Messages)
+	})
+
+	t.Run("skips hooks that do not match", func(t *testing.T) {
+		t.Parallel()
+		result := run(t.Context(), []config.Hook{
+			{Matcher: "edit|write", Command: `exit 2`},
+			{Matcher: "ba.*", Command: `echo '{"decision":"allow"}'`},
+		}, input)
+		require.Equal(t, DecisionAllow, result.Decision)
+	})
+
+	t.Run("ignores failing hooks and plain output", func(t *testing.T) {
+		t.Parallel()
+		result := run(t.Context(), []config.Hook{
+			{Command: `exit 1`},
+			{Command: `echo "all good"`},
+		}, input)
+		require.Equal(t, Result{}, result)
+	})
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	Collect(t.Context(), "nobody listens")
+
+	ctx, collector := WithCollector(t.Context())
+	Collect(ctx, "first")
+	Collect(ctx, "")
+	Collect(ctx, "second")
+	require.Equal(t, "first\nsecond", collector.Feedback())
+}
@@ -4,20 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/llm/tools"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 )
 
 type agentTool struct {
-	agent    Service
-	sessions session.Service
-	messages message.Service
+	name        string
+	description string
+	agent       Service
+	sessions    session.Service
+	messages    message.Service
 }
 
 const (
 	AgentToolName = "agent"
+
+	// Tools of user-defined agents are named agent_<id>.
+	agentToolPrefix = AgentToolName + "_"
 )
 
 type AgentParams struct {
@@ -25,13 +32,13 @@ type AgentParams struct {
 }
 
 func (b *agentTool) Name() string {
-	return AgentToolName
+	return b.name
 }
 
 func (b *agentTool) Info() tools.ToolInfo {
 	return tools.ToolInfo{
-		Name:        AgentToolName,
-		Description: "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent.",
+		Name:        b.name,
+		Description: b.description,
 		Parameters: map[string]any{
 			"prompt": map[string]any{
 				"type":        "string",
@@ -85,6 +92,10 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 	}
 
 	parentSession.Cost += updatedSession.Cost
+	parentSession.TotalPromptTokens += updatedSession.TotalPromptTokens
+	parentSession.CacheReadTokens += updatedSession.CacheReadTokens
+	parentSession.CacheCreationTokens += updatedSession.CacheCreationTokens
+	parentSession.CacheSavings += updatedSession.CacheSavings
 
 	_, err = b.sessions.Save(ctx, parentSession)
 	if err != nil {
@@ -99,8 +110,50 @@ func NewAgentTool(
 	messages message.Service,
 ) tools.BaseTool {
 	return &agentTool{
-		sessions: sessions,
-		messages: messages,
-		agent:    agent,
+		name:        AgentToolName,
+		description: taskAgentDescription,
+		sessions:    sessions,
+		messages:    messages,
+		agent:       agent,
 	}
 }
+
+// NewUserAgentTool lets the primary agent delegate work to a user-defined
+// agent.
+func NewUserAgentTool(
+	agentCfg config.Agent,
+	agent Service,
+	sessions session.Service,
+	messages message.Service,
+) tools.BaseTool {
+	description := fmt.Sprintf("Launch the %s agent.", agentCfg.Name)
+	if agentCfg.Description != "" {
+		description += " " + agentCfg.Description
+	}
+	description += userAgentUsageNotes
+	return &agentTool{
+		name:        agentToolPrefix + agentCfg.ID,
+		description: description,
+		sessions:    sessions,
+		messages:    messages,
+		agent:       agent,
+	}
+}
+
+// IsAgentToolName reports whether the tool with the given name runs a
+// sub-agent.
+func IsAgentToolName(name string) bool {
+	return name == AgentToolName || strings.HasPrefix(name, agentToolPrefix)
+}
+
+// AgentIDFromToolName returns the ID of the agent run by the given tool.
+func AgentIDFromToolName(name string) string {
+	if id, ok := strings.CutPrefix(name, agentToolPrefix); ok {
+		return id
+	}
+	return "task"
+}
+
+const taskAgentDescription = "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent."
+
+const userAgentUsageNotes = "\n\nUsage notes:\n1. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n2. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you."
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/llm/prompt"
 	"github.com/charmbracelet/crush/internal/llm/provider"
 	"github.com/charmbracelet/crush/internal/llm/tools"
@@ -22,7 +23,7 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
-	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/todo"
 )
 
 // Common errors
@@ -44,10 +45,13 @@ type AgentEvent struct {
 	Message message.Message
 	Error   error
 
-	// When summarizing
+	// The session the event is about, so a run can be followed when its
+	// session isn't the one shown.
 	SessionID string
-	Progress  string
-	Done      bool
+
+	// When summarizing
+	Progress string
+	Done     bool
 }
 
 type Service interface {
@@ -62,6 +66,10 @@ type Service interface {
 	UpdateModel() error
 	QueuedPrompts(sessionID string) int
 	ClearQueue(sessionID string)
+	SetPlanMode(sessionID string, enabled bool)
+	IsPlanMode(sessionID string) bool
+	PlanModeSessions() []string
+	ApprovePlan(ctx context.Context, sessionID string, plan string) (<-chan AgentEvent, error)
 }
 
 type agent struct {
@@ -69,6 +77,7 @@ type agent struct {
 	agentCfg config.Agent
 	sessions session.Service
 	messages message.Service
+	todos    todo.Service
 	mcpTools []McpTool
 
 	tools *csync.LazySlice[tools.BaseTool]
@@ -83,13 +92,43 @@ type agent struct {
 	activeRequests *csync.Map[string, context.CancelFunc]
 
 	promptQueue *csync.Map[string, []string]
+
+	// Sessions in plan mode, see plan.go.
+	planMode *csync.Map[string, bool]
 }
 
-var agentPromptMap = map[string]prompt.PromptID{
-	"coder": prompt.PromptCoder,
-	"task":  prompt.PromptTask,
+// CoreTools returns the built-in tools that work on the files of the working
+// directory. The diagnostics tool is only included when there are LSP
+// clients.
+func CoreTools(
+	cwd string,
+	permissions permission.Service,
+	history history.Service,
+	todos todo.Service,
+	lspClients map[string]*lsp.Client,
+) []tools.BaseTool {
+	coreTools := []tools.BaseTool{
+		tools.NewBashTool(permissions, cwd),
+		tools.NewDownloadTool(permissions, cwd),
+		tools.NewEditTool(lspClients, permissions, history, cwd),
+		tools.NewMultiEditTool(lspClients, permissions, history, cwd),
+		tools.NewFetchTool(permissions, cwd),
+		tools.NewGlobTool(cwd),
+		tools.NewGrepTool(cwd),
+		tools.NewLsTool(permissions, cwd),
+		tools.NewSourcegraphTool(),
+		tools.NewTodosTool(todos),
+		tools.NewViewTool(lspClients, permissions, cwd),
+		tools.NewWriteTool(lspClients, permissions, history, cwd),
+	}
+	if len(lspClients) > 0 {
+		coreTools = append(coreTools, tools.NewDiagnosticsTool(lspClients))
+	}
+	return coreTools
 }
 
+// NewAgent creates the primary agent, which can delegate work to the task
+// agent and to the user-defined agents.
 func NewAgent(
 	ctx context.Context,
 	agentCfg config.Agent,
@@ -98,23 +137,28 @@ func NewAgent(
 	sessions session.Service,
 	messages message.Service,
 	history history.Service,
+	todos todo.Service,
 	lspClients map[string]*lsp.Client,
 ) (Service, error) {
-	cfg := config.Get()
-
-	var agentTool tools.BaseTool
-	if agentCfg.ID == "coder" {
-		taskAgentCfg := config.Get().Agents["task"]
-		if taskAgentCfg.ID == "" {
-			return nil, fmt.Errorf("task agent not found in config")
-		}
-		taskAgent, err := NewAgent(ctx, taskAgentCfg, permissions, sessions, messages, history, lspClients)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create task agent: %w", err)
-		}
-
-		agentTool = NewAgentTool(taskAgent, sessions, messages)
+	agentTools, err := subAgentTools(ctx, agentCfg, permissions, sessions, messages, history, todos, lspClients)
+	if err != nil {
+		return nil, err
 	}
+	return newAgent(ctx, agentCfg, agentTools, permissions, sessions, messages, history, todos, lspClients)
+}
+
+func newAgent(
+	ctx context.Context,
+	agentCfg config.Agent,
+	agentTools []tools.BaseTool,
+	permissions permission.Service,
+	sessions session.Service,
+	messages message.Service,
+	history history.Service,
+	todos todo.Service,
+	lspClients map[string]*lsp.Client,
+) (Service, error) {
+	cfg := config.Get()
 
 	providerCfg := config.Get().GetProviderForModel(agentCfg.Model)
 	if providerCfg == nil {
@@ -126,13 +170,13 @@ func NewAgent(
 		return nil, fmt.Errorf("model not found for agent %s", agentCfg.Name)
 	}
 
-	promptID := agentPromptMap[agentCfg.ID]
-	if promptID == "" {
-		promptID = prompt.PromptDefault
+	systemMessage, err := systemPrompt(agentCfg, providerCfg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the system prompt for agent %s: %w", agentCfg.Name, err)
 	}
 	opts := []provider.ProviderClientOption{
 		provider.WithModel(agentCfg.Model),
-		provider.WithSystemMessage(prompt.GetPrompt(promptID, providerCfg.ID, config.Get().Options.ContextPaths...)),
+		provider.WithSystemMessage(systemMessage),
 	}
 	agentProvider, err := provider.NewProvider(*providerCfg, opts...)
 	if err != nil {
@@ -179,45 +223,14 @@ func NewAgent(
 			slog.Info("Initialized agent tools", "agent", agentCfg.ID)
 		}()
 
-		cwd := cfg.WorkingDir()
-		allTools := []tools.BaseTool{
-			tools.NewBashTool(permissions, cwd),
-			tools.NewDownloadTool(permissions, cwd),
-			tools.NewEditTool(lspClients, permissions, history, cwd),
-			tools.NewMultiEditTool(lspClients, permissions, history, cwd),
-			tools.NewFetchTool(permissions, cwd),
-			tools.NewGlobTool(cwd),
-			tools.NewGrepTool(cwd),
-			tools.NewLsTool(permissions, cwd),
-			tools.NewSourcegraphTool(),
-			tools.NewViewTool(lspClients, permissions, cwd),
-			tools.NewWriteTool(lspClients, permissions, history, cwd),
-		}
+		allTools := CoreTools(cfg.WorkingDir(), permissions, history, todos, lspClients)
 
-		mcpToolsOnce.Do(func() {
-			mcpTools = doGetMCPTools(ctx, permissions, cfg)
-		})
-		allTools = append(allTools, mcpTools...)
-
-		if len(lspClients) > 0 {
-			allTools = append(allTools, tools.NewDiagnosticsTool(lspClients))
-		}
+		// The MCP tools are added to each request, since servers can change
+		// them at any time.
+		InitMCPClients(ctx, permissions, cfg)
 
-		if agentTool != nil {
-			allTools = append(allTools, agentTool)
-		}
-
-		if agentCfg.AllowedTools == nil {
-			return allTools
-		}
-
-		var filteredTools []tools.BaseTool
-		for _, tool := range allTools {
-			if slices.Contains(agentCfg.AllowedTools, tool.Name()) {
-				filteredTools = append(filteredTools, tool)
-			}
-		}
-		return filteredTools
+		allTools = append(allTools, agentTools...)
+		return allowedTools(agentCfg, allTools)
 	}
 
 	return &agent{
@@ -227,12 +240,14 @@ func NewAgent(
 		providerID:          string(providerCfg.ID),
 		messages:            messages,
 		sessions:            sessions,
+		todos:               todos,
 		titleProvider:       titleProvider,
 		summarizeProvider:   summarizeProvider,
 		summarizeProviderID: string(providerCfg.ID),
 		activeRequests:      csync.NewMap[string, context.CancelFunc](),
 		tools:               csync.NewLazySlice(toolFn),
 		promptQueue:         csync.NewMap[string, []string](),
+		planMode:            csync.NewMap[string, bool](),
 	}, nil
 }
 
@@ -341,7 +356,13 @@ func (a *agent) err(err error) AgentEvent {
 
 func (a *agent) Run(ctx context.Context, sessionID string, content string, attachments ...message.Attachment) (<-chan AgentEvent, error) {
 	if !a.Model().SupportsImages && attachments != nil {
-		attachments = nil
+		// Text attachments, such as MCP resources, work with any model, and
+		// so do documents, which are sent as their text to providers that
+		// can't read them.
+		attachments = slices.DeleteFunc(attachments, func(attachment message.Attachment) bool {
+			content := message.BinaryContent{MIMEType: attachment.MimeType}
+			return !content.IsText() && !content.IsPDF()
+		})
 	}
 	events := make(chan AgentEvent)
 	if a.IsSessionBusy(sessionID) {
@@ -364,9 +385,11 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 		})
 		var attachmentParts []message.ContentPart
 		for _, attachment := range attachments {
-			attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
+			part := message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content}
+			attachmentParts = append(attachmentParts, part.WithDocumentText())
 		}
 		result := a.processGeneration(genCtx, sessionID, content, attachmentParts)
+		result.SessionID = sessionID
 		if result.Error != nil && !errors.Is(result.Error, ErrRequestCancelled) && !errors.Is(result.Error, context.Canceled) {
 			slog.Error(result.Error.Error())
 		}
@@ -402,19 +425,7 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	if err != nil {
 		return a.err(fmt.Errorf("failed to get session: %w", err))
 	}
-	if session.SummaryMessageID != "" {
-		summaryMsgInex := -1
-		for i, msg := range msgs {
-			if msg.ID == session.SummaryMessageID {
-				summaryMsgInex = i
-				break
-			}
-		}
-		if summaryMsgInex != -1 {
-			msgs = msgs[summaryMsgInex:]
-			msgs[0].Role = message.User
-		}
-	}
+	msgs, _ = conversationHistory(session, msgs, cfg.Options.Compaction)
 
 	userMsg, err := a.createUserMessage(ctx, sessionID, content, attachmentParts)
 	if err != nil {
@@ -423,6 +434,7 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	// Append the new user message to the conversation history.
 	msgHistory := append(msgs, userMsg)
 
+	finishHookTurns := 0
 	for {
 		// Check for cancellation before each iteration
 		select {
@@ -446,6 +458,7 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 		if (agentMessage.FinishReason() == message.FinishReasonToolUse) && toolResults != nil {
 			// We are not done, we need to respond with the tool response
 			msgHistory = append(msgHistory, agentMessage, *toolResults)
+			msgHistory = a.compactIfNeeded(ctx, sessionID, msgHistory)
 			// If there are queued prompts, process the next one
 			nextPrompt, ok := a.promptQueue.Take(sessionID)
 			if ok {
@@ -462,6 +475,20 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 
 			continue
 		} else if agentMessage.FinishReason() == message.FinishReasonEndTurn {
+			msgHistory = append(msgHistory, agentMessage)
+			msgHistory = a.compactIfNeeded(ctx, sessionID, msgHistory)
+			if finishHookTurns < maxFinishHookTurns {
+				if feedback := a.finishHooks(ctx, sessionID, agentMessage); feedback != "" {
+					// Send the agent back to work with the hook feedback.
+					finishHookTurns++
+					userMsg, err := a.createUserMessage(ctx, sessionID, feedback, nil)
+					if err != nil {
+						return a.err(fmt.Errorf("failed to create user message for hook feedback: %w", err))
+					}
+					msgHistory = append(msgHistory, userMsg)
+					continue
+				}
+			}
 			queuePrompts, ok := a.promptQueue.Take(sessionID)
 			if ok {
 				for _, prompt := range queuePrompts {
@@ -515,7 +542,11 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 	}
 
 	// Now collect tools (which may block on MCP initialization)
-	eventChan := a.provider.StreamResponse(ctx, msgHistory, slices.Collect(a.tools.Seq()))
+	agentTools := a.sessionTools(sessionID)
+	if a.IsPlanMode(sessionID) {
+		msgHistory = planModeHistory(msgHistory)
+	}
+	eventChan := a.provider.StreamResponse(ctx, msgHistory, agentTools)
 
 	// Add the session and message ID into the context if needed by tools.
 	ctx = context.WithValue(ctx, tools.MessageIDContextKey, assistantMsg.ID)
@@ -554,7 +585,7 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 		default:
 			// Continue processing
 			var tool tools.BaseTool
-			for availableTool := range a.tools.Seq() {
+			for _, availableTool := range agentTools {
 				if availableTool.Info().Name == toolCall.Name {
 					tool = availableTool
 					break
@@ -571,15 +602,32 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 				continue
 			}
 
+			originalInput := toolCall.Input
+			toolCall, feedback, blocked := a.preToolUseHooks(ctx, sessionID, toolCall)
+			if blocked != nil {
+				toolResults[i] = *blocked
+				continue
+			}
+			if toolCall.Input != originalInput {
+				// Keep the stored tool call in sync with what actually runs.
+				assistantMsg.AddToolCall(toolCall)
+				if err := a.messages.Update(ctx, assistantMsg); err != nil {
+					slog.Error("Failed to update tool call input", "toolCall", toolCall.ID, "error", err)
+				}
+			}
+
 			// Run tool in goroutine to allow cancellation
 			type toolExecResult struct {
 				response tools.ToolResponse
 				err      error
 			}
 			resultChan := make(chan toolExecResult, 1)
+			// The permission_request hooks run deep in the tool, their
+			// feedback is collected to go with the tool result.
+			toolCtx, collector := hooks.WithCollector(ctx)
 
 			go func() {
-				response, err := tool.Run(ctx, tools.ToolCall{
+				response, err := tool.Run(toolCtx, tools.ToolCall{
 					ID:    toolCall.ID,
 					Name:  toolCall.Name,
 					Input: toolCall.Input,
@@ -612,7 +660,7 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 				if errors.Is(toolErr, permission.ErrorPermissionDenied) {
 					toolResults[i] = message.ToolResult{
 						ToolCallID: toolCall.ID,
-						Content:    "Permission denied",
+						Content:    withHookFeedback("Permission denied", feedback, collector.Feedback()),
 						IsError:    true,
 					}
 					for j := i + 1; j < len(toolCalls); j++ {
@@ -626,12 +674,13 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 					break
 				}
 			}
-			toolResults[i] = message.ToolResult{
+			toolResults[i] = a.postToolUseHooks(ctx, sessionID, toolCall, message.ToolResult{
 				ToolCallID: toolCall.ID,
 				Content:    toolResponse.Content,
 				Metadata:   toolResponse.Metadata,
 				IsError:    toolResponse.IsError,
-			}
+				Images:     toolResponse.Images,
+			}, feedback, collector.Feedback())
 		}
 	}
 out:
@@ -673,6 +722,9 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 		return a.messages.Update(ctx, *assistantMsg)
 	case provider.EventSignatureDelta:
 		assistantMsg.AppendReasoningSignature(event.Signature)
+		if event.ReasoningItemID != "" {
+			assistantMsg.SetReasoningItemID(event.ReasoningItemID)
+		}
 		return a.messages.Update(ctx, *assistantMsg)
 	case provider.EventContentDelta:
 		assistantMsg.FinishThinking()
@@ -696,6 +748,12 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 		assistantMsg.FinishThinking()
 		assistantMsg.SetToolCalls(event.Response.ToolCalls)
 		assistantMsg.AddFinish(event.Response.FinishReason, "", "")
+		assistantMsg.Usage = message.Usage{
+			InputTokens:         event.Response.Usage.InputTokens,
+			OutputTokens:        event.Response.Usage.OutputTokens,
+			CacheReadTokens:     event.Response.Usage.CacheReadTokens,
+			CacheCreationTokens: event.Response.Usage.CacheCreationTokens,
+		}
 		if err := a.messages.Update(ctx, *assistantMsg); err != nil {
 			return fmt.Errorf("failed to update message: %w", err)
 		}
@@ -705,28 +763,6 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 	return nil
 }
 
-func (a *agent) TrackUsage(ctx context.Context, sessionID string, model catwalk.Model, usage provider.TokenUsage) error {
-	sess, err := a.sessions.Get(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
-	}
-
-	cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-		model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-		model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-		model.CostPer1MOut/1e6*float64(usage.OutputTokens)
-
-	sess.Cost += cost
-	sess.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
-	sess.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
-
-	_, err = a.sessions.Save(ctx, sess)
-	if err != nil {
-		return fmt.Errorf("failed to save session: %w", err)
-	}
-	return nil
-}
-
 func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 	if a.summarizeProvider == nil {
 		return fmt.Errorf("summarize provider not available")
@@ -746,160 +782,29 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 	go func() {
 		defer a.activeRequests.Del(sessionID + "-summarize")
 		defer cancel()
-		event := AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Starting summarization...",
-		}
-
-		a.Publish(pubsub.CreatedEvent, event)
-		// Get all messages from the session
-		msgs, err := a.messages.List(summarizeCtx, sessionID)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to list messages: %w", err),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-		summarizeCtx = context.WithValue(summarizeCtx, tools.SessionIDContextKey, sessionID)
-
-		if len(msgs) == 0 {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("no messages to summarize"),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Analyzing conversation...",
-		}
-		a.Publish(pubsub.CreatedEvent, event)
-
-		// Add a system message to guide the summarization
-		summarizePrompt := "Provide a detailed but concise summary of our conversation above. Focus on information that would be helpful for continuing the conversation, including what we did, what we're doing, which files we're working on, and what we're going to do next."
-
-		// Create a new message with the summarize prompt
-		promptMsg := message.Message{
-			Role:  message.User,
-			Parts: []message.ContentPart{message.TextContent{Text: summarizePrompt}},
-		}
-
-		// Append the prompt to the messages
-		msgsWithPrompt := append(msgs, promptMsg)
-
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Generating summary...",
-		}
-
-		a.Publish(pubsub.CreatedEvent, event)
-
-		// Send the messages to the summarize provider
-		response := a.summarizeProvider.StreamResponse(
-			summarizeCtx,
-			msgsWithPrompt,
-			nil,
-		)
-		var finalResponse *provider.ProviderResponse
-		for r := range response {
-			if r.Error != nil {
-				event = AgentEvent{
-					Type:  AgentEventTypeError,
-					Error: fmt.Errorf("failed to summarize: %w", err),
-					Done:  true,
-				}
-				a.Publish(pubsub.CreatedEvent, event)
-				return
-			}
-			finalResponse = r.Response
-		}
-
-		summary := strings.TrimSpace(finalResponse.Content)
-		if summary == "" {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("empty summary returned"),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-		shell := shell.GetPersistentShell(config.Get().WorkingDir())
-		summary += "\n\n**Current working directory of the persistent shell**\n\n" + shell.GetWorkingDir()
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Creating new session...",
-		}
 
-		a.Publish(pubsub.CreatedEvent, event)
-		oldSession, err := a.sessions.Get(summarizeCtx, sessionID)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to get session: %w", err),
-				Done:  true,
-			}
-
-			a.Publish(pubsub.CreatedEvent, event)
-			return
+		progress := func(p string) {
+			a.Publish(pubsub.CreatedEvent, AgentEvent{
+				Type:     AgentEventTypeSummarize,
+				Progress: p,
+			})
 		}
-		// Create a message in the new session with the summary
-		msg, err := a.messages.Create(summarizeCtx, oldSession.ID, message.CreateMessageParams{
-			Role: message.Assistant,
-			Parts: []message.ContentPart{
-				message.TextContent{Text: summary},
-				message.Finish{
-					Reason: message.FinishReasonEndTurn,
-					Time:   time.Now().Unix(),
-				},
-			},
-			Model:    a.summarizeProvider.Model().ID,
-			Provider: a.summarizeProviderID,
-		})
-		if err != nil {
-			event = AgentEvent{
+		progress("Starting summarization...")
+		if err := a.summarize(summarizeCtx, sessionID, false, progress); err != nil {
+			a.Publish(pubsub.CreatedEvent, AgentEvent{
 				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to create summary message: %w", err),
+				Error: err,
 				Done:  true,
-			}
-
-			a.Publish(pubsub.CreatedEvent, event)
+			})
 			return
 		}
-		oldSession.SummaryMessageID = msg.ID
-		oldSession.CompletionTokens = finalResponse.Usage.OutputTokens
-		oldSession.PromptTokens = 0
-		model := a.summarizeProvider.Model()
-		usage := finalResponse.Usage
-		cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-			model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-			model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-			model.CostPer1MOut/1e6*float64(usage.OutputTokens)
-		oldSession.Cost += cost
-		_, err = a.sessions.Save(summarizeCtx, oldSession)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to save session: %w", err),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-		}
 
-		event = AgentEvent{
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
 			Type:      AgentEventTypeSummarize,
-			SessionID: oldSession.ID,
+			SessionID: sessionID,
 			Progress:  "Summary complete",
 			Done:      true,
-		}
-		a.Publish(pubsub.CreatedEvent, event)
-		// Send final success event with the new session ID
+		})
 	}()
 
 	return nil
@@ -948,14 +853,13 @@ func (a *agent) UpdateModel() error {
 			return fmt.Errorf("model not found for agent %s", a.agentCfg.Name)
 		}
 
-		promptID := agentPromptMap[a.agentCfg.ID]
-		if promptID == "" {
-			promptID = prompt.PromptDefault
+		systemMessage, err := systemPrompt(a.agentCfg, currentProviderCfg.ID)
+		if err != nil {
+			return fmt.Errorf("failed to build the system prompt: %w", err)
 		}
-
 		opts := []provider.ProviderClientOption{
 			provider.WithModel(a.agentCfg.Model),
-			provider.WithSystemMessage(prompt.GetPrompt(promptID, currentProviderCfg.ID, cfg.Options.ContextPaths...)),
+			provider.WithSystemMessage(systemMessage),
 		}
 
 		newProvider, err := provider.NewProvider(*currentProviderCfg, opts...)
@@ -0,0 +1,325 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/llm/provider"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig points the agent at a mock provider, so that the tests run
+// without network access or API keys.
+const testConfig = `{
+  "providers": {
+    "mock": {
+      "type": "mock",
+      "script": "empty.yaml",
+      "models": [{"id": "mock", "name": "Mock", "context_window": 200000, "default_max_tokens": 4096}]
+    }
+  },
+  "models": {
+    "large": {"provider": "mock", "model": "mock"},
+    "small": {"provider": "mock", "model": "mock"}
+  }
+}`
+
+var (
+	initConfigOnce sync.Once
+	initConfigErr  error
+)
+
+// initTestConfig loads the test configuration once, since the configuration
+// is global. It returns the working directory.
+func initTestConfig(t *testing.T) string {
+	t.Helper()
+	initConfigOnce.Do(func() {
+		root, err := os.MkdirTemp("", "crush-agent-test")
+		if err != nil {
+			initConfigErr = err
+			return
+		}
+		// Keep the user's configuration and the providers list out of the
+		// tests.
+		os.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+		os.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+		os.Setenv("CATWALK_URL", "http://127.0.0.1:1")
+
+		cwd := filepath.Join(root, "project")
+		files := map[string]string{
+			"crush.json": testConfig,
+			"empty.yaml": "turns: []\n",
+			"main.go":    "package main\n",
+		}
+		if err := os.MkdirAll(cwd, 0o755); err != nil {
+			initConfigErr = err
+			return
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(cwd, name), []byte(content), 0o644); err != nil {
+				initConfigErr = err
+				return
+			}
+		}
+		_, initConfigErr = config.Init(cwd, false)
+	})
+	require.NoError(t, initConfigErr)
+	return config.Get().WorkingDir()
+}
+
+type testAgent struct {
+	*agent
+	sessions session.Service
+	messages message.Service
+}
+
+// newTestAgent returns a coder agent answering from the script.
+func newTestAgent(t *testing.T, script provider.MockScript) *testAgent {
+	t.Helper()
+	cwd := initTestConfig(t)
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+
+	service, err := newAgent(
+		t.Context(),
+		config.Get().Agents["coder"],
+		nil,
+		permission.NewPermissionService(cwd, true, nil),
+		sessions,
+		messages,
+		history.NewService(q, conn),
+		todo.NewService(q),
+		nil,
+	)
+	require.NoError(t, err)
+	a := service.(*agent)
+	a.provider = provider.NewMockProvider(a.Model(), script)
+	a.titleProvider = nil
+	return &testAgent{agent: a, sessions: sessions, messages: messages}
+}
+
+func (a *testAgent) newSession(t *testing.T) string {
+	t.Helper()
+	sess, err := a.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	return sess.ID
+}
+
+func (a *testAgent) run(t *testing.T, sessionID, content string) AgentEvent {
+	t.Helper()
+	events, err := a.Run(t.Context(), sessionID, content)
+	require.NoError(t, err)
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the agent")
+		return AgentEvent{}
+	}
+}
+
+func loadScript(t *testing.T, name string) provider.MockScript {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	script, err := provider.ParseMockScript(data)
+	require.NoError(t, err)
+	return script
+}
+
+// spyProvider records the requests sent to a provider.
+type spyProvider struct {
+	provider.Provider
+
+	mu       sync.Mutex
+	requests [][]message.Message
+}
+
+func (p *spyProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan provider.ProviderEvent {
+	p.mu.Lock()
+	p.requests = append(p.requests, messages)
+	p.mu.Unlock()
+	return p.Provider.StreamResponse(ctx, messages, tools)
+}
+
+func TestAgentToolLoop(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAgent(t, loadScript(t, "tool_loop.yaml"))
+	sessionID := a.newSession(t)
+
+	result := a.run(t, sessionID, "what is in here?")
+	require.NoError(t, result.Error)
+	require.True(t, result.Done)
+	require.Equal(t, "There is a single Go file, main.go.", result.Message.Content().String())
+
+	msgs, err := a.messages.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	require.Equal(t, message.Assistant, msgs[1].Role)
+	require.Equal(t, "I should look at the files.", msgs[1].ReasoningContent().Thinking)
+	require.Equal(t, message.FinishReasonToolUse, msgs[1].FinishReason())
+	require.Len(t, msgs[1].ToolCalls(), 1)
+	require.Equal(t, "call_1_1", msgs[1].ToolCalls()[0].ID)
+	require.Equal(t, message.Tool, msgs[2].Role)
+	require.Contains(t, msgs[2].ToolResults()[0].Content, "main.go")
+	require.Equal(t, message.FinishReasonEndTurn, msgs[3].FinishReason())
+	require.Equal(t, message.Usage{InputTokens: 120, OutputTokens: 20}, msgs[1].Usage)
+	require.Equal(t, message.Usage{InputTokens: 180, OutputTokens: 12, CacheReadTokens: 100}, msgs[3].Usage)
+
+	sess, err := a.sessions.Get(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.Equal(t, int64(280), sess.PromptTokens)
+	require.Equal(t, int64(12), sess.CompletionTokens)
+	require.Equal(t, int64(400), sess.TotalPromptTokens)
+	require.Equal(t, int64(100), sess.CacheReadTokens)
+	require.InDelta(t, 0.25, sess.CacheHitRatio(), 1e-9)
+}
+
+func TestAgentCancel(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAgent(t, provider.MockScript{Turns: []provider.MockTurn{
+		{Delay: time.Minute, Content: "Too late."},
+	}})
+	sessionID := a.newSession(t)
+
+	events, err := a.Run(t.Context(), sessionID, "take your time")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		msgs, err := a.messages.List(t.Context(), sessionID)
+		return err == nil && len(msgs) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+	a.Cancel(sessionID)
+
+	result := <-events
+	require.ErrorIs(t, result.Error, ErrRequestCancelled)
+	require.False(t, a.IsSessionBusy(sessionID))
+
+	msgs, err := a.messages.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.FinishReasonCanceled, msgs[1].FinishReason())
+	require.Empty(t, msgs[1].Content().String())
+}
+
+func TestAgentQueue(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAgent(t, provider.MockScript{Turns: []provider.MockTurn{
+		{Expect: "first", Delay: 200 * time.Millisecond, Content: "First answer."},
+		{Expect: "second", Content: "Second answer."},
+	}})
+	sessionID := a.newSession(t)
+
+	events, err := a.Run(t.Context(), sessionID, "first prompt")
+	require.NoError(t, err)
+	queued, err := a.Run(t.Context(), sessionID, "second prompt")
+	require.NoError(t, err)
+	require.Nil(t, queued)
+	require.Equal(t, 1, a.QueuedPrompts(sessionID))
+
+	result := <-events
+	require.NoError(t, result.Error)
+	require.Equal(t, "Second answer.", result.Message.Content().String())
+	require.Zero(t, a.QueuedPrompts(sessionID))
+
+	msgs, err := a.messages.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, msg.Content().String())
+	}
+	require.Equal(t, []string{"first prompt", "First answer.", "second prompt", "Second answer."}, texts)
+}
+
+func TestAgentSummarize(t *testing.T) {
+	t.Parallel()
+
+	spy := &spyProvider{}
+	a := newTestAgent(t, provider.MockScript{Turns: []provider.MockTurn{
+		{Content: "Hello."},
+		{Expect: "what did we say", Content: "We said hello."},
+	}})
+	spy.Provider = a.provider
+	a.provider = spy
+	a.summarizeProvider = provider.NewMockProvider(a.Model(), provider.MockScript{Turns: []provider.MockTurn{
+		{Expect: summarizePrompt, Content: "The user greeted the assistant."},
+	}})
+	sessionID := a.newSession(t)
+
+	result := a.run(t, sessionID, "hi")
+	require.NoError(t, result.Error)
+
+	events := a.Subscribe(t.Context())
+	require.NoError(t, a.Summarize(t.Context(), sessionID))
+	for event := range events {
+		require.NoError(t, event.Payload.Error)
+		if event.Payload.Type == AgentEventTypeSummarize && event.Payload.Done {
+			break
+		}
+	}
+
+	sess, err := a.sessions.Get(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.NotEmpty(t, sess.SummaryMessageID)
+	summary, err := a.messages.Get(t.Context(), sess.SummaryMessageID)
+	require.NoError(t, err)
+	require.Contains(t, summary.Content().String(), "The user greeted the assistant.")
+
+	// The summary replaces the conversation it summarizes.
+	result = a.run(t, sessionID, "what did we say?")
+	require.NoError(t, result.Error)
+	require.Len(t, spy.requests, 2)
+	last := spy.requests[1]
+	require.Len(t, last, 2)
+	require.Contains(t, last[0].Content().String(), "The user greeted the assistant.")
+	require.Equal(t, "what did we say?", last[1].Content().String())
+}
+
+func TestAgentCassette(t *testing.T) {
+	t.Parallel()
+
+	cwd := initTestConfig(t)
+	cassette := config.CassetteConfig{
+		Path: filepath.Join(t.TempDir(), "tool_loop.json"),
+		Mode: config.CassetteModeRecord,
+	}
+
+	a := newTestAgent(t, loadScript(t, "tool_loop.yaml"))
+	recorder, err := provider.NewCassetteProvider(a.provider, cassette, cwd)
+	require.NoError(t, err)
+	a.provider = recorder
+	recorded := a.run(t, a.newSession(t), "what is in here?")
+	require.NoError(t, recorded.Error)
+
+	// Replaying needs neither the script nor the provider.
+	cassette.Mode = config.CassetteModeReplay
+	b := newTestAgent(t, provider.MockScript{})
+	b.provider, err = provider.NewCassetteProvider(nil, cassette, cwd)
+	require.NoError(t, err)
+	replayed := b.run(t, b.newSession(t), "what is in here?")
+	require.NoError(t, replayed.Error)
+	require.Equal(t, recorded.Message.Content().String(), replayed.Message.Content().String())
+
+	// Requests that weren't recorded fail.
+	result := b.run(t, b.newSession(t), "something else")
+	require.ErrorContains(t, result.Error, "no interaction recorded")
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/llm/prompt"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
+)
+
+var agentPromptMap = map[string]prompt.PromptID{
+	"coder": prompt.PromptCoder,
+	"task":  prompt.PromptTask,
+}
+
+// systemPrompt returns the system prompt of the agent. User-defined agents
+// read it from their prompt file.
+func systemPrompt(agentCfg config.Agent, providerID string) (string, error) {
+	if agentCfg.Prompt != "" {
+		return prompt.CustomPrompt(agentCfg.Prompt, agentCfg.ContextPaths...)
+	}
+	promptID := agentPromptMap[agentCfg.ID]
+	if promptID == "" {
+		promptID = prompt.PromptDefault
+	}
+	return prompt.GetPrompt(promptID, providerID, agentCfg.ContextPaths...), nil
+}
+
+// allowedTools returns the tools the agent is allowed to use.
+func allowedTools(agentCfg config.Agent, allTools []tools.BaseTool) []tools.BaseTool {
+	if agentCfg.AllowedTools == nil {
+		return allTools
+	}
+
+	var filteredTools []tools.BaseTool
+	for _, tool := range allTools {
+		if slices.Contains(agentCfg.AllowedTools, tool.Name()) {
+			filteredTools = append(filteredTools, tool)
+		}
+	}
+	return filteredTools
+}
+
+// subAgentTools creates the tools the primary agent uses to delegate work to
+// the task agent and to the user-defined agents. Sub-agents can not delegate
+// any further. A user-defined agent that fails to load is skipped so that it
+// does not prevent the primary agent from starting.
+func subAgentTools(
+	ctx context.Context,
+	primaryCfg config.Agent,
+	permissions permission.Service,
+	sessions session.Service,
+	messages message.Service,
+	history history.Service,
+	todos todo.Service,
+	lspClients map[string]*lsp.Client,
+) ([]tools.BaseTool, error) {
+	cfg := config.Get()
+	taskAgentCfg := cfg.Agents["task"]
+	if taskAgentCfg.ID == "" {
+		return nil, fmt.Errorf("task agent not found in config")
+	}
+	taskAgent, err := newAgent(ctx, taskAgentCfg, nil, permissions, sessions, messages, history, todos, lspClients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task agent: %w", err)
+	}
+	agentTools := []tools.BaseTool{NewAgentTool(taskAgent, sessions, messages)}
+
+	for _, agentCfg := range cfg.UserAgents() {
+		if agentCfg.ID == primaryCfg.ID {
+			continue
+		}
+		userAgent, err := newAgent(ctx, agentCfg, nil, permissions, sessions, messages, history, todos, lspClients)
+		if err != nil {
+			slog.Error("Failed to create agent", "agent", agentCfg.ID, "error", err)
+			continue
+		}
+		agentTools = append(agentTools, NewUserAgentTool(agentCfg, userAgent, sessions, messages))
+	}
+	return agentTools, nil
+}
@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentToolNames(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsAgentToolName(AgentToolName))
+	require.True(t, IsAgentToolName("agent_reviewer"))
+	require.False(t, IsAgentToolName("agents"))
+	require.False(t, IsAgentToolName("view"))
+
+	require.Equal(t, "task", AgentIDFromToolName(AgentToolName))
+	require.Equal(t, "reviewer", AgentIDFromToolName("agent_reviewer"))
+}
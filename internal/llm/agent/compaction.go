@@ -0,0 +1,377 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/provider"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/todo"
+)
+
+const (
+	summarizePrompt = "Provide a detailed but concise summary of our conversation above. Focus on information that would be helpful for continuing the conversation, including what we did, what we're doing, which files we're working on, and what we're going to do next."
+
+	droppedToolResultContent = "[Tool result removed to save context. Run the tool again if you need this output.]"
+
+	// Rough number of characters per token, used to estimate how much
+	// context was freed before the provider reports the real numbers.
+	charsPerToken = 4
+)
+
+// conversationHistory returns the messages that are sent to the model for
+// the given session. If the session was summarized, the summary replaces the
+// messages it covers. It also returns the index of the first message that
+// comes after the summary, which is 0 when there is no summary.
+func conversationHistory(sess session.Session, msgs []message.Message, opts *config.CompactionOptions) ([]message.Message, int) {
+	start := 0
+	if sess.SummaryMessageID != "" {
+		summaryIdx := slices.IndexFunc(msgs, func(m message.Message) bool {
+			return m.ID == sess.SummaryMessageID
+		})
+		if summaryIdx != -1 {
+			summary := msgs[summaryIdx]
+			summary.Role = message.User
+			history := []message.Message{summary}
+			if sess.SummaryKeepMessageID != "" {
+				keepIdx := slices.IndexFunc(msgs[:summaryIdx], func(m message.Message) bool {
+					return m.ID == sess.SummaryKeepMessageID
+				})
+				if keepIdx != -1 {
+					history = append(history, msgs[keepIdx:summaryIdx]...)
+				}
+			}
+			start = len(history)
+			msgs = append(history, msgs[summaryIdx+1:]...)
+		}
+	}
+	return compactedToolResults(msgs, opts), start
+}
+
+// compactedToolResults replaces the content of tool results that were marked
+// as compacted according to the configured strategy.
+func compactedToolResults(msgs []message.Message, opts *config.CompactionOptions) []message.Message {
+	result := make([]message.Message, len(msgs))
+	for i, msg := range msgs {
+		result[i] = msg
+		if msg.Role != message.Tool {
+			continue
+		}
+		parts := make([]message.ContentPart, len(msg.Parts))
+		for j, part := range msg.Parts {
+			if tr, ok := part.(message.ToolResult); ok && tr.Compacted {
+				if opts.Strategy == config.CompactionStrategyTruncateToolResults {
+					tr.Content = truncateToolResult(tr.Content, opts.MaxToolResultLength)
+				} else {
+					tr.Content = droppedToolResultContent
+				}
+				part = tr
+			}
+			parts[j] = part
+		}
+		result[i].Parts = parts
+	}
+	return result
+}
+
+// truncateToolResult keeps the beginning and the end of content, which is
+// where tool output is usually the most useful.
+func truncateToolResult(content string, maxLength int) string {
+	if len(content) <= maxLength {
+		return content
+	}
+	half := maxLength / 2
+	omitted := len(content) - 2*half
+	return fmt.Sprintf("%s\n\n[... %d characters truncated to save context ...]\n\n%s", content[:half], omitted, content[len(content)-half:])
+}
+
+// keepFromIndex returns the index of the first message of the last turns
+// turns, where a turn starts with a user message. It returns 0 if there are
+// not enough turns to keep anything out of the compaction.
+func keepFromIndex(msgs []message.Message, turns int) int {
+	seen := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != message.User {
+			continue
+		}
+		seen++
+		if seen == turns {
+			return i
+		}
+	}
+	return 0
+}
+
+// estimateTokens gives a rough token count for msgs.
+func estimateTokens(msgs []message.Message) int64 {
+	var chars int
+	for _, msg := range msgs {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case message.TextContent:
+				chars += len(p.Text)
+			case message.ReasoningContent:
+				chars += len(p.Thinking)
+			case message.ToolCall:
+				chars += len(p.Input)
+			case message.ToolResult:
+				chars += len(p.Content)
+			}
+		}
+	}
+	return int64(chars / charsPerToken)
+}
+
+func (a *agent) loadHistory(ctx context.Context, sessionID string) ([]message.Message, error) {
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	history, _ := conversationHistory(sess, msgs, config.Get().Options.Compaction)
+	return history, nil
+}
+
+// needsCompaction reports whether the last request of the session used
+// enough of the context window to trigger an automatic compaction.
+func (a *agent) needsCompaction(sess session.Session) bool {
+	cfg := config.Get()
+	if cfg.Options.DisableAutoSummarize {
+		return false
+	}
+	contextWindow := a.Model().ContextWindow
+	if contextWindow <= 0 {
+		return false
+	}
+	tokens := sess.PromptTokens + sess.CompletionTokens
+	return tokens >= cfg.Options.Compaction.ThresholdTokens(contextWindow)
+}
+
+// compactIfNeeded compacts the session when it is close to the context
+// limit, returning the history to continue with.
+func (a *agent) compactIfNeeded(ctx context.Context, sessionID string, msgHistory []message.Message) []message.Message {
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil || !a.needsCompaction(sess) {
+		return msgHistory
+	}
+
+	progress := func(p string) {
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeSummarize,
+			SessionID: sessionID,
+			Progress:  p,
+		})
+	}
+	strategy := config.Get().Options.Compaction.Strategy
+	slog.Info("Compacting conversation", "session_id", sessionID, "strategy", strategy)
+	if err := a.compact(ctx, sess, strategy, progress); err != nil {
+		slog.Error("Failed to compact conversation", "session_id", sessionID, "error", err)
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeError,
+			SessionID: sessionID,
+			Error:     fmt.Errorf("failed to compact conversation: %w", err),
+			Done:      true,
+		})
+		return msgHistory
+	}
+	a.Publish(pubsub.CreatedEvent, AgentEvent{
+		Type:      AgentEventTypeSummarize,
+		SessionID: sessionID,
+		Progress:  "Conversation compacted",
+		Done:      true,
+	})
+
+	history, err := a.loadHistory(ctx, sessionID)
+	if err != nil {
+		slog.Error("Failed to reload history after compaction", "session_id", sessionID, "error", err)
+		return msgHistory
+	}
+	return history
+}
+
+func (a *agent) compact(ctx context.Context, sess session.Session, strategy config.CompactionStrategy, progress func(string)) error {
+	switch strategy {
+	case config.CompactionStrategyDropToolResults, config.CompactionStrategyTruncateToolResults:
+		progress("Compacting tool results...")
+		sess, err := a.compactToolResults(ctx, sess, strategy)
+		if err != nil {
+			return err
+		}
+		if !a.needsCompaction(sess) {
+			return nil
+		}
+		// Tool results alone did not free enough room.
+		return a.summarize(ctx, sess.ID, true, progress)
+	case config.CompactionStrategySummarizeOldest:
+		return a.summarize(ctx, sess.ID, true, progress)
+	default:
+		return a.summarize(ctx, sess.ID, false, progress)
+	}
+}
+
+// compactToolResults marks the tool results outside of the recent turns as
+// compacted and lowers the session token count by the estimated savings.
+func (a *agent) compactToolResults(ctx context.Context, sess session.Session, strategy config.CompactionStrategy) (session.Session, error) {
+	opts := config.Get().Options.Compaction
+	msgs, err := a.messages.List(ctx, sess.ID)
+	if err != nil {
+		return sess, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	var freed int
+	for _, msg := range msgs[:keepFromIndex(msgs, opts.KeepRecentTurns)] {
+		if msg.Role != message.Tool {
+			continue
+		}
+		changed := false
+		for i, part := range msg.Parts {
+			tr, ok := part.(message.ToolResult)
+			if !ok || tr.Compacted {
+				continue
+			}
+			var compacted string
+			if strategy == config.CompactionStrategyTruncateToolResults {
+				if len(tr.Content) <= opts.MaxToolResultLength {
+					continue
+				}
+				compacted = truncateToolResult(tr.Content, opts.MaxToolResultLength)
+			} else {
+				compacted = droppedToolResultContent
+			}
+			freed += len(tr.Content) - len(compacted)
+			tr.Compacted = true
+			msg.Parts[i] = tr
+			changed = true
+		}
+		if changed {
+			if err := a.messages.Update(ctx, msg); err != nil {
+				return sess, fmt.Errorf("failed to update tool results: %w", err)
+			}
+		}
+	}
+
+	sess.PromptTokens = max(sess.PromptTokens-int64(freed/charsPerToken), 0)
+	return a.sessions.Save(ctx, sess)
+}
+
+// summarize replaces the conversation with a summary. When keepRecent is
+// true, the most recent turns are left out of the summary and kept verbatim.
+func (a *agent) summarize(ctx context.Context, sessionID string, keepRecent bool, progress func(string)) error {
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.messages.List(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("no messages to summarize")
+	}
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+
+	progress("Analyzing conversation...")
+	opts := config.Get().Options.Compaction
+	history, start := conversationHistory(sess, msgs, opts)
+	toSummarize, kept := history, []message.Message(nil)
+	if keepRecent {
+		// The kept messages must come after the current summary so that
+		// they can be found again when rebuilding the history.
+		keepIdx := max(keepFromIndex(history, opts.KeepRecentTurns), start)
+		if keepIdx > 0 && keepIdx < len(history) {
+			toSummarize, kept = history[:keepIdx], history[keepIdx:]
+		}
+	}
+
+	promptMsg := message.Message{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: summarizePrompt}},
+	}
+
+	progress("Generating summary...")
+	response := a.summarizeProvider.StreamResponse(
+		ctx,
+		append(slices.Clone(toSummarize), promptMsg),
+		nil,
+	)
+	var finalResponse *provider.ProviderResponse
+	for r := range response {
+		if r.Error != nil {
+			return fmt.Errorf("failed to summarize: %w", r.Error)
+		}
+		finalResponse = r.Response
+	}
+	if finalResponse == nil {
+		return fmt.Errorf("no response received from summarize provider")
+	}
+
+	summary := strings.TrimSpace(finalResponse.Content)
+	if summary == "" {
+		return fmt.Errorf("empty summary returned")
+	}
+	shell := shell.GetPersistentShell(config.Get().WorkingDir())
+	summary += "\n\n**Current working directory of the persistent shell**\n\n" + shell.GetWorkingDir()
+	todos, err := a.todos.List(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list todos: %w", err)
+	}
+	summary += openTodosReminder(todos)
+
+	progress("Saving summary...")
+	msg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: summary},
+			message.Finish{
+				Reason: message.FinishReasonEndTurn,
+				Time:   time.Now().Unix(),
+			},
+		},
+		Model:    a.summarizeProvider.Model().ID,
+		Provider: a.summarizeProviderID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create summary message: %w", err)
+	}
+
+	// The session may have changed while the summary was being generated.
+	sess, err = a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	sess.SummaryMessageID = msg.ID
+	sess.SummaryKeepMessageID = ""
+	if len(kept) > 0 {
+		sess.SummaryKeepMessageID = kept[0].ID
+	}
+	sess.CompletionTokens = finalResponse.Usage.OutputTokens
+	sess.PromptTokens = estimateTokens(kept)
+	sess.Cost += usageCost(a.summarizeProvider.Model(), finalResponse.Usage)
+	trackCacheUsage(&sess, a.summarizeProvider.Model(), finalResponse.Usage)
+	if _, err := a.sessions.Save(ctx, sess); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// openTodosReminder reminds the agent of the checklist items it has not
+// completed yet, so that the work is picked up again after a summary.
+func openTodosReminder(todos []todo.Todo) string {
+	open := todo.Open(todos)
+	if len(open) == 0 {
+		return ""
+	}
+	return "\n\n**Open todo items**\n\nThese items of the checklist are not completed yet. Keep working on them and update them with the todos tool as you go.\n\n" + strings.TrimSuffix(todo.Markdown(open, true), "\n")
+}
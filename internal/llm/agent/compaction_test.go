@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func textMsg(id string, role message.MessageRole, text string) message.Message {
+	return message.Message{
+		ID:    id,
+		Role:  role,
+		Parts: []message.ContentPart{message.TextContent{Text: text}},
+	}
+}
+
+func msgIDs(msgs []message.Message) []string {
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestConversationHistory(t *testing.T) {
+	t.Parallel()
+
+	opts := &config.CompactionOptions{Strategy: config.CompactionStrategySummarize}
+	msgs := []message.Message{
+		textMsg("u1", message.User, "first"),
+		textMsg("a1", message.Assistant, "first answer"),
+		textMsg("u2", message.User, "second"),
+		textMsg("a2", message.Assistant, "second answer"),
+		textMsg("s1", message.Assistant, "summary"),
+		textMsg("u3", message.User, "third"),
+	}
+
+	t.Run("no summary", func(t *testing.T) {
+		t.Parallel()
+		history, start := conversationHistory(session.Session{}, msgs, opts)
+		require.Equal(t, msgIDs(msgs), msgIDs(history))
+		require.Equal(t, 0, start)
+	})
+
+	t.Run("full summary", func(t *testing.T) {
+		t.Parallel()
+		history, start := conversationHistory(session.Session{SummaryMessageID: "s1"}, msgs, opts)
+		require.Equal(t, []string{"s1", "u3"}, msgIDs(history))
+		require.Equal(t, message.User, history[0].Role)
+		require.Equal(t, message.Assistant, msgs[4].Role)
+		require.Equal(t, 1, start)
+	})
+
+	t.Run("partial summary", func(t *testing.T) {
+		t.Parallel()
+		history, start := conversationHistory(session.Session{
+			SummaryMessageID:     "s1",
+			SummaryKeepMessageID: "u2",
+		}, msgs, opts)
+		require.Equal(t, []string{"s1", "u2", "a2", "u3"}, msgIDs(history))
+		require.Equal(t, 3, start)
+	})
+}
+
+func TestCompactedToolResults(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("a", 50) + strings.Repeat("b", 50)
+	msgs := []message.Message{
+		{
+			ID:   "t1",
+			Role: message.Tool,
+			Parts: []message.ContentPart{
+				message.ToolResult{ToolCallID: "1", Content: content, Compacted: true},
+				message.ToolResult{ToolCallID: "2", Content: content},
+			},
+		},
+	}
+
+	dropped := compactedToolResults(msgs, &config.CompactionOptions{
+		Strategy: config.CompactionStrategyDropToolResults,
+	})
+	require.Equal(t, droppedToolResultContent, dropped[0].ToolResults()[0].Content)
+	require.Equal(t, content, dropped[0].ToolResults()[1].Content)
+	require.Equal(t, content, msgs[0].ToolResults()[0].Content)
+
+	truncated := compactedToolResults(msgs, &config.CompactionOptions{
+		Strategy:            config.CompactionStrategyTruncateToolResults,
+		MaxToolResultLength: 20,
+	})
+	result := truncated[0].ToolResults()[0].Content
+	require.True(t, strings.HasPrefix(result, strings.Repeat("a", 10)))
+	require.True(t, strings.HasSuffix(result, strings.Repeat("b", 10)))
+	require.Contains(t, result, "80 characters truncated")
+}
+
+func TestKeepFromIndex(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		textMsg("u1", message.User, ""),
+		textMsg("a1", message.Assistant, ""),
+		textMsg("u2", message.User, ""),
+		textMsg("a2", message.Assistant, ""),
+		textMsg("u3", message.User, ""),
+	}
+	require.Equal(t, 4, keepFromIndex(msgs, 1))
+	require.Equal(t, 2, keepFromIndex(msgs, 2))
+	require.Equal(t, 0, keepFromIndex(msgs, 3))
+	require.Equal(t, 0, keepFromIndex(msgs, 10))
+}
+
+func TestOpenTodosReminder(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, openTodosReminder(nil))
+	require.Empty(t, openTodosReminder([]todo.Todo{
+		{ID: "1", Content: "done", Status: todo.StatusCompleted},
+	}))
+
+	reminder := openTodosReminder([]todo.Todo{
+		{ID: "1", Content: "done", Status: todo.StatusCompleted},
+		{ID: "2", Content: "doing", Status: todo.StatusInProgress},
+		{ID: "3", Content: "to do", Status: todo.StatusPending},
+	})
+	require.NotContains(t, reminder, "done")
+	require.Contains(t, reminder, "- [ ] doing (in progress) (id: 2)")
+	require.Contains(t, reminder, "- [ ] to do (id: 3)")
+}
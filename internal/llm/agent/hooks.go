@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// maxFinishHookTurns limits how many times the finish hooks can send the
+// agent back to work within a single request.
+const maxFinishHookTurns = 5
+
+// preToolUseHooks runs the hooks before a tool call. It returns the tool
+// call to run, with its input changed if a hook asked for it, and the
+// feedback of the hooks for the tool result, or the result to use instead
+// when a hook blocked the call.
+func (a *agent) preToolUseHooks(ctx context.Context, sessionID string, toolCall message.ToolCall) (message.ToolCall, string, *message.ToolResult) {
+	result := hooks.Run(ctx, hooks.Input{
+		Event:      config.HookPreToolUse,
+		SessionID:  sessionID,
+		Agent:      a.agentCfg.ID,
+		ToolName:   toolCall.Name,
+		ToolCallID: toolCall.ID,
+		ToolInput:  hooks.RawJSON(toolCall.Input),
+	})
+	if result.Blocked() {
+		content := "Tool call blocked by a hook"
+		if result.Reason != "" {
+			content += ": " + result.Reason
+		}
+		return toolCall, "", &message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    withHookFeedback(content, strings.Join(result.Messages, "\n")),
+			IsError:    true,
+		}
+	}
+	if result.ToolInput != nil {
+		toolCall.Input = string(result.ToolInput)
+	}
+	return toolCall, result.Feedback(), nil
+}
+
+// postToolUseHooks runs the hooks after a tool call and appends the feedback
+// of the earlier hooks of the call and their own to the tool result.
+func (a *agent) postToolUseHooks(ctx context.Context, sessionID string, toolCall message.ToolCall, toolResult message.ToolResult, feedback ...string) message.ToolResult {
+	result := hooks.Run(ctx, hooks.Input{
+		Event:      config.HookPostToolUse,
+		SessionID:  sessionID,
+		Agent:      a.agentCfg.ID,
+		ToolName:   toolCall.Name,
+		ToolCallID: toolCall.ID,
+		ToolInput:  hooks.RawJSON(toolCall.Input),
+		ToolResponse: &hooks.ToolResponse{
+			Content: toolResult.Content,
+			IsError: toolResult.IsError,
+		},
+	})
+	toolResult.Content = withHookFeedback(toolResult.Content, append(feedback, result.Feedback())...)
+	return toolResult
+}
+
+// withHookFeedback appends the feedback of hooks to the content of a tool
+// result.
+func withHookFeedback(content string, feedback ...string) string {
+	var lines []string
+	for _, f := range feedback {
+		if f != "" {
+			lines = append(lines, f)
+		}
+	}
+	if len(lines) == 0 {
+		return content
+	}
+	return fmt.Sprintf("%s\n\n<hook_feedback>\n%s\n</hook_feedback>", content, strings.Join(lines, "\n"))
+}
+
+// finishHooks runs the hooks when the agent finishes its turn and returns
+// their feedback, which is sent back to the agent.
+func (a *agent) finishHooks(ctx context.Context, sessionID string, agentMessage message.Message) string {
+	result := hooks.Run(ctx, hooks.Input{
+		Event:        config.HookFinish,
+		SessionID:    sessionID,
+		Agent:        a.agentCfg.ID,
+		Message:      agentMessage.Content().String(),
+		FinishReason: string(agentMessage.FinishReason()),
+	})
+	return result.Feedback()
+}
@@ -0,0 +1,18 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHookFeedback(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "done", withHookFeedback("done"))
+	require.Equal(t, "done", withHookFeedback("done", "", ""))
+	require.Equal(t,
+		"Permission denied\n\n<hook_feedback>\nasked the user\nlogged\n</hook_feedback>",
+		withHookFeedback("Permission denied", "asked the user", "", "logged"),
+	)
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MCPPrompt is a prompt offered by an MCP server.
+type MCPPrompt struct {
+	Server      string
+	Name        string
+	Description string
+	Arguments   []MCPPromptArgument
+}
+
+// MCPPromptArgument is an argument of an MCP prompt.
+type MCPPromptArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+var mcpPrompts = csync.NewMap[string, []mcp.Prompt]()
+
+// ListMCPPrompts returns the prompts offered by the connected MCP servers,
+// sorted by server and name.
+func ListMCPPrompts() []MCPPrompt {
+	var result []MCPPrompt
+	for name, prompts := range mcpPrompts.Seq2() {
+		for _, p := range prompts {
+			prompt := MCPPrompt{
+				Server:      name,
+				Name:        p.Name,
+				Description: p.Description,
+			}
+			for _, arg := range p.Arguments {
+				prompt.Arguments = append(prompt.Arguments, MCPPromptArgument{
+					Name:        arg.Name,
+					Description: arg.Description,
+					Required:    arg.Required,
+				})
+			}
+			result = append(result, prompt)
+		}
+	}
+	slices.SortFunc(result, func(a, b MCPPrompt) int {
+		return cmp.Or(
+			cmp.Compare(a.Server, b.Server),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return result
+}
+
+// GetMCPPrompt gets a prompt from an MCP server with the given arguments and
+// returns its messages as a single text.
+func GetMCPPrompt(ctx context.Context, server, name string, args map[string]string) (string, error) {
+	c, err := getOrRenewClient(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.GetPrompt(ctx, mcp.GetPromptRequest{
+		Params: mcp.GetPromptParams{
+			Name:      name,
+			Arguments: args,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+	text := promptText(result.Messages)
+	if text == "" {
+		return "", fmt.Errorf("prompt %s is empty", name)
+	}
+	return text, nil
+}
+
+// promptText joins the text of the prompt messages. Content that is not
+// text, like images, is left out.
+func promptText(messages []mcp.PromptMessage) string {
+	var parts []string
+	for _, msg := range messages {
+		switch content := msg.Content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, content.Text)
+		case mcp.EmbeddedResource:
+			if resource, ok := content.Resource.(mcp.TextResourceContents); ok {
+				parts = append(parts, FormatMCPResourceContents([]MCPResourceContent{{
+					URI:      resource.URI,
+					MIMEType: cmp.Or(resource.MIMEType, "text/plain"),
+					Text:     resource.Text,
+				}}))
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
+
+// discoverPrompts lists the prompts of the server and keeps the list up to
+// date with the server's notifications.
+func discoverPrompts(ctx context.Context, name string, c *client.Client) {
+	if c.GetServerCapabilities().Prompts == nil {
+		return
+	}
+	listPrompts(ctx, name, c)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method != mcp.MethodNotificationPromptsListChanged {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), mcpTimeout(config.Get().MCP[name]))
+			defer cancel()
+			listPrompts(ctx, name, c)
+		}()
+	})
+}
+
+func listPrompts(ctx context.Context, name string, c *client.Client) {
+	result, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		slog.Error("error listing mcp prompts", "error", err, "name", name)
+		return
+	}
+	mcpPrompts.Set(name, result.Prompts)
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMCPPrompts(t *testing.T) {
+	t.Parallel()
+
+	const server = "prompts-test"
+	mcpPrompts.Set(server, []mcp.Prompt{
+		mcp.NewPrompt("review", mcp.WithPromptDescription("Review a change"),
+			mcp.WithArgument("ticket", mcp.ArgumentDescription("The ticket ID"), mcp.RequiredArgument()),
+		),
+		mcp.NewPrompt("deploy"),
+	})
+	t.Cleanup(func() { mcpPrompts.Del(server) })
+
+	var got []MCPPrompt
+	for _, p := range ListMCPPrompts() {
+		if p.Server == server {
+			got = append(got, p)
+		}
+	}
+	require.Equal(t, []MCPPrompt{
+		{Server: server, Name: "deploy"},
+		{
+			Server:      server,
+			Name:        "review",
+			Description: "Review a change",
+			Arguments: []MCPPromptArgument{
+				{Name: "ticket", Description: "The ticket ID", Required: true},
+			},
+		},
+	}, got)
+}
+
+func TestPromptText(t *testing.T) {
+	t.Parallel()
+
+	got := promptText([]mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Review ticket 42.")),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewImageContent("aGk=", "image/png")),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+			URI:  "tickets://42",
+			Text: "Fix the login page",
+		})),
+	})
+	require.Equal(t, `Review ticket 42.
+
+<resource uri="tickets://42" mime_type="text/plain">
+Fix the login page
+</resource>`, got)
+}
@@ -0,0 +1,404 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MCPResource is a resource, or a resource template, offered by an MCP
+// server.
+type MCPResource struct {
+	Server      string
+	URI         string
+	Name        string
+	Description string
+	MIMEType    string
+	// Template is set when URI is an RFC 6570 template that has to be
+	// expanded before the resource can be read.
+	Template bool
+}
+
+// MCPResourceContent is the content of a resource read from an MCP server.
+type MCPResourceContent struct {
+	URI      string
+	MIMEType string
+	Text     string
+	Blob     []byte
+}
+
+// IsText reports whether the content is text.
+func (c MCPResourceContent) IsText() bool {
+	return c.Blob == nil
+}
+
+// Data returns the raw content.
+func (c MCPResourceContent) Data() []byte {
+	if c.IsText() {
+		return []byte(c.Text)
+	}
+	return c.Blob
+}
+
+type mcpResourceKey struct {
+	server string
+	uri    string
+}
+
+var (
+	mcpResources         = csync.NewMap[string, []mcp.Resource]()
+	mcpResourceTemplates = csync.NewMap[string, []mcp.ResourceTemplate]()
+	mcpResourceContents  = csync.NewMap[mcpResourceKey, []MCPResourceContent]()
+)
+
+// ListMCPResources returns the resources and resource templates offered by
+// the connected MCP servers, sorted by server and name.
+func ListMCPResources() []MCPResource {
+	var result []MCPResource
+	for name, resources := range mcpResources.Seq2() {
+		for _, r := range resources {
+			result = append(result, MCPResource{
+				Server:      name,
+				URI:         r.URI,
+				Name:        r.Name,
+				Description: r.Description,
+				MIMEType:    r.MIMEType,
+			})
+		}
+	}
+	for name, templates := range mcpResourceTemplates.Seq2() {
+		for _, t := range templates {
+			if t.URITemplate == nil || t.URITemplate.Template == nil {
+				continue
+			}
+			result = append(result, MCPResource{
+				Server:      name,
+				URI:         t.URITemplate.Raw(),
+				Name:        t.Name,
+				Description: t.Description,
+				MIMEType:    t.MIMEType,
+				Template:    true,
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b MCPResource) int {
+		return cmp.Or(
+			cmp.Compare(a.Server, b.Server),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.URI, b.URI),
+		)
+	})
+	return result
+}
+
+// ReadMCPResource reads a resource from an MCP server. The content is only
+// cached when the server lets us subscribe to the resource, which keeps it up
+// to date; otherwise it's read again every time.
+func ReadMCPResource(ctx context.Context, server, uri string) ([]MCPResourceContent, error) {
+	key := mcpResourceKey{server: server, uri: uri}
+	if contents, ok := mcpResourceContents.Get(key); ok {
+		return contents, nil
+	}
+
+	c, err := getOrRenewClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	if !isKnownResource(server, uri) {
+		return nil, fmt.Errorf("resource %s not found on mcp '%s'", uri, server)
+	}
+	contents, err := readResource(ctx, c, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if caps := c.GetServerCapabilities().Resources; caps != nil && caps.Subscribe {
+		if err := c.Subscribe(ctx, mcp.SubscribeRequest{Params: mcp.SubscribeParams{URI: uri}}); err != nil {
+			slog.Warn("Failed to subscribe to mcp resource", "name", server, "uri", uri, "error", err)
+		} else {
+			mcpResourceContents.Set(key, contents)
+		}
+	}
+	return contents, nil
+}
+
+// isKnownResource reports whether the server listed the resource, or one of
+// its resource templates matches it.
+func isKnownResource(server, uri string) bool {
+	resources, _ := mcpResources.Get(server)
+	if slices.ContainsFunc(resources, func(r mcp.Resource) bool { return r.URI == uri }) {
+		return true
+	}
+	templates, _ := mcpResourceTemplates.Get(server)
+	return slices.ContainsFunc(templates, func(t mcp.ResourceTemplate) bool {
+		return t.URITemplate != nil && t.URITemplate.Template != nil && t.URITemplate.Match(uri) != nil
+	})
+}
+
+func readResource(ctx context.Context, c *client.Client, uri string) ([]MCPResourceContent, error) {
+	result, err := c.ReadResource(ctx, mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	contents := make([]MCPResourceContent, 0, len(result.Contents))
+	for _, content := range result.Contents {
+		switch content := content.(type) {
+		case mcp.TextResourceContents:
+			contents = append(contents, MCPResourceContent{
+				URI:      content.URI,
+				MIMEType: cmp.Or(content.MIMEType, "text/plain"),
+				Text:     content.Text,
+			})
+		case mcp.BlobResourceContents:
+			data, err := base64.StdEncoding.DecodeString(content.Blob)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode resource %s: %w", content.URI, err)
+			}
+			contents = append(contents, MCPResourceContent{
+				URI:      content.URI,
+				MIMEType: cmp.Or(content.MIMEType, "application/octet-stream"),
+				Blob:     data,
+			})
+		}
+	}
+	return contents, nil
+}
+
+// discoverResources lists the resources and resource templates of the
+// server and keeps them, and the cached contents, up to date with the
+// server's notifications.
+func discoverResources(ctx context.Context, name string, c *client.Client) {
+	if c.GetServerCapabilities().Resources == nil {
+		return
+	}
+	listResources(ctx, name, c)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		switch notification.Method {
+		case mcp.MethodNotificationResourcesListChanged, mcp.MethodNotificationResourceUpdated:
+		default:
+			return
+		}
+		// Notifications are delivered by the transport's read loop, so the
+		// requests they trigger must not block it.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), mcpTimeout(config.Get().MCP[name]))
+			defer cancel()
+			if notification.Method == mcp.MethodNotificationResourcesListChanged {
+				listResources(ctx, name, c)
+				for key := range mcpResourceContents.Seq2() {
+					if key.server == name && !isKnownResource(name, key.uri) {
+						mcpResourceContents.Del(key)
+					}
+				}
+				return
+			}
+			uri, _ := notification.Params.AdditionalFields["uri"].(string)
+			refreshResource(ctx, name, c, uri)
+		}()
+	})
+}
+
+func listResources(ctx context.Context, name string, c *client.Client) {
+	resources, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		slog.Error("error listing mcp resources", "error", err, "name", name)
+	} else {
+		mcpResources.Set(name, resources.Resources)
+	}
+	templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		slog.Debug("error listing mcp resource templates", "error", err, "name", name)
+	} else {
+		mcpResourceTemplates.Set(name, templates.ResourceTemplates)
+	}
+}
+
+// refreshResource reads an updated resource again if it is cached.
+func refreshResource(ctx context.Context, name string, c *client.Client, uri string) {
+	key := mcpResourceKey{server: name, uri: uri}
+	if _, ok := mcpResourceContents.Get(key); !ok {
+		return
+	}
+	contents, err := readResource(ctx, c, uri)
+	if err != nil {
+		slog.Error("error refreshing mcp resource", "error", err, "name", name, "uri", uri)
+		mcpResourceContents.Del(key)
+		return
+	}
+	mcpResourceContents.Set(key, contents)
+}
+
+// forgetResources drops everything known about the resources of a server.
+func forgetResources(name string) {
+	mcpResources.Del(name)
+	mcpResourceTemplates.Del(name)
+	for key := range mcpResourceContents.Seq2() {
+		if key.server == name {
+			mcpResourceContents.Del(key)
+		}
+	}
+}
+
+// FormatMCPResourceContents renders the contents of a resource as text for
+// the model. Binary contents are only described.
+func FormatMCPResourceContents(contents []MCPResourceContent) string {
+	var sb strings.Builder
+	for i, content := range contents {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		if content.IsText() {
+			fmt.Fprintf(&sb, "<resource uri=%q mime_type=%q>\n%s\n</resource>", content.URI, content.MIMEType, content.Text)
+		} else {
+			fmt.Fprintf(&sb, "<resource uri=%q mime_type=%q>\n(binary content, %d bytes)\n</resource>", content.URI, content.MIMEType, len(content.Blob))
+		}
+	}
+	return sb.String()
+}
+
+type ReadMCPResourceParams struct {
+	Server string `json:"server"`
+	URI    string `json:"uri"`
+}
+
+// maxListedResources limits how many resources are listed in the tool
+// description.
+const maxListedResources = 50
+
+type readMCPResourceTool struct {
+	permissions permission.Service
+	workingDir  string
+}
+
+const (
+	ReadMCPResourceToolName        = "read_mcp_resource"
+	readMCPResourceToolDescription = `Reads a resource from an MCP server, such as a database schema, a document or a ticket.
+
+HOW TO USE:
+- Provide the name of the MCP server and the URI of the resource
+- The available resources are listed below
+- Resource templates use RFC 6570 syntax: expand the variables in braces to build the URI
+
+AVAILABLE RESOURCES:
+`
+)
+
+// NewReadMCPResourceTool creates the tool the agent uses to read MCP
+// resources.
+func NewReadMCPResourceTool(permissions permission.Service, workingDir string) tools.BaseTool {
+	return &readMCPResourceTool{
+		permissions: permissions,
+		workingDir:  workingDir,
+	}
+}
+
+func (t *readMCPResourceTool) Name() string {
+	return ReadMCPResourceToolName
+}
+
+func (t *readMCPResourceTool) Info() tools.ToolInfo {
+	var sb strings.Builder
+	sb.WriteString(readMCPResourceToolDescription)
+	resources := ListMCPResources()
+	for i, r := range resources {
+		if i == maxListedResources {
+			fmt.Fprintf(&sb, "- ...and %d more\n", len(resources)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "- server: %s, uri: %s, name: %s", r.Server, r.URI, r.Name)
+		if r.Template {
+			sb.WriteString(" (template)")
+		}
+		if r.Description != "" {
+			fmt.Fprintf(&sb, ": %s", r.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return tools.ToolInfo{
+		Name:        ReadMCPResourceToolName,
+		Description: sb.String(),
+		Parameters: map[string]any{
+			"server": map[string]any{
+				"type":        "string",
+				"description": "The name of the MCP server",
+			},
+			"uri": map[string]any{
+				"type":        "string",
+				"description": "The URI of the resource to read",
+			},
+		},
+		Required: []string{"server", "uri"},
+	}
+}
+
+func (t *readMCPResourceTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	var params ReadMCPResourceParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return tools.NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Server == "" || params.URI == "" {
+		return tools.NewTextErrorResponse("server and uri are required"), nil
+	}
+
+	sessionID, messageID := tools.GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return tools.ToolResponse{}, fmt.Errorf("session ID and message ID are required for reading a resource")
+	}
+	p := t.permissions.Request(ctx,
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			ToolCallID:  call.ID,
+			Path:        t.workingDir,
+			ToolName:    ReadMCPResourceToolName,
+			Action:      "read",
+			Description: fmt.Sprintf("Read resource %s from mcp %s", params.URI, params.Server),
+			Params:      params,
+		},
+	)
+	if !p {
+		return tools.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	contents, err := ReadMCPResource(ctx, params.Server, params.URI)
+	if err != nil {
+		return tools.NewTextErrorResponse(err.Error()), nil
+	}
+	if len(contents) == 0 {
+		return tools.NewTextResponse("The resource is empty."), nil
+	}
+	return tools.NewTextResponse(FormatMCPResourceContents(contents)), nil
+}
+
+// MCPResourceMention returns the @server:uri text that refers to a resource
+// in a prompt.
+func MCPResourceMention(server, uri string) string {
+	return "@" + server + ":" + uri
+}
+
+// ParseMCPResourceMention returns the server and URI of an @server:uri
+// mention, if it refers to a resource of a connected MCP server.
+func ParseMCPResourceMention(mention string) (server, uri string, ok bool) {
+	mention, ok = strings.CutPrefix(mention, "@")
+	if !ok {
+		return "", "", false
+	}
+	server, uri, ok = strings.Cut(mention, ":")
+	if !ok || server == "" || uri == "" || !isKnownResource(server, uri) {
+		return "", "", false
+	}
+	return server, uri, true
+}
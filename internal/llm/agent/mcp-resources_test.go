@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPResources(t *testing.T) {
+	t.Parallel()
+
+	const server = "resources-test"
+	mcpResources.Set(server, []mcp.Resource{
+		mcp.NewResource("db://schema", "schema"),
+	})
+	mcpResourceTemplates.Set(server, []mcp.ResourceTemplate{
+		mcp.NewResourceTemplate("tickets://{id}", "ticket"),
+	})
+	t.Cleanup(func() { forgetResources(server) })
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+		var got []MCPResource
+		for _, r := range ListMCPResources() {
+			if r.Server == server {
+				got = append(got, r)
+			}
+		}
+		require.Equal(t, []MCPResource{
+			{Server: server, URI: "db://schema", Name: "schema"},
+			{Server: server, URI: "tickets://{id}", Name: "ticket", Template: true},
+		}, got)
+	})
+
+	t.Run("mentions", func(t *testing.T) {
+		t.Parallel()
+		for mention, want := range map[string]string{
+			"@resources-test:db://schema":  "db://schema",
+			"@resources-test:tickets://42": "tickets://42",
+			"@resources-test:db://other":   "",
+			"@unknown:db://schema":         "",
+			"resources-test:db://schema":   "",
+			"@resources-test":              "",
+		} {
+			gotServer, gotURI, ok := ParseMCPResourceMention(mention)
+			require.Equal(t, want != "", ok, mention)
+			require.Equal(t, want, gotURI, mention)
+			if ok {
+				require.Equal(t, server, gotServer)
+				require.Equal(t, mention, MCPResourceMention(gotServer, gotURI))
+			}
+		}
+	})
+}
+
+func TestForgetResources(t *testing.T) {
+	t.Parallel()
+
+	const server = "forget-test"
+	mcpResources.Set(server, []mcp.Resource{mcp.NewResource("file:///a", "a")})
+	mcpResourceContents.Set(mcpResourceKey{server: server, uri: "file:///a"}, []MCPResourceContent{{Text: "a"}})
+
+	forgetResources(server)
+	require.False(t, isKnownResource(server, "file:///a"))
+	_, ok := mcpResourceContents.Get(mcpResourceKey{server: server, uri: "file:///a"})
+	require.False(t, ok)
+}
+
+func TestFormatMCPResourceContents(t *testing.T) {
+	t.Parallel()
+
+	got := FormatMCPResourceContents([]MCPResourceContent{
+		{URI: "db://schema", MIMEType: "text/plain", Text: "CREATE TABLE t;"},
+		{URI: "img://logo", MIMEType: "image/png", Blob: []byte{1, 2, 3}},
+	})
+	require.Equal(t, `<resource uri="db://schema" mime_type="text/plain">
+CREATE TABLE t;
+</resource>
+
+<resource uri="img://logo" mime_type="image/png">
+(binary content, 3 bytes)
+</resource>`, got)
+}
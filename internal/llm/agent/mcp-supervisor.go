@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+const (
+	mcpHealthCheckInterval = 30 * time.Second
+	mcpRestartMinBackoff   = time.Second
+	mcpRestartMaxBackoff   = time.Minute
+	// mcpMaxRestarts is the number of restarts in a row after which a server
+	// is left in the error state until it is restarted by hand.
+	mcpMaxRestarts = 8
+)
+
+var (
+	// mcpRestartLocks holds a mutex per server so that a server is only
+	// restarted once at a time.
+	mcpRestartLocks sync.Map
+	// mcpSupervisors holds the servers that are currently supervised.
+	mcpSupervisors sync.Map
+
+	mcpSupervisorCtx, mcpSupervisorCancel = context.WithCancel(context.Background())
+)
+
+// RestartMCPClient restarts an MCP server, for example after it crashed or
+// its configuration changed.
+func RestartMCPClient(ctx context.Context, name string) error {
+	m, ok := config.Get().MCP[name]
+	if !ok {
+		return fmt.Errorf("mcp '%s' not found", name)
+	}
+	if m.Disabled {
+		return fmt.Errorf("mcp '%s' is disabled", name)
+	}
+	if _, err := restartMCPClient(ctx, name, m); err != nil {
+		return err
+	}
+	if m.Type == config.MCPStdio {
+		superviseMCPClient(name, m)
+	}
+	return nil
+}
+
+// restartMCPClient closes the client of a server, if any, and starts a new
+// one.
+func restartMCPClient(ctx context.Context, name string, m config.MCPConfig) (*client.Client, error) {
+	lock, _ := mcpRestartLocks.LoadOrStore(name, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if c, ok := mcpClients.Take(name); ok {
+		_ = c.Close()
+	}
+	state, _ := mcpStates.Get(name)
+	updateMCPState(name, MCPStateRestarting, nil, nil, state.ToolCount)
+
+	ctx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
+	defer cancel()
+	c, err := createAndInitializeClient(ctx, name, m)
+	if err != nil {
+		forgetServerFeatures(name)
+		return nil, err
+	}
+	if err := connectMCPClient(ctx, name, c); err != nil {
+		return nil, err
+	}
+	slog.Info("Restarted mcp client", "name", name)
+	return c, nil
+}
+
+// superviseMCPClient checks the health of a stdio server in the background
+// and restarts it with an exponential backoff when it stops responding.
+func superviseMCPClient(name string, m config.MCPConfig) {
+	if _, running := mcpSupervisors.LoadOrStore(name, true); running {
+		return
+	}
+	go func() {
+		defer mcpSupervisors.Delete(name)
+		ctx := mcpSupervisorCtx
+		ticker := time.NewTicker(mcpHealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if isMCPClientHealthy(ctx, name, m) {
+				continue
+			}
+			if !restartWithBackoff(ctx, name, m) {
+				slog.Error("Giving up restarting mcp client", "name", name, "attempts", mcpMaxRestarts)
+				return
+			}
+		}
+	}()
+}
+
+func isMCPClientHealthy(ctx context.Context, name string, m config.MCPConfig) bool {
+	c, ok := mcpClients.Get(name)
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		slog.Warn("mcp client is not responding", "name", name, "error", err)
+		state, _ := mcpStates.Get(name)
+		updateMCPState(name, MCPStateError, err, nil, state.ToolCount)
+		return false
+	}
+	return true
+}
+
+// restartWithBackoff restarts the server until it succeeds, waiting twice
+// as long after each failure. It returns false when it gave up.
+func restartWithBackoff(ctx context.Context, name string, m config.MCPConfig) bool {
+	for attempt := range mcpMaxRestarts {
+		backoff := mcpRestartBackoff(attempt)
+		slog.Info("Restarting mcp client", "name", name, "attempt", attempt+1, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		if _, err := restartMCPClient(ctx, name, m); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mcpRestartBackoff returns how long to wait before a restart attempt.
+func mcpRestartBackoff(attempt int) time.Duration {
+	backoff := mcpRestartMinBackoff << attempt
+	if backoff <= 0 || backoff > mcpRestartMaxBackoff {
+		return mcpRestartMaxBackoff
+	}
+	return backoff
+}
+
+func stopMCPSupervisors() {
+	mcpSupervisorCancel()
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPRestartBackoff(t *testing.T) {
+	t.Parallel()
+
+	for attempt, want := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		32 * time.Second,
+		time.Minute,
+		time.Minute,
+	} {
+		require.Equal(t, want, mcpRestartBackoff(attempt), "attempt %d", attempt)
+	}
+	require.Equal(t, time.Minute, mcpRestartBackoff(100))
+}
+
+func TestGetMCPTools(t *testing.T) {
+	t.Parallel()
+
+	mcpServerTools.Set("tools-test-b", []tools.BaseTool{namedTool{"mcp_tools-test-b_run"}})
+	mcpServerTools.Set("tools-test-a", []tools.BaseTool{
+		namedTool{"mcp_tools-test-a_list"},
+		namedTool{"mcp_tools-test-a_get"},
+	})
+	t.Cleanup(func() {
+		forgetServerFeatures("tools-test-a")
+		forgetServerFeatures("tools-test-b")
+	})
+
+	var got []string
+	for _, tool := range getMCPTools() {
+		if tool.Name() != ReadMCPResourceToolName {
+			got = append(got, tool.Name())
+		}
+	}
+	require.Equal(t, []string{
+		"mcp_tools-test-a_list",
+		"mcp_tools-test-a_get",
+		"mcp_tools-test-b_run",
+	}, got)
+
+	allowed := allowedTools(config.Agent{AllowedTools: []string{"mcp_tools-test-b_run"}}, getMCPTools())
+	require.Len(t, allowed, 1)
+	require.Equal(t, "mcp_tools-test-b_run", allowed[0].Name())
+}
@@ -3,10 +3,12 @@ package agent
 import (
 	"cmp"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"maps"
+	"net/http"
 	"slices"
 	"strings"
 	"sync"
@@ -15,6 +17,8 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/mcpauth"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/version"
@@ -31,6 +35,8 @@ const (
 	MCPStateStarting
 	MCPStateConnected
 	MCPStateError
+	MCPStateRestarting
+	MCPStateAuthRequired
 )
 
 func (s MCPState) String() string {
@@ -43,6 +49,10 @@ func (s MCPState) String() string {
 		return "connected"
 	case MCPStateError:
 		return "error"
+	case MCPStateRestarting:
+		return "restarting"
+	case MCPStateAuthRequired:
+		return "auth_required"
 	default:
 		return "unknown"
 	}
@@ -75,11 +85,17 @@ type MCPClientInfo struct {
 }
 
 var (
-	mcpToolsOnce sync.Once
-	mcpTools     []tools.BaseTool
-	mcpClients   = csync.NewMap[string, *client.Client]()
-	mcpStates    = csync.NewMap[string, MCPClientInfo]()
-	mcpBroker    = pubsub.NewBroker[MCPEvent]()
+	mcpInitOnce    sync.Once
+	mcpClients     = csync.NewMap[string, *client.Client]()
+	mcpServerTools = csync.NewMap[string, []tools.BaseTool]()
+	mcpStates      = csync.NewMap[string, MCPClientInfo]()
+	mcpBroker      = pubsub.NewBroker[MCPEvent]()
+
+	// mcpPermissions and mcpWorkingDir are used by the tools of every MCP
+	// server, including the ones loaded after a server restarts or changes
+	// its tools.
+	mcpPermissions permission.Service
+	mcpWorkingDir  string
 )
 
 type McpTool struct {
@@ -112,29 +128,44 @@ func runTool(ctx context.Context, name, toolName string, input string) (tools.To
 		return tools.NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
 	}
 
-	c, err := getOrRenewClient(ctx, name)
-	if err != nil {
-		return tools.NewTextErrorResponse(err.Error()), nil
-	}
-	result, err := c.CallTool(ctx, mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      toolName,
-			Arguments: args,
-		},
-	})
+	result, err := CallMCPTool(ctx, name, toolName, args)
 	if err != nil {
 		return tools.NewTextErrorResponse(err.Error()), nil
 	}
 
 	output := make([]string, 0, len(result.Content))
+	var images []message.ToolResultImage
 	for _, v := range result.Content {
 		if v, ok := v.(mcp.TextContent); ok {
 			output = append(output, v.Text)
 		} else {
 			output = append(output, fmt.Sprintf("%v", v))
 		}
+		// Images are also kept to be shown in the chat.
+		if v, ok := v.(mcp.ImageContent); ok {
+			if data, err := base64.StdEncoding.DecodeString(v.Data); err == nil {
+				images = append(images, message.ToolResultImage{MIMEType: v.MIMEType, Data: data})
+			}
+		}
 	}
-	return tools.NewTextResponse(strings.Join(output, "\n")), nil
+	response := tools.NewTextResponse(strings.Join(output, "\n"))
+	response.Images = images
+	return response, nil
+}
+
+// CallMCPTool calls a tool of an MCP server. Unlike the MCP tools given to
+// the agents, it does not ask for permission first.
+func CallMCPTool(ctx context.Context, server, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+	c, err := getOrRenewClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return c.CallTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      tool,
+			Arguments: args,
+		},
+	})
 }
 
 func getOrRenewClient(ctx context.Context, name string) (*client.Client, error) {
@@ -153,15 +184,7 @@ func getOrRenewClient(ctx context.Context, name string) (*client.Client, error)
 		return c, nil
 	}
 	updateMCPState(name, MCPStateError, err, nil, state.ToolCount)
-
-	c, err = createAndInitializeClient(ctx, name, m)
-	if err != nil {
-		return nil, err
-	}
-
-	updateMCPState(name, MCPStateConnected, nil, c, state.ToolCount)
-	mcpClients.Set(name, c)
-	return c, nil
+	return restartMCPClient(ctx, name, m)
 }
 
 func (b *McpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
@@ -170,7 +193,7 @@ func (b *McpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolRes
 		return tools.ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
 	permissionDescription := fmt.Sprintf("execute %s with the following parameters: %s", b.Info().Name, params.Input)
-	p := b.permissions.Request(
+	p := b.permissions.Request(ctx,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			ToolCallID:  params.ID,
@@ -188,25 +211,102 @@ func (b *McpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolRes
 	return runTool(ctx, b.mcpName, b.tool.Name, params.Input)
 }
 
-func getTools(ctx context.Context, name string, permissions permission.Service, c *client.Client, workingDir string) []tools.BaseTool {
-	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+// connectMCPClient loads the tools, resources and prompts of an initialized
+// client and keeps its tools up to date with the server's notifications.
+func connectMCPClient(ctx context.Context, name string, c *client.Client) error {
+	mcpClients.Set(name, c)
+	discoverServerFeatures(ctx, name, c)
+	toolCount, err := loadTools(ctx, name, c)
 	if err != nil {
-		slog.Error("error listing tools", "error", err)
+		slog.Error("error listing tools", "error", err, "name", name)
 		updateMCPState(name, MCPStateError, err, nil, 0)
 		c.Close()
 		mcpClients.Del(name)
-		return nil
+		forgetServerFeatures(name)
+		return err
 	}
-	mcpTools := make([]tools.BaseTool, 0, len(result.Tools))
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method != mcp.MethodNotificationToolsListChanged {
+			return
+		}
+		// Notifications are delivered by the transport's read loop, so the
+		// requests they trigger must not block it.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), mcpTimeout(config.Get().MCP[name]))
+			defer cancel()
+			toolCount, err := loadTools(ctx, name, c)
+			if err != nil {
+				slog.Error("error refreshing tools", "error", err, "name", name)
+				return
+			}
+			slog.Info("Refreshed mcp tools", "name", name, "tools", toolCount)
+			updateMCPState(name, MCPStateConnected, nil, c, toolCount)
+		}()
+	})
+	updateMCPState(name, MCPStateConnected, nil, c, toolCount)
+	return nil
+}
+
+// loadTools lists the tools of the server and replaces the ones the agents
+// use.
+func loadTools(ctx context.Context, name string, c *client.Client) (int, error) {
+	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return 0, err
+	}
+	serverTools := make([]tools.BaseTool, 0, len(result.Tools))
 	for _, tool := range result.Tools {
-		mcpTools = append(mcpTools, &McpTool{
+		serverTools = append(serverTools, &McpTool{
 			mcpName:     name,
 			tool:        tool,
-			permissions: permissions,
-			workingDir:  workingDir,
+			permissions: mcpPermissions,
+			workingDir:  mcpWorkingDir,
 		})
 	}
-	return mcpTools
+	mcpServerTools.Set(name, serverTools)
+	return len(serverTools), nil
+}
+
+// MCPServerTools returns the tools of a connected MCP server as the server
+// describes them.
+func MCPServerTools(name string) []mcp.Tool {
+	serverTools, _ := mcpServerTools.Get(name)
+	result := make([]mcp.Tool, 0, len(serverTools))
+	for _, tool := range serverTools {
+		if tool, ok := tool.(*McpTool); ok {
+			result = append(result, tool.tool)
+		}
+	}
+	return result
+}
+
+// getMCPTools returns the current tools of all the MCP servers.
+func getMCPTools() []tools.BaseTool {
+	var result []tools.BaseTool
+	names := slices.Sorted(maps.Keys(maps.Collect(mcpServerTools.Seq2())))
+	for _, name := range names {
+		serverTools, _ := mcpServerTools.Get(name)
+		result = append(result, serverTools...)
+	}
+	if mcpResources.Len() > 0 {
+		result = append(result, NewReadMCPResourceTool(mcpPermissions, mcpWorkingDir))
+	}
+	return result
+}
+
+// discoverServerFeatures lists the resources and prompts the server offers
+// besides its tools.
+func discoverServerFeatures(ctx context.Context, name string, c *client.Client) {
+	discoverResources(ctx, name, c)
+	discoverPrompts(ctx, name, c)
+}
+
+// forgetServerFeatures drops the tools, resources and prompts of a server
+// that is no longer available.
+func forgetServerFeatures(name string) {
+	mcpServerTools.Del(name)
+	forgetResources(name)
+	mcpPrompts.Del(name)
 }
 
 // SubscribeMCPEvents returns a channel for MCP events
@@ -250,6 +350,7 @@ func updateMCPState(name string, state MCPState, err error, client *client.Clien
 
 // CloseMCPClients closes all MCP clients. This should be called during application shutdown.
 func CloseMCPClients() {
+	stopMCPSupervisors()
 	for c := range mcpClients.Seq() {
 		_ = c.Close()
 	}
@@ -266,9 +367,20 @@ var mcpInitRequest = mcp.InitializeRequest{
 	},
 }
 
-func doGetMCPTools(ctx context.Context, permissions permission.Service, cfg *config.Config) []tools.BaseTool {
+// InitMCPClients starts the configured MCP servers, unless an agent already
+// did. It is meant for commands that talk to the servers without an agent.
+func InitMCPClients(ctx context.Context, permissions permission.Service, cfg *config.Config) {
+	mcpInitOnce.Do(func() {
+		initMCPClients(ctx, permissions, cfg)
+	})
+}
+
+// initMCPClients starts the configured MCP servers and waits for them to be
+// initialized. Servers started with stdio are supervised afterwards.
+func initMCPClients(ctx context.Context, permissions permission.Service, cfg *config.Config) {
 	var wg sync.WaitGroup
-	result := csync.NewSlice[tools.BaseTool]()
+	mcpPermissions = permissions
+	mcpWorkingDir = cfg.WorkingDir()
 
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.MCP {
@@ -300,25 +412,25 @@ func doGetMCPTools(ctx context.Context, permissions permission.Service, cfg *con
 				}
 			}()
 
+			if m.Type == config.MCPStdio {
+				defer superviseMCPClient(name, m)
+			}
+
 			ctx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
 			defer cancel()
 			c, err := createAndInitializeClient(ctx, name, m)
 			if err != nil {
 				return
 			}
-			mcpClients.Set(name, c)
-
-			tools := getTools(ctx, name, permissions, c, cfg.WorkingDir())
-			updateMCPState(name, MCPStateConnected, nil, c, len(tools))
-			result.Append(tools...)
+			_ = connectMCPClient(ctx, name, c)
 		}(name, m)
 	}
 	wg.Wait()
-	return slices.Collect(result.Seq())
 }
 
 func createAndInitializeClient(ctx context.Context, name string, m config.MCPConfig) (*client.Client, error) {
-	c, err := createMcpClient(m)
+	tracker := mcpauth.NewTracker()
+	c, err := createMcpClient(name, m, tracker)
 	if err != nil {
 		updateMCPState(name, MCPStateError, err, nil, 0)
 		slog.Error("error creating mcp client", "error", err, "name", name)
@@ -327,14 +439,14 @@ func createAndInitializeClient(ctx context.Context, name string, m config.MCPCon
 	// Only call Start() for non-stdio clients, as stdio clients auto-start
 	if m.Type != config.MCPStdio {
 		if err := c.Start(ctx); err != nil {
-			updateMCPState(name, MCPStateError, err, nil, 0)
+			updateMCPState(name, initErrorState(err, tracker), err, nil, 0)
 			slog.Error("error starting mcp client", "error", err, "name", name)
 			_ = c.Close()
 			return nil, err
 		}
 	}
 	if _, err := c.Initialize(ctx, mcpInitRequest); err != nil {
-		updateMCPState(name, MCPStateError, err, nil, 0)
+		updateMCPState(name, initErrorState(err, tracker), err, nil, 0)
 		slog.Error("error initializing mcp client", "error", err, "name", name)
 		_ = c.Close()
 		return nil, err
@@ -344,7 +456,17 @@ func createAndInitializeClient(ctx context.Context, name string, m config.MCPCon
 	return c, nil
 }
 
-func createMcpClient(m config.MCPConfig) (*client.Client, error) {
+// initErrorState returns the state of a server that failed to start, which
+// is MCPStateAuthRequired when the user needs to log in to it.
+func initErrorState(err error, tracker *mcpauth.Tracker) MCPState {
+	if mcpauth.IsAuthRequired(err) || tracker.Unauthorized() {
+		return MCPStateAuthRequired
+	}
+	return MCPStateError
+}
+
+func createMcpClient(name string, m config.MCPConfig, tracker *mcpauth.Tracker) (*client.Client, error) {
+	httpClient := &http.Client{Transport: tracker}
 	switch m.Type {
 	case config.MCPStdio:
 		return client.NewStdioMCPClientWithOptions(
@@ -354,22 +476,39 @@ func createMcpClient(m config.MCPConfig) (*client.Client, error) {
 			transport.WithCommandLogger(mcpLogger{}),
 		)
 	case config.MCPHttp:
-		return client.NewStreamableHttpClient(
-			m.URL,
+		opts := []transport.StreamableHTTPCOption{
 			transport.WithHTTPHeaders(m.ResolvedHeaders()),
 			transport.WithHTTPLogger(mcpLogger{}),
-		)
+			transport.WithHTTPBasicClient(httpClient),
+		}
+		if dataDir := mcpDataDir(); mcpauth.Enabled(dataDir, name, m) {
+			return client.NewOAuthStreamableHttpClient(m.URL, mcpauth.ClientConfig(dataDir, name, m), opts...)
+		}
+		return client.NewStreamableHttpClient(m.URL, opts...)
 	case config.MCPSse:
-		return client.NewSSEMCPClient(
-			m.URL,
+		opts := []transport.ClientOption{
 			client.WithHeaders(m.ResolvedHeaders()),
 			transport.WithSSELogger(mcpLogger{}),
-		)
+			transport.WithHTTPClient(httpClient),
+		}
+		if dataDir := mcpDataDir(); mcpauth.Enabled(dataDir, name, m) {
+			return client.NewOAuthSSEClient(m.URL, mcpauth.ClientConfig(dataDir, name, m), opts...)
+		}
+		return client.NewSSEMCPClient(m.URL, opts...)
 	default:
 		return nil, fmt.Errorf("unsupported mcp type: %s", m.Type)
 	}
 }
 
+// mcpDataDir returns the directory where the OAuth credentials of the MCP
+// servers are stored.
+func mcpDataDir() string {
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil {
+		return cfg.Options.DataDirectory
+	}
+	return ""
+}
+
 // for MCP's clients.
 type mcpLogger struct{}
 
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/llm/prompt"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// planModeWithheldTools are removed from the agent while a session is in
+// plan mode. Bash stays available but only runs read-only commands.
+var planModeWithheldTools = []string{
+	tools.DownloadToolName,
+	tools.EditToolName,
+	tools.MultiEditToolName,
+	tools.WriteToolName,
+}
+
+func (a *agent) SetPlanMode(sessionID string, enabled bool) {
+	if enabled {
+		a.planMode.Set(sessionID, true)
+		return
+	}
+	a.planMode.Del(sessionID)
+}
+
+func (a *agent) IsPlanMode(sessionID string) bool {
+	_, ok := a.planMode.Get(sessionID)
+	return ok
+}
+
+// PlanModeSessions returns the IDs of the sessions in plan mode.
+func (a *agent) PlanModeSessions() []string {
+	var ids []string
+	for id := range a.planMode.Seq2() {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ApprovePlan turns plan mode off for the session and asks the agent to
+// implement the approved plan.
+func (a *agent) ApprovePlan(ctx context.Context, sessionID string, plan string) (<-chan AgentEvent, error) {
+	if a.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	a.SetPlanMode(sessionID, false)
+	return a.Run(ctx, sessionID, prompt.ApprovedPlanPrompt(plan))
+}
+
+// sessionTools returns the tools available to the agent in the given
+// session.
+func (a *agent) sessionTools(sessionID string) []tools.BaseTool {
+	allTools := slices.Collect(a.tools.Seq())
+	allTools = append(allTools, allowedTools(a.agentCfg, getMCPTools())...)
+	if !a.IsPlanMode(sessionID) {
+		return allTools
+	}
+	return planModeTools(allTools)
+}
+
+// planModeTools drops the tools that can change files, including the
+// user-defined agents, and restricts bash to read-only commands.
+func planModeTools(allTools []tools.BaseTool) []tools.BaseTool {
+	var filteredTools []tools.BaseTool
+	for _, tool := range allTools {
+		if slices.Contains(planModeWithheldTools, tool.Name()) {
+			continue
+		}
+		if IsAgentToolName(tool.Name()) && tool.Name() != AgentToolName {
+			continue
+		}
+		if tool.Name() == tools.BashToolName {
+			tool = tools.NewReadOnlyBashTool(tool)
+		}
+		filteredTools = append(filteredTools, tool)
+	}
+	return filteredTools
+}
+
+// planModeHistory appends the plan mode instructions to the text of the
+// latest user message. The instructions are only sent to the provider, they
+// are never stored.
+func planModeHistory(msgHistory []message.Message) []message.Message {
+	for i := len(msgHistory) - 1; i >= 0; i-- {
+		if msgHistory[i].Role != message.User {
+			continue
+		}
+		parts := slices.Clone(msgHistory[i].Parts)
+		for j, part := range parts {
+			if text, ok := part.(message.TextContent); ok {
+				parts[j] = message.TextContent{Text: text.Text + "\n\n" + prompt.PlanModePrompt()}
+				break
+			}
+		}
+		history := slices.Clone(msgHistory)
+		history[i].Parts = parts
+		return history
+	}
+	return msgHistory
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/llm/prompt"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+type namedTool struct {
+	name string
+}
+
+func (t namedTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{Name: t.name}
+}
+
+func (t namedTool) Name() string {
+	return t.name
+}
+
+func (t namedTool) Run(context.Context, tools.ToolCall) (tools.ToolResponse, error) {
+	return tools.NewTextResponse(t.name), nil
+}
+
+func TestPlanModeTools(t *testing.T) {
+	t.Parallel()
+
+	var allTools []tools.BaseTool
+	for _, name := range []string{
+		tools.BashToolName,
+		tools.DownloadToolName,
+		tools.EditToolName,
+		tools.GlobToolName,
+		tools.MultiEditToolName,
+		tools.ViewToolName,
+		tools.WriteToolName,
+		AgentToolName,
+		"agent_reviewer",
+	} {
+		allTools = append(allTools, namedTool{name: name})
+	}
+
+	filtered := planModeTools(allTools)
+	var names []string
+	for _, tool := range filtered {
+		names = append(names, tool.Name())
+	}
+	require.Equal(t, []string{tools.BashToolName, tools.GlobToolName, tools.ViewToolName, AgentToolName}, names)
+
+	bash := filtered[0]
+	resp, err := bash.Run(t.Context(), tools.ToolCall{Input: `{"command": "rm -rf /"}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+
+	resp, err = bash.Run(t.Context(), tools.ToolCall{Input: `{"command": "git status"}`})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+}
+
+func TestPlanModeHistory(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		textMsg("u1", message.User, "first"),
+		textMsg("a1", message.Assistant, "answer"),
+		textMsg("u2", message.User, "second"),
+		{ID: "t1", Role: message.Tool},
+	}
+	history := planModeHistory(msgs)
+	require.Equal(t, msgIDs(msgs), msgIDs(history))
+	require.Equal(t, "first", history[0].Content().Text)
+	require.Equal(t, "second\n\n"+prompt.PlanModePrompt(), history[2].Content().Text)
+	require.Equal(t, "second", msgs[2].Content().Text)
+}
+
+func TestPlanModeSessions(t *testing.T) {
+	t.Parallel()
+
+	a := &agent{planMode: csync.NewMap[string, bool]()}
+	require.Empty(t, a.PlanModeSessions())
+
+	a.SetPlanMode("s1", true)
+	a.SetPlanMode("s2", true)
+	a.SetPlanMode("s2", false)
+	require.Equal(t, []string{"s1"}, a.PlanModeSessions())
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/llm/provider"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// TrackUsage adds the usage of a request to the cost and token counts of
+// the session.
+func (a *agent) TrackUsage(ctx context.Context, sessionID string, model catwalk.Model, usage provider.TokenUsage) error {
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	sess.Cost += usageCost(model, usage)
+	// Cached tokens are part of the prompt, whether read or written.
+	sess.PromptTokens = usage.InputTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	sess.CompletionTokens = usage.OutputTokens
+	trackCacheUsage(&sess, model, usage)
+
+	_, err = a.sessions.Save(ctx, sess)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// usageCost returns the cost of a request.
+func usageCost(model catwalk.Model, usage provider.TokenUsage) float64 {
+	return model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+		model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
+		model.CostPer1MIn/1e6*float64(usage.InputTokens) +
+		model.CostPer1MOut/1e6*float64(usage.OutputTokens)
+}
+
+// cacheSavings returns what the prompt cache saved compared to sending the
+// whole prompt uncached, net of the premium for writing to the cache. Models
+// list the cache write price as CostPer1MInCached and the cache read price as
+// CostPer1MOutCached.
+func cacheSavings(model catwalk.Model, usage provider.TokenUsage) float64 {
+	readDiscount := max(model.CostPer1MIn-model.CostPer1MOutCached, 0)
+	writePremium := max(model.CostPer1MInCached-model.CostPer1MIn, 0)
+	return (readDiscount*float64(usage.CacheReadTokens) - writePremium*float64(usage.CacheCreationTokens)) / 1e6
+}
+
+// trackCacheUsage adds the prompt cache usage of a request to the session.
+func trackCacheUsage(sess *session.Session, model catwalk.Model, usage provider.TokenUsage) {
+	sess.TotalPromptTokens += usage.InputTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	sess.CacheReadTokens += usage.CacheReadTokens
+	sess.CacheCreationTokens += usage.CacheCreationTokens
+	sess.CacheSavings += cacheSavings(model, usage)
+}
@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/llm/provider"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSavings(t *testing.T) {
+	t.Parallel()
+
+	model := catwalk.Model{
+		CostPer1MIn:        3,
+		CostPer1MOut:       15,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+	}
+	usage := provider.TokenUsage{
+		InputTokens:         1_000,
+		CacheReadTokens:     1_000_000,
+		CacheCreationTokens: 100_000,
+	}
+	// 2.70 saved on reads, less 0.075 paid for writing.
+	require.InDelta(t, 2.625, cacheSavings(model, usage), 1e-9)
+
+	sess := session.Session{}
+	trackCacheUsage(&sess, model, usage)
+	trackCacheUsage(&sess, model, provider.TokenUsage{InputTokens: 99_000})
+	require.Equal(t, int64(1_200_000), sess.TotalPromptTokens)
+	require.Equal(t, int64(1_000_000), sess.CacheReadTokens)
+	require.Equal(t, int64(100_000), sess.CacheCreationTokens)
+	require.InDelta(t, 1_000_000.0/1_200_000, sess.CacheHitRatio(), 1e-9)
+}
@@ -0,0 +1,37 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// CustomPrompt builds the system prompt of a user-defined agent from its
+// prompt file. The environment, LSP information and context files are added
+// the same way as for the coder prompt.
+func CustomPrompt(promptFile string, contextPaths ...string) (string, error) {
+	workingDir := config.Get().WorkingDir()
+	path := expandPath(promptFile)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	basePrompt := strings.TrimSpace(string(content))
+	if basePrompt == "" {
+		return "", fmt.Errorf("prompt file %s is empty", promptFile)
+	}
+	basePrompt = fmt.Sprintf("%s\n\n%s\n%s", basePrompt, getEnvironmentInfo(), lspInformation())
+
+	contextContent := getContextFromPaths(workingDir, contextPaths)
+	if contextContent != "" {
+		return fmt.Sprintf("%s\n\n# Project-Specific Context\n Make sure to follow the instructions in the context below\n%s", basePrompt, contextContent), nil
+	}
+	return basePrompt, nil
+}
@@ -0,0 +1,25 @@
+package prompt
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+//go:embed plan.md
+var planModePrompt []byte
+
+// PlanModePrompt returns the instructions given to the agent while a session
+// is in plan mode.
+func PlanModePrompt() string {
+	return string(planModePrompt)
+}
+
+// ApprovedPlanPrompt returns the message that hands an approved plan back to
+// the agent once plan mode is turned off.
+func ApprovedPlanPrompt(plan string) string {
+	return fmt.Sprintf(`The following plan was approved. Plan mode is off and all tools are available again: implement the plan now, step by step.
+
+<approved_plan>
+%s
+</approved_plan>`, plan)
+}
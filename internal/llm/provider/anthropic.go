@@ -102,15 +102,13 @@ func createAnthropicClient(opts providerClientOptions, tp AnthropicClientType) a
 }
 
 func (a *anthropicClient) convertMessages(messages []message.Message) (anthropicMessages []anthropic.MessageParam) {
+	cachedFrom := len(messages) - a.providerOptions.cachedMessages()
 	for i, msg := range messages {
-		cache := false
-		if i > len(messages)-3 {
-			cache = true
-		}
+		cache := i >= cachedFrom
 		switch msg.Role {
 		case message.User:
 			content := anthropic.NewTextBlock(msg.Content().String())
-			if cache && !a.providerOptions.disableCache {
+			if cache {
 				content.OfText.CacheControl = anthropic.CacheControlEphemeralParam{
 					Type: "ephemeral",
 				}
@@ -118,6 +116,16 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 			var contentBlocks []anthropic.ContentBlockParamUnion
 			contentBlocks = append(contentBlocks, content)
 			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.IsText() {
+					contentBlocks = append(contentBlocks, anthropic.NewTextBlock(binaryContent.Text()))
+					continue
+				}
+				if binaryContent.IsPDF() {
+					contentBlocks = append(contentBlocks, anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{
+						Data: binaryContent.String(catwalk.InferenceProviderAnthropic),
+					}))
+					continue
+				}
 				base64Image := binaryContent.String(catwalk.InferenceProviderAnthropic)
 				imageBlock := anthropic.NewImageBlockBase64(binaryContent.MIMEType, base64Image)
 				contentBlocks = append(contentBlocks, imageBlock)
@@ -127,15 +135,18 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 		case message.Assistant:
 			blocks := []anthropic.ContentBlockParamUnion{}
 
-			// Add thinking blocks first if present (required when thinking is enabled with tool use)
-			if reasoningContent := msg.ReasoningContent(); reasoningContent.Thinking != "" {
+			// Add thinking blocks first if present (required when thinking is enabled with tool use).
+			// Signatures only verify with the provider that made them, and the
+			// encrypted reasoning of the OpenAI Responses API isn't one.
+			if reasoningContent := msg.ReasoningContent(); reasoningContent.Thinking != "" &&
+				reasoningContent.ItemID == "" && msg.Provider == a.providerOptions.config.ID {
 				thinkingBlock := anthropic.NewThinkingBlock(reasoningContent.Signature, reasoningContent.Thinking)
 				blocks = append(blocks, thinkingBlock)
 			}
 
 			if msg.Content().String() != "" {
 				content := anthropic.NewTextBlock(msg.Content().String())
-				if cache && !a.providerOptions.disableCache {
+				if cache {
 					content.OfText.CacheControl = anthropic.CacheControlEphemeralParam{
 						Type: "ephemeral",
 					}
@@ -162,6 +173,11 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 			for i, toolResult := range msg.ToolResults() {
 				results[i] = anthropic.NewToolResultBlock(toolResult.ToolCallID, toolResult.Content, toolResult.IsError)
 			}
+			if cache && len(results) > 0 {
+				results[len(results)-1].OfToolResult.CacheControl = anthropic.CacheControlEphemeralParam{
+					Type: "ephemeral",
+				}
+			}
 			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(results...))
 		}
 	}
@@ -182,7 +198,7 @@ func (a *anthropicClient) convertTools(tools []tools.BaseTool) []anthropic.ToolU
 			},
 		}
 
-		if i == len(tools)-1 && !a.providerOptions.disableCache {
+		if i == len(tools)-1 && a.providerOptions.caches(config.CacheBreakpointTools) {
 			toolParam.CacheControl = anthropic.CacheControlEphemeralParam{
 				Type: "ephemeral",
 			}
@@ -211,10 +227,7 @@ func (a *anthropicClient) finishReason(reason string) message.FinishReason {
 
 func (a *anthropicClient) isThinkingEnabled() bool {
 	cfg := config.Get()
-	modelConfig := cfg.Models[config.SelectedModelTypeLarge]
-	if a.providerOptions.modelType == config.SelectedModelTypeSmall {
-		modelConfig = cfg.Models[config.SelectedModelTypeSmall]
-	}
+	modelConfig := cfg.ModelConfig(a.providerOptions.modelType)
 	return a.Model().CanReason && modelConfig.Think
 }
 
@@ -222,10 +235,7 @@ func (a *anthropicClient) preparedMessages(messages []anthropic.MessageParam, to
 	model := a.providerOptions.model(a.providerOptions.modelType)
 	var thinkingParam anthropic.ThinkingConfigParamUnion
 	cfg := config.Get()
-	modelConfig := cfg.Models[config.SelectedModelTypeLarge]
-	if a.providerOptions.modelType == config.SelectedModelTypeSmall {
-		modelConfig = cfg.Models[config.SelectedModelTypeSmall]
-	}
+	modelConfig := cfg.ModelConfig(a.providerOptions.modelType)
 	temperature := anthropic.Float(0)
 
 	maxTokens := model.DefaultMaxTokens
@@ -255,12 +265,15 @@ func (a *anthropicClient) preparedMessages(messages []anthropic.MessageParam, to
 		})
 	}
 
-	systemBlocks = append(systemBlocks, anthropic.TextBlockParam{
+	systemBlock := anthropic.TextBlockParam{
 		Text: a.providerOptions.systemMessage,
-		CacheControl: anthropic.CacheControlEphemeralParam{
+	}
+	if a.providerOptions.caches(config.CacheBreakpointSystem) {
+		systemBlock.CacheControl = anthropic.CacheControlEphemeralParam{
 			Type: "ephemeral",
-		},
-	})
+		}
+	}
+	systemBlocks = append(systemBlocks, systemBlock)
 
 	return anthropic.MessageNewParams{
 		Model:       anthropic.Model(model.ID),
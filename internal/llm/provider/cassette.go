@@ -0,0 +1,383 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+const cassetteVersion = 1
+
+// Cassette holds recorded exchanges with a provider.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and the events it produced.
+type Interaction struct {
+	Hash    string          `json:"hash"`
+	Request CassetteRequest `json:"request"`
+	Events  []CassetteEvent `json:"events"`
+}
+
+// CassetteRequest is a request as it is matched against a cassette. Only
+// what makes the conversation is kept: message IDs, timestamps and the
+// working directory are left out so that replays match across runs.
+type CassetteRequest struct {
+	Model    string            `json:"model"`
+	Messages []CassetteMessage `json:"messages"`
+	Tools    []CassetteTool    `json:"tools,omitempty"`
+}
+
+// CassetteMessage is a message of a recorded request.
+type CassetteMessage struct {
+	Role        message.MessageRole  `json:"role"`
+	Text        string               `json:"text,omitempty"`
+	Reasoning   string               `json:"reasoning,omitempty"`
+	Attachments []CassetteAttachment `json:"attachments,omitempty"`
+	ToolCalls   []CassetteToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []CassetteToolResult `json:"tool_results,omitempty"`
+}
+
+// CassetteAttachment is an attachment of a recorded message, kept by its
+// digest.
+type CassetteAttachment struct {
+	MIMEType string `json:"mime_type"`
+	SHA256   string `json:"sha256"`
+}
+
+// CassetteToolCall is a tool call of a recorded message.
+type CassetteToolCall struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input string `json:"input"`
+}
+
+// CassetteToolResult is a tool result of a recorded message.
+type CassetteToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name,omitempty"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error,omitempty"`
+}
+
+// CassetteTool is the schema of a tool offered with a recorded request.
+type CassetteTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+	Required    []string       `json:"required,omitempty"`
+}
+
+// CassetteEvent is a recorded provider event.
+type CassetteEvent struct {
+	Type            EventType         `json:"type"`
+	Content         string            `json:"content,omitempty"`
+	Thinking        string            `json:"thinking,omitempty"`
+	Signature       string            `json:"signature,omitempty"`
+	ReasoningItemID string            `json:"reasoning_item_id,omitempty"`
+	ToolCall        *message.ToolCall `json:"tool_call,omitempty"`
+	Response        *ProviderResponse `json:"response,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// Hash returns the digest a request is matched by. It covers the model, the
+// messages and the names of the tools, but not their descriptions, so that
+// rewording a tool doesn't invalidate cassettes.
+func (r CassetteRequest) Hash() string {
+	toolNames := make([]string, len(r.Tools))
+	for i, tool := range r.Tools {
+		toolNames[i] = tool.Name
+	}
+	data, _ := json.Marshal(struct {
+		Model    string            `json:"model"`
+		Messages []CassetteMessage `json:"messages"`
+		Tools    []string          `json:"tools"`
+	}{r.Model, r.Messages, toolNames})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type cassetteProvider struct {
+	inner      Provider
+	path       string
+	mode       string
+	workingDir string
+
+	mu       sync.Mutex
+	cassette Cassette
+	// replayed counts the replays of each hash, so that a request made
+	// several times gets its recorded answers in order.
+	replayed map[string]int
+}
+
+// NewCassetteProvider returns a provider that records the exchanges with
+// inner to the cassette of cfg, or replays them from it, depending on its
+// mode. inner may be nil when only replaying.
+func NewCassetteProvider(inner Provider, cfg config.CassetteConfig, workingDir string) (Provider, error) {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = config.CassetteModeReplay
+	}
+	path := cfg.Path
+	if path == "" {
+		return nil, errors.New("cassette path is required")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	p := &cassetteProvider{
+		inner:      inner,
+		path:       path,
+		mode:       mode,
+		workingDir: workingDir,
+		cassette:   Cassette{Version: cassetteVersion},
+		replayed:   make(map[string]int),
+	}
+	switch mode {
+	case config.CassetteModeReplay, config.CassetteModeAuto:
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) && mode == config.CassetteModeAuto {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &p.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+	case config.CassetteModeRecord:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %s", mode)
+	}
+	if mode != config.CassetteModeReplay && inner == nil {
+		return nil, fmt.Errorf("cassette mode %s needs a provider to record", mode)
+	}
+	return p, nil
+}
+
+func (p *cassetteProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	for event := range p.StreamResponse(ctx, messages, tools) {
+		switch event.Type {
+		case EventError:
+			return nil, event.Error
+		case EventComplete:
+			return event.Response, nil
+		}
+	}
+	return nil, errors.New("cassette interaction ended without a response")
+}
+
+func (p *cassetteProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	request := p.request(messages, tools)
+	hash := request.Hash()
+
+	if interaction, ok := p.lookup(hash); ok {
+		return p.replay(ctx, interaction)
+	}
+	if p.mode == config.CassetteModeReplay {
+		eventChan := make(chan ProviderEvent, 1)
+		eventChan <- ProviderEvent{
+			Type:  EventError,
+			Error: fmt.Errorf("no interaction recorded in cassette %s for request %s", p.path, hash),
+		}
+		close(eventChan)
+		return eventChan
+	}
+	return p.record(ctx, Interaction{Hash: hash, Request: request}, messages, tools)
+}
+
+func (p *cassetteProvider) Model() catwalk.Model {
+	if p.inner != nil {
+		return p.inner.Model()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.cassette.Interactions) == 0 {
+		return catwalk.Model{}
+	}
+	return catwalk.Model{ID: p.cassette.Interactions[0].Request.Model}
+}
+
+// lookup returns the next recorded interaction of the hash. Once the
+// recorded ones are used up, the last is repeated.
+func (p *cassetteProvider) lookup(hash string) (Interaction, bool) {
+	if p.mode == config.CassetteModeRecord {
+		return Interaction{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var matches []Interaction
+	for _, interaction := range p.cassette.Interactions {
+		if interaction.Hash == hash {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return Interaction{}, false
+	}
+	n := min(p.replayed[hash], len(matches)-1)
+	p.replayed[hash]++
+	return matches[n], true
+}
+
+func (p *cassetteProvider) replay(ctx context.Context, interaction Interaction) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+	go func() {
+		defer close(eventChan)
+		for _, event := range interaction.Events {
+			providerEvent := ProviderEvent{
+				Type:            event.Type,
+				Content:         event.Content,
+				Thinking:        event.Thinking,
+				Signature:       event.Signature,
+				ReasoningItemID: event.ReasoningItemID,
+				ToolCall:        event.ToolCall,
+				Response:        event.Response,
+			}
+			if event.Error != "" {
+				providerEvent.Error = errors.New(event.Error)
+			}
+			select {
+			case eventChan <- providerEvent:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return eventChan
+}
+
+func (p *cassetteProvider) record(ctx context.Context, interaction Interaction, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+	go func() {
+		defer close(eventChan)
+		for event := range p.inner.StreamResponse(ctx, messages, tools) {
+			recorded := CassetteEvent{
+				Type:            event.Type,
+				Content:         event.Content,
+				Thinking:        event.Thinking,
+				Signature:       event.Signature,
+				ReasoningItemID: event.ReasoningItemID,
+				ToolCall:        event.ToolCall,
+				Response:        event.Response,
+			}
+			if event.Error != nil {
+				recorded.Error = event.Error.Error()
+			}
+			interaction.Events = append(interaction.Events, recorded)
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		// A cancelled exchange depends on timing, so it isn't worth replaying.
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.save(interaction); err != nil {
+			select {
+			case eventChan <- ProviderEvent{Type: EventError, Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return eventChan
+}
+
+func (p *cassetteProvider) save(interaction Interaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cassette.Interactions = append(p.cassette.Interactions, interaction)
+	data, err := json.MarshalIndent(p.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(p.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// request normalizes a request for the cassette.
+func (p *cassetteProvider) request(messages []message.Message, tools []tools.BaseTool) CassetteRequest {
+	request := CassetteRequest{Model: p.Model().ID}
+	for _, msg := range messages {
+		// Providers skip messages without content, so do the same to match.
+		if len(msg.Parts) == 0 {
+			continue
+		}
+		normalized := CassetteMessage{
+			Role:      msg.Role,
+			Text:      p.normalize(msg.Content().String()),
+			Reasoning: msg.ReasoningContent().Thinking,
+		}
+		for _, binaryContent := range msg.BinaryContent() {
+			sum := sha256.Sum256(binaryContent.Data)
+			normalized.Attachments = append(normalized.Attachments, CassetteAttachment{
+				MIMEType: binaryContent.MIMEType,
+				SHA256:   hex.EncodeToString(sum[:]),
+			})
+		}
+		for _, call := range msg.ToolCalls() {
+			normalized.ToolCalls = append(normalized.ToolCalls, CassetteToolCall{
+				ID:    call.ID,
+				Name:  call.Name,
+				Input: p.normalize(call.Input),
+			})
+		}
+		for _, result := range msg.ToolResults() {
+			normalized.ToolResults = append(normalized.ToolResults, CassetteToolResult{
+				ToolCallID: result.ToolCallID,
+				Name:       result.Name,
+				Content:    p.normalize(result.Content),
+				IsError:    result.IsError,
+			})
+		}
+		request.Messages = append(request.Messages, normalized)
+	}
+	for _, tool := range tools {
+		info := tool.Info()
+		request.Tools = append(request.Tools, CassetteTool{
+			Name:        info.Name,
+			Description: info.Description,
+			Parameters:  info.Parameters,
+			Required:    info.Required,
+		})
+	}
+	slices.SortFunc(request.Tools, func(a, b CassetteTool) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return request
+}
+
+// normalize replaces the working directory, which differs between machines,
+// with a placeholder.
+func (p *cassetteProvider) normalize(s string) string {
+	if p.workingDir == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, p.workingDir, "$CWD")
+	// Tool inputs are JSON, where backslashes are escaped.
+	escaped, _ := json.Marshal(p.workingDir)
+	return strings.ReplaceAll(s, strings.Trim(string(escaped), `"`), "$CWD")
+}
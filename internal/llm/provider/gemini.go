@@ -22,6 +22,7 @@ import (
 type geminiClient struct {
 	providerOptions providerClientOptions
 	client          *genai.Client
+	cache           geminiCache
 }
 
 type GeminiClient ProviderClient
@@ -62,6 +63,17 @@ func (g *geminiClient) convertMessages(messages []message.Message) []*genai.Cont
 			var parts []*genai.Part
 			parts = append(parts, &genai.Part{Text: msg.Content().String()})
 			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.IsText() {
+					parts = append(parts, &genai.Part{Text: binaryContent.Text()})
+					continue
+				}
+				if binaryContent.IsPDF() {
+					parts = append(parts, &genai.Part{InlineData: &genai.Blob{
+						MIMEType: "application/pdf",
+						Data:     binaryContent.Data,
+					}})
+					continue
+				}
 				imageFormat := strings.Split(binaryContent.MIMEType, "/")
 				parts = append(parts, &genai.Part{InlineData: &genai.Blob{
 					MIMEType: imageFormat[1],
@@ -175,10 +187,7 @@ func (g *geminiClient) send(ctx context.Context, messages []message.Message, too
 	model := g.providerOptions.model(g.providerOptions.modelType)
 	cfg := config.Get()
 
-	modelConfig := cfg.Models[config.SelectedModelTypeLarge]
-	if g.providerOptions.modelType == config.SelectedModelTypeSmall {
-		modelConfig = cfg.Models[config.SelectedModelTypeSmall]
-	}
+	modelConfig := cfg.ModelConfig(g.providerOptions.modelType)
 
 	maxTokens := model.DefaultMaxTokens
 	if modelConfig.MaxTokens > 0 {
@@ -190,13 +199,7 @@ func (g *geminiClient) send(ctx context.Context, messages []message.Message, too
 	}
 	history := geminiMessages[:len(geminiMessages)-1] // All but last message
 	lastMsg := geminiMessages[len(geminiMessages)-1]
-	config := &genai.GenerateContentConfig{
-		MaxOutputTokens: int32(maxTokens),
-		SystemInstruction: &genai.Content{
-			Parts: []*genai.Part{{Text: systemMessage}},
-		},
-	}
-	config.Tools = g.convertTools(tools)
+	config := g.generateContentConfig(ctx, model.ID, maxTokens, systemMessage, g.convertTools(tools))
 	chat, _ := g.client.Chats.Create(ctx, model.ID, config, history)
 
 	attempts := 0
@@ -271,10 +274,7 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 	model := g.providerOptions.model(g.providerOptions.modelType)
 	cfg := config.Get()
 
-	modelConfig := cfg.Models[config.SelectedModelTypeLarge]
-	if g.providerOptions.modelType == config.SelectedModelTypeSmall {
-		modelConfig = cfg.Models[config.SelectedModelTypeSmall]
-	}
+	modelConfig := cfg.ModelConfig(g.providerOptions.modelType)
 	maxTokens := model.DefaultMaxTokens
 	if modelConfig.MaxTokens > 0 {
 		maxTokens = modelConfig.MaxTokens
@@ -290,13 +290,7 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 	}
 	history := geminiMessages[:len(geminiMessages)-1] // All but last message
 	lastMsg := geminiMessages[len(geminiMessages)-1]
-	config := &genai.GenerateContentConfig{
-		MaxOutputTokens: int32(maxTokens),
-		SystemInstruction: &genai.Content{
-			Parts: []*genai.Part{{Text: systemMessage}},
-		},
-	}
-	config.Tools = g.convertTools(tools)
+	config := g.generateContentConfig(ctx, model.ID, maxTokens, systemMessage, g.convertTools(tools))
 	chat, _ := g.client.Chats.Create(ctx, model.ID, config, history)
 
 	attempts := 0
@@ -459,11 +453,13 @@ func (g *geminiClient) usage(resp *genai.GenerateContentResponse) TokenUsage {
 		return TokenUsage{}
 	}
 
+	// The prompt token count includes the cached tokens. Cached content is
+	// billed for storage rather than when written.
+	cachedTokens := int64(resp.UsageMetadata.CachedContentTokenCount)
 	return TokenUsage{
-		InputTokens:         int64(resp.UsageMetadata.PromptTokenCount),
-		OutputTokens:        int64(resp.UsageMetadata.CandidatesTokenCount),
-		CacheCreationTokens: 0, // Not directly provided by Gemini
-		CacheReadTokens:     int64(resp.UsageMetadata.CachedContentTokenCount),
+		InputTokens:     int64(resp.UsageMetadata.PromptTokenCount) - cachedTokens,
+		OutputTokens:    int64(resp.UsageMetadata.CandidatesTokenCount),
+		CacheReadTokens: cachedTokens,
 	}
 }
 
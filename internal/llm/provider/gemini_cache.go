@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"google.golang.org/genai"
+)
+
+const (
+	// geminiCacheRenewMargin is how long before it expires cached content is
+	// replaced, so that it doesn't expire in the middle of a request.
+	geminiCacheRenewMargin = 30 * time.Second
+	// geminiCacheMinBackoff and geminiCacheMaxBackoff bound how long content
+	// that couldn't be cached is sent uncached before trying again.
+	geminiCacheMinBackoff = time.Minute
+	geminiCacheMaxBackoff = time.Hour
+)
+
+// geminiCache holds the cached contents of a Gemini client: the system
+// prompt and the tools, which are sent with every request. Contents are keyed
+// by a hash of what they hold and are never deleted: when the prompt changes
+// the previous content expires on its own, so requests still using it don't
+// fail.
+type geminiCache struct {
+	mu      sync.Mutex
+	entries map[string]*geminiCacheEntry
+}
+
+// geminiCacheEntry is a cached content, or content being cached or that
+// couldn't be cached.
+type geminiCacheEntry struct {
+	name    string
+	expires time.Time
+	// creating is set while the content is being cached. Other requests send
+	// it uncached meanwhile rather than waiting.
+	creating bool
+	// failures counts the attempts to cache the content that failed, for
+	// instance because it is below the minimum size or the request failed.
+	// It's tried again after retryAt, waiting longer after each failure.
+	failures int
+	retryAt  time.Time
+}
+
+// prune forgets the contents that expired and the failures that are old
+// enough to be tried again from scratch.
+func (c *geminiCache) prune(now time.Time) {
+	for key, entry := range c.entries {
+		switch {
+		case entry.creating:
+		case entry.failures > 0:
+			if now.After(entry.retryAt.Add(geminiCacheMaxBackoff)) {
+				delete(c.entries, key)
+			}
+		case now.After(entry.expires):
+			delete(c.entries, key)
+		}
+	}
+}
+
+// generateContentConfig returns the configuration of a request. The system
+// prompt and the tools are sent as cached content when possible.
+func (g *geminiClient) generateContentConfig(ctx context.Context, modelID string, maxTokens int64, systemMessage string, tools []*genai.Tool) *genai.GenerateContentConfig {
+	contentConfig := &genai.GenerateContentConfig{
+		MaxOutputTokens: int32(maxTokens),
+	}
+	system := &genai.Content{
+		Parts: []*genai.Part{{Text: systemMessage}},
+	}
+	if name := g.cachedContent(ctx, modelID, system, tools); name != "" {
+		contentConfig.CachedContent = name
+		return contentConfig
+	}
+	contentConfig.SystemInstruction = system
+	contentConfig.Tools = tools
+	return contentConfig
+}
+
+// cachedContent returns the name of the cached content holding the system
+// prompt and the tools, creating it if needed, or nothing when they aren't
+// cached. Gemini doesn't allow sending either along with cached content, so
+// they are cached together when either breakpoint is set.
+func (g *geminiClient) cachedContent(ctx context.Context, modelID string, system *genai.Content, tools []*genai.Tool) string {
+	if !g.providerOptions.caches(config.CacheBreakpointSystem) && !g.providerOptions.caches(config.CacheBreakpointTools) {
+		return ""
+	}
+	data, err := json.Marshal(struct {
+		Model  string         `json:"model"`
+		System *genai.Content `json:"system"`
+		Tools  []*genai.Tool  `json:"tools"`
+	}{modelID, system, tools})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	g.cache.mu.Lock()
+	now := time.Now()
+	g.cache.prune(now)
+	entry := g.cache.entries[key]
+	if entry != nil && entry.name != "" && now.Add(geminiCacheRenewMargin).Before(entry.expires) {
+		name := entry.name
+		g.cache.mu.Unlock()
+		return name
+	}
+	if entry != nil && (entry.creating || now.Before(entry.retryAt)) {
+		g.cache.mu.Unlock()
+		return ""
+	}
+	if entry == nil {
+		entry = &geminiCacheEntry{}
+		if g.cache.entries == nil {
+			g.cache.entries = make(map[string]*geminiCacheEntry)
+		}
+		g.cache.entries[key] = entry
+	}
+	entry.creating = true
+	g.cache.mu.Unlock()
+
+	ttl, err := time.ParseDuration(config.Get().Options.Cache.TTL)
+	if err != nil || ttl <= geminiCacheRenewMargin {
+		ttl = 5 * time.Minute
+	}
+	cached, err := g.client.Caches.Create(ctx, modelID, &genai.CreateCachedContentConfig{
+		DisplayName:       "crush",
+		TTL:               ttl,
+		SystemInstruction: system,
+		Tools:             tools,
+	})
+
+	g.cache.mu.Lock()
+	defer g.cache.mu.Unlock()
+	entry.creating = false
+	if err != nil {
+		entry.failures++
+		entry.retryAt = time.Now().Add(geminiCacheBackoff(entry.failures))
+		slog.Debug("Failed to cache the Gemini prompt", "model", modelID, "error", err, "retry_at", entry.retryAt)
+		return ""
+	}
+	// A content replaced before it expires is left to expire on its own.
+	entry.name = cached.Name
+	entry.expires = time.Now().Add(ttl)
+	entry.failures = 0
+	entry.retryAt = time.Time{}
+	return cached.Name
+}
+
+// geminiCacheBackoff returns how long to wait before caching content again
+// after the given number of failures.
+func geminiCacheBackoff(failures int) time.Duration {
+	backoff := geminiCacheMinBackoff
+	for range failures - 1 {
+		backoff *= 2
+		if backoff >= geminiCacheMaxBackoff {
+			return geminiCacheMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiCacheBackoff(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Minute, geminiCacheBackoff(1))
+	require.Equal(t, 2*time.Minute, geminiCacheBackoff(2))
+	require.Equal(t, 32*time.Minute, geminiCacheBackoff(6))
+	require.Equal(t, time.Hour, geminiCacheBackoff(7))
+	require.Equal(t, time.Hour, geminiCacheBackoff(100))
+}
+
+func TestGeminiCachePrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := geminiCache{entries: map[string]*geminiCacheEntry{
+		"live":     {name: "cachedContents/live", expires: now.Add(time.Minute)},
+		"replaced": {name: "cachedContents/replaced", expires: now.Add(time.Second)},
+		"expired":  {name: "cachedContents/expired", expires: now.Add(-time.Second)},
+		"creating": {creating: true},
+		"failed":   {failures: 1, retryAt: now.Add(-time.Minute)},
+		"stale":    {failures: 3, retryAt: now.Add(-2 * geminiCacheMaxBackoff)},
+	}}
+	cache.prune(now)
+	require.ElementsMatch(t, []string{"live", "replaced", "creating", "failed"}, slices.Collect(maps.Keys(cache.entries)))
+}
@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"gopkg.in/yaml.v3"
+)
+
+// MockScript is what a mock provider answers. Each turn answers one request,
+// in order:
+//
+//	turns:
+//	  - expect: list the files
+//	    content: Let me look.
+//	    tool_calls:
+//	      - name: ls
+//	        input: {path: .}
+//	  - delay: 100ms
+//	    content: There are two files.
+//	  - error: overloaded
+type MockScript struct {
+	Turns []MockTurn `yaml:"turns"`
+}
+
+// MockTurn is the answer to one request.
+type MockTurn struct {
+	// Expect fails the request unless the text of its last message contains
+	// it, which catches a conversation going off script.
+	Expect string `yaml:"expect"`
+	// Delay waits before answering, for instance to cancel the request.
+	Delay     time.Duration  `yaml:"delay"`
+	Thinking  string         `yaml:"thinking"`
+	Content   string         `yaml:"content"`
+	ToolCalls []MockToolCall `yaml:"tool_calls"`
+	// Error fails the request with the message.
+	Error string `yaml:"error"`
+	// Finish overrides the finish reason, which is tool_use when there are
+	// tool calls and end_turn otherwise.
+	Finish message.FinishReason `yaml:"finish"`
+	Usage  MockUsage            `yaml:"usage"`
+}
+
+// MockToolCall is a tool call of a mock turn.
+type MockToolCall struct {
+	// ID defaults to one derived from the position of the call, so that
+	// the conversation is the same on every run.
+	ID    string         `yaml:"id"`
+	Name  string         `yaml:"name"`
+	Input map[string]any `yaml:"input"`
+}
+
+// MockUsage is the token usage reported by a mock turn.
+type MockUsage struct {
+	Input         int64 `yaml:"input"`
+	Output        int64 `yaml:"output"`
+	CacheCreation int64 `yaml:"cache_creation"`
+	CacheRead     int64 `yaml:"cache_read"`
+}
+
+// ParseMockScript parses a YAML mock script.
+func ParseMockScript(data []byte) (MockScript, error) {
+	var script MockScript
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&script); err != nil {
+		return MockScript{}, fmt.Errorf("failed to parse mock script: %w", err)
+	}
+	for i, turn := range script.Turns {
+		for _, call := range turn.ToolCalls {
+			if call.Name == "" {
+				return MockScript{}, fmt.Errorf("turn %d of mock script has a tool call without a name", i+1)
+			}
+		}
+	}
+	return script, nil
+}
+
+type mockClient struct {
+	providerOptions providerClientOptions
+	script          MockScript
+
+	mu   sync.Mutex
+	next int
+}
+
+type MockClient ProviderClient
+
+func newMockClient(opts providerClientOptions) (MockClient, error) {
+	path := opts.config.Script
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.Get().WorkingDir(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock script: %w", err)
+	}
+	script, err := ParseMockScript(data)
+	if err != nil {
+		return nil, err
+	}
+	return &mockClient{providerOptions: opts, script: script}, nil
+}
+
+// NewMockProvider returns a provider of the model that answers from the
+// script, for tests.
+func NewMockProvider(model catwalk.Model, script MockScript) Provider {
+	opts := providerClientOptions{
+		model: func(config.SelectedModelType) catwalk.Model {
+			return model
+		},
+	}
+	return &baseProvider[MockClient]{
+		options: opts,
+		client:  &mockClient{providerOptions: opts, script: script},
+	}
+}
+
+// turn returns the next turn of the script and its index.
+func (m *mockClient) turn() (MockTurn, int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.next >= len(m.script.Turns) {
+		return MockTurn{}, m.next, false
+	}
+	m.next++
+	return m.script.Turns[m.next-1], m.next - 1, true
+}
+
+func (m *mockClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	for event := range m.stream(ctx, messages, tools) {
+		switch event.Type {
+		case EventError:
+			return nil, event.Error
+		case EventComplete:
+			return event.Response, nil
+		}
+	}
+	return nil, errors.New("mock script ended without a response")
+}
+
+func (m *mockClient) stream(ctx context.Context, messages []message.Message, _ []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+	go func() {
+		defer close(eventChan)
+		events, err := m.answer(ctx, messages)
+		if err != nil {
+			events = append(events, ProviderEvent{Type: EventError, Error: err})
+		}
+		for _, event := range events {
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return eventChan
+}
+
+// answer returns the events of the next turn of the script.
+func (m *mockClient) answer(ctx context.Context, messages []message.Message) ([]ProviderEvent, error) {
+	turn, index, ok := m.turn()
+	if !ok {
+		return nil, fmt.Errorf("mock script has no turn left for request %d", index+1)
+	}
+	if turn.Expect != "" {
+		var last string
+		if len(messages) > 0 {
+			last = messageText(messages[len(messages)-1])
+		}
+		if !strings.Contains(last, turn.Expect) {
+			return nil, fmt.Errorf("turn %d of mock script expected %q in the last message, got %q", index+1, turn.Expect, last)
+		}
+	}
+	if turn.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(turn.Delay):
+		}
+	}
+	if turn.Error != "" {
+		return nil, errors.New(turn.Error)
+	}
+
+	var events []ProviderEvent
+	if turn.Thinking != "" {
+		events = append(events, ProviderEvent{Type: EventThinkingDelta, Thinking: turn.Thinking})
+	}
+	// Stream the content word by word, like a model would.
+	for _, chunk := range strings.SplitAfter(turn.Content, " ") {
+		if chunk != "" {
+			events = append(events, ProviderEvent{Type: EventContentDelta, Content: chunk})
+		}
+	}
+
+	var toolCalls []message.ToolCall
+	for i, call := range turn.ToolCalls {
+		input, err := json.Marshal(call.Input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode input of tool call %s: %w", call.Name, err)
+		}
+		if call.Input == nil {
+			input = []byte("{}")
+		}
+		toolCall := message.ToolCall{
+			ID:    call.ID,
+			Name:  call.Name,
+			Input: string(input),
+			Type:  "function",
+		}
+		if toolCall.ID == "" {
+			toolCall.ID = fmt.Sprintf("call_%d_%d", index+1, i+1)
+		}
+		events = append(events, ProviderEvent{Type: EventToolUseStart, ToolCall: &message.ToolCall{ID: toolCall.ID, Name: toolCall.Name}})
+		events = append(events, ProviderEvent{Type: EventToolUseDelta, ToolCall: &message.ToolCall{ID: toolCall.ID, Input: toolCall.Input}})
+		toolCall.Finished = true
+		events = append(events, ProviderEvent{Type: EventToolUseStop, ToolCall: &toolCall})
+		toolCalls = append(toolCalls, toolCall)
+	}
+
+	finishReason := turn.Finish
+	if finishReason == "" {
+		finishReason = message.FinishReasonEndTurn
+		if len(toolCalls) > 0 {
+			finishReason = message.FinishReasonToolUse
+		}
+	}
+	events = append(events, ProviderEvent{
+		Type: EventComplete,
+		Response: &ProviderResponse{
+			Content:   turn.Content,
+			ToolCalls: toolCalls,
+			Usage: TokenUsage{
+				InputTokens:         turn.Usage.Input,
+				OutputTokens:        turn.Usage.Output,
+				CacheCreationTokens: turn.Usage.CacheCreation,
+				CacheReadTokens:     turn.Usage.CacheRead,
+			},
+			FinishReason: finishReason,
+		},
+	})
+	return events, nil
+}
+
+// messageText returns the text of a message, or the content of its tool
+// results.
+func messageText(msg message.Message) string {
+	if text := msg.Content().String(); text != "" {
+		return text
+	}
+	var results []string
+	for _, result := range msg.ToolResults() {
+		results = append(results, result.Content)
+	}
+	return strings.Join(results, "\n")
+}
+
+func (m *mockClient) Model() catwalk.Model {
+	return m.providerOptions.model(m.providerOptions.modelType)
+}
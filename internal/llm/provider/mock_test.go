@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+// requireClosed fails unless the channel of a cancelled request gets closed
+// while nobody reads it.
+func requireClosed(t *testing.T, eventChan <-chan ProviderEvent) {
+	t.Helper()
+	// Reading right away would race the cancellation for the next event.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case _, ok := <-eventChan:
+		require.False(t, ok, "events sent after the request was cancelled")
+	case <-time.After(time.Second):
+		t.Fatal("events still sent after the request was cancelled")
+	}
+}
+
+func TestMockProviderCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p := NewMockProvider(catwalk.Model{ID: "mock"}, MockScript{
+		Turns: []MockTurn{{Content: "a long answer nobody reads"}},
+	})
+	eventChan := p.StreamResponse(ctx, nil, nil)
+	cancel()
+	requireClosed(t, eventChan)
+}
+
+func TestCassetteReplayCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p := &cassetteProvider{}
+	eventChan := p.replay(ctx, Interaction{Events: []CassetteEvent{
+		{Type: EventContentDelta, Content: "a long "},
+		{Type: EventContentDelta, Content: "answer"},
+	}})
+	cancel()
+	requireClosed(t, eventChan)
+}
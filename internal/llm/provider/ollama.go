@@ -0,0 +1,420 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ollama"
+	"github.com/google/uuid"
+)
+
+type ollamaClient struct {
+	providerOptions providerClientOptions
+	client          *ollama.Client
+	// nativeTools records, per model, whether the model can call tools
+	// natively. Models that cannot are taught to call them in the system
+	// prompt instead.
+	nativeTools *csync.Map[string, bool]
+}
+
+type OllamaClient ProviderClient
+
+func newOllamaClient(opts providerClientOptions) OllamaClient {
+	return &ollamaClient{
+		providerOptions: opts,
+		client:          createOllamaClient(opts),
+		nativeTools:     csync.NewMap[string, bool](),
+	}
+}
+
+func createOllamaClient(opts providerClientOptions) *ollama.Client {
+	baseURL := ollama.DefaultHost
+	if opts.baseURL != "" {
+		resolvedBaseURL, err := config.Get().Resolve(opts.baseURL)
+		if err == nil {
+			baseURL = resolvedBaseURL
+		}
+	}
+
+	var httpClient *http.Client
+	if config.Get().Options.Debug {
+		httpClient = log.NewHTTPClient()
+	}
+
+	return ollama.NewClient(baseURL, httpClient, ollama.Headers(opts.apiKey, opts.extraHeaders))
+}
+
+func (o *ollamaClient) convertMessages(messages []message.Message, tools []ollama.Tool, promptTools bool) (ollamaMessages []ollama.ChatMessage) {
+	systemMessage := o.providerOptions.systemMessage
+	if o.providerOptions.systemPromptPrefix != "" {
+		systemMessage = o.providerOptions.systemPromptPrefix + "\n" + systemMessage
+	}
+	if promptTools {
+		systemMessage += "\n\n" + ollama.ToolPrompt(tools)
+	}
+	ollamaMessages = append(ollamaMessages, ollama.ChatMessage{
+		Role:    "system",
+		Content: systemMessage,
+	})
+
+	// Tool results only refer to their call by ID but Ollama wants the name
+	// of the tool.
+	toolNames := make(map[string]string)
+	toolName := func(result message.ToolResult) string {
+		if result.Name != "" {
+			return result.Name
+		}
+		return toolNames[result.ToolCallID]
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			userMsg := ollama.ChatMessage{
+				Role:    "user",
+				Content: msg.Content().String(),
+			}
+			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.IsText() || binaryContent.IsPDF() {
+					userMsg.Content += "\n\n" + binaryContent.Text()
+					continue
+				}
+				userMsg.Images = append(userMsg.Images, binaryContent.Data)
+			}
+			ollamaMessages = append(ollamaMessages, userMsg)
+
+		case message.Assistant:
+			assistantMsg := ollama.ChatMessage{
+				Role:     "assistant",
+				Content:  msg.Content().String(),
+				Thinking: msg.ReasoningContent().Thinking,
+			}
+			for _, call := range msg.ToolCalls() {
+				toolNames[call.ID] = call.Name
+				if promptTools {
+					assistantMsg.Content = strings.TrimSpace(strings.TrimSpace(assistantMsg.Content) + "\n\n" + ollama.FormatToolCall(call.Name, call.Input))
+					continue
+				}
+				var arguments map[string]any
+				if err := json.Unmarshal([]byte(call.Input), &arguments); err != nil {
+					slog.Warn("Failed to decode tool call input", "tool", call.Name, "error", err)
+				}
+				assistantMsg.ToolCalls = append(assistantMsg.ToolCalls, ollama.ToolCall{
+					Function: ollama.ToolCallFunction{
+						Name:      call.Name,
+						Arguments: arguments,
+					},
+				})
+			}
+			if assistantMsg.Content == "" && len(assistantMsg.ToolCalls) == 0 {
+				continue
+			}
+			ollamaMessages = append(ollamaMessages, assistantMsg)
+
+		case message.Tool:
+			if promptTools {
+				var results []string
+				for _, result := range msg.ToolResults() {
+					results = append(results, ollama.FormatToolResult(toolName(result), result.Content, result.IsError))
+				}
+				ollamaMessages = append(ollamaMessages, ollama.ChatMessage{
+					Role:    "user",
+					Content: strings.Join(results, "\n\n"),
+				})
+				continue
+			}
+			for _, result := range msg.ToolResults() {
+				ollamaMessages = append(ollamaMessages, ollama.ChatMessage{
+					Role:     "tool",
+					Content:  result.Content,
+					ToolName: toolName(result),
+				})
+			}
+		}
+	}
+
+	return
+}
+
+func (o *ollamaClient) convertTools(tools []tools.BaseTool) []ollama.Tool {
+	ollamaTools := make([]ollama.Tool, len(tools))
+
+	for i, tool := range tools {
+		info := tool.Info()
+		ollamaTools[i] = ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        info.Name,
+				Description: info.Description,
+				Parameters: map[string]any{
+					"type":       "object",
+					"properties": info.Parameters,
+					"required":   info.Required,
+				},
+			},
+		}
+	}
+
+	return ollamaTools
+}
+
+func (o *ollamaClient) finishReason(reason string) message.FinishReason {
+	switch reason {
+	case "stop":
+		return message.FinishReasonEndTurn
+	case "length":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+func (o *ollamaClient) preparedRequest(messages []message.Message, tools []ollama.Tool, promptTools bool) ollama.ChatRequest {
+	model := o.providerOptions.model(o.providerOptions.modelType)
+	modelConfig := config.Get().ModelConfig(o.providerOptions.modelType)
+
+	maxTokens := model.DefaultMaxTokens
+	if modelConfig.MaxTokens > 0 {
+		maxTokens = modelConfig.MaxTokens
+	}
+	// Override max tokens if set in provider options
+	if o.providerOptions.maxTokens > 0 {
+		maxTokens = o.providerOptions.maxTokens
+	}
+
+	req := ollama.ChatRequest{
+		Model:     model.ID,
+		Messages:  o.convertMessages(messages, tools, promptTools),
+		KeepAlive: o.providerOptions.config.KeepAlive,
+		Options:   map[string]any{},
+		Extra:     o.providerOptions.extraBody,
+	}
+	if !promptTools && len(tools) > 0 {
+		req.Tools = tools
+	}
+	// Ollama loads models with a small context unless told otherwise, which
+	// silently truncates the conversation.
+	if model.ContextWindow > 0 {
+		req.Options["num_ctx"] = model.ContextWindow
+	}
+	if maxTokens > 0 {
+		req.Options["num_predict"] = maxTokens
+	}
+	if model.CanReason {
+		if modelConfig.ReasoningEffort != "" {
+			req.Think = modelConfig.ReasoningEffort
+		} else {
+			req.Think = true
+		}
+	}
+	return req
+}
+
+// supportsNativeTools reports whether the model can be given tools in the
+// request. When the server cannot tell, the model is assumed to support
+// them; the chat request fails if it does not.
+func (o *ollamaClient) supportsNativeTools(ctx context.Context, modelID string) bool {
+	if native, ok := o.nativeTools.Get(modelID); ok {
+		return native
+	}
+	show, err := o.client.Show(ctx, modelID)
+	if err != nil {
+		slog.Warn("Failed to get ollama model capabilities", "model", modelID, "error", err)
+		return true
+	}
+	native := show.HasCapability(ollama.CapabilityTools)
+	o.nativeTools.Set(modelID, native)
+	return native
+}
+
+func (o *ollamaClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (response *ProviderResponse, err error) {
+	for event := range o.stream(ctx, messages, tools) {
+		switch event.Type {
+		case EventError:
+			return nil, event.Error
+		case EventComplete:
+			return event.Response, nil
+		}
+	}
+	return nil, fmt.Errorf("received empty response from ollama - check endpoint configuration")
+}
+
+func (o *ollamaClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	ollamaTools := o.convertTools(tools)
+	modelID := o.Model().ID
+	promptTools := len(ollamaTools) > 0 && !o.supportsNativeTools(ctx, modelID)
+	req := o.preparedRequest(messages, ollamaTools, promptTools)
+
+	attempts := 0
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		for {
+			attempts++
+			err := o.chat(ctx, req, promptTools, eventChan)
+			if err == nil {
+				return
+			}
+
+			if !promptTools && ollama.IsToolsUnsupported(err) {
+				slog.Info("Model cannot call tools natively, describing them in the prompt", "model", modelID)
+				o.nativeTools.Set(modelID, false)
+				promptTools = true
+				req = o.preparedRequest(messages, ollamaTools, promptTools)
+				continue
+			}
+
+			// If there is an error we are going to see if we can retry the call
+			retry, after, retryErr := o.shouldRetry(attempts, err)
+			if retryErr != nil {
+				eventChan <- ProviderEvent{Type: EventError, Error: retryErr}
+				return
+			}
+			if retry {
+				slog.Warn("Retrying due to ollama error", "attempt", attempts, "max_retries", maxRetries)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Duration(after) * time.Millisecond):
+					continue
+				}
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+	}()
+
+	return eventChan
+}
+
+// chat streams one answer of the model into eventChan, ending with the
+// complete event.
+func (o *ollamaClient) chat(ctx context.Context, req ollama.ChatRequest, promptTools bool, eventChan chan<- ProviderEvent) error {
+	var content strings.Builder
+	var toolCalls []message.ToolCall
+	var parser ollama.ToolCallParser
+	var usage TokenUsage
+	finishReason := message.FinishReasonUnknown
+
+	emitContent := func(text string) {
+		if text == "" {
+			return
+		}
+		content.WriteString(text)
+		eventChan <- ProviderEvent{
+			Type:    EventContentDelta,
+			Content: text,
+		}
+	}
+	emitToolCall := func(name, input string) {
+		toolCall := message.ToolCall{
+			ID:       uuid.NewString(),
+			Name:     name,
+			Input:    input,
+			Type:     "function",
+			Finished: true,
+		}
+		toolCalls = append(toolCalls, toolCall)
+		eventChan <- ProviderEvent{Type: EventToolUseStart, ToolCall: &toolCall}
+		eventChan <- ProviderEvent{Type: EventToolUseStop, ToolCall: &toolCall}
+	}
+	emitParsed := func(text string, calls []ollama.ParsedToolCall) {
+		emitContent(text)
+		for _, call := range calls {
+			emitToolCall(call.Name, call.Input)
+		}
+	}
+
+	err := o.client.Chat(ctx, req, func(chunk ollama.ChatResponse) error {
+		if chunk.Message.Thinking != "" {
+			eventChan <- ProviderEvent{
+				Type:     EventThinkingDelta,
+				Thinking: chunk.Message.Thinking,
+			}
+		}
+		if promptTools {
+			emitParsed(parser.Write(chunk.Message.Content))
+		} else {
+			emitContent(chunk.Message.Content)
+		}
+		for _, call := range chunk.Message.ToolCalls {
+			input, err := json.Marshal(call.Function.Arguments)
+			if err != nil {
+				return fmt.Errorf("failed to encode tool call arguments: %w", err)
+			}
+			emitToolCall(call.Function.Name, string(input))
+		}
+		if chunk.Done {
+			finishReason = o.finishReason(chunk.DoneReason)
+			usage = TokenUsage{
+				InputTokens:  chunk.PromptEvalCount,
+				OutputTokens: chunk.EvalCount,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if promptTools {
+		emitParsed(parser.Flush())
+	}
+	if len(toolCalls) > 0 {
+		finishReason = message.FinishReasonToolUse
+	}
+
+	eventChan <- ProviderEvent{
+		Type: EventComplete,
+		Response: &ProviderResponse{
+			Content:      content.String(),
+			ToolCalls:    toolCalls,
+			Usage:        usage,
+			FinishReason: finishReason,
+		},
+	}
+	return nil
+}
+
+func (o *ollamaClient) shouldRetry(attempts int, err error) (bool, int64, error) {
+	if attempts > maxRetries {
+		return false, 0, fmt.Errorf("maximum retry attempts reached: %d retries", maxRetries)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0, err
+	}
+	if ollama.IsModelNotFound(err) {
+		return false, 0, fmt.Errorf("%w (pull it with: crush providers pull %s)", err, o.Model().ID)
+	}
+	var statusErr ollama.StatusError
+	if !errors.As(err, &statusErr) {
+		// The server is not reachable; retrying will not help.
+		return false, 0, err
+	}
+	switch statusErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable:
+	default:
+		return false, 0, err
+	}
+
+	slog.Warn("Ollama error", "status_code", statusErr.StatusCode, "message", statusErr.Message)
+	backoffMs := 2000 * (1 << (attempts - 1))
+	jitterMs := int(float64(backoffMs) * 0.2)
+	return true, int64(backoffMs + jitterMs), nil
+}
+
+func (o *ollamaClient) Model() catwalk.Model {
+	return o.providerOptions.model(o.providerOptions.modelType)
+}
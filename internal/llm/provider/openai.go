@@ -74,7 +74,7 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 	}
 
 	system := openai.SystemMessage(systemMessage)
-	if isAnthropicModel && !o.providerOptions.disableCache {
+	if isAnthropicModel && o.providerOptions.caches(config.CacheBreakpointSystem) {
 		systemTextBlock := openai.ChatCompletionContentPartTextParam{Text: systemMessage}
 		systemTextBlock.SetExtraFields(
 			map[string]any{
@@ -89,11 +89,9 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 	}
 	openaiMessages = append(openaiMessages, system)
 
+	cachedFrom := len(messages) - o.providerOptions.cachedMessages()
 	for i, msg := range messages {
-		cache := false
-		if i > len(messages)-3 {
-			cache = true
-		}
+		cache := i >= cachedFrom
 		switch msg.Role {
 		case message.User:
 			var content []openai.ChatCompletionContentPartUnionParam
@@ -103,19 +101,26 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 			hasBinaryContent := false
 			for _, binaryContent := range msg.BinaryContent() {
 				hasBinaryContent = true
+				// Documents are sent as their text, as compatible APIs
+				// rarely accept files.
+				if binaryContent.IsText() || binaryContent.IsPDF() {
+					attachmentBlock := openai.ChatCompletionContentPartTextParam{Text: binaryContent.Text()}
+					content = append(content, openai.ChatCompletionContentPartUnionParam{OfText: &attachmentBlock})
+					continue
+				}
 				imageURL := openai.ChatCompletionContentPartImageImageURLParam{URL: binaryContent.String(catwalk.InferenceProviderOpenAI)}
 				imageBlock := openai.ChatCompletionContentPartImageParam{ImageURL: imageURL}
 
 				content = append(content, openai.ChatCompletionContentPartUnionParam{OfImageURL: &imageBlock})
 			}
-			if cache && !o.providerOptions.disableCache && isAnthropicModel {
+			if cache && isAnthropicModel {
 				textBlock.SetExtraFields(map[string]any{
 					"cache_control": map[string]string{
 						"type": "ephemeral",
 					},
 				})
 			}
-			if hasBinaryContent || (isAnthropicModel && !o.providerOptions.disableCache) {
+			if hasBinaryContent || (isAnthropicModel && cache) {
 				openaiMessages = append(openaiMessages, openai.UserMessage(content))
 			} else {
 				openaiMessages = append(openaiMessages, openai.UserMessage(msg.Content().String()))
@@ -130,7 +135,7 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 			if msg.Content().String() != "" {
 				hasContent = true
 				textBlock := openai.ChatCompletionContentPartTextParam{Text: msg.Content().String()}
-				if cache && !o.providerOptions.disableCache && isAnthropicModel {
+				if cache && isAnthropicModel {
 					textBlock.SetExtraFields(map[string]any{
 						"cache_control": map[string]string{
 							"type": "ephemeral",
@@ -223,10 +228,7 @@ func (o *openaiClient) preparedParams(messages []openai.ChatCompletionMessagePar
 	model := o.providerOptions.model(o.providerOptions.modelType)
 	cfg := config.Get()
 
-	modelConfig := cfg.Models[config.SelectedModelTypeLarge]
-	if o.providerOptions.modelType == config.SelectedModelTypeSmall {
-		modelConfig = cfg.Models[config.SelectedModelTypeSmall]
-	}
+	modelConfig := cfg.ModelConfig(o.providerOptions.modelType)
 
 	reasoningEffort := modelConfig.ReasoningEffort
 
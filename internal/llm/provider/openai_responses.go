@@ -0,0 +1,351 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/param"
+	"github.com/openai/openai-go/responses"
+	"github.com/openai/openai-go/shared"
+)
+
+// openaiResponsesClient talks to OpenAI through the Responses API, which
+// unlike Chat Completions returns the reasoning of the model. Requests are
+// not stored by OpenAI; the encrypted reasoning items are sent back with
+// the conversation instead.
+type openaiResponsesClient struct {
+	*openaiClient
+}
+
+type OpenAIResponsesClient ProviderClient
+
+func newOpenAIResponsesClient(opts providerClientOptions) OpenAIResponsesClient {
+	return &openaiResponsesClient{
+		openaiClient: &openaiClient{
+			providerOptions: opts,
+			client:          createOpenAIClient(opts),
+		},
+	}
+}
+
+func (o *openaiResponsesClient) convertInput(messages []message.Message) (input responses.ResponseInputParam) {
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			content := responses.ResponseInputMessageContentListParam{
+				responses.ResponseInputContentParamOfInputText(msg.Content().String()),
+			}
+			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.IsText() {
+					content = append(content, responses.ResponseInputContentParamOfInputText(binaryContent.Text()))
+					continue
+				}
+				if binaryContent.IsPDF() {
+					content = append(content, responses.ResponseInputContentUnionParam{
+						OfInputFile: &responses.ResponseInputFileParam{
+							FileData: openai.String(binaryContent.String(catwalk.InferenceProviderOpenAI)),
+							Filename: openai.String(filepath.Base(binaryContent.Path)),
+						},
+					})
+					continue
+				}
+				content = append(content, responses.ResponseInputContentUnionParam{
+					OfInputImage: &responses.ResponseInputImageParam{
+						ImageURL: openai.String(binaryContent.String(catwalk.InferenceProviderOpenAI)),
+						Detail:   responses.ResponseInputImageDetailAuto,
+					},
+				})
+			}
+			input = append(input, responses.ResponseInputItemParamOfMessage(content, responses.EasyInputMessageRoleUser))
+
+		case message.Assistant:
+			text := msg.Content().String()
+			toolCalls := msg.ToolCalls()
+			if text == "" && len(toolCalls) == 0 {
+				continue
+			}
+			if reasoning, ok := o.reasoningItem(msg); ok {
+				input = append(input, responses.ResponseInputItemUnionParam{OfReasoning: &reasoning})
+			}
+			if text != "" {
+				input = append(input, responses.ResponseInputItemParamOfMessage(text, responses.EasyInputMessageRoleAssistant))
+			}
+			for _, call := range toolCalls {
+				input = append(input, responses.ResponseInputItemParamOfFunctionCall(call.Input, call.ID, call.Name))
+			}
+
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				input = append(input, responses.ResponseInputItemParamOfFunctionCallOutput(result.ToolCallID, result.Content))
+			}
+		}
+	}
+	return
+}
+
+// reasoningItem returns the reasoning item of an assistant message so that
+// the model keeps its reasoning across turns. Reasoning from other providers
+// can't be sent back.
+func (o *openaiResponsesClient) reasoningItem(msg message.Message) (responses.ResponseReasoningItemParam, bool) {
+	reasoning := msg.ReasoningContent()
+	if reasoning.ItemID == "" || msg.Provider != o.providerOptions.config.ID {
+		return responses.ResponseReasoningItemParam{}, false
+	}
+	item := responses.ResponseReasoningItemParam{
+		ID:      reasoning.ItemID,
+		Summary: []responses.ResponseReasoningItemSummaryParam{},
+	}
+	if reasoning.Thinking != "" {
+		item.Summary = append(item.Summary, responses.ResponseReasoningItemSummaryParam{Text: reasoning.Thinking})
+	}
+	if reasoning.Signature != "" {
+		item.EncryptedContent = param.NewOpt(reasoning.Signature)
+	}
+	return item, true
+}
+
+func (o *openaiResponsesClient) convertTools(tools []tools.BaseTool) []responses.ToolUnionParam {
+	responsesTools := make([]responses.ToolUnionParam, len(tools))
+
+	for i, tool := range tools {
+		info := tool.Info()
+		responsesTools[i] = responses.ToolUnionParam{
+			OfFunction: &responses.FunctionToolParam{
+				Name:        info.Name,
+				Description: openai.String(info.Description),
+				Parameters: map[string]any{
+					"type":       "object",
+					"properties": info.Parameters,
+					"required":   info.Required,
+				},
+				Strict: openai.Bool(false),
+			},
+		}
+	}
+
+	return responsesTools
+}
+
+func (o *openaiResponsesClient) preparedParams(input responses.ResponseInputParam, tools []responses.ToolUnionParam) responses.ResponseNewParams {
+	model := o.providerOptions.model(o.providerOptions.modelType)
+	modelConfig := config.Get().ModelConfig(o.providerOptions.modelType)
+
+	systemMessage := o.providerOptions.systemMessage
+	if o.providerOptions.systemPromptPrefix != "" {
+		systemMessage = o.providerOptions.systemPromptPrefix + "\n" + systemMessage
+	}
+
+	params := responses.ResponseNewParams{
+		Model:        shared.ResponsesModel(model.ID),
+		Instructions: openai.String(systemMessage),
+		Input:        responses.ResponseNewParamsInputUnion{OfInputItemList: input},
+		Store:        openai.Bool(false),
+	}
+	if len(tools) > 0 {
+		params.Tools = tools
+	}
+
+	maxTokens := model.DefaultMaxTokens
+	if modelConfig.MaxTokens > 0 {
+		maxTokens = modelConfig.MaxTokens
+	}
+	// Override max tokens if set in provider options
+	if o.providerOptions.maxTokens > 0 {
+		maxTokens = o.providerOptions.maxTokens
+	}
+	if maxTokens > 0 {
+		params.MaxOutputTokens = openai.Int(maxTokens)
+	}
+
+	if model.CanReason {
+		params.Reasoning = shared.ReasoningParam{
+			Effort:  shared.ReasoningEffort(modelConfig.ReasoningEffort),
+			Summary: shared.ReasoningSummaryAuto,
+		}
+		params.Include = []responses.ResponseIncludable{
+			responses.ResponseIncludableReasoningEncryptedContent,
+		}
+	}
+
+	return params
+}
+
+func (o *openaiResponsesClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (response *ProviderResponse, err error) {
+	for event := range o.stream(ctx, messages, tools) {
+		switch event.Type {
+		case EventError:
+			return nil, event.Error
+		case EventComplete:
+			return event.Response, nil
+		}
+	}
+	return nil, fmt.Errorf("received empty response from OpenAI Responses API - check endpoint configuration")
+}
+
+func (o *openaiResponsesClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	params := o.preparedParams(o.convertInput(messages), o.convertTools(tools))
+
+	attempts := 0
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		for {
+			attempts++
+			err := o.streamResponse(ctx, params, eventChan)
+			if err == nil {
+				return
+			}
+
+			// If there is an error we are going to see if we can retry the call
+			retry, after, retryErr := o.shouldRetry(attempts, err)
+			if retryErr != nil {
+				eventChan <- ProviderEvent{Type: EventError, Error: retryErr}
+				return
+			}
+			if retry {
+				slog.Warn("Retrying due to rate limit", "attempt", attempts, "max_retries", maxRetries)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Duration(after) * time.Millisecond):
+					continue
+				}
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+	}()
+
+	return eventChan
+}
+
+// streamResponse streams one response into eventChan, ending with the
+// complete event.
+func (o *openaiResponsesClient) streamResponse(ctx context.Context, params responses.ResponseNewParams, eventChan chan<- ProviderEvent) error {
+	stream := o.client.Responses.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var (
+		content       string
+		toolCalls     []message.ToolCall
+		final         *responses.Response
+		reasoningSent bool
+		summaryIndex  int64
+	)
+	// Function calls are streamed by item ID but referred to by call ID.
+	callIDs := make(map[string]string)
+
+	for stream.Next() {
+		event := stream.Current()
+		switch event.Type {
+		case "response.output_text.delta":
+			content += event.Delta.OfString
+			eventChan <- ProviderEvent{
+				Type:    EventContentDelta,
+				Content: event.Delta.OfString,
+			}
+		case "response.reasoning_summary_text.delta":
+			thinking := event.Delta.OfString
+			if event.SummaryIndex != summaryIndex {
+				summaryIndex = event.SummaryIndex
+				thinking = "\n\n" + thinking
+			}
+			eventChan <- ProviderEvent{
+				Type:     EventThinkingDelta,
+				Thinking: thinking,
+			}
+		case "response.output_item.added":
+			if event.Item.Type != "function_call" {
+				continue
+			}
+			callIDs[event.Item.ID] = event.Item.CallID
+			eventChan <- ProviderEvent{
+				Type: EventToolUseStart,
+				ToolCall: &message.ToolCall{
+					ID:   event.Item.CallID,
+					Name: event.Item.Name,
+				},
+			}
+		case "response.function_call_arguments.delta":
+			eventChan <- ProviderEvent{
+				Type: EventToolUseDelta,
+				ToolCall: &message.ToolCall{
+					ID:    callIDs[event.ItemID],
+					Input: event.Delta.OfString,
+				},
+			}
+		case "response.output_item.done":
+			switch event.Item.Type {
+			case "function_call":
+				toolCall := message.ToolCall{
+					ID:       event.Item.CallID,
+					Name:     event.Item.Name,
+					Input:    event.Item.Arguments,
+					Type:     "function",
+					Finished: true,
+				}
+				toolCalls = append(toolCalls, toolCall)
+				eventChan <- ProviderEvent{Type: EventToolUseStop, ToolCall: &toolCall}
+			case "reasoning":
+				// A message holds a single reasoning item; later ones are
+				// dropped, which the API allows.
+				if reasoningSent {
+					continue
+				}
+				reasoningSent = true
+				eventChan <- ProviderEvent{
+					Type:            EventSignatureDelta,
+					Signature:       event.Item.EncryptedContent,
+					ReasoningItemID: event.Item.ID,
+				}
+			}
+		case "response.completed", "response.incomplete":
+			final = &event.Response
+		case "response.failed":
+			return fmt.Errorf("response failed: %s", event.Response.Error.Message)
+		case "error":
+			return fmt.Errorf("response error %s: %s", event.Code, event.Message)
+		}
+	}
+	if err := stream.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if final == nil {
+		return fmt.Errorf("received incomplete streaming response from OpenAI Responses API - check endpoint configuration")
+	}
+
+	finishReason := message.FinishReasonEndTurn
+	if final.IncompleteDetails.Reason == "max_output_tokens" {
+		finishReason = message.FinishReasonMaxTokens
+	}
+	if len(toolCalls) > 0 {
+		finishReason = message.FinishReasonToolUse
+	}
+
+	cachedTokens := final.Usage.InputTokensDetails.CachedTokens
+	eventChan <- ProviderEvent{
+		Type: EventComplete,
+		Response: &ProviderResponse{
+			Content:   content,
+			ToolCalls: toolCalls,
+			Usage: TokenUsage{
+				InputTokens:     final.Usage.InputTokens - cachedTokens,
+				OutputTokens:    final.Usage.OutputTokens,
+				CacheReadTokens: cachedTokens,
+			},
+			FinishReason: finishReason,
+		},
+	}
+	return nil
+}
@@ -30,17 +30,17 @@ const (
 )
 
 type TokenUsage struct {
-	InputTokens         int64
-	OutputTokens        int64
-	CacheCreationTokens int64
-	CacheReadTokens     int64
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int64 `json:"cache_read_tokens,omitempty"`
 }
 
 type ProviderResponse struct {
-	Content      string
-	ToolCalls    []message.ToolCall
-	Usage        TokenUsage
-	FinishReason message.FinishReason
+	Content      string               `json:"content,omitempty"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	Usage        TokenUsage           `json:"usage"`
+	FinishReason message.FinishReason `json:"finish_reason"`
 }
 
 type ProviderEvent struct {
@@ -49,9 +49,12 @@ type ProviderEvent struct {
 	Content   string
 	Thinking  string
 	Signature string
-	Response  *ProviderResponse
-	ToolCall  *message.ToolCall
-	Error     error
+	// ReasoningItemID is set with the signature of reasoning items of the
+	// OpenAI Responses API.
+	ReasoningItemID string
+	Response        *ProviderResponse
+	ToolCall        *message.ToolCall
+	Error           error
 }
 type Provider interface {
 	SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error)
@@ -76,6 +79,35 @@ type providerClientOptions struct {
 	extraParams        map[string]string
 }
 
+// caches reports whether the part of the prompt is marked for caching.
+func (o providerClientOptions) caches(breakpoint config.CacheBreakpoint) bool {
+	if o.disableCache {
+		return false
+	}
+	cfg := config.Get()
+	return cfg != nil && cfg.Options != nil && cfg.Options.Cache.Caches(breakpoint)
+}
+
+// maxCacheBreakpoints is the number of cache breakpoints Anthropic allows in
+// a request.
+const maxCacheBreakpoints = 4
+
+// cachedMessages returns how many of the last messages are marked for
+// caching, leaving room for the other breakpoints.
+func (o providerClientOptions) cachedMessages() int {
+	if !o.caches(config.CacheBreakpointMessages) {
+		return 0
+	}
+	available := maxCacheBreakpoints
+	if o.caches(config.CacheBreakpointSystem) {
+		available--
+	}
+	if o.caches(config.CacheBreakpointTools) {
+		available--
+	}
+	return min(config.Get().Options.Cache.Messages, available)
+}
+
 type ProviderClientOption func(*providerClientOptions)
 
 type ProviderClient interface {
@@ -143,7 +175,7 @@ func NewProvider(cfg config.ProviderConfig, opts ...ProviderClientOption) (Provi
 	restore := config.PushPopCrushEnv()
 	defer restore()
 	resolvedAPIKey, err := config.Get().Resolve(cfg.APIKey)
-	if err != nil {
+	if err != nil && !cfg.Cassette.ReplayOnly() {
 		return nil, fmt.Errorf("failed to resolve API key for provider %s: %w", cfg.ID, err)
 	}
 
@@ -172,13 +204,27 @@ func NewProvider(cfg config.ProviderConfig, opts ...ProviderClientOption) (Provi
 	for _, o := range opts {
 		o(&clientOptions)
 	}
-	switch cfg.Type {
+	provider, err := newProvider(clientOptions)
+	if err != nil || cfg.Cassette == nil {
+		return provider, err
+	}
+	return NewCassetteProvider(provider, *cfg.Cassette, config.Get().WorkingDir())
+}
+
+func newProvider(clientOptions providerClientOptions) (Provider, error) {
+	switch clientOptions.config.Type {
 	case catwalk.TypeAnthropic:
 		return &baseProvider[AnthropicClient]{
 			options: clientOptions,
 			client:  newAnthropicClient(clientOptions, AnthropicClientTypeNormal),
 		}, nil
 	case catwalk.TypeOpenAI:
+		if config.Get().ModelAPI(clientOptions.modelType) == config.APIResponses {
+			return &baseProvider[OpenAIResponsesClient]{
+				options: clientOptions,
+				client:  newOpenAIResponsesClient(clientOptions),
+			}, nil
+		}
 		return &baseProvider[OpenAIClient]{
 			options: clientOptions,
 			client:  newOpenAIClient(clientOptions),
@@ -198,11 +244,25 @@ func NewProvider(cfg config.ProviderConfig, opts ...ProviderClientOption) (Provi
 			options: clientOptions,
 			client:  newAzureClient(clientOptions),
 		}, nil
+	case config.TypeOllama:
+		return &baseProvider[OllamaClient]{
+			options: clientOptions,
+			client:  newOllamaClient(clientOptions),
+		}, nil
 	case catwalk.TypeVertexAI:
 		return &baseProvider[VertexAIClient]{
 			options: clientOptions,
 			client:  newVertexAIClient(clientOptions),
 		}, nil
+	case config.TypeMock:
+		client, err := newMockClient(clientOptions)
+		if err != nil {
+			return nil, err
+		}
+		return &baseProvider[MockClient]{
+			options: clientOptions,
+			client:  client,
+		}, nil
 	}
-	return nil, fmt.Errorf("provider not supported: %s", cfg.Type)
+	return nil, fmt.Errorf("provider not supported: %s", clientOptions.config.Type)
 }
@@ -352,24 +352,14 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("missing command"), nil
 	}
 
-	isSafeReadOnly := false
-	cmdLower := strings.ToLower(params.Command)
-
-	for _, safe := range safeCommands {
-		if strings.HasPrefix(cmdLower, safe) {
-			if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
-				isSafeReadOnly = true
-				break
-			}
-		}
-	}
+	isSafeReadOnly := hasCommandPrefix(strings.ToLower(params.Command), safeCommands)
 
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
 	if !isSafeReadOnly {
-		p := b.permissions.Request(
+		p := b.permissions.Request(ctx,
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
 				Path:        b.workingDir,
@@ -466,3 +456,34 @@ func countLines(s string) int {
 	}
 	return len(strings.Split(s, "\n"))
 }
+
+// readOnlyBashTool restricts a bash tool to read-only commands. It is what
+// the agent sees instead of bash while a session is in plan mode.
+type readOnlyBashTool struct {
+	BaseTool
+}
+
+// NewReadOnlyBashTool wraps bash so that it refuses any command that could
+// change files or system state.
+func NewReadOnlyBashTool(bash BaseTool) BaseTool {
+	return &readOnlyBashTool{BaseTool: bash}
+}
+
+func (b *readOnlyBashTool) Info() ToolInfo {
+	info := b.BaseTool.Info()
+	info.Description = fmt.Sprintf(`PLAN MODE: only read-only commands are allowed. Pipelines are fine, but command lists (;, &&, ||), redirections and substitutions are rejected. Allowed commands: %s.
+
+%s`, strings.Join(readOnlyCommands, ", "), info.Description)
+	return info
+}
+
+func (b *readOnlyBashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if !IsReadOnlyCommand(params.Command) {
+		return NewTextErrorResponse(fmt.Sprintf("Command not allowed in plan mode: %s. Only read-only commands can run until the plan is approved.", params.Command)), nil
+	}
+	return b.BaseTool.Run(ctx, call)
+}
@@ -137,7 +137,7 @@ func (t *downloadTool) Run(ctx context.Context, call ToolCall) (ToolResponse, er
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for downloading files")
 	}
 
-	p := t.permissions.Request(
+	p := t.permissions.Request(ctx,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        filePath,
@@ -132,7 +132,7 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
 
-	p := t.permissions.Request(
+	p := t.permissions.Request(ctx,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        t.workingDir,
@@ -160,34 +160,44 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		defer cancel()
 	}
 
-	req, err := http.NewRequestWithContext(requestCtx, "GET", params.URL, nil)
+	content, err := Fetch(requestCtx, t.client, params.URL, format)
 	if err != nil {
-		return ToolResponse{}, fmt.Errorf("failed to create request: %w", err)
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	return NewTextResponse(content), nil
+}
+
+// Fetch gets the content of a URL in the given format, which is text,
+// markdown or html.
+func Fetch(ctx context.Context, client *http.Client, url, format string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "crush/1.0")
 
-	resp, err := t.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return ToolResponse{}, fmt.Errorf("failed to fetch URL: %w", err)
+		return "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
+		return "", fmt.Errorf("request failed with status code: %d", resp.StatusCode)
 	}
 
 	maxSize := int64(5 * 1024 * 1024) // 5MB
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
 	if err != nil {
-		return NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	content := string(body)
 
 	isValidUt8 := utf8.ValidString(content)
 	if !isValidUt8 {
-		return NewTextErrorResponse("Response content is not valid UTF-8"), nil
+		return "", fmt.Errorf("response content is not valid UTF-8")
 	}
 	contentType := resp.Header.Get("Content-Type")
 
@@ -196,7 +206,7 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		if strings.Contains(contentType, "text/html") {
 			text, err := extractTextFromHTML(content)
 			if err != nil {
-				return NewTextErrorResponse("Failed to extract text from HTML: " + err.Error()), nil
+				return "", fmt.Errorf("failed to extract text from HTML: %w", err)
 			}
 			content = text
 		}
@@ -205,7 +215,7 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		if strings.Contains(contentType, "text/html") {
 			markdown, err := convertHTMLToMarkdown(content)
 			if err != nil {
-				return NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
+				return "", fmt.Errorf("failed to convert HTML to Markdown: %w", err)
 			}
 			content = markdown
 		}
@@ -217,14 +227,14 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		if strings.Contains(contentType, "text/html") {
 			doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 			if err != nil {
-				return NewTextErrorResponse("Failed to parse HTML: " + err.Error()), nil
+				return "", fmt.Errorf("failed to parse HTML: %w", err)
 			}
 			body, err := doc.Find("body").Html()
 			if err != nil {
-				return NewTextErrorResponse("Failed to extract body from HTML: " + err.Error()), nil
+				return "", fmt.Errorf("failed to extract body from HTML: %w", err)
 			}
 			if body == "" {
-				return NewTextErrorResponse("No body content found in HTML"), nil
+				return "", fmt.Errorf("no body content found in HTML")
 			}
 			content = "<html>\n<body>\n" + body + "\n</body>\n</html>"
 		}
@@ -236,7 +246,7 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		content += fmt.Sprintf("\n\n[Content truncated to %d bytes]", MaxReadSize)
 	}
 
-	return NewTextResponse(content), nil
+	return content, nil
 }
 
 func extractTextFromHTML(html string) (string, error) {
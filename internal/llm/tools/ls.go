@@ -150,7 +150,7 @@ func (l *lsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
 			return ToolResponse{}, fmt.Errorf("session ID and message ID are required for accessing directories outside working directory")
 		}
 
-		granted := l.permissions.Request(
+		granted := l.permissions.Request(ctx,
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
 				Path:        absSearchPath,
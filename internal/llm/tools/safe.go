@@ -1,6 +1,9 @@
 package tools
 
-import "runtime"
+import (
+	"runtime"
+	"strings"
+)
 
 var safeCommands = []string{
 	// Bash builtins and core utils
@@ -69,6 +72,124 @@ var safeCommands = []string{
 	"go vet",
 }
 
+// readOnlyCommands are the only commands bash may run while a session is in
+// plan mode. Unlike safeCommands, none of them can change files or spawn
+// other programs.
+var readOnlyCommands = []string{
+	"cat",
+	"cut",
+	"df",
+	"diff",
+	"du",
+	"echo",
+	"file",
+	"grep",
+	"head",
+	"id",
+	"ls",
+	"nl",
+	"printenv",
+	"ps",
+	"pwd",
+	"rg",
+	"stat",
+	"tail",
+	"tree",
+	"uname",
+	"wc",
+	"whereis",
+	"which",
+	"whoami",
+
+	// Git
+	"git blame",
+	"git describe",
+	"git diff",
+	"git grep",
+	"git log",
+	"git ls-files",
+	"git rev-parse",
+	"git shortlog",
+	"git show",
+	"git status",
+
+	// Go
+	"go doc",
+	"go env",
+	"go list",
+	"go version",
+}
+
+// writingFlags are the flags of readOnlyCommands that write files or run
+// other programs, which plan mode rejects. Flags are case-sensitive, as -O
+// and -o often mean different things.
+var writingFlags = map[string][]string{
+	"file":     {"-C", "--compile"},
+	"rg":       {"--pre"},
+	"tree":     {"-o"},
+	"git diff": {"--output", "--ext-diff"},
+	"git grep": {"-O", "--open-files-in-pager"},
+	"git log":  {"--output", "--ext-diff"},
+	"git show": {"--output", "--ext-diff"},
+}
+
+// IsReadOnlyCommand reports whether command only reads state. Pipelines are
+// allowed as long as every stage is read-only; command lists, redirections
+// and substitutions are not.
+func IsReadOnlyCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" || strings.ContainsAny(command, ";&<>`\n") || strings.Contains(command, "$(") {
+		return false
+	}
+	for stage := range strings.SplitSeq(command, "|") {
+		stage = strings.TrimSpace(stage)
+		// Commands are matched in any case, but flags as they are written.
+		name := strings.ToLower(stage)
+		if !hasCommandPrefix(name, readOnlyCommands) {
+			return false
+		}
+		for c, flags := range writingFlags {
+			if hasCommandPrefix(name, []string{c}) && hasFlag(stage[len(c):], flags) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasFlag reports whether args contain one of the given flags. Short flags
+// also match when grouped with others, and long flags when abbreviated, as
+// git accepts unambiguous prefixes.
+func hasFlag(args string, flags []string) bool {
+	for _, arg := range strings.Fields(args) {
+		name, _, _ := strings.Cut(arg, "=")
+		for _, flag := range flags {
+			switch {
+			case strings.HasPrefix(flag, "--"):
+				if len(name) > 2 && strings.HasPrefix(flag, name) {
+					return true
+				}
+			case len(name) > 1 && name[0] == '-' && name[1] != '-':
+				if strings.Contains(name[1:], flag[1:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// hasCommandPrefix reports whether command is one of the given commands,
+// alone or followed by its arguments, so that cat doesn't match cat-foo.
+func hasCommandPrefix(command string, commands []string) bool {
+	for _, c := range commands {
+		if command == c || strings.HasPrefix(command, c+" ") {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	if runtime.GOOS == "windows" {
 		safeCommands = append(
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command  string
+		readOnly bool
+	}{
+		{"ls", true},
+		{"ls -la internal", true},
+		{"git log --oneline -n 5", true},
+		{"grep -rn foo . | head -20", true},
+		{"GIT STATUS", true},
+		{"", false},
+		{"rm -rf build", false},
+		{"lsof -i", false},
+		{"git checkout main", false},
+		{"git branch -D main", false},
+		{"ls; rm -rf build", false},
+		{"ls && touch file", false},
+		{"ls || touch file", false},
+		{"cat file > other", false},
+		{"echo $(rm file)", false},
+		{"echo `rm file`", false},
+		{"cat file | xargs rm", false},
+		{"ls\nrm file", false},
+		{"date -s 2020-01-01", false},
+		{"hostname evil", false},
+		{"go vet ./...", false},
+		{"tree -L 2", true},
+		{"tree -o out.txt", false},
+		{"tree -fo out.txt", false},
+		{"git diff --stat", true},
+		{"git diff --output=patch.diff", false},
+		{"git log -p --output patch.diff", false},
+		{"git show --ext-diff HEAD", false},
+		{"git show --ext HEAD", false},
+		{"rg --pre ./script foo", false},
+		{"file -C -m magic", false},
+		{"file -c", true},
+		{"uniq in out", false},
+		{"git grep -o foo", true},
+		{"git grep -O foo", false},
+		{"git grep -Ovim foo", false},
+		{"git grep -nO foo", false},
+		{"git grep --open-files-in-pager=vim foo", false},
+		{"git grep --open foo", false},
+		{"tree-sitter generate", false},
+		{"file-roller archive.zip", false},
+		{"cat-foo", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.readOnly, IsReadOnlyCommand(tt.command))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/todo"
+)
+
+type TodosParams struct {
+	Action  string   `json:"action"`
+	Items   []string `json:"items,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Content string   `json:"content,omitempty"`
+	Status  string   `json:"status,omitempty"`
+}
+
+type TodoItem struct {
+	Content string      `json:"content"`
+	Status  todo.Status `json:"status"`
+}
+
+type TodosResponseMetadata struct {
+	Action string     `json:"action"`
+	Todos  []TodoItem `json:"todos"`
+}
+
+type todosTool struct {
+	todos todo.Service
+}
+
+const (
+	TodosToolName    = "todos"
+	todosDescription = `Manage a checklist of the steps needed to complete the current task.
+WHEN TO USE THIS TOOL:
+- Use for tasks that take three or more distinct steps
+- Use when the user gives you a list of things to do
+- Do not use for simple, single step requests
+HOW TO USE:
+- "create" adds the given items (one string per step) as pending
+- "update" changes the content and/or status of the item with the given id
+- "complete" marks the item with the given id as completed
+- "delete" removes the item with the given id
+- "list" shows the current checklist
+STATUSES:
+- pending: not started yet
+- in_progress: being worked on, keep only one item in progress at a time
+- completed: done
+TIPS:
+- Create the checklist before starting to work, then keep it up to date
+- Mark an item in_progress before starting it and complete it as soon as it is done
+- Every response contains the full checklist with the id of each item
+- The user sees the checklist live, so keep items short and concrete
+`
+)
+
+func NewTodosTool(todos todo.Service) BaseTool {
+	return &todosTool{
+		todos: todos,
+	}
+}
+
+func (t *todosTool) Name() string {
+	return TodosToolName
+}
+
+func (t *todosTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TodosToolName,
+		Description: todosDescription,
+		Parameters: map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "The action to perform",
+				"enum":        []string{"create", "update", "complete", "delete", "list"},
+			},
+			"items": map[string]any{
+				"type":        "array",
+				"description": "The items to add, for the create action",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"id": map[string]any{
+				"type":        "string",
+				"description": "The id of the item, for the update, complete and delete actions",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The new content of the item, for the update action",
+			},
+			"status": map[string]any{
+				"type":        "string",
+				"description": "The new status of the item, for the update action",
+				"enum":        []string{string(todo.StatusPending), string(todo.StatusInProgress), string(todo.StatusCompleted)},
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (t *todosTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TodosParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for managing todos")
+	}
+
+	switch params.Action {
+	case "create":
+		if len(params.Items) == 0 {
+			return NewTextErrorResponse("items are required for the create action"), nil
+		}
+		for _, item := range params.Items {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if _, err := t.todos.Create(ctx, sessionID, item); err != nil {
+				return ToolResponse{}, fmt.Errorf("error creating todo: %w", err)
+			}
+		}
+	case "update":
+		item, err := t.sessionTodo(ctx, sessionID, params.ID)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		if params.Content == "" && params.Status == "" {
+			return NewTextErrorResponse("content or status is required for the update action"), nil
+		}
+		if params.Content != "" {
+			item.Content = strings.TrimSpace(params.Content)
+		}
+		if params.Status != "" {
+			item.Status = todo.Status(params.Status)
+			if !item.Status.Valid() {
+				return NewTextErrorResponse(fmt.Sprintf("invalid status: %s", params.Status)), nil
+			}
+		}
+		if _, err := t.todos.Save(ctx, item); err != nil {
+			return ToolResponse{}, fmt.Errorf("error updating todo: %w", err)
+		}
+	case "complete":
+		item, err := t.sessionTodo(ctx, sessionID, params.ID)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		item.Status = todo.StatusCompleted
+		if _, err := t.todos.Save(ctx, item); err != nil {
+			return ToolResponse{}, fmt.Errorf("error updating todo: %w", err)
+		}
+	case "delete":
+		item, err := t.sessionTodo(ctx, sessionID, params.ID)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		if err := t.todos.Delete(ctx, item.ID); err != nil {
+			return ToolResponse{}, fmt.Errorf("error deleting todo: %w", err)
+		}
+	case "list":
+		// The checklist is returned below.
+	default:
+		return NewTextErrorResponse(fmt.Sprintf("unknown action: %s", params.Action)), nil
+	}
+
+	todos, err := t.todos.List(ctx, sessionID)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error listing todos: %w", err)
+	}
+
+	metadata := TodosResponseMetadata{
+		Action: params.Action,
+		Todos:  make([]TodoItem, len(todos)),
+	}
+	for i, item := range todos {
+		metadata.Todos[i] = TodoItem{Content: item.Content, Status: item.Status}
+	}
+
+	if len(todos) == 0 {
+		return WithResponseMetadata(NewTextResponse("The checklist is empty."), metadata), nil
+	}
+	open := len(todo.Open(todos))
+	output := fmt.Sprintf("Checklist (%d of %d done):\n%s", len(todos)-open, len(todos), todo.Markdown(todos, true))
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// sessionTodo returns the item with the given id, making sure it belongs to
+// the current session.
+func (t *todosTool) sessionTodo(ctx context.Context, sessionID, id string) (todo.Todo, error) {
+	if id == "" {
+		return todo.Todo{}, fmt.Errorf("id is required for this action")
+	}
+	item, err := t.todos.Get(ctx, id)
+	if err != nil || item.SessionID != sessionID {
+		return todo.Todo{}, fmt.Errorf("todo not found: %s", id)
+	}
+	return item, nil
+}
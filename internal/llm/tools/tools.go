@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/charmbracelet/crush/internal/message"
 )
 
 type ToolInfo struct {
@@ -32,6 +34,8 @@ type ToolResponse struct {
 	Content  string           `json:"content"`
 	Metadata string           `json:"metadata,omitempty"`
 	IsError  bool             `json:"is_error"`
+	// Images are shown to the user along with the content.
+	Images []message.ToolResultImage `json:"images,omitempty"`
 }
 
 func NewTextResponse(content string) ToolResponse {
@@ -151,7 +151,7 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 			return ToolResponse{}, fmt.Errorf("session ID and message ID are required for accessing files outside working directory")
 		}
 
-		granted := v.permissions.Request(
+		granted := v.permissions.Request(ctx,
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
 				Path:        absFilePath,
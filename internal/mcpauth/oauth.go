@@ -0,0 +1,247 @@
+package mcpauth
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+const (
+	clientName   = "Crush"
+	callbackPath = "/callback"
+)
+
+// Enabled reports whether requests to the server should be authorized with
+// OAuth, either because it is configured to or because someone logged in to
+// it.
+func Enabled(dataDir, name string, m config.MCPConfig) bool {
+	if m.Type != config.MCPHttp && m.Type != config.MCPSse {
+		return false
+	}
+	return m.OAuth != nil || NewStore(dataDir, name).Exists()
+}
+
+// ClientConfig returns the OAuth configuration of the server's MCP client.
+// Its tokens come from the store and are refreshed, and saved, automatically
+// once they expire.
+func ClientConfig(dataDir, name string, m config.MCPConfig) transport.OAuthConfig {
+	store := NewStore(dataDir, name)
+	cfg := transport.OAuthConfig{
+		TokenStore:  store,
+		PKCEEnabled: true,
+	}
+	if m.OAuth != nil {
+		cfg.ClientID = m.OAuth.ClientID
+		cfg.ClientSecret = m.OAuth.ClientSecret
+		cfg.Scopes = m.OAuth.Scopes
+	}
+	// The client that logged in is the one that can refresh the token.
+	if creds, err := store.Load(); err == nil && creds.ClientID != "" {
+		cfg.ClientID = creds.ClientID
+		cfg.ClientSecret = creds.ClientSecret
+		cfg.RedirectURI = creds.RedirectURI
+	}
+	return cfg
+}
+
+// IsAuthRequired reports whether the error means the server needs the user
+// to log in.
+func IsAuthRequired(err error) bool {
+	return client.IsOAuthAuthorizationRequiredError(err)
+}
+
+// Login authorizes Crush to access the server with the OAuth authorization
+// code flow. It discovers the authorization server from the server's
+// metadata, registers a client when none is configured, and opens the
+// authorization page with openURL. The browser is redirected back to a
+// listener on the loopback interface, and the token it gets is saved in the
+// data directory.
+func Login(ctx context.Context, dataDir, name string, m config.MCPConfig, openURL func(string) error) error {
+	if m.Type != config.MCPHttp && m.Type != config.MCPSse {
+		return fmt.Errorf("mcp '%s' is not an http or sse server", name)
+	}
+	baseURL, err := serverBaseURL(m.URL)
+	if err != nil {
+		return err
+	}
+
+	var settings config.MCPOAuthConfig
+	if m.OAuth != nil {
+		settings = *m.OAuth
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", settings.RedirectPort))
+	if err != nil {
+		return fmt.Errorf("failed to start redirect listener: %w", err)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath)
+
+	store := NewStore(dataDir, name)
+	handler := transport.NewOAuthHandler(transport.OAuthConfig{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		RedirectURI:  redirectURI,
+		Scopes:       settings.Scopes,
+		TokenStore:   store,
+		PKCEEnabled:  true,
+	})
+	handler.SetBaseURL(baseURL)
+	if settings.ClientID == "" {
+		if err := handler.RegisterClient(ctx, clientName); err != nil {
+			return fmt.Errorf("failed to register client: %w", err)
+		}
+	}
+	// Save the client before the token so that the token refresh later uses
+	// the client the token was issued to.
+	if err := store.Save(Credentials{
+		ClientID:     handler.GetClientID(),
+		ClientSecret: handler.GetClientSecret(),
+		RedirectURI:  redirectURI,
+	}); err != nil {
+		return err
+	}
+
+	verifier, err := transport.GenerateCodeVerifier()
+	if err != nil {
+		return fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	state, err := transport.GenerateState()
+	if err != nil {
+		return fmt.Errorf("failed to generate state: %w", err)
+	}
+	authURL, err := handler.GetAuthorizationURL(ctx, state, transport.GenerateCodeChallenge(verifier))
+	if err != nil {
+		return fmt.Errorf("failed to get authorization url: %w", err)
+	}
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{
+		Handler:           callbackHandler(results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()
+
+	if err := openURL(authURL); err != nil {
+		return fmt.Errorf("failed to open authorization url: %w", err)
+	}
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result = <-results:
+	}
+	if result.err != nil {
+		return result.err
+	}
+	if err := handler.ProcessAuthorizationResponse(ctx, result.code, result.state, verifier); err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	return nil
+}
+
+// Logout forgets the credentials of the server.
+func Logout(dataDir, name string) error {
+	return NewStore(dataDir, name).Delete()
+}
+
+type callbackResult struct {
+	code  string
+	state string
+	err   error
+}
+
+// callbackHandler handles the redirect of the browser after the user
+// authorized, or refused to authorize, Crush.
+func callbackHandler(results chan<- callbackResult) http.Handler {
+	var done atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		result := callbackResult{
+			code:  query.Get("code"),
+			state: query.Get("state"),
+		}
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s", cmp.Or(query.Get("error_description"), query.Get("error")))
+		case result.code == "":
+			result.err = errors.New("authorization failed: no code in redirect")
+		}
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = fmt.Fprintln(w, "Crush is now authorized. You can close this window.")
+		}
+		if done.CompareAndSwap(false, true) {
+			results <- result
+		}
+	})
+	return mux
+}
+
+// OpenBrowser opens the URL in the default browser.
+func OpenBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// serverBaseURL returns the URL that the authorization metadata is
+// discovered from, the scheme and host of the server's URL.
+func serverBaseURL(serverURL string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid mcp url: %q", serverURL)
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), nil
+}
+
+// Tracker is an http.RoundTripper that remembers whether the server answered
+// with 401 Unauthorized, which is how servers that were not set up for OAuth
+// tell that they need the user to log in.
+type Tracker struct {
+	base         http.RoundTripper
+	unauthorized atomic.Bool
+}
+
+// NewTracker returns a tracker that sends requests with the default
+// transport.
+func NewTracker() *Tracker {
+	return &Tracker{base: http.DefaultTransport}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Tracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.unauthorized.Store(true)
+	}
+	return resp, err
+}
+
+// Unauthorized reports whether a request was answered with 401
+// Unauthorized.
+func (t *Tracker) Unauthorized() bool {
+	return t.unauthorized.Load()
+}
@@ -0,0 +1,128 @@
+package mcpauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := NewStore(dir, "remote/server")
+	require.False(t, store.Exists())
+
+	_, err := store.GetToken()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, store.Save(Credentials{ClientID: "client"}))
+	_, err = store.GetToken()
+	require.Error(t, err)
+
+	require.NoError(t, store.SaveToken(&transport.Token{AccessToken: "access"}))
+	creds, err := store.Load()
+	require.NoError(t, err)
+	require.Equal(t, "client", creds.ClientID)
+	require.Equal(t, "access", creds.Token.AccessToken)
+
+	info, err := os.Stat(filepath.Join(dir, "mcp-oauth", "remote%2Fserver.json"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, store.Delete())
+	require.False(t, store.Exists())
+	require.NoError(t, store.Delete())
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"client_id": "registered"})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		require.Equal(t, "registered", query.Get("client_id"))
+		require.Equal(t, "S256", query.Get("code_challenge_method"))
+		challenge = query.Get("code_challenge")
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"code"}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "code", r.Form.Get("code"))
+		require.Equal(t, challenge, transport.GenerateCodeChallenge(r.Form.Get("code_verifier")))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	m := config.MCPConfig{Type: config.MCPHttp, URL: server.URL + "/mcp"}
+	require.False(t, Enabled(dir, "remote", m))
+
+	err := Login(t.Context(), dir, "remote", m, func(u string) error {
+		resp, err := http.Get(u)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	})
+	require.NoError(t, err)
+	require.True(t, Enabled(dir, "remote", m))
+
+	creds, err := NewStore(dir, "remote").Load()
+	require.NoError(t, err)
+	require.Equal(t, "registered", creds.ClientID)
+	require.Equal(t, "access", creds.Token.AccessToken)
+	require.Equal(t, "refresh", creds.Token.RefreshToken)
+
+	cfg := ClientConfig(dir, "remote", m)
+	require.Equal(t, "registered", cfg.ClientID)
+	require.Equal(t, creds.RedirectURI, cfg.RedirectURI)
+}
+
+func TestLoginDenied(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		redirect, _ := url.Parse(r.URL.Query().Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"error": {"access_denied"}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	m := config.MCPConfig{
+		Type:  config.MCPSse,
+		URL:   server.URL + "/sse",
+		OAuth: &config.MCPOAuthConfig{ClientID: "configured"},
+	}
+	err := Login(t.Context(), t.TempDir(), "remote", m, func(u string) error {
+		resp, err := http.Get(u)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	})
+	require.ErrorContains(t, err, "access_denied")
+}
@@ -0,0 +1,127 @@
+// Package mcpauth implements the OAuth authorization of remote MCP servers.
+package mcpauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// Credentials are what Crush keeps about an MCP server after logging in to
+// it: the OAuth client it used and the last token it got.
+type Credentials struct {
+	ClientID     string           `json:"client_id"`
+	ClientSecret string           `json:"client_secret,omitempty"`
+	RedirectURI  string           `json:"redirect_uri,omitempty"`
+	Token        *transport.Token `json:"token,omitempty"`
+}
+
+// Store keeps the credentials of an MCP server in the data directory. It is
+// also the token store of the server's OAuth client, so refreshed tokens are
+// saved too.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ transport.TokenStore = (*Store)(nil)
+
+// NewStore returns the store of the named server.
+func NewStore(dataDir, name string) *Store {
+	return &Store{
+		path: filepath.Join(dataDir, "mcp-oauth", url.PathEscape(name)+".json"),
+	}
+}
+
+// Exists reports whether credentials were saved for the server.
+func (s *Store) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// Load reads the saved credentials. It returns an error wrapping
+// os.ErrNotExist if there are none.
+func (s *Store) Load() (Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Save replaces the saved credentials.
+func (s *Store) Save(creds Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(creds)
+}
+
+// Delete removes the saved credentials, logging out of the server.
+func (s *Store) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+	return nil
+}
+
+// GetToken implements transport.TokenStore.
+func (s *Store) GetToken() (*transport.Token, error) {
+	creds, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	if creds.Token == nil {
+		return nil, errors.New("no token available")
+	}
+	return creds.Token, nil
+}
+
+// SaveToken implements transport.TokenStore.
+func (s *Store) SaveToken(token *transport.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	creds, err := s.load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	creds.Token = token
+	return s.save(creds)
+}
+
+func (s *Store) load() (Credentials, error) {
+	var creds Credentials
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return creds, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return creds, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func (s *Store) save(creds Credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	// Write to a temporary file first so that a token refresh never leaves
+	// a truncated file behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	sessionsURI         = "crush://sessions"
+	sessionURITemplate  = "crush://sessions/{id}"
+	messagesURITemplate = "crush://messages/{session_id}"
+
+	jsonMIMEType = "application/json"
+)
+
+// sessionJSON is how a session is described to MCP clients.
+type sessionJSON struct {
+	ID               string  `json:"id"`
+	ParentSessionID  string  `json:"parent_session_id,omitempty"`
+	Title            string  `json:"title"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	CreatedAt        int64   `json:"created_at"`
+	UpdatedAt        int64   `json:"updated_at"`
+	URI              string  `json:"uri"`
+	MessagesURI      string  `json:"messages_uri"`
+}
+
+// messageJSON is how a message is described to MCP clients.
+type messageJSON struct {
+	ID           string               `json:"id"`
+	Role         message.MessageRole  `json:"role"`
+	Model        string               `json:"model,omitempty"`
+	Provider     string               `json:"provider,omitempty"`
+	Text         string               `json:"text,omitempty"`
+	Reasoning    string               `json:"reasoning,omitempty"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults  []message.ToolResult `json:"tool_results,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	CreatedAt    int64                `json:"created_at"`
+}
+
+func (s *Server) addResources() {
+	s.mcp.AddResource(
+		mcp.NewResource(
+			sessionsURI,
+			"sessions",
+			mcp.WithResourceDescription("The chat sessions of Crush, newest first"),
+			mcp.WithMIMEType(jsonMIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			sessions, err := s.sessions.List(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list sessions: %w", err)
+			}
+			result := make([]sessionJSON, 0, len(sessions))
+			for _, sess := range sessions {
+				result = append(result, toSessionJSON(sess))
+			}
+			return jsonContents(request.Params.URI, result)
+		},
+	)
+	s.mcp.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			sessionURITemplate,
+			"session",
+			mcp.WithTemplateDescription("A chat session of Crush"),
+			mcp.WithTemplateMIMEType(jsonMIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			sess, err := s.sessions.Get(ctx, templateArgument(request, "id"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get session: %w", err)
+			}
+			return jsonContents(request.Params.URI, toSessionJSON(sess))
+		},
+	)
+	s.mcp.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			messagesURITemplate,
+			"messages",
+			mcp.WithTemplateDescription("The messages of a chat session of Crush, oldest first"),
+			mcp.WithTemplateMIMEType(jsonMIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			sessionID := templateArgument(request, "session_id")
+			if _, err := s.sessions.Get(ctx, sessionID); err != nil {
+				return nil, fmt.Errorf("failed to get session: %w", err)
+			}
+			messages, err := s.messages.List(ctx, sessionID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list messages: %w", err)
+			}
+			result := make([]messageJSON, 0, len(messages))
+			for _, msg := range messages {
+				result = append(result, toMessageJSON(msg))
+			}
+			return jsonContents(request.Params.URI, result)
+		},
+	)
+}
+
+// templateArgument returns a variable of the resource template that matched
+// the request.
+func templateArgument(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: jsonMIMEType,
+			Text:     string(data),
+		},
+	}, nil
+}
+
+func toSessionJSON(sess session.Session) sessionJSON {
+	return sessionJSON{
+		ID:               sess.ID,
+		ParentSessionID:  sess.ParentSessionID,
+		Title:            sess.Title,
+		MessageCount:     sess.MessageCount,
+		PromptTokens:     sess.PromptTokens,
+		CompletionTokens: sess.CompletionTokens,
+		Cost:             sess.Cost,
+		CreatedAt:        sess.CreatedAt,
+		UpdatedAt:        sess.UpdatedAt,
+		URI:              "crush://sessions/" + sess.ID,
+		MessagesURI:      "crush://messages/" + sess.ID,
+	}
+}
+
+func toMessageJSON(msg message.Message) messageJSON {
+	return messageJSON{
+		ID:           msg.ID,
+		Role:         msg.Role,
+		Model:        msg.Model,
+		Provider:     msg.Provider,
+		Text:         msg.Content().Text,
+		Reasoning:    msg.ReasoningContent().Thinking,
+		ToolCalls:    msg.ToolCalls(),
+		ToolResults:  msg.ToolResults(),
+		FinishReason: msg.FinishReason(),
+		CreatedAt:    msg.CreatedAt,
+	}
+}
@@ -0,0 +1,180 @@
+// Package mcpserver publishes the tools and sessions of Crush over the Model
+// Context Protocol, so that other agents and editors can use them.
+package mcpserver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	serverName = "crush"
+
+	instructions = `Crush exposes the tools of its coding agent and its chat sessions.
+Tool calls are checked by Crush's permission rules: calls that are not allowed by permissions.allowed_tools, a permission_request hook or --yolo are denied.
+Sessions are listed in the crush://sessions resource.`
+
+	permissionDeniedMessage = "permission denied: allow this tool with permissions.allowed_tools or a permission_request hook in the Crush configuration, or start the server with --yolo"
+)
+
+// Server is an MCP server backed by the services of a Crush app.
+type Server struct {
+	mcp         *server.MCPServer
+	sessions    session.Service
+	messages    message.Service
+	permissions permission.Service
+
+	// clientSessions maps the MCP session of each client to the Crush
+	// session its tool calls are made in.
+	clientSessions *csync.Map[string, string]
+	sessionMu      sync.Mutex
+}
+
+// New returns a server that publishes the given tools, and the sessions and
+// messages of the app as resources. Tool calls that need the user's
+// permission are denied, since there is nobody to ask, unless the permission
+// service allows them on its own.
+func New(ctx context.Context, sessions session.Service, messages message.Service, permissions permission.Service, serverTools []tools.BaseTool) *Server {
+	s := &Server{
+		mcp: server.NewMCPServer(
+			serverName,
+			version.Version,
+			server.WithToolCapabilities(false),
+			server.WithResourceCapabilities(false, false),
+			server.WithInstructions(instructions),
+			server.WithRecovery(),
+		),
+		sessions:       sessions,
+		messages:       messages,
+		permissions:    permissions,
+		clientSessions: csync.NewMap[string, string](),
+	}
+	for _, tool := range serverTools {
+		s.mcp.AddTool(toolSchema(tool.Info()), s.toolHandler(tool))
+	}
+	s.addResources()
+	go s.denyPermissionRequests(s.permissions.Subscribe(ctx))
+	return s
+}
+
+// ServeStdio serves a single client over stdin and stdout until the input
+// is closed or the context is done.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	return server.NewStdioServer(s.mcp).Listen(ctx, in, out)
+}
+
+// HTTPHandler returns a handler that serves clients with the streamable
+// HTTP transport at the given path.
+func (s *Server) HTTPHandler(path string) http.Handler {
+	return server.NewStreamableHTTPServer(s.mcp, server.WithEndpointPath(path))
+}
+
+// toolSchema converts the description of a Crush tool to an MCP tool.
+func toolSchema(info tools.ToolInfo) mcp.Tool {
+	properties := info.Parameters
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := info.Required
+	if required == nil {
+		required = []string{}
+	}
+	schema, _ := json.Marshal(map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	})
+	return mcp.NewToolWithRawSchema(info.Name, info.Description, schema)
+}
+
+func (s *Server) toolHandler(tool tools.BaseTool) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID, err := s.sessionFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args := request.GetArguments()
+		if args == nil {
+			args = map[string]any{}
+		}
+		input, err := json.Marshal(args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid arguments", err), nil
+		}
+
+		// Tools expect to run for a message of a session; each call is its
+		// own message.
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		ctx = context.WithValue(ctx, tools.MessageIDContextKey, uuid.NewString())
+		response, err := tool.Run(ctx, tools.ToolCall{
+			ID:    uuid.NewString(),
+			Name:  tool.Name(),
+			Input: string(input),
+		})
+		switch {
+		case errors.Is(err, permission.ErrorPermissionDenied):
+			return mcp.NewToolResultError(permissionDeniedMessage), nil
+		case err != nil:
+			return mcp.NewToolResultErrorFromErr("tool failed", err), nil
+		case response.IsError:
+			return mcp.NewToolResultError(response.Content), nil
+		default:
+			return mcp.NewToolResultText(response.Content), nil
+		}
+	}
+}
+
+// sessionFor returns the Crush session of the client making the request,
+// creating it on the client's first tool call.
+func (s *Server) sessionFor(ctx context.Context) (string, error) {
+	clientID := "default"
+	clientName := "client"
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		clientID = cmp.Or(cs.SessionID(), clientID)
+		if withInfo, ok := cs.(server.SessionWithClientInfo); ok {
+			clientName = cmp.Or(withInfo.GetClientInfo().Name, clientName)
+		}
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if id, ok := s.clientSessions.Get(clientID); ok {
+		return id, nil
+	}
+	sess, err := s.sessions.Create(ctx, fmt.Sprintf("MCP: %s", clientName))
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	s.clientSessions.Set(clientID, sess.ID)
+	return sess.ID, nil
+}
+
+// denyPermissionRequests denies the permission requests that reach the
+// user, since the server has no user to ask.
+func (s *Server) denyPermissionRequests(events <-chan pubsub.Event[permission.PermissionRequest]) {
+	for event := range events {
+		if event.Type != pubsub.CreatedEvent {
+			continue
+		}
+		slog.Info("Denying mcp tool call", "tool", event.Payload.ToolName, "action", event.Payload.Action)
+		s.permissions.Deny(event.Payload)
+	}
+}
@@ -0,0 +1,170 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTool echoes its input after asking for permission.
+type echoTool struct {
+	permissions permission.Service
+}
+
+func (t *echoTool) Name() string { return "echo" }
+
+func (t *echoTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{
+		Name:        "echo",
+		Description: "Echoes its input",
+		Parameters:  map[string]any{"text": map[string]any{"type": "string"}},
+		Required:    []string{"text"},
+	}
+}
+
+func (t *echoTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	sessionID, messageID := tools.GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return tools.NewTextErrorResponse("missing session"), nil
+	}
+	if !t.permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:  sessionID,
+		ToolCallID: call.ID,
+		ToolName:   t.Name(),
+		Action:     "echo",
+		Path:       ".",
+	}) {
+		return tools.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	return tools.NewTextResponse(call.Input), nil
+}
+
+func newTestClient(t *testing.T, allowedTools []string) (*client.Client, session.Service, message.Service) {
+	t.Helper()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+	permissions := permission.NewPermissionService(t.TempDir(), false, allowedTools)
+
+	srv := New(t.Context(), sessions, messages, permissions, []tools.BaseTool{&echoTool{permissions}})
+	c, err := client.NewInProcessClient(srv.mcp)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo:      mcp.Implementation{Name: "test"},
+		},
+	})
+	require.NoError(t, err)
+	return c, sessions, messages
+}
+
+func callEcho(t *testing.T, c *client.Client) *mcp.CallToolResult {
+	t.Helper()
+	result, err := c.CallTool(t.Context(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "echo",
+			Arguments: map[string]any{"text": "hi"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	return result
+}
+
+func TestTools(t *testing.T) {
+	t.Parallel()
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		c, _, _ := newTestClient(t, nil)
+		result, err := c.ListTools(t.Context(), mcp.ListToolsRequest{})
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		schema, err := json.Marshal(result.Tools[0].InputSchema)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`, string(schema))
+	})
+
+	t.Run("denied without permission", func(t *testing.T) {
+		t.Parallel()
+		c, _, _ := newTestClient(t, nil)
+		result := callEcho(t, c)
+		require.True(t, result.IsError)
+		require.Contains(t, result.Content[0].(mcp.TextContent).Text, "permission denied")
+	})
+
+	t.Run("allowed tools", func(t *testing.T) {
+		t.Parallel()
+		c, sessions, _ := newTestClient(t, []string{"echo"})
+		result := callEcho(t, c)
+		require.False(t, result.IsError)
+		require.JSONEq(t, `{"text":"hi"}`, result.Content[0].(mcp.TextContent).Text)
+
+		// The calls of a client share a session.
+		callEcho(t, c)
+		list, err := sessions.List(t.Context())
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.True(t, strings.HasPrefix(list[0].Title, "MCP: "))
+	})
+}
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	c, sessions, messages := newTestClient(t, nil)
+	sess, err := sessions.Create(t.Context(), "Refactor")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	read := func(uri string, v any) {
+		result, err := c.ReadResource(t.Context(), mcp.ReadResourceRequest{
+			Params: mcp.ReadResourceParams{URI: uri},
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		text := result.Contents[0].(mcp.TextResourceContents).Text
+		require.NoError(t, json.Unmarshal([]byte(text), v))
+	}
+
+	var list []sessionJSON
+	read(sessionsURI, &list)
+	require.Len(t, list, 1)
+	require.Equal(t, "Refactor", list[0].Title)
+
+	var got sessionJSON
+	read(list[0].URI, &got)
+	require.Equal(t, sess.ID, got.ID)
+
+	var msgs []messageJSON
+	read(list[0].MessagesURI, &msgs)
+	require.Len(t, msgs, 1)
+	require.Equal(t, message.User, msgs[0].Role)
+	require.Equal(t, "hello", msgs[0].Text)
+
+	_, err = c.ReadResource(t.Context(), mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: "crush://sessions/missing"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+// Package mention finds the @ mentions of a prompt, such as files, folders,
+// symbols, URLs and sessions, and resolves them to the context they refer
+// to.
+package mention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	KindFile Kind = iota
+	KindFolder
+	KindSymbol
+	KindURL
+	KindSession
+)
+
+const (
+	// SymbolPrefix starts the mention of an LSP workspace symbol, as in
+	// @symbol:NewService.
+	SymbolPrefix = "symbol:"
+	// SessionPrefix starts the mention of another session, as in
+	// @session:<id>.
+	SessionPrefix = "session:"
+)
+
+// lineRangePattern matches the line range of a file mention, as in
+// #L10-40, #L10-L40 or #L10.
+var lineRangePattern = regexp.MustCompile(`#L(\d+)(?:-L?(\d+))?$`)
+
+// Mention is a reference to some context in a prompt.
+type Mention struct {
+	Kind Kind
+	// Target is the path, symbol name, URL or session ID.
+	Target string
+	// StartLine and EndLine are the lines of a file to include, starting
+	// at 1. They are zero to include the whole file.
+	StartLine int
+	EndLine   int
+}
+
+// String returns the mention as it is written in a prompt, without the @.
+func (m Mention) String() string {
+	switch m.Kind {
+	case KindSymbol:
+		return SymbolPrefix + m.Target
+	case KindSession:
+		return SessionPrefix + m.Target
+	case KindFile:
+		switch {
+		case m.StartLine == 0:
+			return m.Target
+		case m.EndLine == m.StartLine:
+			return fmt.Sprintf("%s#L%d", m.Target, m.StartLine)
+		default:
+			return fmt.Sprintf("%s#L%d-%d", m.Target, m.StartLine, m.EndLine)
+		}
+	default:
+		return m.Target
+	}
+}
+
+// Parse parses a word of a prompt as a mention. Paths aren't checked to
+// exist, and are all parsed as files.
+func Parse(word string) (Mention, bool) {
+	word, ok := strings.CutPrefix(word, "@")
+	if !ok {
+		return Mention{}, false
+	}
+	// Mentions are often followed by punctuation in prose.
+	word = strings.TrimRight(word, ",;!?)")
+	if word == "" {
+		return Mention{}, false
+	}
+
+	switch {
+	case strings.HasPrefix(word, "http://"), strings.HasPrefix(word, "https://"):
+		return Mention{Kind: KindURL, Target: word}, true
+	case strings.HasPrefix(word, SymbolPrefix):
+		if name := strings.TrimPrefix(word, SymbolPrefix); name != "" {
+			return Mention{Kind: KindSymbol, Target: name}, true
+		}
+		return Mention{}, false
+	case strings.HasPrefix(word, SessionPrefix):
+		if id := strings.TrimPrefix(word, SessionPrefix); id != "" {
+			return Mention{Kind: KindSession, Target: id}, true
+		}
+		return Mention{}, false
+	}
+
+	m := Mention{Kind: KindFile, Target: word}
+	if match := lineRangePattern.FindStringSubmatchIndex(word); match != nil {
+		start, _ := strconv.Atoi(word[match[2]:match[3]])
+		end := start
+		if match[4] >= 0 {
+			end, _ = strconv.Atoi(word[match[4]:match[5]])
+		}
+		if start < 1 || end < start {
+			return Mention{}, false
+		}
+		m.Target = word[:match[0]]
+		m.StartLine, m.EndLine = start, end
+	}
+	if m.Target == "" {
+		return Mention{}, false
+	}
+	return m, true
+}
+
+// Find returns the mentions of a prompt in order, without duplicates. Words
+// naming paths that don't exist in the working directory aren't mentions,
+// and mentioned directories are folders.
+func Find(text, workingDir string) []Mention {
+	var mentions []Mention
+	seen := make(map[Mention]bool)
+	for word := range strings.FieldsSeq(text) {
+		m, ok := Parse(word)
+		if !ok {
+			continue
+		}
+		if m.Kind == KindFile {
+			info, err := os.Stat(absPath(m.Target, workingDir))
+			if err != nil {
+				continue
+			}
+			if info.IsDir() {
+				m = Mention{Kind: KindFolder, Target: m.Target}
+			}
+		}
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		mentions = append(mentions, m)
+	}
+	return mentions
+}
+
+func absPath(path, workingDir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workingDir, path)
+}
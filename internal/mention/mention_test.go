@@ -0,0 +1,141 @@
+package mention
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/lsp/protocol"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		word     string
+		expected Mention
+		ok       bool
+	}{
+		{"@main.go", Mention{Kind: KindFile, Target: "main.go"}, true},
+		{"@main.go,", Mention{Kind: KindFile, Target: "main.go"}, true},
+		{"@main.go#L10", Mention{Kind: KindFile, Target: "main.go", StartLine: 10, EndLine: 10}, true},
+		{"@main.go#L10-40", Mention{Kind: KindFile, Target: "main.go", StartLine: 10, EndLine: 40}, true},
+		{"@main.go#L10-L40", Mention{Kind: KindFile, Target: "main.go", StartLine: 10, EndLine: 40}, true},
+		{"@main.go#L40-10", Mention{}, false},
+		{"@https://example.com/docs", Mention{Kind: KindURL, Target: "https://example.com/docs"}, true},
+		{"@symbol:NewService", Mention{Kind: KindSymbol, Target: "NewService"}, true},
+		{"@session:abc", Mention{Kind: KindSession, Target: "abc"}, true},
+		{"@session:", Mention{}, false},
+		{"@", Mention{}, false},
+		{"main.go", Mention{}, false},
+	}
+	for _, tt := range tests {
+		m, ok := Parse(tt.word)
+		require.Equal(t, tt.ok, ok, tt.word)
+		require.Equal(t, tt.expected, m, tt.word)
+	}
+
+	m, _ := Parse("@main.go#L10-L40")
+	require.Equal(t, "main.go#L10-40", m.String())
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+
+	mentions := Find("look at @main.go#L1 and @pkg, not @missing.go or @someone; @main.go#L1 again, and @session:abc", dir)
+	require.Equal(t, []Mention{
+		{Kind: KindFile, Target: "main.go", StartLine: 1, EndLine: 1},
+		{Kind: KindFolder, Target: "pkg"},
+		{Kind: KindSession, Target: "abc"},
+	}, mentions)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "lib.go"), []byte("package pkg\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("line 1\nline 2\nline 3\nline 4\n"), 0o644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body><h1>Docs</h1></body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+	sess, err := sessions.Create(t.Context(), "Earlier work")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "rename the package"}},
+	})
+	require.NoError(t, err)
+
+	r := &Resolver{WorkingDir: dir, Sessions: sessions, Messages: messages}
+	attachments, err := r.ResolveAll(t.Context(), "see @main.go#L2-3, @pkg, @"+server.URL+" and @session:"+sess.ID)
+	require.NoError(t, err)
+	require.Len(t, attachments, 4)
+
+	require.Equal(t, "main.go#L2-3", attachments[0].FilePath)
+	require.Equal(t, "text/plain", attachments[0].MimeType)
+	require.Equal(t, "line 2\nline 3\n", string(attachments[0].Content))
+
+	require.Equal(t, "pkg/", attachments[1].FileName)
+	require.Contains(t, string(attachments[1].Content), "lib.go")
+
+	require.Contains(t, string(attachments[2].Content), "# Docs")
+
+	require.Contains(t, string(attachments[3].Content), `"Earlier work"`)
+	require.Contains(t, string(attachments[3].Content), "user: rename the package")
+
+	// Mentions that fail are left out, the others still resolve.
+	attachments, err = r.ResolveAll(t.Context(), "@main.go#L9 @main.go#L2")
+	require.ErrorContains(t, err, "has only 4 lines")
+	require.Len(t, attachments, 1)
+	require.Equal(t, "main.go#L2", attachments[0].FilePath)
+}
+
+func TestFindDocumentSymbol(t *testing.T) {
+	t.Parallel()
+
+	lines := func(start, end uint32) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: start},
+			End:   protocol.Position{Line: end, Character: 1},
+		}
+	}
+	symbols := []protocol.DocumentSymbol{
+		{Name: "Service", Range: lines(2, 5)},
+		{Name: "(*Service).Create", Range: lines(7, 12)},
+		{Name: "Create", Range: lines(14, 16)},
+	}
+
+	name := lines(7, 7)
+	rng, ok := findDocumentSymbol(symbols, symbol{name: "Service.Create", rng: &name})
+	require.True(t, ok)
+	require.Equal(t, lines(7, 12), rng)
+
+	rng, ok = findDocumentSymbol(symbols, symbol{name: "Create"})
+	require.True(t, ok)
+	require.Equal(t, lines(7, 12), rng)
+
+	_, ok = findDocumentSymbol(symbols, symbol{name: "Delete"})
+	require.False(t, ok)
+}
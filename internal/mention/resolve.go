@@ -0,0 +1,178 @@
+package mention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	// maxSessionSize is the maximum size of the transcript of a mentioned
+	// session without a summary. The end of the session is kept.
+	maxSessionSize = 20 * 1024
+	fetchTimeout   = 30 * time.Second
+)
+
+// Resolver resolves mentions to the context they refer to.
+type Resolver struct {
+	WorkingDir string
+	LSPClients map[string]*lsp.Client
+	Sessions   session.Service
+	Messages   message.Service
+	HTTPClient *http.Client
+}
+
+// ResolveAll resolves the mentions of a prompt. Mentions that can't be
+// resolved are left out, and their errors joined.
+func (r *Resolver) ResolveAll(ctx context.Context, text string) ([]message.Attachment, error) {
+	var (
+		attachments []message.Attachment
+		errs        []error
+	)
+	for _, m := range Find(text, r.WorkingDir) {
+		attachment, err := r.Resolve(ctx, m)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, errors.Join(errs...)
+}
+
+// Resolve returns the context of a mention as an attachment, which is text
+// except for mentioned images.
+func (r *Resolver) Resolve(ctx context.Context, m Mention) (message.Attachment, error) {
+	attachment := message.Attachment{
+		FilePath: m.String(),
+		FileName: m.String(),
+		MimeType: "text/plain",
+	}
+	var (
+		content string
+		err     error
+	)
+	switch m.Kind {
+	case KindFile:
+		path := absPath(m.Target, r.WorkingDir)
+		attachment.FileName = filepath.Base(m.String())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return message.Attachment{}, fmt.Errorf("failed to read %s: %w", m.Target, err)
+		}
+		if mimeType := http.DetectContentType(data); strings.HasPrefix(mimeType, "image/") && m.StartLine == 0 {
+			attachment.MimeType = mimeType
+			attachment.Content = data
+			return attachment, nil
+		}
+		content, err = fileContent(m, data)
+		if err != nil {
+			return message.Attachment{}, err
+		}
+	case KindFolder:
+		attachment.FileName = filepath.Base(m.Target) + "/"
+		content, err = tools.ListDirectoryTree(absPath(m.Target, r.WorkingDir), nil)
+		if err != nil {
+			return message.Attachment{}, fmt.Errorf("failed to list %s: %w", m.Target, err)
+		}
+	case KindURL:
+		ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+		client := r.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		content, err = tools.Fetch(ctx, client, m.Target, "markdown")
+		if err != nil {
+			return message.Attachment{}, fmt.Errorf("failed to fetch %s: %w", m.Target, err)
+		}
+	case KindSession:
+		content, err = r.sessionContent(ctx, m.Target)
+		if err != nil {
+			return message.Attachment{}, err
+		}
+	case KindSymbol:
+		content, err = r.symbolContent(ctx, m.Target)
+		if err != nil {
+			return message.Attachment{}, err
+		}
+	}
+	attachment.Content = []byte(content)
+	return attachment, nil
+}
+
+// fileContent returns the text of a file, or the lines of its range.
+func fileContent(m Mention, data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%s is not a text file", m.Target)
+	}
+	content := string(data)
+	if m.StartLine > 0 {
+		lines := strings.SplitAfter(content, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if m.StartLine > len(lines) {
+			return "", fmt.Errorf("%s has only %d lines", m.Target, len(lines))
+		}
+		content = strings.Join(lines[m.StartLine-1:min(m.EndLine, len(lines))], "")
+	}
+	if len(content) > tools.MaxReadSize {
+		return "", fmt.Errorf("%s is too large to mention, at most %d bytes can be", m.Target, tools.MaxReadSize)
+	}
+	return content, nil
+}
+
+// sessionContent returns the summary of a session, or the end of its
+// conversation when it wasn't summarized.
+func (r *Resolver) sessionContent(ctx context.Context, id string) (string, error) {
+	if r.Sessions == nil || r.Messages == nil {
+		return "", fmt.Errorf("sessions can't be mentioned here")
+	}
+	sess, err := r.Sessions.Get(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	if sess.SummaryMessageID != "" {
+		summary, err := r.Messages.Get(ctx, sess.SummaryMessageID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the summary of session %s: %w", id, err)
+		}
+		return fmt.Sprintf("Summary of the session %q:\n\n%s", sess.Title, summary.Content().String()), nil
+	}
+
+	msgs, err := r.Messages.List(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the messages of session %s: %w", id, err)
+	}
+	var transcript []string
+	size := 0
+	for i := len(msgs) - 1; i >= 0 && size < maxSessionSize; i-- {
+		msg := msgs[i]
+		text := strings.TrimSpace(msg.Content().String())
+		if text == "" || (msg.Role != message.User && msg.Role != message.Assistant) {
+			continue
+		}
+		entry := fmt.Sprintf("%s: %s", msg.Role, text)
+		transcript = append(transcript, entry)
+		size += len(entry)
+	}
+	if len(transcript) == 0 {
+		return "", fmt.Errorf("session %q has no messages", sess.Title)
+	}
+	for i, j := 0, len(transcript)-1; i < j; i, j = i+1, j-1 {
+		transcript[i], transcript[j] = transcript[j], transcript[i]
+	}
+	return fmt.Sprintf("Conversation of the session %q:\n\n%s", sess.Title, strings.Join(transcript, "\n\n")), nil
+}
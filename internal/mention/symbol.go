@@ -0,0 +1,214 @@
+package mention
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/protocol"
+)
+
+const symbolTimeout = 5 * time.Second
+
+// symbol is a workspace symbol found by an LSP server.
+type symbol struct {
+	name string
+	uri  protocol.DocumentURI
+	// rng is the range of the symbol, when the server told it.
+	rng *protocol.Range
+}
+
+// Symbols returns the names of the workspace symbols matching the query, for
+// completion. An empty query lists the symbols the servers are willing to
+// list.
+func (r *Resolver) Symbols(ctx context.Context, query string, limit int) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range slices.Sorted(maps.Keys(r.LSPClients)) {
+		symbols, err := workspaceSymbols(ctx, r.LSPClients[name], query)
+		if err != nil {
+			continue
+		}
+		for _, s := range symbols {
+			if seen[s.name] {
+				continue
+			}
+			seen[s.name] = true
+			names = append(names, s.name)
+			if len(names) >= limit {
+				return names
+			}
+		}
+	}
+	return names
+}
+
+// symbolContent returns the definition of a workspace symbol, as found by
+// the first LSP server that knows it.
+func (r *Resolver) symbolContent(ctx context.Context, name string) (string, error) {
+	for _, clientName := range slices.Sorted(maps.Keys(r.LSPClients)) {
+		client := r.LSPClients[clientName]
+		symbols, err := workspaceSymbols(ctx, client, name)
+		if err != nil {
+			continue
+		}
+		s, ok := bestSymbol(symbols, name)
+		if !ok {
+			continue
+		}
+		path, err := s.uri.Path()
+		if err != nil {
+			continue
+		}
+		rng, ok := declarationRange(ctx, client, path, s)
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the definition of %s: %w", name, err)
+		}
+		lines := strings.SplitAfter(string(data), "\n")
+		start := int(rng.Start.Line)
+		end := min(int(rng.End.Line)+1, len(lines))
+		if start >= end {
+			continue
+		}
+		if rel, err := filepath.Rel(r.WorkingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		return fmt.Sprintf("Definition of %s in %s:%d:\n\n%s", name, path, start+1, strings.Join(lines[start:end], "")), nil
+	}
+	return "", fmt.Errorf("symbol %s not found", name)
+}
+
+func workspaceSymbols(ctx context.Context, client *lsp.Client, query string) ([]symbol, error) {
+	ctx, cancel := context.WithTimeout(ctx, symbolTimeout)
+	defer cancel()
+	result, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []symbol
+	switch value := result.Value.(type) {
+	case []protocol.SymbolInformation:
+		for _, info := range value {
+			symbols = append(symbols, symbol{
+				name: info.Name,
+				uri:  info.Location.URI,
+				rng:  &info.Location.Range,
+			})
+		}
+	case []protocol.WorkspaceSymbol:
+		for _, ws := range value {
+			s := symbol{name: ws.Name}
+			switch location := ws.Location.Value.(type) {
+			case protocol.Location:
+				s.uri = location.URI
+				s.rng = &location.Range
+			case protocol.LocationUriOnly:
+				s.uri = location.URI
+			default:
+				continue
+			}
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols, nil
+}
+
+// bestSymbol returns the symbol named exactly as the query, or else the
+// method or field named so, as servers qualify them with their type.
+func bestSymbol(symbols []symbol, name string) (symbol, bool) {
+	for _, s := range symbols {
+		if s.name == name {
+			return s, true
+		}
+	}
+	for _, s := range symbols {
+		if strings.HasSuffix(s.name, "."+name) {
+			return s, true
+		}
+	}
+	return symbol{}, false
+}
+
+// declarationRange returns the range of the whole declaration of a symbol.
+// Workspace symbols usually only locate the name, while document symbols
+// span their declaration.
+func declarationRange(ctx context.Context, client *lsp.Client, path string, s symbol) (protocol.Range, bool) {
+	ctx, cancel := context.WithTimeout(ctx, symbolTimeout)
+	defer cancel()
+	_ = client.OpenFileOnDemand(ctx, path)
+	result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: s.uri},
+	})
+	if err == nil {
+		switch value := result.Value.(type) {
+		case []protocol.DocumentSymbol:
+			if rng, ok := findDocumentSymbol(value, s); ok {
+				return rng, true
+			}
+		case []protocol.SymbolInformation:
+			for _, info := range value {
+				if matchesSymbol(info.Name, info.Location.Range, s) {
+					return info.Location.Range, true
+				}
+			}
+		}
+	}
+	if s.rng != nil {
+		return *s.rng, true
+	}
+	return protocol.Range{}, false
+}
+
+// findDocumentSymbol returns the range of the innermost document symbol
+// matching the workspace symbol.
+func findDocumentSymbol(symbols []protocol.DocumentSymbol, s symbol) (protocol.Range, bool) {
+	for _, ds := range symbols {
+		if rng, ok := findDocumentSymbol(ds.Children, s); ok {
+			return rng, true
+		}
+		if matchesSymbol(ds.Name, ds.Range, s) {
+			return ds.Range, true
+		}
+	}
+	return protocol.Range{}, false
+}
+
+// matchesSymbol reports whether a document symbol is the workspace symbol:
+// it must have the same unqualified name, and contain its location when it
+// is known.
+func matchesSymbol(name string, rng protocol.Range, s symbol) bool {
+	if unqualified(name) != unqualified(s.name) {
+		return false
+	}
+	return s.rng == nil || containsPosition(rng, s.rng.Start)
+}
+
+// unqualified strips the type or package a symbol name is qualified with,
+// as in T.Method or (*T).Method.
+func unqualified(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func containsPosition(rng protocol.Range, pos protocol.Position) bool {
+	if pos.Line < rng.Start.Line || pos.Line > rng.End.Line {
+		return false
+	}
+	if pos.Line == rng.Start.Line && pos.Character < rng.Start.Character {
+		return false
+	}
+	if pos.Line == rng.End.Line && pos.Character > rng.End.Character {
+		return false
+	}
+	return true
+}
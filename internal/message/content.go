@@ -2,10 +2,14 @@ package message
 
 import (
 	"encoding/base64"
+	"fmt"
+	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/pdf"
 )
 
 type MessageRole string
@@ -36,8 +40,11 @@ type ContentPart interface {
 }
 
 type ReasoningContent struct {
-	Thinking   string `json:"thinking"`
-	Signature  string `json:"signature"`
+	Thinking  string `json:"thinking"`
+	Signature string `json:"signature"`
+	// ItemID is the ID of the reasoning item of the OpenAI Responses API,
+	// whose encrypted content is kept in Signature.
+	ItemID     string `json:"item_id,omitempty"`
 	StartedAt  int64  `json:"started_at,omitempty"`
 	FinishedAt int64  `json:"finished_at,omitempty"`
 }
@@ -72,6 +79,8 @@ type BinaryContent struct {
 	Path     string
 	MIMEType string
 	Data     []byte
+	// DocumentText is the text of a PDF, extracted once when it's attached.
+	DocumentText string
 }
 
 func (bc BinaryContent) String(p catwalk.InferenceProvider) string {
@@ -82,6 +91,86 @@ func (bc BinaryContent) String(p catwalk.InferenceProvider) string {
 	return base64Encoded
 }
 
+// IsText reports whether the content is text, such as an MCP resource,
+// which is sent to the model as text rather than as an image.
+func (bc BinaryContent) IsText() bool {
+	mimeType, _, _ := strings.Cut(bc.MIMEType, ";")
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return true
+	case strings.HasSuffix(mimeType, "json"), strings.HasSuffix(mimeType, "xml"), strings.HasSuffix(mimeType, "yaml"):
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPDF reports whether the content is a PDF document, which is sent to
+// providers that read documents natively and as its text to the others.
+func (bc BinaryContent) IsPDF() bool {
+	mimeType, _, _ := strings.Cut(bc.MIMEType, ";")
+	return mimeType == "application/pdf"
+}
+
+// Text returns the content wrapped in a tag naming where it comes from. Text
+// is inlined in a fenced block, and PDFs are replaced by their text.
+func (bc BinaryContent) Text() string {
+	if bc.IsPDF() {
+		text := bc.DocumentText
+		if text == "" {
+			// Documents attached before the text was kept with them.
+			text = documentText(bc.Data)
+		}
+		return fmt.Sprintf("<attachment path=%q>\n%s\n</attachment>", bc.Path, text)
+	}
+	content := strings.TrimSuffix(string(bc.Data), "\n")
+	fence := codeFence(content)
+	return fmt.Sprintf("<attachment path=%q>\n%s%s\n%s\n%s\n</attachment>", bc.Path, fence, fenceLanguage(bc.Path), content, fence)
+}
+
+// WithDocumentText returns the content with the text of a PDF extracted, so
+// that it isn't extracted again for every request.
+func (bc BinaryContent) WithDocumentText() BinaryContent {
+	if bc.IsPDF() && bc.DocumentText == "" {
+		bc.DocumentText = documentText(bc.Data)
+	}
+	return bc
+}
+
+// documentText returns the text of a PDF, or a note saying why it couldn't
+// be extracted.
+func documentText(data []byte) string {
+	text, err := pdf.ExtractText(data)
+	if err != nil {
+		return fmt.Sprintf("[could not extract the text of the document: %v]", err)
+	}
+	return text
+}
+
+// codeFence returns a fence longer than any run of backticks in the content.
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// fenceLanguage returns the language of a fenced block for the extension of
+// a path, ignoring the line range of mentions.
+func fenceLanguage(path string) string {
+	if strings.Contains(path, "://") {
+		return ""
+	}
+	path, _, _ = strings.Cut(path, "#")
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
 func (BinaryContent) isPart() {}
 
 type ToolCall struct {
@@ -100,10 +189,22 @@ type ToolResult struct {
 	Content    string `json:"content"`
 	Metadata   string `json:"metadata"`
 	IsError    bool   `json:"is_error"`
+	// Compacted marks results that are shortened or dropped when sent to the
+	// model. The original content is kept for display.
+	Compacted bool `json:"compacted,omitempty"`
+	// Images returned by the tool, such as screenshots taken by MCP servers.
+	// They are shown in the chat, not sent to the model.
+	Images []ToolResultImage `json:"images,omitempty"`
 }
 
 func (ToolResult) isPart() {}
 
+// ToolResultImage is an image returned by a tool.
+type ToolResultImage struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data"`
+}
+
 type Finish struct {
 	Reason  FinishReason `json:"reason"`
 	Time    int64        `json:"time"`
@@ -120,10 +221,35 @@ type Message struct {
 	Parts     []ContentPart
 	Model     string
 	Provider  string
+	// Usage is set on assistant messages once their response completes.
+	Usage     Usage
 	CreatedAt int64
 	UpdatedAt int64
 }
 
+// Usage is the token usage of the request that produced a message.
+// InputTokens doesn't include the tokens read from or written to the
+// prompt cache.
+type Usage struct {
+	InputTokens         int64
+	OutputTokens        int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+}
+
+// PromptTokens returns the size of the prompt, cached or not.
+func (u Usage) PromptTokens() int64 {
+	return u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens
+}
+
+// CacheHitRatio returns the share of the prompt read from the cache.
+func (u Usage) CacheHitRatio() float64 {
+	if u.PromptTokens() == 0 {
+		return 0
+	}
+	return float64(u.CacheReadTokens) / float64(u.PromptTokens())
+}
+
 func (m *Message) Content() TextContent {
 	for _, part := range m.Parts {
 		if c, ok := part.(TextContent); ok {
@@ -236,6 +362,7 @@ func (m *Message) AppendReasoningContent(delta string) {
 			m.Parts[i] = ReasoningContent{
 				Thinking:   c.Thinking + delta,
 				Signature:  c.Signature,
+				ItemID:     c.ItemID,
 				StartedAt:  c.StartedAt,
 				FinishedAt: c.FinishedAt,
 			}
@@ -256,6 +383,7 @@ func (m *Message) AppendReasoningSignature(signature string) {
 			m.Parts[i] = ReasoningContent{
 				Thinking:   c.Thinking,
 				Signature:  c.Signature + signature,
+				ItemID:     c.ItemID,
 				StartedAt:  c.StartedAt,
 				FinishedAt: c.FinishedAt,
 			}
@@ -265,6 +393,19 @@ func (m *Message) AppendReasoningSignature(signature string) {
 	m.Parts = append(m.Parts, ReasoningContent{Signature: signature})
 }
 
+// SetReasoningItemID records the ID of the reasoning item the reasoning
+// content comes from.
+func (m *Message) SetReasoningItemID(id string) {
+	for i, part := range m.Parts {
+		if c, ok := part.(ReasoningContent); ok {
+			c.ItemID = id
+			m.Parts[i] = c
+			return
+		}
+	}
+	m.Parts = append(m.Parts, ReasoningContent{ItemID: id})
+}
+
 func (m *Message) FinishThinking() {
 	for i, part := range m.Parts {
 		if c, ok := part.(ReasoningContent); ok {
@@ -272,6 +413,7 @@ func (m *Message) FinishThinking() {
 				m.Parts[i] = ReasoningContent{
 					Thinking:   c.Thinking,
 					Signature:  c.Signature,
+					ItemID:     c.ItemID,
 					StartedAt:  c.StartedAt,
 					FinishedAt: time.Now().Unix(),
 				}
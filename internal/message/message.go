@@ -110,9 +110,13 @@ func (s *service) Update(ctx context.Context, message Message) error {
 		finishedAt.Valid = true
 	}
 	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
-		ID:         message.ID,
-		Parts:      string(parts),
-		FinishedAt: finishedAt,
+		ID:                  message.ID,
+		Parts:               string(parts),
+		FinishedAt:          finishedAt,
+		InputTokens:         message.Usage.InputTokens,
+		OutputTokens:        message.Usage.OutputTokens,
+		CacheReadTokens:     message.Usage.CacheReadTokens,
+		CacheCreationTokens: message.Usage.CacheCreationTokens,
 	})
 	if err != nil {
 		return err
@@ -157,6 +161,12 @@ func (s *service) fromDBItem(item db.Message) (Message, error) {
 		Parts:     parts,
 		Model:     item.Model.String,
 		Provider:  item.Provider.String,
+		Usage: Usage{
+			InputTokens:         item.InputTokens,
+			OutputTokens:        item.OutputTokens,
+			CacheReadTokens:     item.CacheReadTokens,
+			CacheCreationTokens: item.CacheCreationTokens,
+		},
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
 	}, nil
@@ -0,0 +1,369 @@
+// Package ollama is a client for the REST API of Ollama, which serves models
+// locally.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultHost is where Ollama listens unless OLLAMA_HOST says otherwise.
+const DefaultHost = "http://localhost:11434"
+
+// Capabilities reported by the show endpoint.
+const (
+	CapabilityCompletion = "completion"
+	CapabilityTools      = "tools"
+	CapabilityVision     = "vision"
+	CapabilityThinking   = "thinking"
+)
+
+// Host returns the URL of the Ollama server given the value of OLLAMA_HOST,
+// which may leave out the scheme and the port.
+func Host(env string) string {
+	env = strings.TrimSpace(env)
+	if env == "" {
+		return DefaultHost
+	}
+	if !strings.Contains(env, "://") {
+		env = "http://" + env
+	}
+	u, err := url.Parse(strings.TrimSuffix(env, "/"))
+	if err != nil || u.Host == "" {
+		return DefaultHost
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "11434")
+	}
+	return u.String()
+}
+
+// Client talks to an Ollama server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    map[string]string
+}
+
+// NewClient returns a client for the server at baseURL. A nil httpClient
+// means http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client, headers map[string]string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		headers:    headers,
+	}
+}
+
+// Headers returns the headers of the requests to a server. Ollama has no
+// authentication of its own but is often put behind a proxy that has, so a
+// non-empty API key is sent as a bearer token.
+func Headers(apiKey string, extra map[string]string) map[string]string {
+	headers := make(map[string]string, len(extra)+1)
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+	maps.Copy(headers, extra)
+	return headers
+}
+
+// StatusError is returned when the server answers with an error status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ollama: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("ollama: %s", e.Message)
+}
+
+// IsModelNotFound reports whether the error means the model is not pulled.
+func IsModelNotFound(err error) bool {
+	var statusErr StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// IsToolsUnsupported reports whether the error means the model cannot be
+// given tools.
+func IsToolsUnsupported(err error) bool {
+	var statusErr StatusError
+	return errors.As(err, &statusErr) &&
+		statusErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(statusErr.Message, "does not support tools")
+}
+
+// ModelSummary is a model returned by List.
+type ModelSummary struct {
+	Name    string       `json:"name"`
+	Model   string       `json:"model"`
+	Size    int64        `json:"size"`
+	Details ModelDetails `json:"details"`
+}
+
+// ModelDetails describes the format of a model.
+type ModelDetails struct {
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// ShowResponse is the metadata of a model.
+type ShowResponse struct {
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"`
+}
+
+// ContextLength returns the context length the model was trained with, or
+// zero if the metadata does not say.
+func (s ShowResponse) ContextLength() int64 {
+	for key, value := range s.ModelInfo {
+		if !strings.HasSuffix(key, ".context_length") {
+			continue
+		}
+		if n, ok := value.(float64); ok {
+			return int64(n)
+		}
+	}
+	return 0
+}
+
+// HasCapability reports whether the model has the capability. Old servers
+// do not report capabilities; they are assumed to have them all.
+func (s ShowResponse) HasCapability(capability string) bool {
+	if s.Capabilities == nil {
+		return capability != CapabilityThinking
+	}
+	for _, c := range s.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// PullProgress is a status update while a model is pulled.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ChatRequest is a request to the chat endpoint.
+type ChatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []ChatMessage  `json:"messages"`
+	Tools     []Tool         `json:"tools,omitempty"`
+	Stream    bool           `json:"stream"`
+	Think     any            `json:"think,omitempty"`
+	KeepAlive string         `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+
+	// Extra fields are added to the body of the request, replacing the
+	// ones above.
+	Extra map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r ChatRequest) MarshalJSON() ([]byte, error) {
+	type request ChatRequest
+	data, err := json.Marshal(request(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	maps.Copy(body, r.Extra)
+	return json.Marshal(body)
+}
+
+// ChatMessage is a message of a conversation.
+type ChatMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
+	Images    [][]byte   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+// ToolCall is a call of a tool by the model.
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the tool a model calls and its arguments.
+type ToolCallFunction struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// Tool is a tool the model can call.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a tool.
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// ChatResponse is a chunk of the answer of the chat endpoint.
+type ChatResponse struct {
+	Model           string      `json:"model"`
+	Message         ChatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	DoneReason      string      `json:"done_reason,omitempty"`
+	PromptEvalCount int64       `json:"prompt_eval_count,omitempty"`
+	EvalCount       int64       `json:"eval_count,omitempty"`
+}
+
+// List returns the models available locally.
+func (c *Client) List(ctx context.Context) ([]ModelSummary, error) {
+	var result struct {
+		Models []ModelSummary `json:"models"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Models, nil
+}
+
+// Show returns the metadata of a model.
+func (c *Client) Show(ctx context.Context, model string) (ShowResponse, error) {
+	var result ShowResponse
+	err := c.do(ctx, http.MethodPost, "/api/show", map[string]string{"model": model}, &result)
+	return result, err
+}
+
+// Pull downloads a model, calling progress with each status update.
+func (c *Client) Pull(ctx context.Context, model string, progress func(PullProgress)) error {
+	return c.stream(ctx, "/api/pull", map[string]any{"model": model, "stream": true}, func(data []byte) error {
+		var p PullProgress
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if p.Error != "" {
+			return errors.New(p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+		return nil
+	})
+}
+
+// Chat sends the conversation to the model and calls fn with each chunk of
+// the answer. The request is always streamed.
+func (c *Client) Chat(ctx context.Context, req ChatRequest, fn func(ChatResponse) error) error {
+	req.Stream = true
+	return c.stream(ctx, "/api/chat", req, func(data []byte) error {
+		var chunk struct {
+			ChatResponse
+			Error string `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to decode chat response: %w", err)
+		}
+		if chunk.Error != "" {
+			return errors.New(chunk.Error)
+		}
+		return fn(chunk.ChatResponse)
+	})
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result any) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// stream calls fn with each line of a newline delimited JSON response.
+func (c *Client) stream(ctx context.Context, path string, body any, fn func([]byte) error) error {
+	resp, err := c.request(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response of %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach ollama at %s: %w", c.baseURL, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(data))
+		}
+		return nil, StatusError{StatusCode: resp.StatusCode, Message: apiErr.Error}
+	}
+	return resp, nil
+}
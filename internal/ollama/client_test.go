@@ -0,0 +1,152 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[
+			{"name":"qwen3:8b","model":"qwen3:8b","details":{"parameter_size":"8.2B"}},
+			{"name":"llava:7b","model":"llava:7b","details":{}},
+			{"name":"nomic-embed-text:latest","model":"nomic-embed-text:latest","details":{}},
+			{"name":"broken:1b","model":"broken:1b","details":{}}
+		]}`)
+	})
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch req.Model {
+		case "qwen3:8b":
+			fmt.Fprint(w, `{"model_info":{"qwen3.context_length":40960},"capabilities":["completion","tools","thinking"]}`)
+		case "llava:7b":
+			fmt.Fprint(w, `{"model_info":{"llama.context_length":4096},"capabilities":["completion","vision"]}`)
+		case "nomic-embed-text:latest":
+			fmt.Fprint(w, `{"model_info":{"nomic-bert.context_length":2048},"capabilities":["embedding"]}`)
+		case "broken:1b":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":"unable to load model"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":"model '%s' not found"}`, req.Model)
+		}
+	})
+	mux.HandleFunc("POST /api/pull", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"pulling abc","digest":"sha256:abc","total":100,"completed":50}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	})
+	mux.HandleFunc("POST /api/chat", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, true, req["stream"])
+		require.Equal(t, "5m", req["keep_alive"])
+		require.Equal(t, 0.5, req["temperature"])
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":2}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, srv.Client(), nil)
+}
+
+func TestDiscoverModels(t *testing.T) {
+	t.Parallel()
+
+	models, err := DiscoverModels(context.Background(), newTestServer(t))
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{
+		{ID: "llava:7b", Name: "llava:7b", ContextWindow: 4096, DefaultMaxTokens: 2048, SupportsImages: true},
+		{ID: "qwen3:8b", Name: "qwen3:8b (8.2B)", ContextWindow: 40960, DefaultMaxTokens: 8192, CanReason: true},
+	}, models)
+}
+
+func TestShow(t *testing.T) {
+	t.Parallel()
+
+	client := newTestServer(t)
+	show, err := client.Show(context.Background(), "llava:7b")
+	require.NoError(t, err)
+	require.True(t, show.HasCapability(CapabilityVision))
+	require.False(t, show.HasCapability(CapabilityTools))
+
+	_, err = client.Show(context.Background(), "missing")
+	require.True(t, IsModelNotFound(err))
+	require.EqualError(t, err, "ollama: model 'missing' not found")
+}
+
+func TestPull(t *testing.T) {
+	t.Parallel()
+
+	var statuses []PullProgress
+	err := newTestServer(t).Pull(context.Background(), "qwen3:8b", func(p PullProgress) {
+		statuses = append(statuses, p)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []PullProgress{
+		{Status: "pulling manifest"},
+		{Status: "pulling abc", Digest: "sha256:abc", Total: 100, Completed: 50},
+		{Status: "success"},
+	}, statuses)
+}
+
+func TestChat(t *testing.T) {
+	t.Parallel()
+
+	var content string
+	var last ChatResponse
+	err := newTestServer(t).Chat(context.Background(), ChatRequest{
+		Model:     "qwen3:8b",
+		Messages:  []ChatMessage{{Role: "user", Content: "Hi"}},
+		KeepAlive: "5m",
+		Extra:     map[string]any{"temperature": 0.5},
+	}, func(chunk ChatResponse) error {
+		content += chunk.Message.Content
+		last = chunk
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Hello", content)
+	require.True(t, last.Done)
+	require.Equal(t, int64(10), last.PromptEvalCount)
+	require.Equal(t, int64(2), last.EvalCount)
+}
+
+func TestHost(t *testing.T) {
+	t.Parallel()
+
+	for env, want := range map[string]string{
+		"":                      DefaultHost,
+		"0.0.0.0":               "http://0.0.0.0:11434",
+		"gpu-box:8080":          "http://gpu-box:8080",
+		"https://ollama.local/": "https://ollama.local:11434",
+		"http://[::1]:11434":    "http://[::1]:11434",
+		"http://[::1]":          "http://[::1]:11434",
+	} {
+		require.Equal(t, want, Host(env), env)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Headers("", nil))
+	require.Equal(t, map[string]string{
+		"Authorization": "Bearer key",
+		"X-Team":        "crush",
+	}, Headers("key", map[string]string{"X-Team": "crush"}))
+	require.Equal(t, map[string]string{
+		"Authorization": "Basic abc",
+	}, Headers("key", map[string]string{"Authorization": "Basic abc"}))
+}
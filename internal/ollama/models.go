@@ -0,0 +1,87 @@
+package ollama
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+const (
+	// defaultContextWindow is used when the metadata of a model does not
+	// tell its context length.
+	defaultContextWindow = 8192
+	// maxDefaultMaxTokens caps the number of tokens a model is asked to
+	// generate by default.
+	maxDefaultMaxTokens = 8192
+)
+
+// DiscoverModels returns the models available on the server, filling in
+// their context window and capabilities from their metadata. Models that
+// cannot complete text, like embedding models, are left out, and so are
+// models whose metadata can't be read, which doesn't fail the others.
+func DiscoverModels(ctx context.Context, client *Client) ([]catwalk.Model, error) {
+	summaries, err := client.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ollama models: %w", err)
+	}
+	// Ask for the metadata of every model at once, so that discovery stays
+	// quick with many models.
+	shows := make([]ShowResponse, len(summaries))
+	errs := make([]error, len(summaries))
+	var wg sync.WaitGroup
+	for i, summary := range summaries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := cmp.Or(summary.Model, summary.Name)
+			shows[i], errs[i] = client.Show(ctx, id)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("failed to get metadata of ollama model %s: %w", id, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	models := make([]catwalk.Model, 0, len(summaries))
+	for i, summary := range summaries {
+		if errs[i] != nil {
+			slog.Warn("Skipping ollama model", "error", errs[i])
+			continue
+		}
+		if !shows[i].HasCapability(CapabilityCompletion) {
+			continue
+		}
+		models = append(models, Model(cmp.Or(summary.Model, summary.Name), summary.Details, shows[i]))
+	}
+	slices.SortFunc(models, func(a, b catwalk.Model) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return models, nil
+}
+
+// Model returns the catwalk model of a local model given its metadata.
+// Local models are free, so their costs are left at zero.
+func Model(id string, details ModelDetails, show ShowResponse) catwalk.Model {
+	contextWindow := show.ContextLength()
+	if contextWindow <= 0 {
+		contextWindow = defaultContextWindow
+	}
+	name := id
+	if details.ParameterSize != "" {
+		name = fmt.Sprintf("%s (%s)", id, details.ParameterSize)
+	}
+	return catwalk.Model{
+		ID:               id,
+		Name:             name,
+		ContextWindow:    contextWindow,
+		DefaultMaxTokens: min(maxDefaultMaxTokens, contextWindow/2),
+		CanReason:        show.HasCapability(CapabilityThinking),
+		SupportsImages:   show.HasCapability(CapabilityVision),
+	}
+}
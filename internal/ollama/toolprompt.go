@@ -0,0 +1,174 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Models that cannot call tools natively are taught to call them in their
+// answer, in blocks like this:
+//
+//	<tool_call>
+//	{"name": "view", "arguments": {"file_path": "main.go"}}
+//	</tool_call>
+//
+// The results are sent back in <tool_result> blocks.
+const (
+	toolCallOpen  = "<tool_call>"
+	toolCallClose = "</tool_call>"
+)
+
+// ToolPrompt returns the part of the system prompt that describes the tools
+// and how to call them.
+func ToolPrompt(tools []Tool) string {
+	if len(tools) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`# Tools
+
+You can call the tools below. To call a tool, write a block like this in your answer:
+
+<tool_call>
+{"name": "tool_name", "arguments": {"parameter": "value"}}
+</tool_call>
+
+The arguments must be valid JSON matching the parameters of the tool. You can call several tools in one answer, one block per call. Stop your answer after the calls: the results are sent back to you in <tool_result> blocks.
+
+Available tools:
+`)
+	for _, tool := range tools {
+		parameters, _ := json.Marshal(tool.Function.Parameters)
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\nParameters (JSON schema): %s\n",
+			tool.Function.Name, strings.TrimSpace(tool.Function.Description), parameters)
+	}
+	return b.String()
+}
+
+// FormatToolCall returns the block a model writes to call a tool, to show
+// the model its past calls.
+func FormatToolCall(name, arguments string) string {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	return fmt.Sprintf("%s\n{\"name\": %q, \"arguments\": %s}\n%s", toolCallOpen, name, arguments, toolCallClose)
+}
+
+// FormatToolResult returns the block that gives a model the result of a
+// tool call.
+func FormatToolResult(name, content string, isError bool) string {
+	status := ""
+	if isError {
+		status = ` error="true"`
+	}
+	return fmt.Sprintf("<tool_result name=%q%s>\n%s\n</tool_result>", name, status, content)
+}
+
+// ParsedToolCall is a tool call found in the answer of a model.
+type ParsedToolCall struct {
+	Name string
+	// Input holds the arguments as a JSON object.
+	Input string
+}
+
+// ToolCallParser extracts the tool calls from an answer as it is streamed.
+// The text that could be the beginning of a call is held back until it is
+// known not to be one.
+type ToolCallParser struct {
+	pending string
+	inCall  bool
+}
+
+// Write adds streamed text and returns the text to show and the calls that
+// were completed.
+func (p *ToolCallParser) Write(s string) (string, []ParsedToolCall) {
+	p.pending += s
+	var text strings.Builder
+	var calls []ParsedToolCall
+	for {
+		if !p.inCall {
+			i := strings.Index(p.pending, toolCallOpen)
+			if i < 0 {
+				keep := partialSuffix(p.pending, toolCallOpen)
+				text.WriteString(p.pending[:len(p.pending)-keep])
+				p.pending = p.pending[len(p.pending)-keep:]
+				return text.String(), calls
+			}
+			text.WriteString(p.pending[:i])
+			p.pending = p.pending[i+len(toolCallOpen):]
+			p.inCall = true
+		}
+		j := strings.Index(p.pending, toolCallClose)
+		if j < 0 {
+			return text.String(), calls
+		}
+		body := p.pending[:j]
+		p.pending = p.pending[j+len(toolCallClose):]
+		p.inCall = false
+		if call, ok := parseToolCall(body); ok {
+			calls = append(calls, call)
+		} else {
+			text.WriteString(toolCallOpen + body + toolCallClose)
+		}
+	}
+}
+
+// Flush returns what is left at the end of the answer. A call the model did
+// not close is still parsed.
+func (p *ToolCallParser) Flush() (string, []ParsedToolCall) {
+	pending, inCall := p.pending, p.inCall
+	p.pending, p.inCall = "", false
+	if !inCall {
+		return pending, nil
+	}
+	if call, ok := parseToolCall(pending); ok {
+		return "", []ParsedToolCall{call}
+	}
+	return toolCallOpen + pending, nil
+}
+
+// parseToolCall parses the body of a call block. Models often wrap it in a
+// code fence, name the arguments "parameters" or encode them as a string.
+func parseToolCall(body string) (ParsedToolCall, bool) {
+	body = strings.TrimSpace(body)
+	body = strings.TrimPrefix(body, "```json")
+	body = strings.TrimPrefix(body, "```")
+	body = strings.TrimSuffix(body, "```")
+
+	var call struct {
+		Name       string          `json:"name"`
+		Arguments  json.RawMessage `json:"arguments"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &call); err != nil || call.Name == "" {
+		return ParsedToolCall{}, false
+	}
+	args := call.Arguments
+	if len(args) == 0 {
+		args = call.Parameters
+	}
+	var encoded string
+	if json.Unmarshal(args, &encoded) == nil {
+		args = json.RawMessage(encoded)
+	}
+	var object map[string]any
+	if len(args) == 0 || string(args) == "null" {
+		object = map[string]any{}
+	} else if err := json.Unmarshal(args, &object); err != nil {
+		return ParsedToolCall{}, false
+	}
+	input, _ := json.Marshal(object)
+	return ParsedToolCall{Name: call.Name, Input: string(input)}, true
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package ollama
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallParser(t *testing.T) {
+	t.Parallel()
+
+	t.Run("streamed", func(t *testing.T) {
+		t.Parallel()
+		answer := "Let me look.\n<tool_call>\n{\"name\": \"view\", \"arguments\": {\"file_path\": \"main.go\"}}\n</tool_call>"
+		var p ToolCallParser
+		var text string
+		var calls []ParsedToolCall
+		for _, r := range answer {
+			chunk, found := p.Write(string(r))
+			text += chunk
+			calls = append(calls, found...)
+		}
+		rest, found := p.Flush()
+		text += rest
+		calls = append(calls, found...)
+		require.Equal(t, "Let me look.\n", text)
+		require.Equal(t, []ParsedToolCall{{Name: "view", Input: `{"file_path":"main.go"}`}}, calls)
+	})
+
+	t.Run("variants", func(t *testing.T) {
+		t.Parallel()
+		var p ToolCallParser
+		text, calls := p.Write("<tool_call>```json\n{\"name\": \"ls\", \"parameters\": \"{\\\"path\\\": \\\".\\\"}\"}\n```</tool_call>" +
+			"<tool_call>{\"name\": \"glob\"}</tool_call><tool_call>not json</tool_call> a < b")
+		require.Equal(t, "<tool_call>not json</tool_call> a < b", text)
+		require.Equal(t, []ParsedToolCall{
+			{Name: "ls", Input: `{"path":"."}`},
+			{Name: "glob", Input: `{}`},
+		}, calls)
+	})
+
+	t.Run("unclosed", func(t *testing.T) {
+		t.Parallel()
+		var p ToolCallParser
+		text, calls := p.Write("Sure <tool_call>{\"name\": \"ls\", \"arguments\": {}}")
+		require.Equal(t, "Sure ", text)
+		require.Empty(t, calls)
+		text, calls = p.Flush()
+		require.Empty(t, text)
+		require.Equal(t, []ParsedToolCall{{Name: "ls", Input: `{}`}}, calls)
+	})
+
+	t.Run("held back", func(t *testing.T) {
+		t.Parallel()
+		var p ToolCallParser
+		text, _ := p.Write("done <tool")
+		require.Equal(t, "done ", text)
+		text, _ = p.Flush()
+		require.Equal(t, "<tool", text)
+	})
+}
+
+func TestFormatToolCall(t *testing.T) {
+	t.Parallel()
+
+	block := FormatToolCall("view", `{"file_path":"a.go"}`)
+	var p ToolCallParser
+	text, calls := p.Write(block)
+	require.Empty(t, text)
+	require.Equal(t, []ParsedToolCall{{Name: "view", Input: `{"file_path":"a.go"}`}}, calls)
+	require.Equal(t, "<tool_result name=\"view\" error=\"true\">\nboom\n</tool_result>", FormatToolResult("view", "boom", true))
+}
@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// cmap is the ToUnicode map of a font, from character codes to text.
+type cmap struct {
+	// codeLength is the length of the character codes in bytes.
+	codeLength int
+	chars      map[uint32]string
+}
+
+// parseCMap parses the bfchar and bfrange mappings of a ToUnicode map.
+func parseCMap(data []byte) *cmap {
+	m := &cmap{codeLength: 1, chars: make(map[uint32]string)}
+	l := &lexer{data: data}
+	for {
+		operator, operands, ok := l.operation()
+		if !ok {
+			break
+		}
+		switch operator {
+		case "endcodespacerange":
+			if len(operands) > 0 && operands[0].kind == tokenString {
+				m.codeLength = max(1, len(operands[0].value))
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, dst := operands[i], operands[i+1]
+				if src.kind == tokenString && dst.kind == tokenString {
+					m.setLength(src.value)
+					m.chars[code(src.value)] = utf16String(dst.value)
+				}
+			}
+		case "endbfrange":
+			m.parseRanges(operands)
+		}
+	}
+	return m
+}
+
+// parseRanges parses bfrange mappings. A range maps either to consecutive
+// characters, or to the strings of an array.
+func (m *cmap) parseRanges(operands []token) {
+	for i := 0; i+2 < len(operands); i += 3 {
+		lo, hi, dst := operands[i], operands[i+1], operands[i+2]
+		if lo.kind != tokenString || hi.kind != tokenString {
+			return
+		}
+		m.setLength(lo.value)
+		start, end := code(lo.value), code(hi.value)
+		if end < start || end-start > 0xffff {
+			continue
+		}
+		switch dst.kind {
+		case tokenString:
+			base := utf16.Decode(utf16Units(dst.value))
+			if len(base) == 0 {
+				continue
+			}
+			for c := start; c <= end; c++ {
+				chars := append([]rune(nil), base...)
+				chars[len(chars)-1] += rune(c - start)
+				m.chars[c] = string(chars)
+			}
+		case tokenArray:
+			for j, item := range dst.items {
+				if start+uint32(j) > end {
+					break
+				}
+				m.chars[start+uint32(j)] = utf16String(item.value)
+			}
+		}
+	}
+}
+
+func (m *cmap) setLength(src []byte) {
+	m.codeLength = max(1, len(src))
+}
+
+// decode returns the text of a string drawn with the font. Without a map,
+// the string is read as Latin-1.
+func (m *cmap) decode(s []byte) string {
+	var b strings.Builder
+	if m == nil || len(m.chars) == 0 {
+		for _, c := range s {
+			if c >= 0x20 || c == '\t' || c == '\n' {
+				b.WriteRune(rune(c))
+			}
+		}
+		return b.String()
+	}
+	for i := 0; i+m.codeLength <= len(s); i += m.codeLength {
+		if text, ok := m.chars[code(s[i:i+m.codeLength])]; ok {
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+func code(b []byte) uint32 {
+	var c uint32
+	for _, v := range b {
+		c = c<<8 | uint32(v)
+	}
+	return c
+}
+
+func utf16Units(b []byte) []uint16 {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return units
+}
+
+func utf16String(b []byte) string {
+	return string(utf16.Decode(utf16Units(b)))
+}
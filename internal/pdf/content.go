@@ -0,0 +1,336 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// spaceThreshold is the gap between two strings of a TJ array, in
+// thousandths of a text space unit, above which they are separate words.
+const spaceThreshold = 200
+
+// tokenKind is the kind of a token of a content stream.
+type tokenKind int
+
+const (
+	tokenOperator tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenName
+	tokenArrayStart
+	tokenArrayEnd
+	tokenArray
+	tokenOther
+)
+
+type token struct {
+	kind  tokenKind
+	value []byte
+	// items are the elements of an array.
+	items []token
+}
+
+// lexer splits a content stream into tokens.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) next() (token, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isWhitespace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return l.token(), true
+		}
+	}
+	return token{}, false
+}
+
+func (l *lexer) token() token {
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return token{kind: tokenString, value: l.literalString()}
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return token{kind: tokenOther}
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return token{kind: tokenOther}
+	case c == '<':
+		return token{kind: tokenString, value: l.hexString()}
+	case c == '[':
+		l.pos++
+		return token{kind: tokenArrayStart}
+	case c == ']':
+		l.pos++
+		return token{kind: tokenArrayEnd}
+	case c == '/':
+		l.pos++
+		return token{kind: tokenName, value: l.regular()}
+	}
+
+	value := l.regular()
+	if len(value) == 0 {
+		// A stray delimiter.
+		l.pos++
+		return token{kind: tokenOther}
+	}
+	if _, err := strconv.ParseFloat(string(value), 64); err == nil {
+		return token{kind: tokenNumber, value: value}
+	}
+	return token{kind: tokenOperator, value: value}
+}
+
+// regular reads a run of regular characters.
+func (l *lexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// literalString reads a string in parentheses, which may nest.
+func (l *lexer) literalString() []byte {
+	var s []byte
+	depth := 0
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			if depth > 0 {
+				s = append(s, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s
+			}
+			s = append(s, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return s
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b':
+				s = append(s, '\b')
+			case 'f':
+				s = append(s, '\f')
+			case '\r':
+				// A line continuation.
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					s = append(s, byte(n))
+				} else {
+					s = append(s, e)
+				}
+			}
+		default:
+			s = append(s, c)
+		}
+	}
+	return s
+}
+
+// hexString reads a string of hexadecimal digits in angle brackets.
+func (l *lexer) hexString() []byte {
+	l.pos++
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		end = len(l.data) - l.pos
+	}
+	digits := l.data[l.pos : l.pos+end]
+	l.pos += end + 1
+	return decodeHex(digits)
+}
+
+// decodeHex decodes hexadecimal digits, ignoring whitespace. A missing last
+// digit is zero.
+func decodeHex(digits []byte) []byte {
+	var s []byte
+	var b byte
+	odd := false
+	for _, c := range digits {
+		var v byte
+		switch {
+		case c >= '0' && c <= '9':
+			v = c - '0'
+		case c >= 'a' && c <= 'f':
+			v = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			v = c - 'A' + 10
+		default:
+			continue
+		}
+		if odd {
+			s = append(s, b<<4|v)
+		} else {
+			b = v
+		}
+		odd = !odd
+	}
+	if odd {
+		s = append(s, b<<4)
+	}
+	return s
+}
+
+// operation reads the operands of the next operator, and the operator.
+// Arrays are read as one operand.
+func (l *lexer) operation() (operator string, operands []token, ok bool) {
+	var array []token
+	inArray := false
+	for {
+		tok, ok := l.next()
+		if !ok {
+			return "", nil, false
+		}
+		switch tok.kind {
+		case tokenOperator:
+			return string(tok.value), operands, true
+		case tokenArrayStart:
+			inArray = true
+			array = nil
+		case tokenArrayEnd:
+			inArray = false
+			operands = append(operands, token{kind: tokenArray, items: array})
+		default:
+			if inArray {
+				array = append(array, tok)
+			} else {
+				operands = append(operands, tok)
+			}
+		}
+	}
+}
+
+// skipInlineImage skips the data of an inline image, up to its EI operator.
+func (l *lexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			isWhitespace(l.data[l.pos-1]) && (l.pos+2 == len(l.data) || isWhitespace(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+// extractStreamText writes the text drawn by a content stream.
+func extractStreamText(text *strings.Builder, stream []byte, fonts map[string]*cmap) {
+	l := &lexer{data: stream}
+	var (
+		font  *cmap
+		lastY string
+	)
+	show := func(s []byte) {
+		text.WriteString(font.decode(s))
+	}
+	newline := func() {
+		text.WriteString("\n")
+	}
+
+	for {
+		operator, operands, ok := l.operation()
+		if !ok {
+			return
+		}
+		switch operator {
+		case "Tf":
+			if len(operands) >= 2 && operands[len(operands)-2].kind == tokenName {
+				font = fonts[string(operands[len(operands)-2].value)]
+			}
+		case "Tj":
+			if s, ok := lastString(operands); ok {
+				show(s)
+			}
+		case "'", `"`:
+			newline()
+			if s, ok := lastString(operands); ok {
+				show(s)
+			}
+		case "TJ":
+			if len(operands) == 0 || operands[len(operands)-1].kind != tokenArray {
+				break
+			}
+			for _, item := range operands[len(operands)-1].items {
+				switch item.kind {
+				case tokenString:
+					show(item.value)
+				case tokenNumber:
+					if gap, _ := strconv.ParseFloat(string(item.value), 64); gap < -spaceThreshold {
+						text.WriteString(" ")
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if y, _ := strconv.ParseFloat(string(operands[len(operands)-1].value), 64); y != 0 {
+					newline()
+				} else {
+					text.WriteString(" ")
+				}
+			}
+		case "T*":
+			newline()
+		case "Tm":
+			if len(operands) >= 6 {
+				y := string(operands[len(operands)-1].value)
+				if y != lastY {
+					newline()
+				} else {
+					text.WriteString(" ")
+				}
+				lastY = y
+			}
+		case "ET":
+			text.WriteString(" ")
+		case "ID":
+			l.skipInlineImage()
+		}
+	}
+}
+
+func lastString(operands []token) ([]byte, bool) {
+	if len(operands) == 0 || operands[len(operands)-1].kind != tokenString {
+		return nil, false
+	}
+	return operands[len(operands)-1].value, true
+}
@@ -0,0 +1,130 @@
+package pdf
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// dictEntries returns the entries of a dictionary, with their values as
+// written. Nested dictionaries and arrays are values, not entries.
+func dictEntries(dict string) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		s := strings.TrimLeftFunc(dict, func(r rune) bool { return r < 0x80 && isWhitespace(byte(r)) })
+		if !strings.HasPrefix(s, "<<") {
+			return
+		}
+		pos := skipWhitespace(s, 2)
+		for pos < len(s) && s[pos] == '/' {
+			end := skipValue(s, pos)
+			key := s[pos+1 : end]
+			start := skipWhitespace(s, end)
+			if start >= len(s) || strings.HasPrefix(s[start:], ">>") {
+				return
+			}
+			end = skipValue(s, start)
+			if !yield(key, s[start:end]) {
+				return
+			}
+			pos = skipWhitespace(s, end)
+		}
+	}
+}
+
+// dictValue returns the value of a key of a dictionary.
+func dictValue(dict, key string) (string, bool) {
+	for k, v := range dictEntries(dict) {
+		if k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ref returns the object number of an indirect reference.
+func ref(value string) (int, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 || fields[2] != "R" {
+		return 0, false
+	}
+	num, err := strconv.Atoi(fields[0])
+	return num, err == nil
+}
+
+// refs returns the object numbers of the references of an array.
+func refs(value string) []int {
+	var nums []int
+	for _, match := range refPattern.FindAllStringSubmatch(value, -1) {
+		num, _ := strconv.Atoi(match[1])
+		nums = append(nums, num)
+	}
+	return nums
+}
+
+func skipWhitespace(s string, pos int) int {
+	for pos < len(s) && isWhitespace(s[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// skipValue returns the end of the value starting at pos. A number followed
+// by a generation and R is an indirect reference, which is one value.
+func skipValue(s string, pos int) int {
+	switch {
+	case strings.HasPrefix(s[pos:], "<<"), s[pos] == '[':
+		return skipNested(s, pos)
+	case s[pos] == '(':
+		l := &lexer{data: []byte(s), pos: pos}
+		l.literalString()
+		return l.pos
+	case s[pos] == '<':
+		if end := strings.IndexByte(s[pos:], '>'); end >= 0 {
+			return pos + end + 1
+		}
+		return len(s)
+	}
+	end := skipToken(s, pos)
+	if match := indirectRefPattern.FindStringIndex(s[pos:]); match != nil && match[0] == 0 {
+		return pos + match[1]
+	}
+	return end
+}
+
+// skipToken returns the end of a name, number or keyword.
+func skipToken(s string, pos int) int {
+	end := pos + 1
+	for end < len(s) && !isWhitespace(s[end]) && !isDelimiter(s[end]) {
+		end++
+	}
+	return end
+}
+
+// skipNested returns the end of the dictionary or array starting at pos.
+func skipNested(s string, pos int) int {
+	depth := 0
+	for pos < len(s) {
+		switch {
+		case strings.HasPrefix(s[pos:], "<<"):
+			depth++
+			pos += 2
+		case strings.HasPrefix(s[pos:], ">>"):
+			depth--
+			pos += 2
+		case s[pos] == '[':
+			depth++
+			pos++
+		case s[pos] == ']':
+			depth--
+			pos++
+		case s[pos] == '(', s[pos] == '<':
+			pos = skipValue(s, pos)
+		default:
+			pos++
+		}
+		if depth == 0 {
+			return pos
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,421 @@
+// Package pdf extracts the text of PDF documents, for models that can't read
+// them natively.
+//
+// It is a best effort rather than a full PDF reader: the text drawn by the
+// content streams of the pages is read in page order, decoding strings with
+// the ToUnicode map of their font or else as Latin-1, while the layout is
+// reduced to line breaks and spaces.
+//
+// Documents are untrusted, so only the streams the text needs are decoded,
+// the content, ToUnicode and object streams, and all of them together within
+// maxDecodedSize.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNoText is returned for documents without text, such as scans.
+var ErrNoText = errors.New("the PDF has no text")
+
+// maxDecodedSize bounds the decoded size of the streams of a document,
+// against documents that inflate to much more than their own size.
+const maxDecodedSize = 64 << 20
+
+// maxTreeDepth bounds the depth of the page tree, against documents whose
+// tree loops.
+const maxTreeDepth = 64
+
+var (
+	objectPattern      = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	refPattern         = regexp.MustCompile(`(\d+)\s+\d+\s+R\b`)
+	indirectRefPattern = regexp.MustCompile(`^\d+\s+\d+\s+R\b`)
+	rootPattern        = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R\b`)
+	filterPattern      = regexp.MustCompile(`/Filter\s*(\[[^\]]*\]|/\w+)`)
+)
+
+// object is an indirect object of a document. The data of streams is only
+// decoded when it's needed.
+type object struct {
+	dict string
+	// raw is the data of a stream as it's stored, nil for other objects.
+	raw []byte
+
+	decoded bool
+	stream  []byte
+	// decodable is false for streams with filters other than FlateDecode,
+	// such as images.
+	decodable bool
+}
+
+// document holds the objects of a PDF, and what's left of the budget of
+// decoded bytes.
+type document struct {
+	data    []byte
+	objects map[int]*object
+	// objectStreams is set once the objects of the object streams are
+	// loaded, which is only done when an object isn't found otherwise.
+	objectStreams bool
+	fonts         map[int]*cmap
+	budget        int
+	// err is the first error of decoding, which stops it.
+	err error
+}
+
+// page is a page of a document, with the resources it inherits.
+type page struct {
+	dict      string
+	resources string
+}
+
+// ExtractText returns the text of a PDF document.
+func ExtractText(data []byte) (string, error) {
+	return extractText(data, maxDecodedSize)
+}
+
+func extractText(data []byte, budget int) (_ string, err error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\r "), []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+	// A malformed document that trips the parser is reported rather than
+	// taking the application down.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF document: %v", r)
+		}
+	}()
+	d := &document{
+		data:    data,
+		objects: parseObjects(data),
+		fonts:   make(map[int]*cmap),
+		budget:  budget,
+	}
+
+	var text strings.Builder
+	for _, p := range d.pages() {
+		fonts := d.pageFonts(p)
+		for _, num := range d.pageContents(p) {
+			if stream, ok := d.stream(num); ok {
+				extractStreamText(&text, stream, fonts)
+				text.WriteString("\n")
+			}
+		}
+	}
+	if d.err != nil {
+		return "", d.err
+	}
+
+	result := cleanText(text.String())
+	if result == "" {
+		return "", ErrNoText
+	}
+	return result, nil
+}
+
+// parseObjects returns the objects of a document by number, without decoding
+// their streams.
+func parseObjects(data []byte) map[int]*object {
+	objects := make(map[int]*object)
+	matches := objectPattern.FindAllSubmatchIndex(data, -1)
+	for i, match := range matches {
+		num, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		// Objects updated later in the file replace the earlier ones.
+		objects[num] = parseObject(data[match[1]:end])
+	}
+	return objects
+}
+
+// parseObject parses the body of an object, up to the next one.
+func parseObject(body []byte) *object {
+	if end := bytes.Index(body, []byte("endobj")); end >= 0 && !bytes.Contains(body[:end], []byte("stream")) {
+		return &object{dict: string(body[:end])}
+	}
+	start := bytes.Index(body, []byte("stream"))
+	if start < 0 {
+		return &object{dict: string(body)}
+	}
+	obj := &object{dict: string(body[:start])}
+	data := body[start+len("stream"):]
+	data = bytes.TrimPrefix(data, []byte("\r"))
+	data = bytes.TrimPrefix(data, []byte("\n"))
+	if end := bytes.LastIndex(data, []byte("endstream")); end >= 0 {
+		data = bytes.TrimRight(data[:end], "\r\n")
+	}
+	obj.raw = data
+	return obj
+}
+
+// get returns an object, loading the objects of the object streams when it
+// isn't found otherwise.
+func (d *document) get(num int) (*object, bool) {
+	if obj, ok := d.objects[num]; ok {
+		return obj, true
+	}
+	if d.objectStreams {
+		return nil, false
+	}
+	d.objectStreams = true
+	for _, n := range slices.Sorted(maps.Keys(d.objects)) {
+		obj := d.objects[n]
+		if kind, _ := dictValue(obj.dict, "Type"); obj.raw == nil || kind != "/ObjStm" {
+			continue
+		}
+		stream, ok := d.decode(obj)
+		if !ok {
+			continue
+		}
+		for num, body := range objectStreamObjects(obj.dict, stream) {
+			if _, exists := d.objects[num]; !exists {
+				d.objects[num] = &object{dict: string(body)}
+			}
+		}
+	}
+	obj, ok := d.objects[num]
+	return obj, ok
+}
+
+// resolve returns the dictionary a value refers to, or the value itself.
+func (d *document) resolve(value string) string {
+	num, ok := ref(value)
+	if !ok {
+		return value
+	}
+	obj, ok := d.get(num)
+	if !ok {
+		return ""
+	}
+	return obj.dict
+}
+
+// stream returns the decoded data of a stream object.
+func (d *document) stream(num int) ([]byte, bool) {
+	obj, ok := d.get(num)
+	if !ok || obj.raw == nil {
+		return nil, false
+	}
+	return d.decode(obj)
+}
+
+// decode decodes the data of a stream once, if it isn't filtered or only
+// with FlateDecode, and takes it from the budget.
+func (d *document) decode(obj *object) ([]byte, bool) {
+	if obj.decoded {
+		return obj.stream, obj.decodable
+	}
+	obj.decoded = true
+	if d.err != nil {
+		return nil, false
+	}
+	stream, decodable, err := decodeStream(obj.dict, obj.raw, d.budget)
+	if err != nil {
+		d.err = err
+		return nil, false
+	}
+	if decodable {
+		d.budget -= len(stream)
+	}
+	obj.stream, obj.decodable = stream, decodable
+	return stream, decodable
+}
+
+// decodeStream decodes the data of a stream, if it isn't filtered or only
+// with FlateDecode. Streams that decode past the budget are an error.
+func decodeStream(dict string, data []byte, budget int) ([]byte, bool, error) {
+	match := filterPattern.FindStringSubmatch(dict)
+	if match == nil {
+		return data, true, nil
+	}
+	filters := strings.Fields(strings.NewReplacer("[", " ", "]", " ", "/", " ").Replace(match[1]))
+	for _, filter := range filters {
+		if filter != "FlateDecode" {
+			return data, false, nil
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return data, false, nil
+		}
+		// Streams that are cut short keep what could be read.
+		decoded, err := io.ReadAll(io.LimitReader(r, int64(budget)+1))
+		switch {
+		case len(decoded) > budget:
+			return nil, false, fmt.Errorf("the PDF decodes to more than %d bytes", maxDecodedSize)
+		case err != nil && !errors.Is(err, io.ErrUnexpectedEOF):
+			return data, false, nil
+		case len(decoded) == 0:
+			return data, false, nil
+		}
+		data = decoded
+	}
+	return data, true, nil
+}
+
+// objectStreamObjects returns the bodies of the objects compressed in an
+// object stream.
+func objectStreamObjects(dict string, stream []byte) map[int][]byte {
+	value, _ := dictValue(dict, "First")
+	first, err := strconv.Atoi(value)
+	if err != nil || first < 0 || first > len(stream) {
+		return nil
+	}
+	// Offsets are relative to first and increasing, so entries that aren't
+	// make the whole stream unreadable.
+	header := strings.Fields(string(stream[:first]))
+	type entry struct{ num, offset int }
+	var entries []entry
+	for i := 0; i+1 < len(header); i += 2 {
+		num, err1 := strconv.Atoi(header[i])
+		offset, err2 := strconv.Atoi(header[i+1])
+		if err1 != nil || err2 != nil || offset < 0 || first+offset > len(stream) {
+			return nil
+		}
+		if len(entries) > 0 && first+offset < entries[len(entries)-1].offset {
+			return nil
+		}
+		entries = append(entries, entry{num, first + offset})
+	}
+	bodies := make(map[int][]byte, len(entries))
+	for i, e := range entries {
+		end := len(stream)
+		if i+1 < len(entries) {
+			end = entries[i+1].offset
+		}
+		bodies[e.num] = stream[e.offset:end]
+	}
+	return bodies
+}
+
+// pages returns the pages of the document in order, walking the page tree
+// from the catalog. Documents without a page tree that can be read fall back
+// to the page objects in the order of their numbers.
+func (d *document) pages() []page {
+	var pages []page
+	if match := rootPattern.FindAllSubmatch(d.data, -1); match != nil {
+		// The last trailer is the one of the latest update.
+		root, _ := strconv.Atoi(string(match[len(match)-1][1]))
+		if catalog, ok := d.get(root); ok {
+			if value, ok := dictValue(catalog.dict, "Pages"); ok {
+				if num, ok := ref(value); ok {
+					pages = d.walkPages(num, "", make(map[int]bool), 0)
+				}
+			}
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+	for _, num := range slices.Sorted(maps.Keys(d.objects)) {
+		dict := d.objects[num].dict
+		if kind, _ := dictValue(dict, "Type"); kind == "/Page" {
+			resources, _ := dictValue(dict, "Resources")
+			pages = append(pages, page{dict: dict, resources: d.resolve(resources)})
+		}
+	}
+	return pages
+}
+
+// walkPages returns the pages under a node of the page tree, which inherit
+// its resources.
+func (d *document) walkPages(num int, resources string, seen map[int]bool, depth int) []page {
+	obj, ok := d.get(num)
+	if !ok || seen[num] || depth > maxTreeDepth {
+		return nil
+	}
+	seen[num] = true
+	if value, ok := dictValue(obj.dict, "Resources"); ok {
+		resources = d.resolve(value)
+	}
+	kids, ok := dictValue(obj.dict, "Kids")
+	if !ok {
+		return []page{{dict: obj.dict, resources: resources}}
+	}
+	var pages []page
+	for _, kid := range refs(d.resolve(kids)) {
+		pages = append(pages, d.walkPages(kid, resources, seen, depth+1)...)
+	}
+	return pages
+}
+
+// pageContents returns the numbers of the content streams of a page.
+func (d *document) pageContents(p page) []int {
+	value, ok := dictValue(p.dict, "Contents")
+	if !ok {
+		return nil
+	}
+	num, ok := ref(value)
+	if !ok {
+		return refs(value)
+	}
+	// The contents may be an array object of streams.
+	if obj, ok := d.get(num); ok && obj.raw == nil {
+		return refs(obj.dict)
+	}
+	return []int{num}
+}
+
+// pageFonts returns the ToUnicode maps of the fonts of a page by resource
+// name.
+func (d *document) pageFonts(p page) map[string]*cmap {
+	fonts := make(map[string]*cmap)
+	value, ok := dictValue(p.resources, "Font")
+	if !ok {
+		return fonts
+	}
+	for name, font := range dictEntries(d.resolve(value)) {
+		if num, ok := ref(font); ok {
+			fonts[name] = d.fontMap(num)
+		}
+	}
+	return fonts
+}
+
+// fontMap returns the ToUnicode map of a font, or nil. Maps are parsed once
+// for the fonts shared by several pages.
+func (d *document) fontMap(num int) *cmap {
+	if m, ok := d.fonts[num]; ok {
+		return m
+	}
+	var m *cmap
+	if font, ok := d.get(num); ok {
+		if value, ok := dictValue(font.dict, "ToUnicode"); ok {
+			if n, ok := ref(value); ok {
+				if stream, ok := d.stream(n); ok {
+					m = parseCMap(stream)
+				}
+			}
+		}
+	}
+	d.fonts[num] = m
+	return m
+}
+
+// cleanText trims the lines of the text and collapses blank lines.
+func cleanText(text string) string {
+	var lines []string
+	blank := false
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF builds a document from the bodies of its objects, numbered from
+// 1 and skipping empty ones. Streams are compressed when compress is set.
+func buildPDF(t *testing.T, compress bool, objects ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	for i, obj := range objects {
+		if obj == "" {
+			continue
+		}
+		if dict, stream, ok := strings.Cut(obj, "stream\n"); ok {
+			data := []byte(stream)
+			if compress {
+				data = compressed(t, data)
+				dict = strings.Replace(dict, "<<", "<< /Filter /FlateDecode", 1)
+			}
+			fmt.Fprintf(&b, "%d 0 obj\n%s /Length %d\nstream\n%s\nendstream\nendobj\n", i+1, dict, len(data), data)
+			continue
+		}
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestExtractText(t *testing.T) {
+	t.Parallel()
+
+	toUnicode := `<<>>stream
+/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <0048>
+<0002> <00E9>
+endbfchar
+1 beginbfrange
+<0010> <0012> [<006C> <006C> <006F>]
+endbfrange
+endcmap`
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents [6 0 R 7 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 8 0 R >>",
+		"<<>>stream\nBT /F1 12 Tf 72 720 Td (Hello, \\(PDF\\) world) Tj 0 -14 Td [(Sep) -50 (arate) -300 (words)] TJ ET",
+		"<<>>stream\nBT /F2 12 Tf 72 600 Td <000100020010> Tj <00110012> Tj ET",
+		toUnicode,
+	}
+
+	for _, compress := range []bool{false, true} {
+		text, err := ExtractText(buildPDF(t, compress, objects...))
+		require.NoError(t, err)
+		require.Equal(t, "Hello, (PDF) world\nSeparate words\n\nHéllo", text)
+	}
+}
+
+func TestExtractTextErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ExtractText([]byte("not a document"))
+	require.ErrorContains(t, err, "not a PDF document")
+
+	scan := buildPDF(t, false,
+		"<< /Type /Page /Contents 2 0 R >>",
+		"<<>>stream\nq 100 0 0 100 0 0 cm /Im1 Do Q",
+	)
+	_, err = ExtractText(scan)
+	require.ErrorIs(t, err, ErrNoText)
+
+}
+
+// compressed returns data compressed with zlib.
+func compressed(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestExtractTextPageOrder(t *testing.T) {
+	t.Parallel()
+
+	cmap := func(char string) string {
+		return "<<>>stream\n1 begincodespacerange <01> <01> endcodespacerange 1 beginbfchar <01> <" + char + "> endbfchar"
+	}
+	// The pages are listed in the reverse order of their numbers, and both
+	// name their font F1, which maps the same code to different text.
+	doc := buildPDF(t, true,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R 3 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 7 0 R >> >> /Contents 4 0 R >>",
+		"<<>>stream\nBT /F1 12 Tf <01> Tj ET",
+		"<< /Type /Page /Parent 2 0 R /Resources 9 0 R /Contents 6 0 R >>",
+		"<<>>stream\nBT /F1 12 Tf <01> Tj ET",
+		"<< /Type /Font /ToUnicode 8 0 R >>",
+		cmap("0042"),
+		"<< /Font << /F1 10 0 R >> >>",
+		"<< /Type /Font /ToUnicode 11 0 R >>",
+		cmap("0041"),
+	)
+	text, err := ExtractText(doc)
+	require.NoError(t, err)
+	require.Equal(t, "A\nB", text)
+}
+
+func TestExtractTextObjectStream(t *testing.T) {
+	t.Parallel()
+
+	pages := "<< /Type /Pages /Kids [4 0 R] /Count 1 >>"
+	page := "<< /Type /Page /Parent 3 0 R /Contents 5 0 R >>"
+	header := fmt.Sprintf("3 0 4 %d ", len(pages))
+	doc := buildPDF(t, true,
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		fmt.Sprintf("<< /Type /ObjStm /N 2 /First %d >>stream\n%s%s%s", len(header), header, pages, page),
+		"",
+		"",
+		"<<>>stream\nBT (Compressed objects) Tj ET",
+	)
+	text, err := ExtractText(doc)
+	require.NoError(t, err)
+	require.Equal(t, "Compressed objects", text)
+}
+
+func TestExtractTextBudget(t *testing.T) {
+	t.Parallel()
+
+	page := func(contents string) []string {
+		return []string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R " + contents + " >>",
+			"<<>>stream\nBT (" + strings.Repeat("a", 600) + ") Tj ET",
+			"<<>>stream\nBT (" + strings.Repeat("b", 600) + ") Tj ET",
+			// An image that isn't decoded, as the text doesn't need it.
+			"<< /Subtype /Image >>stream\n" + strings.Repeat("c", 5000),
+		}
+	}
+
+	// Each stream fits in the budget, but not both.
+	_, err := extractText(buildPDF(t, true, page("/Contents [4 0 R 5 0 R]")...), 1000)
+	require.ErrorContains(t, err, "the PDF decodes to more than")
+
+	text, err := extractText(buildPDF(t, true, page("/Contents 4 0 R")...), 1000)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", 600), text)
+
+	bomb := fmt.Sprintf("%%PDF-1.7\n1 0 obj\n<< /Type /Page /Contents 2 0 R >>\nendobj\n2 0 obj\n<< /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", compressed(t, make([]byte, 1<<20)))
+	_, err = extractText([]byte(bomb), 1000)
+	require.ErrorContains(t, err, "the PDF decodes to more than")
+}
+
+func TestObjectStreamObjects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		stream string
+		bodies map[int][]byte
+	}{
+		{"offsets", "10 0 11 5 <<a>><<b>>", map[int][]byte{10: []byte("<<a>>"), 11: []byte("<<b>>")}},
+		{"decreasing offsets", "10 5 11 0 <<a>><<b>>", nil},
+		{"negative offset", "10 -1 11 0<<a>><<b>>", nil},
+		{"offset past the end", "10 0 11 99 <<a>><<b>>", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.bodies, objectStreamObjects("<< /Type /ObjStm /N 2 /First 10 >>", []byte(tt.stream)))
+		})
+	}
+}
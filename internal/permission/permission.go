@@ -2,13 +2,16 @@ package permission
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
 	"slices"
 	"sync"
 
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -47,7 +50,10 @@ type Service interface {
 	GrantPersistent(permission PermissionRequest)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
-	Request(opts CreatePermissionRequest) bool
+	// Request asks for a permission, first to the permission_request hooks
+	// and then to the user. The feedback of the hooks goes to the
+	// hooks.Collector of ctx.
+	Request(ctx context.Context, opts CreatePermissionRequest) bool
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
@@ -67,9 +73,42 @@ type permissionService struct {
 	skip                  bool
 	allowedTools          []string
 
-	// used to make sure we only process one request at a time
-	requestMu     sync.Mutex
-	activeRequest *PermissionRequest
+	// used to make sure we only process one request at a time per session,
+	// so that sessions running at once don't wait on each other's requests
+	requestLocks   map[string]*requestLock
+	requestLocksMu sync.Mutex
+	activeRequests *csync.Map[string, PermissionRequest]
+}
+
+// requestLock serializes the requests of a session. It's dropped once no
+// request of the session holds or waits for it.
+type requestLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockSession waits for the requests of the session before it, and returns
+// the function that lets the next one through.
+func (s *permissionService) lockSession(sessionID string) func() {
+	s.requestLocksMu.Lock()
+	lock, ok := s.requestLocks[sessionID]
+	if !ok {
+		lock = &requestLock{}
+		s.requestLocks[sessionID] = lock
+	}
+	lock.refs++
+	s.requestLocksMu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		s.requestLocksMu.Lock()
+		defer s.requestLocksMu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(s.requestLocks, sessionID)
+		}
+	}
 }
 
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
@@ -86,9 +125,7 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 	s.sessionPermissions = append(s.sessionPermissions, permission)
 	s.sessionPermissionsMu.Unlock()
 
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
-	}
+	s.clearActiveRequest(permission)
 }
 
 func (s *permissionService) Grant(permission PermissionRequest) {
@@ -101,9 +138,7 @@ func (s *permissionService) Grant(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
-	}
+	s.clearActiveRequest(permission)
 }
 
 func (s *permissionService) Deny(permission PermissionRequest) {
@@ -117,12 +152,10 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 		respCh <- false
 	}
 
-	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
-		s.activeRequest = nil
-	}
+	s.clearActiveRequest(permission)
 }
 
-func (s *permissionService) Request(opts CreatePermissionRequest) bool {
+func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) bool {
 	if s.skip {
 		return true
 	}
@@ -131,8 +164,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: opts.ToolCallID,
 	})
-	s.requestMu.Lock()
-	defer s.requestMu.Unlock()
+	defer s.lockSession(opts.SessionID)()
 
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
@@ -190,7 +222,14 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	}
 	s.sessionPermissionsMu.RUnlock()
 
-	s.activeRequest = &permission
+	switch requestHooks(ctx, permission) {
+	case hooks.DecisionAllow:
+		return true
+	case hooks.DecisionBlock, hooks.DecisionDeny:
+		return false
+	}
+
+	s.activeRequests.Set(permission.SessionID, permission)
 
 	respCh := make(chan bool, 1)
 	s.pendingRequests.Set(permission.ID, respCh)
@@ -202,6 +241,35 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	return <-respCh
 }
 
+func (s *permissionService) clearActiveRequest(permission PermissionRequest) {
+	if active, ok := s.activeRequests.Get(permission.SessionID); ok && active.ID == permission.ID {
+		s.activeRequests.Del(permission.SessionID)
+	}
+}
+
+// requestHooks lets the permission_request hooks allow or deny a request
+// before the user is asked, and passes their messages on.
+func requestHooks(ctx context.Context, permission PermissionRequest) hooks.Decision {
+	var toolInput json.RawMessage
+	if params, err := json.Marshal(permission.Params); err == nil {
+		toolInput = params
+	}
+	result := hooks.Run(ctx, hooks.Input{
+		Event:      config.HookPermissionRequest,
+		SessionID:  permission.SessionID,
+		ToolName:   permission.ToolName,
+		ToolCallID: permission.ToolCallID,
+		ToolInput:  toolInput,
+		Permission: &hooks.Permission{
+			Action:      permission.Action,
+			Description: permission.Description,
+			Path:        permission.Path,
+		},
+	})
+	hooks.Collect(ctx, result.Feedback())
+	return result.Decision
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
@@ -230,5 +298,7 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		skip:                skip,
 		allowedTools:        allowedTools,
 		pendingRequests:     csync.NewMap[string, chan bool](),
+		requestLocks:        make(map[string]*requestLock),
+		activeRequests:      csync.NewMap[string, PermissionRequest](),
 	}
 }
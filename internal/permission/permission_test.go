@@ -81,7 +81,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 func TestPermissionService_SkipMode(t *testing.T) {
 	service := NewPermissionService("/tmp", true, []string{})
 
-	result := service.Request(CreatePermissionRequest{
+	result := service.Request(t.Context(), CreatePermissionRequest{
 		SessionID:   "test-session",
 		ToolName:    "bash",
 		Action:      "execute",
@@ -115,7 +115,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 
 		go func() {
 			defer wg.Done()
-			result1 = service.Request(req1)
+			result1 = service.Request(t.Context(), req1)
 		}()
 
 		var permissionReq PermissionRequest
@@ -136,7 +136,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 			Params:      map[string]string{"file": "test.txt"},
 			Path:        "/tmp/test.txt",
 		}
-		result2 := service.Request(req2)
+		result2 := service.Request(t.Context(), req2)
 		assert.True(t, result2, "Second request should be auto-approved")
 	})
 	t.Run("Sequential requests with temporary grants", func(t *testing.T) {
@@ -158,7 +158,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 
 		go func() {
 			defer wg.Done()
-			result1 = service.Request(req)
+			result1 = service.Request(t.Context(), req)
 		}()
 
 		var permissionReq PermissionRequest
@@ -174,7 +174,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 
 		go func() {
 			defer wg.Done()
-			result2 = service.Request(req)
+			result2 = service.Request(t.Context(), req)
 		}()
 
 		event = <-events
@@ -189,6 +189,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		events := service.Subscribe(t.Context())
 
 		var wg sync.WaitGroup
+		var resultsMu sync.Mutex
 		results := make([]bool, 0)
 
 		requests := []CreatePermissionRequest{
@@ -219,7 +220,10 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 			wg.Add(1)
 			go func(index int, request CreatePermissionRequest) {
 				defer wg.Done()
-				results = append(results, service.Request(request))
+				result := service.Request(t.Context(), request)
+				resultsMu.Lock()
+				results = append(results, result)
+				resultsMu.Unlock()
 			}(i, req)
 		}
 
@@ -245,7 +249,47 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.Equal(t, 2, grantedCount, "Should have 2 granted and 1 denied")
 		secondReq := requests[1]
 		secondReq.Description = "Repeat of second request"
-		result := service.Request(secondReq)
+		result := service.Request(t.Context(), secondReq)
 		assert.True(t, result, "Repeated request should be auto-approved due to persistent permission")
 	})
 }
+
+func TestPermissionService_SessionsDontWaitOnEachOther(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{})
+	events := service.Subscribe(t.Context())
+
+	request := func(sessionID string) <-chan bool {
+		result := make(chan bool, 1)
+		go func() {
+			result <- service.Request(t.Context(), CreatePermissionRequest{
+				SessionID:   sessionID,
+				ToolName:    "bash",
+				Action:      "execute",
+				Path:        "/tmp",
+				Description: "Run a command",
+			})
+		}()
+		return result
+	}
+
+	first := request("background")
+	background := (<-events).Payload
+	assert.Equal(t, "background", background.SessionID)
+
+	// The background session is still waiting for an answer, but the
+	// request of the other session is published anyway.
+	second := request("foreground")
+	foreground := (<-events).Payload
+	assert.Equal(t, "foreground", foreground.SessionID)
+
+	service.Deny(foreground)
+	assert.False(t, <-second)
+	service.Grant(background)
+	assert.True(t, <-first)
+
+	// The locks of sessions without requests are dropped.
+	ps := service.(*permissionService)
+	ps.requestLocksMu.Lock()
+	defer ps.requestLocksMu.Unlock()
+	assert.Empty(t, ps.requestLocks)
+}
@@ -0,0 +1,128 @@
+// Package prompthistory keeps the prompts sent in a project, so they can be
+// recalled in the editor across sessions and restarts.
+package prompthistory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/google/uuid"
+)
+
+// MaxPrompts is the number of recent prompts that are kept and recalled.
+const MaxPrompts = 1000
+
+type Service interface {
+	// Add saves a prompt as the most recent one, unless the history is
+	// disabled or the prompt looks like it contains a secret. A prompt that
+	// was already sent is moved rather than duplicated, and the prompts
+	// beyond MaxPrompts are dropped.
+	Add(ctx context.Context, sessionID, prompt string) error
+	// Keeps reports whether Add saves the prompt.
+	Keeps(prompt string) bool
+	// List returns the most recent prompts first.
+	List(ctx context.Context) ([]string, error)
+}
+
+type service struct {
+	q        *db.Queries
+	db       *sql.DB
+	project  string
+	disabled bool
+	exclude  []*regexp.Regexp
+	// max is the number of prompts kept, which is MaxPrompts.
+	max int64
+}
+
+// NewService returns the history of the project. Invalid exclude patterns
+// are logged and ignored.
+func NewService(q *db.Queries, conn *sql.DB, project string, disabled bool, excludePatterns []string) Service {
+	s := &service{
+		q:        q,
+		db:       conn,
+		project:  project,
+		disabled: disabled,
+		max:      MaxPrompts,
+	}
+	for _, pattern := range excludePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			slog.Warn("Ignoring invalid prompt history exclude pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		s.exclude = append(s.exclude, re)
+	}
+	return s
+}
+
+func (s *service) Add(ctx context.Context, sessionID, prompt string) error {
+	if !s.Keeps(prompt) {
+		return nil
+	}
+	prompt = strings.TrimSpace(prompt)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := s.q.WithTx(tx)
+	if err := qtx.DeletePromptsByContent(ctx, db.DeletePromptsByContentParams{
+		Project: s.project,
+		Content: prompt,
+	}); err != nil {
+		return fmt.Errorf("failed to delete the previous prompt: %w", err)
+	}
+	if _, err := qtx.CreatePrompt(ctx, db.CreatePromptParams{
+		ID:        uuid.New().String(),
+		Project:   s.project,
+		SessionID: sessionID,
+		Content:   prompt,
+	}); err != nil {
+		return fmt.Errorf("failed to save the prompt: %w", err)
+	}
+	if err := qtx.PrunePrompts(ctx, db.PrunePromptsParams{
+		Project: s.project,
+		Offset:  s.max,
+	}); err != nil {
+		return fmt.Errorf("failed to drop old prompts: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Keeps(prompt string) bool {
+	prompt = strings.TrimSpace(prompt)
+	return !s.disabled && prompt != "" && !s.excluded(prompt)
+}
+
+func (s *service) List(ctx context.Context) ([]string, error) {
+	dbPrompts, err := s.q.ListPrompts(ctx, db.ListPromptsParams{
+		Project: s.project,
+		Limit:   s.max,
+	})
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]string, len(dbPrompts))
+	for i, dbPrompt := range dbPrompts {
+		prompts[i] = dbPrompt.Content
+	}
+	return prompts, nil
+}
+
+func (s *service) excluded(prompt string) bool {
+	for _, re := range s.exclude {
+		if re.MatchString(prompt) {
+			return true
+		}
+	}
+	return false
+}
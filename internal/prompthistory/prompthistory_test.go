@@ -0,0 +1,75 @@
+package prompthistory
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	s := NewService(q, conn, "/project", false, config.DefaultPromptHistoryExcludePatterns)
+	for _, prompt := range []string{
+		"run the tests and fix failures",
+		"explain main.go",
+		"  ",
+		"use the key sk-abcdefghijklmnopqrstuvwxyz012345",
+		"password: hunter2",
+		"run the tests and fix failures",
+	} {
+		require.NoError(t, s.Add(t.Context(), "session", prompt))
+	}
+	require.NoError(t, NewService(q, conn, "/other", false, nil).Add(t.Context(), "session", "other project"))
+
+	prompts, err := s.List(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"run the tests and fix failures", "explain main.go"}, prompts)
+	require.True(t, s.Keeps("explain main.go"))
+	require.False(t, s.Keeps("password: hunter2"))
+
+	disabled := NewService(q, conn, "/project", true, nil)
+	require.False(t, disabled.Keeps("not saved"))
+	require.NoError(t, disabled.Add(t.Context(), "session", "not saved"))
+	prompts, err = disabled.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, prompts, 2)
+}
+
+func TestServicePrune(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	s := NewService(q, conn, "/project", false, nil)
+	s.(*service).max = 2
+	other := NewService(q, conn, "/other", false, nil)
+	require.NoError(t, other.Add(t.Context(), "session", "other project"))
+	for _, prompt := range []string{"first", "second", "third", "second"} {
+		require.NoError(t, s.Add(t.Context(), "session", prompt))
+	}
+
+	prompts, err := s.List(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"second", "third"}, prompts)
+	rows, err := conn.QueryContext(t.Context(), "SELECT content FROM prompts ORDER BY content")
+	require.NoError(t, err)
+	defer rows.Close()
+	var all []string
+	for rows.Next() {
+		var content string
+		require.NoError(t, rows.Scan(&content))
+		all = append(all, content)
+	}
+	require.Equal(t, []string{"other project", "second", "third"}, all)
+}
@@ -17,9 +17,30 @@ type Session struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	SummaryMessageID string
-	Cost             float64
-	CreatedAt        int64
-	UpdatedAt        int64
+	// SummaryKeepMessageID is the first message kept verbatim after a partial
+	// summary. When empty the summary replaces everything before it.
+	SummaryKeepMessageID string
+	Cost                 float64
+	// TotalPromptTokens sums the prompts of all the requests, unlike
+	// PromptTokens which is the prompt of the last one. CacheReadTokens and
+	// CacheCreationTokens are the parts of it read from and written to the
+	// prompt cache.
+	TotalPromptTokens   int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	// CacheSavings is what the prompt cache saved, net of the cost of
+	// writing to it.
+	CacheSavings float64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+// CacheHitRatio returns the share of the prompts read from the cache.
+func (s Session) CacheHitRatio() float64 {
+	if s.TotalPromptTokens == 0 {
+		return 0
+	}
+	return float64(s.CacheReadTokens) / float64(s.TotalPromptTokens)
 }
 
 type Service interface {
@@ -110,7 +131,15 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
+		SummaryKeepMessageID: sql.NullString{
+			String: session.SummaryKeepMessageID,
+			Valid:  session.SummaryKeepMessageID != "",
+		},
+		Cost:                session.Cost,
+		TotalPromptTokens:   session.TotalPromptTokens,
+		CacheReadTokens:     session.CacheReadTokens,
+		CacheCreationTokens: session.CacheCreationTokens,
+		CacheSavings:        session.CacheSavings,
 	})
 	if err != nil {
 		return Session{}, err
@@ -134,16 +163,21 @@ func (s *service) List(ctx context.Context) ([]Session, error) {
 
 func (s service) fromDBItem(item db.Session) Session {
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                   item.ID,
+		ParentSessionID:      item.ParentSessionID.String,
+		Title:                item.Title,
+		MessageCount:         item.MessageCount,
+		PromptTokens:         item.PromptTokens,
+		CompletionTokens:     item.CompletionTokens,
+		SummaryMessageID:     item.SummaryMessageID.String,
+		SummaryKeepMessageID: item.SummaryKeepMessageID.String,
+		Cost:                 item.Cost,
+		TotalPromptTokens:    item.TotalPromptTokens,
+		CacheReadTokens:      item.CacheReadTokens,
+		CacheCreationTokens:  item.CacheCreationTokens,
+		CacheSavings:         item.CacheSavings,
+		CreatedAt:            item.CreatedAt,
+		UpdatedAt:            item.UpdatedAt,
 	}
 }
 
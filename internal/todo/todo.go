@@ -0,0 +1,195 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+)
+
+// Valid reports whether s is one of the known statuses.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusPending, StatusInProgress, StatusCompleted:
+		return true
+	}
+	return false
+}
+
+// Todo is an item of the checklist the agent keeps for a session.
+type Todo struct {
+	ID        string
+	SessionID string
+	Content   string
+	Status    Status
+	Position  int64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type Service interface {
+	pubsub.Suscriber[Todo]
+	Create(ctx context.Context, sessionID, content string) (Todo, error)
+	Get(ctx context.Context, id string) (Todo, error)
+	List(ctx context.Context, sessionID string) ([]Todo, error)
+	Save(ctx context.Context, todo Todo) (Todo, error)
+	Delete(ctx context.Context, id string) error
+	DeleteSessionTodos(ctx context.Context, sessionID string) error
+}
+
+type service struct {
+	*pubsub.Broker[Todo]
+	q db.Querier
+}
+
+func NewService(q db.Querier) Service {
+	return &service{
+		Broker: pubsub.NewBroker[Todo](),
+		q:      q,
+	}
+}
+
+// Create adds a pending item at the end of the session's checklist.
+func (s *service) Create(ctx context.Context, sessionID, content string) (Todo, error) {
+	existing, err := s.q.ListTodosBySession(ctx, sessionID)
+	if err != nil {
+		return Todo{}, err
+	}
+	var position int64
+	if len(existing) > 0 {
+		position = existing[len(existing)-1].Position + 1
+	}
+	dbTodo, err := s.q.CreateTodo(ctx, db.CreateTodoParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Content:   content,
+		Status:    string(StatusPending),
+		Position:  position,
+	})
+	if err != nil {
+		return Todo{}, err
+	}
+	todo := fromDBItem(dbTodo)
+	s.Publish(pubsub.CreatedEvent, todo)
+	return todo, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (Todo, error) {
+	dbTodo, err := s.q.GetTodo(ctx, id)
+	if err != nil {
+		return Todo{}, err
+	}
+	return fromDBItem(dbTodo), nil
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Todo, error) {
+	dbTodos, err := s.q.ListTodosBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	todos := make([]Todo, len(dbTodos))
+	for i, dbTodo := range dbTodos {
+		todos[i] = fromDBItem(dbTodo)
+	}
+	return todos, nil
+}
+
+func (s *service) Save(ctx context.Context, todo Todo) (Todo, error) {
+	if !todo.Status.Valid() {
+		return Todo{}, fmt.Errorf("invalid todo status: %s", todo.Status)
+	}
+	dbTodo, err := s.q.UpdateTodo(ctx, db.UpdateTodoParams{
+		ID:       todo.ID,
+		Content:  todo.Content,
+		Status:   string(todo.Status),
+		Position: todo.Position,
+	})
+	if err != nil {
+		return Todo{}, err
+	}
+	todo = fromDBItem(dbTodo)
+	s.Publish(pubsub.UpdatedEvent, todo)
+	return todo, nil
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	todo, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.q.DeleteTodo(ctx, id); err != nil {
+		return err
+	}
+	s.Publish(pubsub.DeletedEvent, todo)
+	return nil
+}
+
+func (s *service) DeleteSessionTodos(ctx context.Context, sessionID string) error {
+	todos, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := s.q.DeleteSessionTodos(ctx, sessionID); err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		s.Publish(pubsub.DeletedEvent, todo)
+	}
+	return nil
+}
+
+func fromDBItem(item db.Todo) Todo {
+	return Todo{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		Content:   item.Content,
+		Status:    Status(item.Status),
+		Position:  item.Position,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+// Open returns the items that are not completed yet.
+func Open(todos []Todo) []Todo {
+	var open []Todo
+	for _, todo := range todos {
+		if todo.Status != StatusCompleted {
+			open = append(open, todo)
+		}
+	}
+	return open
+}
+
+// Markdown renders the items as a markdown checklist. Items in progress are
+// marked as such, and IDs are included when withIDs is set so the agent can
+// refer to them.
+func Markdown(todos []Todo, withIDs bool) string {
+	var sb strings.Builder
+	for _, todo := range todos {
+		check := " "
+		if todo.Status == StatusCompleted {
+			check = "x"
+		}
+		fmt.Fprintf(&sb, "- [%s] %s", check, todo.Content)
+		if todo.Status == StatusInProgress {
+			sb.WriteString(" (in progress)")
+		}
+		if withIDs {
+			fmt.Fprintf(&sb, " (id: %s)", todo.ID)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,30 @@
+package todo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	todos := []Todo{
+		{ID: "1", Content: "Read the code", Status: StatusCompleted},
+		{ID: "2", Content: "Write the fix", Status: StatusInProgress},
+		{ID: "3", Content: "Run the tests", Status: StatusPending},
+	}
+
+	require.Equal(t, "- [x] Read the code\n- [ ] Write the fix (in progress)\n- [ ] Run the tests\n", Markdown(todos, false))
+	require.Equal(t, "- [ ] Run the tests (id: 3)\n", Markdown(todos[2:], true))
+	require.Equal(t, todos[1:], Open(todos))
+}
+
+func TestStatusValid(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, StatusPending.Valid())
+	require.True(t, StatusInProgress.Valid())
+	require.True(t, StatusCompleted.Valid())
+	require.False(t, Status("done").Valid())
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -30,6 +31,12 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// MessagesDeletedMsg is sent once messages of a session are deleted, as when
+// a turn is retried, so the list shows what is left.
+type MessagesDeletedMsg struct {
+	SessionID string
+}
+
 type SelectionCopyMsg struct {
 	clickCount   int
 	endSelection bool
@@ -52,6 +59,9 @@ type MessageListCmp interface {
 	GoToBottom() tea.Cmd
 	GetSelectedText() string
 	CopySelectedText(bool) tea.Cmd
+	OpenSearch() tea.Cmd
+	IsSearching() bool
+	HasSearch() bool
 }
 
 // messageListCmp implements MessageListCmp, providing a virtualized list
@@ -73,6 +83,8 @@ type messageListCmp struct {
 	lastClickY    int
 	clickCount    int
 	promptQueue   int
+
+	search search
 }
 
 // New creates a new message list component with custom keybindings
@@ -92,6 +104,7 @@ func New(app *app.App) MessageListCmp {
 		listCmp:           listCmp,
 		previousSelected:  "",
 		defaultListKeyMap: defaultListKeyMap,
+		search:            search{current: NotFound},
 	}
 }
 
@@ -112,6 +125,11 @@ func (m *messageListCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.search.editing {
+			_, cmd := m.handleSearchKey(msg)
+			cmds = append(cmds, cmd)
+			return m, tea.Batch(cmds...)
+		}
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
 			case key.Matches(msg, messages.CopyKey):
@@ -122,6 +140,12 @@ func (m *messageListCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Batch(cmds...)
 			}
 		}
+		if m.search.active() || key.Matches(msg, SearchKey, ToolErrorsKey) {
+			if handled, cmd := m.handleSearchKey(msg); handled {
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
+			}
+		}
 	case tea.MouseClickMsg:
 		x := msg.X - 1 // Adjust for padding
 		y := msg.Y - 1 // Adjust for padding
@@ -197,14 +221,22 @@ func (m *messageListCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, m.SetSession(msg))
 		}
 		return m, tea.Batch(cmds...)
+	case MessagesDeletedMsg:
+		if msg.SessionID == m.session.ID {
+			cmds = append(cmds, m.loadMessages())
+		}
+		return m, tea.Batch(cmds...)
 	case SessionClearedMsg:
 		m.session = session.Session{}
+		m.resetSearch()
 		cmds = append(cmds, m.listCmp.SetItems([]list.Item{}))
 		return m, tea.Batch(cmds...)
 
 	case pubsub.Event[message.Message]:
 		cmds = append(cmds, m.handleMessageEvent(msg))
 		return m, tea.Batch(cmds...)
+	case image.LaidOutMsg:
+		return m, m.handleImageLayout(msg)
 
 	case tea.MouseWheelMsg:
 		u, cmd := m.listCmp.Update(msg)
@@ -226,6 +258,9 @@ func (m *messageListCmp) View() string {
 	if m.promptQueue > 0 {
 		height -= 4 // pill height and padding
 	}
+	if m.search.active() {
+		height -= searchHeight
+	}
 	view := []string{
 		t.S().Base.
 			Padding(1, 1, 0, 1).
@@ -235,6 +270,9 @@ func (m *messageListCmp) View() string {
 				m.listCmp.View(),
 			),
 	}
+	if m.search.active() {
+		view = append(view, m.searchView())
+	}
 	if m.app.CoderAgent != nil && m.promptQueue > 0 {
 		queuePill := queuePill(m.promptQueue, t)
 		view = append(view, t.S().Base.PaddingLeft(4).PaddingTop(1).Render(queuePill))
@@ -341,6 +379,25 @@ func (m *messageListCmp) handleMessageEvent(event pubsub.Event[message.Message])
 	return nil
 }
 
+// handleImageLayout hands the layout of an image to the item showing it, and
+// draws the item again.
+func (m *messageListCmp) handleImageLayout(msg image.LaidOutMsg) tea.Cmd {
+	type imageItem interface {
+		list.Item
+		UpdateImage(image.LaidOutMsg) (bool, tea.Cmd)
+	}
+	for _, item := range m.listCmp.Items() {
+		i, ok := item.(imageItem)
+		if !ok {
+			continue
+		}
+		if changed, cmd := i.UpdateImage(msg); changed {
+			return tea.Batch(cmd, m.listCmp.UpdateItem(i.ID(), i))
+		}
+	}
+	return nil
+}
+
 // messageExists checks if a message with the given ID already exists in the list.
 func (m *messageListCmp) messageExists(messageID string) bool {
 	items := m.listCmp.Items()
@@ -374,15 +431,18 @@ func (m *messageListCmp) handleNewUserMessage(msg message.Message) tea.Cmd {
 
 // handleToolMessage updates existing tool calls with their results.
 func (m *messageListCmp) handleToolMessage(msg message.Message) tea.Cmd {
+	var cmds []tea.Cmd
 	items := m.listCmp.Items()
 	for _, tr := range msg.ToolResults() {
 		if toolCallIndex := m.findToolCallByID(items, tr.ToolCallID); toolCallIndex != NotFound {
 			toolCall := items[toolCallIndex].(messages.ToolCallCmp)
 			toolCall.SetToolResult(tr)
+			// Lay out the images the tool returned.
+			cmds = append(cmds, toolCall.SetSize(toolCall.GetSize()))
 			m.listCmp.UpdateItem(toolCall.ID(), toolCall)
 		}
 	}
-	return nil
+	return tea.Batch(cmds...)
 }
 
 // findToolCallByID searches for a tool call with the specified ID.
@@ -541,7 +601,19 @@ func (m *messageListCmp) SetSession(session session.Session) tea.Cmd {
 	}
 
 	m.session = session
-	sessionMessages, err := m.app.Messages.List(context.Background(), session.ID)
+	m.resetSearch()
+	return m.loadMessages()
+}
+
+// resetSearch drops the search, as when the session changes.
+func (m *messageListCmp) resetSearch() {
+	m.search = search{current: NotFound}
+	m.listCmp.SetHighlight("")
+}
+
+// loadMessages loads and displays the messages of the session.
+func (m *messageListCmp) loadMessages() tea.Cmd {
+	sessionMessages, err := m.app.Messages.List(context.Background(), m.session.ID)
 	if err != nil {
 		return util.ReportError(err)
 	}
@@ -611,8 +683,8 @@ func (m *messageListCmp) convertAssistantMessage(msg message.Message, toolResult
 	for _, tc := range msg.ToolCalls() {
 		options := m.buildToolCallOptions(tc, msg, toolResultMap)
 		uiMessages = append(uiMessages, messages.NewToolCallCmp(msg.ID, tc, m.app.Permissions, options...))
-		// If this tool call is the agent tool, fetch nested tool calls
-		if tc.Name == agent.AgentToolName {
+		// If this tool call runs a sub-agent, fetch nested tool calls
+		if agent.IsAgentToolName(tc.Name) {
 			nestedMessages, _ := m.app.Messages.List(context.Background(), tc.ID)
 			nestedToolResultMap := m.buildToolResultMap(nestedMessages)
 			nestedUIMessages := m.convertMessagesToUI(nestedMessages, nestedToolResultMap)
@@ -656,6 +728,9 @@ func (m *messageListCmp) GetSize() (int, int) {
 func (m *messageListCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = width
 	m.height = height
+	if m.search.active() {
+		height -= searchHeight
+	}
 	if m.promptQueue > 0 {
 		queueHeight := 3 + 1 // 1 for padding top
 		lHight := max(0, height-(1+queueHeight))
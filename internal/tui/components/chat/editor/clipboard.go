@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const clipboardTimeout = 5 * time.Second
+
+// errNoClipboardImage is returned when the clipboard has no image, or when
+// no tool to read it is installed.
+var errNoClipboardImage = errors.New("no image in the clipboard")
+
+// pasteFromClipboard attaches the image of the clipboard, or else pastes its
+// text, which attaches it if it's the path of a file.
+func pasteFromClipboard() tea.Msg {
+	data, err := readClipboardImage()
+	if err == nil {
+		if int64(len(data)) > filepicker.MaxAttachmentSize {
+			return util.ReportError(fmt.Errorf("image too large, max 5MB"))()
+		}
+		name := fmt.Sprintf("clipboard-%s.png", time.Now().Format("20060102-150405"))
+		return filepicker.FilePickedMsg{
+			Attachment: message.Attachment{
+				FilePath: name,
+				FileName: name,
+				MimeType: http.DetectContentType(data),
+				Content:  data,
+			},
+		}
+	}
+
+	text, err := clipboard.ReadAll()
+	if err != nil {
+		return util.ReportError(fmt.Errorf("unable to read the clipboard: %w", err))()
+	}
+	return tea.PasteMsg(text)
+}
+
+// readClipboardImage reads a PNG image from the clipboard with the tools of
+// the platform, as the clipboard package only supports text.
+func readClipboardImage() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+	defer cancel()
+
+	var commands [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		return readClipboardImageDarwin(ctx)
+	case "windows":
+		commands = [][]string{{
+			"powershell", "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; " +
+				"$img = [System.Windows.Forms.Clipboard]::GetImage(); " +
+				"if ($img -eq $null) { exit 1 }; " +
+				"$ms = New-Object System.IO.MemoryStream; " +
+				"$img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png); " +
+				"[Console]::OpenStandardOutput().Write($ms.ToArray(), 0, $ms.Length)",
+		}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			commands = append(commands, []string{"wl-paste", "--no-newline", "--type", "image/png"})
+		}
+		commands = append(commands, []string{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"})
+	}
+
+	for _, args := range commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		if err == nil && isPNG(out) {
+			return out, nil
+		}
+	}
+	return nil, errNoClipboardImage
+}
+
+// readClipboardImageDarwin writes the image of the clipboard to a temporary
+// file with AppleScript, which can't write binary data to its output.
+func readClipboardImageDarwin(ctx context.Context) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "crush-clipboard")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "clipboard.png")
+
+	script := fmt.Sprintf(`set f to open for access POSIX file %q with write permission
+try
+	write (the clipboard as «class PNGf») to f
+	close access f
+on error
+	close access f
+	error number 1
+end try`, path)
+	if err := exec.CommandContext(ctx, "osascript", "-e", script).Run(); err != nil {
+		return nil, errNoClipboardImage
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !isPNG(data) {
+		return nil, errNoClipboardImage
+	}
+	return data, nil
+}
+
+func isPNG(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n"))
+}
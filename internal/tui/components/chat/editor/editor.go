@@ -2,22 +2,24 @@ package editor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
-	"net/http"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/v2/key"
 	"github.com/charmbracelet/bubbles/v2/textarea"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/llm/agent"
+	"github.com/charmbracelet/crush/internal/mention"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
@@ -26,6 +28,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/prompthistory"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -40,6 +43,7 @@ type Editor interface {
 	layout.Positional
 
 	SetSession(session session.Session) tea.Cmd
+	SetPlanMode(enabled bool)
 	IsCompletionsOpen() bool
 	HasAttachments() bool
 	Cursor() *tea.Cursor
@@ -49,6 +53,15 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+type ResourceCompletionItem struct {
+	Resource agent.MCPResource
+}
+
+// MentionCompletionItem is a file, folder, symbol or session to mention.
+type MentionCompletionItem struct {
+	Mention string // The mention, without the @
+}
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -58,15 +71,29 @@ type editorCmp struct {
 	textarea           *textarea.Model
 	attachments        []message.Attachment
 	deleteMode         bool
+	planMode           bool
 	readyPlaceholder   string
 	workingPlaceholder string
 
 	keyMap EditorKeyMap
 
-	// File path completions
+	// File path and mention completions
 	currentQuery          string
 	completionsStartIndex int
+	completionsPrefix     string
 	isCompletionsOpen     bool
+
+	// Prompt history, most recent first, loaded when the editor starts.
+	// historyIndex is the recalled prompt, or -1 while editing a new one,
+	// which is kept in historyDraft.
+	history      []string
+	historyIndex int
+	historyDraft string
+
+	// Mentions of the prompt, found again only when mentionsText, the text
+	// they were found in, changes.
+	mentionsCache []mention.Mention
+	mentionsText  string
 }
 
 var DeleteKeyMaps = DeleteAttachmentKeyMaps{
@@ -86,55 +113,38 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 
 const (
 	maxAttachments = 5
+	// maxSymbolCompletions is the maximum number of workspace symbols
+	// listed when completing mentions.
+	maxSymbolCompletions = 200
+
+	listMentionsTimeout    = 3 * time.Second
+	resolveMentionsTimeout = time.Minute
+	promptHistoryTimeout   = 5 * time.Second
 )
 
 type OpenEditorMsg struct {
 	Text string
 }
 
-func (m *editorCmp) openEditor(value string) tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		// Use platform-appropriate default editor
-		if runtime.GOOS == "windows" {
-			editor = "notepad"
-		} else {
-			editor = "nvim"
-		}
-	}
+// HistoryLoadedMsg carries the prompt history of the project, most recent
+// first.
+type HistoryLoadedMsg struct {
+	Prompts []string
+}
 
-	tmpfile, err := os.CreateTemp("", "msg_*.md")
-	if err != nil {
-		return util.ReportError(err)
-	}
-	defer tmpfile.Close() //nolint:errcheck
-	if _, err := tmpfile.WriteString(value); err != nil {
-		return util.ReportError(err)
-	}
-	c := exec.CommandContext(context.TODO(), editor, tmpfile.Name())
-	c.Stdin = os.Stdin
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	return tea.ExecProcess(c, func(err error) tea.Msg {
-		if err != nil {
-			return util.ReportError(err)
-		}
-		content, err := os.ReadFile(tmpfile.Name())
-		if err != nil {
-			return util.ReportError(err)
-		}
+func (m *editorCmp) openEditor(value string) tea.Cmd {
+	return util.OpenEditor("msg_*.md", value, func(content string) tea.Msg {
 		if len(content) == 0 {
-			return util.ReportWarn("Message is empty")
+			return util.ReportWarn("Message is empty")()
 		}
-		os.Remove(tmpfile.Name())
 		return OpenEditorMsg{
-			Text: strings.TrimSpace(string(content)),
+			Text: strings.TrimSpace(content),
 		}
 	})
 }
 
 func (m *editorCmp) Init() tea.Cmd {
-	return nil
+	return m.loadHistory()
 }
 
 func (m *editorCmp) send() tea.Cmd {
@@ -157,13 +167,33 @@ func (m *editorCmp) send() tea.Cmd {
 
 	// Change the placeholder when sending a new message.
 	m.randomizePlaceholders()
+	m.addToHistory(value)
+	sessionID := m.session.ID
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), promptHistoryTimeout)
+		defer cancel()
+		if err := m.app.Prompts.Add(ctx, sessionID, value); err != nil {
+			slog.Error("Failed to save the prompt to the history", "error", err)
+		}
 
-	return tea.Batch(
-		util.CmdHandler(chat.SendMsg{
+		// Resources typed by hand, or expanded from a template, are only
+		// read now, like the other mentions. The prompt is sent without the
+		// ones that fail, as the editor is already cleared.
+		resourceAttachments, resourceErr := readResourceMentions(value)
+		ctx, cancel = context.WithTimeout(context.Background(), resolveMentionsTimeout)
+		defer cancel()
+		mentionAttachments, mentionErr := m.mentionResolver().ResolveAll(ctx, value)
+		attachments = append(attachments, resourceAttachments...)
+		send := util.CmdHandler(chat.SendMsg{
 			Text:        value,
-			Attachments: attachments,
-		}),
-	)
+			Attachments: append(attachments, mentionAttachments...),
+		})
+		if err := errors.Join(resourceErr, mentionErr); err != nil {
+			return tea.BatchMsg{send, util.ReportWarn("Sent without some mentions: " + err.Error())}
+		}
+		return send()
+	}
 }
 
 func (m *editorCmp) repositionCompletions() tea.Msg {
@@ -179,9 +209,15 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.repositionCompletions
 	case filepicker.FilePickedMsg:
 		if len(m.attachments) >= maxAttachments {
-			return m, util.ReportError(fmt.Errorf("cannot add more than %d images", maxAttachments))
+			return m, util.ReportError(fmt.Errorf("cannot add more than %d attachments", maxAttachments))
 		}
 		m.attachments = append(m.attachments, msg.Attachment)
+		if strings.HasPrefix(msg.Attachment.MimeType, "image/") {
+			agentCfg := config.Get().PrimaryAgent()
+			if model := config.Get().GetModelByType(agentCfg.Model); model != nil && !model.SupportsImages {
+				return m, util.ReportWarn("Images are not supported by the current model and won't be sent: " + model.Name)
+			}
+		}
 		return m, nil
 	case completions.CompletionsOpenedMsg:
 		m.isCompletionsOpen = true
@@ -189,6 +225,7 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.isCompletionsOpen = false
 		m.currentQuery = ""
 		m.completionsStartIndex = 0
+		m.completionsPrefix = ""
 	case completions.SelectCompletionMsg:
 		if !m.isCompletionsOpen {
 			return m, nil
@@ -209,6 +246,46 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.completionsStartIndex = 0
 			}
 		}
+		if item, ok := msg.Value.(MentionCompletionItem); ok {
+			word := m.textarea.Word()
+			value := m.textarea.Value()
+			value = value[:m.completionsStartIndex] +
+				"@" + item.Mention +
+				value[m.completionsStartIndex+len(word):]
+			m.textarea.SetValue(value)
+			m.textarea.MoveToEnd()
+			if !msg.Insert {
+				m.isCompletionsOpen = false
+				m.currentQuery = ""
+				m.completionsStartIndex = 0
+				m.completionsPrefix = ""
+			}
+		}
+		if item, ok := msg.Value.(ResourceCompletionItem); ok {
+			word := m.textarea.Word()
+			value := m.textarea.Value()
+			mention := ""
+			if item.Resource.Template {
+				// Templates have to be expanded by hand before sending.
+				mention = agent.MCPResourceMention(item.Resource.Server, item.Resource.URI)
+			}
+			value = value[:m.completionsStartIndex] +
+				mention +
+				value[m.completionsStartIndex+len(word):]
+			m.textarea.SetValue(value)
+			m.textarea.MoveToEnd()
+			m.isCompletionsOpen = false
+			m.currentQuery = ""
+			m.completionsStartIndex = 0
+			m.completionsPrefix = ""
+			if !item.Resource.Template {
+				return m, tea.Batch(
+					util.CmdHandler(completions.CloseCompletionsMsg{}),
+					attachResource(item.Resource),
+				)
+			}
+			return m, util.CmdHandler(completions.CloseCompletionsMsg{})
+		}
 
 	case commands.OpenExternalEditorMsg:
 		if m.app.CoderAgent.IsSessionBusy(m.session.ID) {
@@ -218,43 +295,22 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case OpenEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case HistoryLoadedMsg:
+		m.setHistory(msg.Prompts)
+		return m, nil
+	case prompthistory.PromptSelectedMsg:
+		m.historyIndex = -1
+		m.textarea.SetValue(msg.Prompt)
+		m.textarea.MoveToEnd()
+		return m, nil
 	case tea.PasteMsg:
-		path := strings.ReplaceAll(string(msg), "\\ ", " ")
-		// try to get an image
-		path, err := filepath.Abs(strings.TrimSpace(path))
-		if err != nil {
-			m.textarea, cmd = m.textarea.Update(msg)
-			return m, cmd
-		}
-		isAllowedType := false
-		for _, ext := range filepicker.AllowedTypes {
-			if strings.HasSuffix(path, ext) {
-				isAllowedType = true
-				break
-			}
-		}
-		if !isAllowedType {
-			m.textarea, cmd = m.textarea.Update(msg)
-			return m, cmd
+		if attachment, ok := pastedAttachment(string(msg)); ok {
+			return m, util.CmdHandler(filepicker.FilePickedMsg{
+				Attachment: attachment,
+			})
 		}
-		tooBig, _ := filepicker.IsFileTooBig(path, filepicker.MaxAttachmentSize)
-		if tooBig {
-			m.textarea, cmd = m.textarea.Update(msg)
-			return m, cmd
-		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			m.textarea, cmd = m.textarea.Update(msg)
-			return m, cmd
-		}
-		mimeBufferSize := min(512, len(content))
-		mimeType := http.DetectContentType(content[:mimeBufferSize])
-		fileName := filepath.Base(path)
-		attachment := message.Attachment{FilePath: path, FileName: fileName, MimeType: mimeType, Content: content}
-		return m, util.CmdHandler(filepicker.FilePickedMsg{
-			Attachment: attachment,
-		})
+		m.textarea, cmd = m.textarea.Update(msg)
+		return m, cmd
 
 	case commands.ToggleYoloModeMsg:
 		m.setEditorPrompt()
@@ -270,7 +326,15 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.isCompletionsOpen = true
 			m.currentQuery = ""
 			m.completionsStartIndex = curIdx
+			m.completionsPrefix = "/"
 			cmds = append(cmds, m.startCompletions)
+		case msg.String() == "@" && !m.isCompletionsOpen &&
+			(len(m.textarea.Value()) == 0 || unicode.IsSpace(rune(m.textarea.Value()[len(m.textarea.Value())-1]))):
+			m.isCompletionsOpen = true
+			m.currentQuery = ""
+			m.completionsStartIndex = curIdx
+			m.completionsPrefix = "@"
+			cmds = append(cmds, m.startMentionCompletions)
 		case m.isCompletionsOpen && curIdx <= m.completionsStartIndex:
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
@@ -278,6 +342,17 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.deleteMode = true
 			return m, nil
 		}
+		if key.Matches(msg, m.keyMap.HistorySearch) && !m.deleteMode {
+			return m, m.openHistorySearch()
+		}
+		if !m.isCompletionsOpen && !m.deleteMode {
+			if key.Matches(msg, m.keyMap.HistoryPrevious) && m.onFirstLine() && m.recallPrompt(1) {
+				return m, nil
+			}
+			if key.Matches(msg, m.keyMap.HistoryNext) && m.onLastLine() && m.recallPrompt(-1) {
+				return m, nil
+			}
+		}
 		if key.Matches(msg, DeleteKeyMaps.DeleteAllAttachments) && m.deleteMode {
 			m.deleteMode = false
 			m.attachments = nil
@@ -296,6 +371,9 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		}
+		if key.Matches(msg, m.keyMap.Paste) {
+			return m, pasteFromClipboard
+		}
 		if key.Matches(msg, m.keyMap.OpenEditor) {
 			if m.app.CoderAgent.IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
@@ -336,7 +414,8 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 			} else {
 				word := m.textarea.Word()
-				if strings.HasPrefix(word, "/") {
+				if strings.HasPrefix(word, "/") && m.completionsPrefix != "@" ||
+					strings.HasPrefix(word, "@") && m.completionsPrefix == "@" {
 					// XXX: wont' work if editing in the middle of the field.
 					m.completionsStartIndex = strings.LastIndex(m.textarea.Value(), word)
 					m.currentQuery = word[1:]
@@ -364,7 +443,96 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// loadHistory loads the prompt history off the update loop.
+func (m *editorCmp) loadHistory() tea.Cmd {
+	prompts := m.app.Prompts
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), promptHistoryTimeout)
+		defer cancel()
+		history, err := prompts.List(ctx)
+		if err != nil {
+			slog.Error("Failed to load the prompt history", "error", err)
+			return nil
+		}
+		return HistoryLoadedMsg{Prompts: history}
+	}
+}
+
+// setHistory takes the loaded prompt history, keeping the prompts sent
+// before it was loaded, which may or may not be saved yet.
+func (m *editorCmp) setHistory(prompts []string) {
+	history := make([]string, 0, len(m.history)+len(prompts))
+	seen := make(map[string]bool)
+	for _, prompt := range slices.Concat(m.history, prompts) {
+		if !seen[prompt] {
+			seen[prompt] = true
+			history = append(history, prompt)
+		}
+	}
+	m.history = history
+}
+
+// addToHistory puts a sent prompt at the top of the history, unless the
+// history doesn't keep it, such as when it looks like it contains a secret.
+func (m *editorCmp) addToHistory(prompt string) {
+	if !m.app.Prompts.Keeps(prompt) {
+		return
+	}
+	m.history = slices.DeleteFunc(m.history, func(p string) bool { return p == prompt })
+	m.history = slices.Insert(m.history, 0, prompt)
+	m.historyIndex = -1
+	m.historyDraft = ""
+}
+
+// recallPrompt replaces the prompt with an older one, for a positive step,
+// or a newer one, going back to the draft after the most recent. It reports
+// whether there was a prompt to recall.
+func (m *editorCmp) recallPrompt(step int) bool {
+	index := m.historyIndex + step
+	if index < -1 || index >= len(m.history) || index == m.historyIndex {
+		return false
+	}
+	if m.historyIndex == -1 {
+		m.historyDraft = m.textarea.Value()
+	}
+	m.historyIndex = index
+	if index == -1 {
+		m.textarea.SetValue(m.historyDraft)
+	} else {
+		m.textarea.SetValue(m.history[index])
+	}
+	if step > 0 {
+		// Keep going up from the first line.
+		m.textarea.MoveToBegin()
+	} else {
+		m.textarea.MoveToEnd()
+	}
+	return true
+}
+
+func (m *editorCmp) onFirstLine() bool {
+	return m.textarea.Line() == 0 && m.textarea.LineInfo().RowOffset == 0
+}
+
+func (m *editorCmp) onLastLine() bool {
+	info := m.textarea.LineInfo()
+	return m.textarea.Line() == m.textarea.LineCount()-1 && info.RowOffset == info.Height-1
+}
+
+func (m *editorCmp) openHistorySearch() tea.Cmd {
+	if len(m.history) == 0 {
+		return util.ReportInfo("No prompts in the history yet")
+	}
+	return util.CmdHandler(dialogs.OpenDialogMsg{
+		Model: prompthistory.NewPromptHistoryDialogCmp(m.history),
+	})
+}
+
 func (m *editorCmp) setEditorPrompt() {
+	if m.planMode {
+		m.textarea.SetPromptFunc(4, planPromptFunc)
+		return
+	}
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.SetPromptFunc(4, yoloPromptFunc)
 		return
@@ -415,7 +583,7 @@ func (m *editorCmp) randomizePlaceholders() {
 func (m *editorCmp) View() string {
 	t := styles.CurrentTheme()
 	// Update placeholder
-	if m.app.CoderAgent != nil && m.app.CoderAgent.IsBusy() {
+	if m.app.CoderAgent != nil && m.app.CoderAgent.IsSessionBusy(m.session.ID) {
 		m.textarea.Placeholder = m.workingPlaceholder
 	} else {
 		m.textarea.Placeholder = m.readyPlaceholder
@@ -423,7 +591,10 @@ func (m *editorCmp) View() string {
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.Placeholder = "Yolo mode!"
 	}
-	if len(m.attachments) == 0 {
+	if m.planMode {
+		m.textarea.Placeholder = "Plan mode: describe what you want to change"
+	}
+	if len(m.attachments) == 0 && len(m.mentions()) == 0 {
 		content := t.S().Base.Padding(1).Render(
 			m.textarea.View(),
 		)
@@ -450,6 +621,29 @@ func (m *editorCmp) GetSize() (int, int) {
 	return m.textarea.Width(), m.textarea.Height()
 }
 
+// pastedAttachment returns the attachment of a pasted path to an image or a
+// PDF. Other files are pasted as their path, as pasting a path is just as
+// often meant to mention it, and their content can be attached with @.
+func pastedAttachment(text string) (message.Attachment, bool) {
+	path := strings.TrimSpace(strings.ReplaceAll(text, "\\ ", " "))
+	if path == "" || strings.ContainsAny(path, "\n\r") {
+		return message.Attachment{}, false
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if !slices.Contains(filepicker.ImageTypes, ext) && ext != ".pdf" {
+		return message.Attachment{}, false
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return message.Attachment{}, false
+	}
+	attachment, err := filepicker.NewAttachment(path)
+	if err != nil {
+		return message.Attachment{}, false
+	}
+	return attachment, true
+}
+
 func (m *editorCmp) attachmentsContent() string {
 	var styledAttachments []string
 	t := styles.CurrentTheme()
@@ -460,15 +654,23 @@ func (m *editorCmp) attachmentsContent() string {
 	for i, attachment := range m.attachments {
 		var filename string
 		if len(attachment.FileName) > 10 {
-			filename = fmt.Sprintf(" %s %s...", styles.DocumentIcon, attachment.FileName[0:7])
+			filename = fmt.Sprintf(" %s %s...", styles.AttachmentIcon(attachment.MimeType), attachment.FileName[0:7])
 		} else {
-			filename = fmt.Sprintf(" %s %s", styles.DocumentIcon, attachment.FileName)
+			filename = fmt.Sprintf(" %s %s", styles.AttachmentIcon(attachment.MimeType), attachment.FileName)
 		}
 		if m.deleteMode {
 			filename = fmt.Sprintf("%d%s", i, filename)
 		}
 		styledAttachments = append(styledAttachments, attachmentStyles.Render(filename))
 	}
+	mentionStyles := attachmentStyles.Background(t.Secondary).Foreground(t.BgBase)
+	for _, mention := range m.mentions() {
+		label := mention.String()
+		if len(label) > 20 {
+			label = label[0:17] + "..."
+		}
+		styledAttachments = append(styledAttachments, mentionStyles.Render(" @"+label))
+	}
 	content := lipgloss.JoinHorizontal(lipgloss.Left, styledAttachments...)
 	return content
 }
@@ -501,6 +703,75 @@ func (m *editorCmp) startCompletions() tea.Msg {
 	}
 }
 
+func (m *editorCmp) startMentionCompletions() tea.Msg {
+	files, _, _ := fsext.ListDirectory(".", nil, 0)
+	slices.Sort(files)
+	resources := agent.ListMCPResources()
+	completionItems := make([]completions.Completion, 0, len(files)+len(resources))
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "./")
+		completionItems = append(completionItems, completions.Completion{
+			Title: file,
+			Value: MentionCompletionItem{Mention: file},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), listMentionsTimeout)
+	defer cancel()
+	if m.app.Sessions != nil {
+		sessions, _ := m.app.Sessions.List(ctx)
+		for _, sess := range sessions {
+			if sess.ID == m.session.ID || sess.ParentSessionID != "" {
+				continue
+			}
+			completionItems = append(completionItems, completions.Completion{
+				Title: mention.SessionPrefix + sess.Title,
+				Value: MentionCompletionItem{Mention: mention.SessionPrefix + sess.ID},
+			})
+		}
+	}
+	for _, name := range m.mentionResolver().Symbols(ctx, "", maxSymbolCompletions) {
+		completionItems = append(completionItems, completions.Completion{
+			Title: mention.SymbolPrefix + name,
+			Value: MentionCompletionItem{Mention: mention.SymbolPrefix + name},
+		})
+	}
+
+	for _, resource := range resources {
+		completionItems = append(completionItems, completions.Completion{
+			Title: resource.Server + ":" + resource.Name,
+			Value: ResourceCompletionItem{
+				Resource: resource,
+			},
+		})
+	}
+
+	x, y := m.completionsPosition()
+	return completions.OpenCompletionsMsg{
+		Completions: completionItems,
+		X:           x,
+		Y:           y,
+	}
+}
+
+// mentions returns the mentions of the prompt, which are shown as chips.
+func (m *editorCmp) mentions() []mention.Mention {
+	if value := m.textarea.Value(); value != m.mentionsText {
+		m.mentionsCache = mention.Find(value, config.Get().WorkingDir())
+		m.mentionsText = value
+	}
+	return m.mentionsCache
+}
+
+func (m *editorCmp) mentionResolver() *mention.Resolver {
+	return &mention.Resolver{
+		WorkingDir: config.Get().WorkingDir(),
+		LSPClients: m.app.LSPClients,
+		Sessions:   m.app.Sessions,
+		Messages:   m.app.Messages,
+	}
+}
+
 // Blur implements Container.
 func (c *editorCmp) Blur() tea.Cmd {
 	c.textarea.Blur()
@@ -526,9 +797,19 @@ func (c *editorCmp) Bindings() []key.Binding {
 // we need to move some functionality to the page level
 func (c *editorCmp) SetSession(session session.Session) tea.Cmd {
 	c.session = session
+	if c.app.CoderAgent != nil {
+		c.SetPlanMode(c.app.CoderAgent.IsPlanMode(session.ID))
+	}
 	return nil
 }
 
+// SetPlanMode switches the prompt style to show whether the session is in
+// plan mode.
+func (c *editorCmp) SetPlanMode(enabled bool) {
+	c.planMode = enabled
+	c.setEditorPrompt()
+}
+
 func (c *editorCmp) IsCompletionsOpen() bool {
 	return c.isCompletionsOpen
 }
@@ -563,6 +844,20 @@ func yoloPromptFunc(info textarea.PromptInfo) string {
 	return fmt.Sprintf("%s ", t.YoloDotsBlurred)
 }
 
+func planPromptFunc(info textarea.PromptInfo) string {
+	t := styles.CurrentTheme()
+	if info.LineNumber == 0 {
+		if info.Focused {
+			return fmt.Sprintf("%s ", t.PlanIconFocused)
+		}
+		return fmt.Sprintf("%s ", t.PlanIconBlurred)
+	}
+	if info.Focused {
+		return fmt.Sprintf("%s ", t.PlanDotsFocused)
+	}
+	return fmt.Sprintf("%s ", t.PlanDotsBlurred)
+}
+
 func New(app *app.App) Editor {
 	t := styles.CurrentTheme()
 	ta := textarea.New()
@@ -573,9 +868,10 @@ func New(app *app.App) Editor {
 	ta.Focus()
 	e := &editorCmp{
 		// TODO: remove the app instance from here
-		app:      app,
-		textarea: ta,
-		keyMap:   DefaultEditorKeyMap(),
+		app:          app,
+		textarea:     ta,
+		keyMap:       DefaultEditorKeyMap(),
+		historyIndex: -1,
 	}
 	e.setEditorPrompt()
 
@@ -9,6 +9,11 @@ type EditorKeyMap struct {
 	SendMessage key.Binding
 	OpenEditor  key.Binding
 	Newline     key.Binding
+	Paste       key.Binding
+
+	HistoryPrevious key.Binding
+	HistoryNext     key.Binding
+	HistorySearch   key.Binding
 }
 
 func DefaultEditorKeyMap() EditorKeyMap {
@@ -32,6 +37,22 @@ func DefaultEditorKeyMap() EditorKeyMap {
 			// to reflect that.
 			key.WithHelp("ctrl+j", "newline"),
 		),
+		Paste: key.NewBinding(
+			key.WithKeys("ctrl+v"),
+			key.WithHelp("ctrl+v", "paste image or text"),
+		),
+		HistoryPrevious: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous prompt"),
+		),
+		HistoryNext: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next prompt"),
+		),
+		HistorySearch: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "search prompt history"),
+		),
 	}
 }
 
@@ -42,6 +63,10 @@ func (k EditorKeyMap) KeyBindings() []key.Binding {
 		k.SendMessage,
 		k.OpenEditor,
 		k.Newline,
+		k.Paste,
+		k.HistoryPrevious,
+		k.HistoryNext,
+		k.HistorySearch,
 		AttachmentsKeyMaps.AttachmentDeleteMode,
 		AttachmentsKeyMaps.DeleteAllAttachments,
 		AttachmentsKeyMaps.Escape,
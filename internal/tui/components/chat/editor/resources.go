@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/llm/agent"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const readResourceTimeout = 30 * time.Second
+
+// attachResource reads an MCP resource and attaches it to the prompt.
+func attachResource(resource agent.MCPResource) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), readResourceTimeout)
+		defer cancel()
+		attachment, err := readResource(ctx, resource.Server, resource.URI, resource.Name)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return filepicker.FilePickedMsg{Attachment: attachment}
+	}
+}
+
+// readResourceMentions reads the MCP resources mentioned as @server:uri in
+// the prompt.
+func readResourceMentions(value string) ([]message.Attachment, error) {
+	var (
+		attachments []message.Attachment
+		errs        []error
+	)
+	seen := make(map[string]bool)
+	for word := range strings.FieldsSeq(value) {
+		server, uri, ok := agent.ParseMCPResourceMention(word)
+		if !ok || seen[word] {
+			continue
+		}
+		seen[word] = true
+		ctx, cancel := context.WithTimeout(context.Background(), readResourceTimeout)
+		attachment, err := readResource(ctx, server, uri, uri)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, errors.Join(errs...)
+}
+
+// readResource reads an MCP resource as an attachment. The text contents
+// are joined, while an image is attached as it is.
+func readResource(ctx context.Context, server, uri, name string) (message.Attachment, error) {
+	contents, err := agent.ReadMCPResource(ctx, server, uri)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	attachment := message.Attachment{
+		FilePath: fmt.Sprintf("%s:%s", server, uri),
+		FileName: name,
+	}
+	for _, content := range contents {
+		if !content.IsText() {
+			if strings.HasPrefix(content.MIMEType, "image/") && len(contents) == 1 {
+				attachment.MimeType = content.MIMEType
+				attachment.Content = content.Blob
+				return attachment, nil
+			}
+			continue
+		}
+		attachment.MimeType = content.MIMEType
+		if len(attachment.Content) > 0 {
+			attachment.Content = append(attachment.Content, '\n')
+		}
+		attachment.Content = append(attachment.Content, content.Text...)
+	}
+	if attachment.MimeType == "" {
+		return message.Attachment{}, fmt.Errorf("resource %s has no content that can be attached", uri)
+	}
+	return attachment, nil
+}
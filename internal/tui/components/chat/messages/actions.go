@@ -0,0 +1,460 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const ActionsDialogID dialogs.DialogID = "message_actions"
+
+// ActionsKey opens the actions of the selected message.
+var ActionsKey = key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "actions"))
+
+// RetryMsg asks to run the turn of a message again, from the prompt that
+// started it.
+type RetryMsg struct {
+	SessionID string
+	MessageID string
+}
+
+// CodeBlock is a fenced code block of a message.
+type CodeBlock struct {
+	Language string
+	Code     string
+}
+
+// CodeBlocks returns the fenced code blocks of markdown, in order. A block
+// left open runs to the end, like in CommonMark.
+func CodeBlocks(markdown string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var fence string
+	var lines []string
+	for line := range strings.SplitSeq(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current == nil {
+			marker, info, ok := openingFence(trimmed)
+			if !ok {
+				continue
+			}
+			current = &CodeBlock{}
+			if fields := strings.Fields(info); len(fields) > 0 {
+				current.Language = fields[0]
+			}
+			fence, lines = marker, nil
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if current != nil {
+		current.Code = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// openingFence returns the fence and the info string of a line opening a code
+// block.
+func openingFence(line string) (string, string, bool) {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n], strings.TrimSpace(line[n:]), true
+		}
+	}
+	return "", "", false
+}
+
+type actionKind int
+
+const (
+	actionCopy actionKind = iota
+	actionCopyBlock
+	actionSaveBlock
+	actionEdit
+	actionViewImages
+	actionRetry
+)
+
+type messageAction struct {
+	kind  actionKind
+	block int
+}
+
+// ActionsDialog lists what can be done with a message: copying it or one of its
+// code blocks, saving a code block, opening the message in $EDITOR and running
+// its turn again.
+type ActionsDialog interface {
+	dialogs.DialogModel
+}
+
+type ActionsList = list.FilterableList[list.CompletionItem[messageAction]]
+
+type actionsDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	message message.Message
+	blocks  []CodeBlock
+	keyMap  actionsKeyMap
+	actions ActionsList
+	help    help.Model
+
+	// saving is the code block whose path is being typed, if any.
+	saving int
+	path   textinput.Model
+}
+
+// NewActionsDialogCmp creates the actions of a message.
+func NewActionsDialogCmp(msg message.Message) ActionsDialog {
+	t := styles.CurrentTheme()
+	keyMap := defaultActionsKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	blocks := CodeBlocks(msg.Content().Text)
+	items := []list.CompletionItem[messageAction]{
+		list.NewCompletionItem("Copy message as markdown", messageAction{kind: actionCopy}, list.WithCompletionID("copy")),
+	}
+	for i, block := range blocks {
+		label := blockLabel(i, block)
+		items = append(items,
+			list.NewCompletionItem("Copy "+label, messageAction{kind: actionCopyBlock, block: i}, list.WithCompletionID("copy_block_"+strconv.Itoa(i))),
+			list.NewCompletionItem("Save "+label+" to a file", messageAction{kind: actionSaveBlock, block: i}, list.WithCompletionID("save_block_"+strconv.Itoa(i))),
+		)
+	}
+	items = append(items,
+		list.NewCompletionItem("Open in editor", messageAction{kind: actionEdit}, list.WithCompletionID("edit")),
+	)
+	if len(attachedImages(msg)) > 0 {
+		items = append(items,
+			list.NewCompletionItem("View images", messageAction{kind: actionViewImages}, list.WithCompletionID("view_images")),
+		)
+	}
+	items = append(items,
+		list.NewCompletionItem("Retry", messageAction{kind: actionRetry}, list.WithCompletionID("retry")),
+	)
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	actions := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Choose an action"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+
+	path := textinput.New()
+	path.Placeholder = "Path of the file"
+	path.SetStyles(t.S().TextInput)
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &actionsDialogCmp{
+		message: msg,
+		blocks:  blocks,
+		keyMap:  keyMap,
+		actions: actions,
+		help:    help,
+		saving:  -1,
+		path:    path,
+	}
+}
+
+// blockLabel names a code block in the list of actions.
+func blockLabel(i int, block CodeBlock) string {
+	label := fmt.Sprintf("code block %d", i+1)
+	if block.Language != "" {
+		label += fmt.Sprintf(" (%s)", block.Language)
+	}
+	return label
+}
+
+func (a *actionsDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(a.actions.Init(), a.actions.Focus())
+}
+
+func (a *actionsDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.wWidth = msg.Width
+		a.wHeight = msg.Height
+		a.width = min(60, a.wWidth-8)
+		a.actions.SetInputWidth(a.listWidth() - 2)
+		a.path.SetWidth(a.listWidth() - 4)
+		return a, a.actions.SetSize(a.listWidth(), a.listHeight())
+	case tea.KeyPressMsg:
+		if a.saving >= 0 {
+			return a, a.updatePath(msg)
+		}
+		switch {
+		case key.Matches(msg, a.keyMap.Select):
+			selectedItem := a.actions.SelectedItem()
+			if selectedItem == nil {
+				return a, nil
+			}
+			return a, a.run((*selectedItem).Value())
+		case key.Matches(msg, a.keyMap.Close):
+			return a, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := a.actions.Update(msg)
+			a.actions = u.(ActionsList)
+			return a, cmd
+		}
+	}
+	return a, nil
+}
+
+// updatePath handles the keys while the path of a code block is typed.
+func (a *actionsDialogCmp) updatePath(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, a.keyMap.Close):
+		a.saving = -1
+		a.path.Blur()
+		return a.actions.Focus()
+	case key.Matches(msg, a.keyMap.Select):
+		path := strings.TrimSpace(a.path.Value())
+		if path == "" {
+			return util.ReportWarn("Type the path of the file")
+		}
+		block := a.blocks[a.saving]
+		return tea.Sequence(util.CmdHandler(dialogs.CloseDialogMsg{}), saveCodeBlock(path, block))
+	}
+	var cmd tea.Cmd
+	a.path, cmd = a.path.Update(msg)
+	return cmd
+}
+
+func (a *actionsDialogCmp) run(action messageAction) tea.Cmd {
+	closeDialog := util.CmdHandler(dialogs.CloseDialogMsg{})
+	switch action.kind {
+	case actionCopy:
+		return tea.Sequence(closeDialog, copyToClipboard(a.message.Content().Text, "Message copied to clipboard"))
+	case actionCopyBlock:
+		return tea.Sequence(closeDialog, copyToClipboard(a.blocks[action.block].Code, "Code block copied to clipboard"))
+	case actionSaveBlock:
+		a.saving = action.block
+		a.path.SetValue(suggestedFileName(a.blocks[action.block]))
+		a.path.CursorEnd()
+		return tea.Batch(a.actions.Blur(), a.path.Focus())
+	case actionEdit:
+		return tea.Sequence(closeDialog, openInEditor(a.message.Content().Text))
+	case actionViewImages:
+		return tea.Sequence(closeDialog, showImages(attachedImages(a.message)))
+	default:
+		return tea.Sequence(closeDialog, util.CmdHandler(RetryMsg{
+			SessionID: a.message.SessionID,
+			MessageID: a.message.ID,
+		}))
+	}
+}
+
+// copyToClipboard copies text with OSC 52, for terminals over SSH, and with
+// the system clipboard.
+func copyToClipboard(text, info string) tea.Cmd {
+	return tea.Sequence(
+		tea.SetClipboard(text),
+		func() tea.Msg {
+			_ = clipboard.WriteAll(text)
+			return nil
+		},
+		util.ReportInfo(info),
+	)
+}
+
+// suggestedFileName returns a file name for a code block, with the extension
+// of its language when known.
+func suggestedFileName(block CodeBlock) string {
+	extensions := map[string]string{
+		"bash": "sh", "shell": "sh", "sh": "sh", "zsh": "sh",
+		"go": "go", "python": "py", "py": "py", "javascript": "js", "js": "js",
+		"typescript": "ts", "ts": "ts", "tsx": "tsx", "jsx": "jsx", "rust": "rs",
+		"ruby": "rb", "java": "java", "c": "c", "cpp": "cpp", "json": "json",
+		"yaml": "yaml", "yml": "yaml", "toml": "toml", "sql": "sql", "html": "html",
+		"css": "css", "markdown": "md", "md": "md", "diff": "diff", "lua": "lua",
+	}
+	if ext, ok := extensions[strings.ToLower(block.Language)]; ok {
+		return "snippet." + ext
+	}
+	return "snippet.txt"
+}
+
+// saveCodeBlock writes a code block to path, relative to the working
+// directory. Existing files are left alone.
+func saveCodeBlock(path string, block CodeBlock) tea.Cmd {
+	return func() tea.Msg {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(config.Get().WorkingDir(), path)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return util.ReportError(fmt.Errorf("failed to create directory: %w", err))()
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			return util.ReportWarn(fmt.Sprintf("%s already exists", path))()
+		}
+		if err != nil {
+			return util.ReportError(fmt.Errorf("failed to create file: %w", err))()
+		}
+		defer file.Close() //nolint:errcheck
+		code := block.Code
+		if !strings.HasSuffix(code, "\n") {
+			code += "\n"
+		}
+		if _, err := file.WriteString(code); err != nil {
+			return util.ReportError(fmt.Errorf("failed to write file: %w", err))()
+		}
+		return util.ReportInfo(fmt.Sprintf("Code block saved to %s", path))()
+	}
+}
+
+// openInEditor opens the markdown of a message in $EDITOR. Changes aren't
+// read back, the message stays as it is.
+func openInEditor(content string) tea.Cmd {
+	return util.OpenEditor("message_*.md", content, nil)
+}
+
+func (a *actionsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	body := a.actions.View()
+	if a.saving >= 0 {
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Base.PaddingLeft(1).Render(fmt.Sprintf("Save %s to", blockLabel(a.saving, a.blocks[a.saving]))),
+			"",
+			t.S().Base.PaddingLeft(1).Render(a.path.View()),
+		)
+	}
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Message Actions", a.width-4)),
+		body,
+		"",
+		t.S().Base.Width(a.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(a.help.View(a.keyMap)),
+	)
+	return a.style().Render(content)
+}
+
+func (a *actionsDialogCmp) Cursor() *tea.Cursor {
+	row, col := a.Position()
+	if a.saving >= 0 {
+		cursor := a.path.Cursor()
+		if cursor != nil {
+			cursor.Y += row + 5 // Border, title and label
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	if cursor, ok := a.actions.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor.Y += row + 3 // Border + title
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (a *actionsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(a.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (a *actionsDialogCmp) listHeight() int {
+	return min(len(a.actions.Items())+2, a.wHeight/2-6) // 2 for the filter input
+}
+
+func (a *actionsDialogCmp) listWidth() int {
+	return a.width - 2 // 2 for the border
+}
+
+func (a *actionsDialogCmp) Position() (int, int) {
+	row := a.wHeight/4 - 2 // just a bit above the center
+	col := a.wWidth / 2
+	col -= a.width / 2
+	return row, col
+}
+
+// ID implements ActionsDialog.
+func (a *actionsDialogCmp) ID() dialogs.DialogID {
+	return ActionsDialogID
+}
+
+type actionsKeyMap struct {
+	Select,
+	Next,
+	Previous,
+	Close key.Binding
+}
+
+func defaultActionsKeyMap() actionsKeyMap {
+	return actionsKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "tab", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k actionsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Select, k.Next, k.Previous, k.Close}}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k actionsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("down", "up"),
+			key.WithHelp("↑↓", "choose"),
+		),
+		k.Select,
+		k.Close,
+	}
+}
@@ -0,0 +1,30 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	t.Parallel()
+
+	markdown := "Run this:\n\n```go\nfmt.Println(\"hi\")\n\n```\n\nThen:\n\n~~~~ bash title\ngo test ./...\n```\nstill code\n~~~~\n\n  ```\nindented\n  ```\n\n```python\nprint(1)"
+	blocks := CodeBlocks(markdown)
+	require.Equal(t, []CodeBlock{
+		{Language: "go", Code: "fmt.Println(\"hi\")\n"},
+		{Language: "bash", Code: "go test ./...\n```\nstill code"},
+		{Code: "indented"},
+		{Language: "python", Code: "print(1)"},
+	}, blocks)
+
+	require.Empty(t, CodeBlocks("No code here, just `inline` code."))
+}
+
+func TestSuggestedFileName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "snippet.go", suggestedFileName(CodeBlock{Language: "go"}))
+	require.Equal(t, "snippet.py", suggestedFileName(CodeBlock{Language: "Python"}))
+	require.Equal(t, "snippet.txt", suggestedFileName(CodeBlock{}))
+}
@@ -0,0 +1,88 @@
+package messages
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+// ViewImagesKey shows the images of the selected message or tool call full
+// size.
+var ViewImagesKey = key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "view images"))
+
+// attachedImages returns the images attached to a message.
+func attachedImages(msg message.Message) [][]byte {
+	var images [][]byte
+	for _, content := range msg.BinaryContent() {
+		if strings.HasPrefix(content.MIMEType, "image/") {
+			images = append(images, content.Data)
+		}
+	}
+	return images
+}
+
+// toolResultImages returns the images returned by a tool.
+func toolResultImages(result message.ToolResult) [][]byte {
+	images := make([][]byte, len(result.Images))
+	for i, img := range result.Images {
+		images[i] = img.Data
+	}
+	return images
+}
+
+// newInlineImages prepares images to be drawn in the chat.
+func newInlineImages(images [][]byte) []*image.Inline {
+	inline := make([]*image.Inline, len(images))
+	for i, data := range images {
+		inline[i] = image.NewInline(data)
+	}
+	return inline
+}
+
+// setImagesWidth lays images out within width cells.
+func setImagesWidth(images []*image.Inline, width int) tea.Cmd {
+	cmds := make([]tea.Cmd, len(images))
+	for i, img := range images {
+		cmds[i] = img.SetWidth(width)
+	}
+	return tea.Batch(cmds...)
+}
+
+// updateImages hands the layout of an image to the image, reporting whether
+// its view changed.
+func updateImages(images []*image.Inline, msg image.LaidOutMsg) (bool, tea.Cmd) {
+	for _, img := range images {
+		if changed, cmd := img.Update(msg); changed {
+			return true, cmd
+		}
+	}
+	return false, nil
+}
+
+// renderImages draws images one under the other, skipping the ones that
+// can't be decoded.
+func renderImages(images []*image.Inline) string {
+	var views []string
+	for _, img := range images {
+		if view := img.View(); view != "" {
+			views = append(views, view)
+		}
+	}
+	return strings.Join(views, "\n\n")
+}
+
+// showImages shows images full size one after the other.
+func showImages(images [][]byte) tea.Cmd {
+	if len(images) == 0 {
+		return util.ReportWarn("No images to view")
+	}
+	cmds := make([]tea.Cmd, len(images))
+	for i, data := range images {
+		cmds[i] = image.Show(data)
+	}
+	return tea.Sequence(cmds...)
+}
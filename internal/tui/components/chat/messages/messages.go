@@ -20,6 +20,8 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -41,6 +43,9 @@ type MessageCmp interface {
 	SetMessage(msg message.Message) // Update the message content
 	Spinning() bool                 // Animation state for loading messages
 	ID() string
+	// UpdateImage takes the layout of an image of the message, reporting
+	// whether the message changed.
+	UpdateImage(msg image.LaidOutMsg) (bool, tea.Cmd)
 }
 
 // messageCmp implements the MessageCmp interface for displaying chat messages.
@@ -57,6 +62,9 @@ type messageCmp struct {
 
 	// Thinking viewport for displaying reasoning content
 	thinkingViewport viewport.Model
+
+	// Images attached to user messages
+	images []*image.Inline
 }
 
 var focusedMessageBorder = lipgloss.Border{
@@ -81,6 +89,9 @@ func NewMessageCmp(msg message.Message) MessageCmp {
 		}),
 		thinkingViewport: thinkingViewport,
 	}
+	if msg.Role == message.User {
+		m.images = newInlineImages(attachedImages(msg))
+	}
 	return m
 }
 
@@ -113,6 +124,14 @@ func (m *messageCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				util.ReportInfo("Message copied to clipboard"),
 			)
 		}
+		if key.Matches(msg, ViewImagesKey) && len(m.images) > 0 {
+			return m, showImages(attachedImages(m.message))
+		}
+		if key.Matches(msg, ActionsKey) && m.message.ID != "" {
+			return m, util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: NewActionsDialogCmp(m.message),
+			})
+		}
 	}
 	return m, nil
 }
@@ -232,7 +251,7 @@ func (m *messageCmp) renderUserMessage() string {
 		filename := filepath.Base(attachment.Path)
 		attachments[i] = attachmentStyles.Render(fmt.Sprintf(
 			" %s %s ",
-			styles.DocumentIcon,
+			styles.AttachmentIcon(attachment.MIMEType),
 			ansi.Truncate(filename, maxFilenameWidth, "..."),
 		))
 	}
@@ -240,6 +259,9 @@ func (m *messageCmp) renderUserMessage() string {
 	if len(attachments) > 0 {
 		parts = append(parts, "", strings.Join(attachments, ""))
 	}
+	if images := renderImages(m.images); images != "" {
+		parts = append(parts, "", images)
+	}
 
 	joined := lipgloss.JoinVertical(lipgloss.Left, parts...)
 	return m.style().Render(joined)
@@ -347,7 +369,12 @@ func (m *messageCmp) GetSize() (int, int) {
 func (m *messageCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = util.Clamp(width, 1, 120)
 	m.thinkingViewport.SetWidth(m.width - 4)
-	return nil
+	return setImagesWidth(m.images, m.textWidth())
+}
+
+// UpdateImage takes the layout of an attached image.
+func (m *messageCmp) UpdateImage(msg image.LaidOutMsg) (bool, tea.Cmd) {
+	return updateImages(m.images, msg)
 }
 
 // Spinning returns whether the message is currently showing a loading animation
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/llm/agent"
 	"github.com/charmbracelet/crush/internal/llm/tools"
+	"github.com/charmbracelet/crush/internal/todo"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/highlight"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -42,6 +43,9 @@ func (rr renderRegistry) lookup(name string) renderer {
 	if f, ok := rr[name]; ok {
 		return f()
 	}
+	if agent.IsAgentToolName(name) {
+		return agentRenderer{}
+	}
 	return genericRenderer{} // sensible fallback
 }
 
@@ -174,7 +178,9 @@ func init() {
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
+	registry.register(tools.TodosToolName, func() renderer { return todosRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
+	registry.register(agent.ReadMCPResourceToolName, func() renderer { return mcpResourceRenderer{} })
 }
 
 // -----------------------------------------------------------------------------
@@ -439,6 +445,31 @@ func formatTimeout(timeout int) string {
 	return (time.Duration(timeout) * time.Second).String()
 }
 
+// -----------------------------------------------------------------------------
+//  MCP resource renderer
+// -----------------------------------------------------------------------------
+
+// mcpResourceRenderer handles MCP resource reads
+type mcpResourceRenderer struct {
+	baseRenderer
+}
+
+// Render displays the resource URI with its server and plain content output
+func (mr mcpResourceRenderer) Render(v *toolCallCmp) string {
+	var params agent.ReadMCPResourceParams
+	var args []string
+	if err := mr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.URI).
+			addKeyValue("server", params.Server).
+			build()
+	}
+
+	return mr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Download renderer
 // -----------------------------------------------------------------------------
@@ -589,6 +620,43 @@ func (dr diagnosticsRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Todos renderer
+// -----------------------------------------------------------------------------
+
+// todosRenderer handles checklist updates
+type todosRenderer struct {
+	baseRenderer
+}
+
+// Render displays the todos action and the resulting checklist
+func (tr todosRenderer) Render(v *toolCallCmp) string {
+	var params tools.TodosParams
+	var args []string
+	if err := tr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Action).
+			addKeyValue("status", params.Status).
+			build()
+	}
+
+	return tr.renderWithParams(v, "Todos", args, func() string {
+		var meta tools.TodosResponseMetadata
+		if err := tr.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Todos) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+		var sb strings.Builder
+		for _, item := range meta.Todos {
+			check := " "
+			if item.Status == todo.StatusCompleted {
+				check = "x"
+			}
+			fmt.Fprintf(&sb, "[%s] %s\n", check, item.Content)
+		}
+		return renderPlainContent(v, sb.String())
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Task renderer
 // -----------------------------------------------------------------------------
@@ -618,7 +686,11 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 	if res, done := earlyState(header, v); v.cancelled && done {
 		return res
 	}
-	taskTag := t.S().Base.Padding(0, 1).MarginLeft(1).Background(t.BlueLight).Foreground(t.White).Render("Task")
+	tag := "Task"
+	if id := agent.AgentIDFromToolName(v.call.Name); id != "task" {
+		tag = id
+	}
+	taskTag := t.S().Base.Padding(0, 1).MarginLeft(1).Background(t.BlueLight).Foreground(t.White).Render(tag)
 	remainingWidth := v.textWidth() - lipgloss.Width(header) - lipgloss.Width(taskTag) - 2 // -2 for padding
 	prompt = t.S().Muted.Width(remainingWidth).Render(prompt)
 	header = lipgloss.JoinVertical(
@@ -872,6 +944,8 @@ func prettifyToolName(name string) string {
 		return "Edit"
 	case tools.MultiEditToolName:
 		return "Multi-Edit"
+	case agent.ReadMCPResourceToolName:
+		return "Resource"
 	case tools.FetchToolName:
 		return "Fetch"
 	case tools.GlobToolName:
@@ -882,6 +956,8 @@ func prettifyToolName(name string) string {
 		return "List"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.TodosToolName:
+		return "Todos"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
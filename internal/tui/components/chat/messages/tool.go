@@ -18,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/lipgloss/v2"
@@ -43,6 +44,9 @@ type ToolCallCmp interface {
 	ID() string
 	SetPermissionRequested() // Mark permission request
 	SetPermissionGranted()   // Mark permission granted
+	// UpdateImage takes the layout of an image returned by the tool or a
+	// nested one, reporting whether the tool call changed.
+	UpdateImage(msg image.LaidOutMsg) (bool, tea.Cmd)
 }
 
 // toolCallCmp implements the ToolCallCmp interface for displaying tool calls.
@@ -65,6 +69,8 @@ type toolCallCmp struct {
 	anim     util.Model // Animation component for pending states
 
 	nestedToolCalls []ToolCallCmp // Nested tool calls for hierarchical display
+
+	images []*image.Inline // Images returned by the tool
 }
 
 // ToolCallOption provides functional options for configuring tool call components
@@ -168,6 +174,9 @@ func (m *toolCallCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if key.Matches(msg, CopyKey) {
 			return m, m.copyTool()
 		}
+		if key.Matches(msg, ViewImagesKey) && len(m.images) > 0 {
+			return m, showImages(toolResultImages(m.result))
+		}
 	}
 	return m, nil
 }
@@ -186,6 +195,9 @@ func (m *toolCallCmp) View() string {
 	if m.isNested {
 		return box.Render(r.Render(m))
 	}
+	if images := renderImages(m.images); images != "" {
+		return box.Render(lipgloss.JoinVertical(lipgloss.Left, r.Render(m), "", images))
+	}
 	return box.Render(r.Render(m))
 }
 
@@ -244,8 +256,17 @@ func (m *toolCallCmp) formatToolForCopy() string {
 	return strings.Join(parts, "\n\n")
 }
 
+// toolKind returns the name used to pick how the tool call is formatted, all
+// sub-agent tools are formatted like the agent tool.
+func (m *toolCallCmp) toolKind() string {
+	if agent.IsAgentToolName(m.call.Name) {
+		return agent.AgentToolName
+	}
+	return m.call.Name
+}
+
 func (m *toolCallCmp) formatParametersForCopy() string {
-	switch m.call.Name {
+	switch m.toolKind() {
 	case tools.BashToolName:
 		var params tools.BashParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
@@ -382,7 +403,7 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 }
 
 func (m *toolCallCmp) formatResultForCopy() string {
-	switch m.call.Name {
+	switch m.toolKind() {
 	case tools.BashToolName:
 		return m.formatBashResultForCopy()
 	case tools.ViewToolName:
@@ -664,6 +685,9 @@ func (m *toolCallCmp) ParentMessageID() string {
 
 // SetToolResult updates the tool result and stops the spinning animation
 func (m *toolCallCmp) SetToolResult(result message.ToolResult) {
+	if len(result.Images) != len(m.result.Images) {
+		m.images = newInlineImages(toolResultImages(result))
+	}
 	m.result = result
 	m.spinning = false
 }
@@ -772,10 +796,25 @@ func (m *toolCallCmp) GetSize() (int, int) {
 // SetSize updates the width of the tool call component for text wrapping
 func (m *toolCallCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = width
+	cmds := []tea.Cmd{setImagesWidth(m.images, m.textWidth())}
 	for _, nested := range m.nestedToolCalls {
-		nested.SetSize(width, height)
+		cmds = append(cmds, nested.SetSize(width, height))
 	}
-	return nil
+	return tea.Batch(cmds...)
+}
+
+// UpdateImage takes the layout of an image returned by the tool call or one
+// of its nested tool calls.
+func (m *toolCallCmp) UpdateImage(msg image.LaidOutMsg) (bool, tea.Cmd) {
+	if changed, cmd := updateImages(m.images, msg); changed {
+		return true, cmd
+	}
+	for _, nested := range m.nestedToolCalls {
+		if changed, cmd := nested.UpdateImage(msg); changed {
+			return true, cmd
+		}
+	}
+	return false, nil
 }
 
 // shouldSpin determines whether the tool call should show a loading animation.
@@ -0,0 +1,264 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	// SearchKey opens the search of the messages of the session.
+	SearchKey = key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search"))
+	// NextMatchKey and PrevMatchKey step through the matches of the search,
+	// from the most recent one up.
+	NextMatchKey = key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next match"))
+	PrevMatchKey = key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "previous match"))
+	// ToolErrorsKey toggles showing only the tool calls that failed.
+	ToolErrorsKey = key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "tool errors only"))
+)
+
+// searchHeight is the height of the search bar.
+const searchHeight = 1
+
+// search is the state of the search of the messages of a session.
+type search struct {
+	input textinput.Model
+	// editing is set while the query is typed.
+	editing bool
+	query   string
+	// matches are the IDs of the items matching the query, oldest first.
+	matches []string
+	// current is the index of the match scrolled to, or NotFound.
+	current    int
+	errorsOnly bool
+}
+
+// active reports whether the search bar is shown.
+func (s *search) active() bool {
+	return s.editing || s.query != "" || s.errorsOnly
+}
+
+// searchText returns the searchable text of an item: the content of user and
+// assistant messages, and the input and result of tool calls, including the
+// ones of sub-agents.
+func searchText(item list.Item) string {
+	switch item := item.(type) {
+	case messages.MessageCmp:
+		msg := item.GetMessage()
+		return msg.Content().Text
+	case messages.ToolCallCmp:
+		parts := []string{item.GetToolCall().Input, item.GetToolResult().Content}
+		for _, nested := range item.GetNestedToolCalls() {
+			parts = append(parts, searchText(nested))
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// hasToolError reports whether the item is a tool call that failed, or that
+// ran a sub-agent whose tool calls failed.
+func hasToolError(item list.Item) bool {
+	toolCall, ok := item.(messages.ToolCallCmp)
+	if !ok {
+		return false
+	}
+	if toolCall.GetToolResult().IsError {
+		return true
+	}
+	return slices.ContainsFunc(toolCall.GetNestedToolCalls(), func(nested messages.ToolCallCmp) bool {
+		return hasToolError(nested)
+	})
+}
+
+// findMatches returns the IDs of the items whose text contains the query,
+// ignoring case.
+func findMatches(items []list.Item, query string) []string {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+	var matches []string
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(searchText(item)), query) {
+			matches = append(matches, item.ID())
+		}
+	}
+	return matches
+}
+
+// OpenSearch shows the search bar and focuses its input.
+func (m *messageListCmp) OpenSearch() tea.Cmd {
+	if m.session.ID == "" {
+		return nil
+	}
+	if !m.search.active() {
+		t := styles.CurrentTheme()
+		m.search.input = textinput.New()
+		m.search.input.Prompt = "/"
+		m.search.input.Placeholder = "Search messages"
+		m.search.input.SetStyles(t.S().TextInput)
+		m.search.current = NotFound
+	}
+	m.search.editing = true
+	m.search.input.SetValue(m.search.query)
+	m.search.input.CursorEnd()
+	return tea.Batch(m.search.input.Focus(), m.SetSize(m.width, m.height))
+}
+
+// IsSearching reports whether the query of the search is being typed.
+func (m *messageListCmp) IsSearching() bool {
+	return m.search.editing
+}
+
+// HasSearch reports whether a search or the tool errors filter is active.
+func (m *messageListCmp) HasSearch() bool {
+	return m.search.active()
+}
+
+// closeSearch hides the search bar, showing all the messages again.
+func (m *messageListCmp) closeSearch() tea.Cmd {
+	errorsOnly := m.search.errorsOnly
+	m.resetSearch()
+	cmds := []tea.Cmd{m.SetSize(m.width, m.height)}
+	if errorsOnly {
+		cmds = append(cmds, m.loadMessages())
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleSearchKey handles the keys of the search, returning false for the keys
+// it doesn't use.
+func (m *messageListCmp) handleSearchKey(msg tea.KeyPressMsg) (bool, tea.Cmd) {
+	switch {
+	case key.Matches(msg, ToolErrorsKey):
+		return true, m.toggleToolErrors()
+	case msg.String() == "esc":
+		return true, m.closeSearch()
+	}
+
+	if m.search.editing {
+		switch msg.String() {
+		case "enter":
+			m.search.editing = false
+			m.search.input.Blur()
+			if m.search.query == "" && !m.search.errorsOnly {
+				return true, m.closeSearch()
+			}
+			return true, nil
+		case "up", "down":
+			step := 1
+			if msg.String() == "down" {
+				step = -1
+			}
+			return true, m.stepMatch(step)
+		}
+		var cmd tea.Cmd
+		m.search.input, cmd = m.search.input.Update(msg)
+		if query := m.search.input.Value(); query != m.search.query {
+			m.search.query = query
+			m.search.current = NotFound
+			m.listCmp.SetHighlight(query)
+			return true, tea.Batch(cmd, m.stepMatch(1))
+		}
+		return true, cmd
+	}
+
+	switch {
+	case key.Matches(msg, SearchKey):
+		return true, m.OpenSearch()
+	case key.Matches(msg, NextMatchKey):
+		return true, m.stepMatch(1)
+	case key.Matches(msg, PrevMatchKey):
+		return true, m.stepMatch(-1)
+	}
+	return false, nil
+}
+
+// stepMatch scrolls to the match step matches up from the current one, the
+// first step going to the most recent match.
+func (m *messageListCmp) stepMatch(step int) tea.Cmd {
+	var currentID string
+	if m.search.current >= 0 && m.search.current < len(m.search.matches) {
+		currentID = m.search.matches[m.search.current]
+	}
+	m.search.matches = findMatches(m.listCmp.Items(), m.search.query)
+	n := len(m.search.matches)
+	if n == 0 {
+		m.search.current = NotFound
+		return nil
+	}
+
+	current := slices.Index(m.search.matches, currentID)
+	if current < 0 {
+		current = n
+		if step < 0 {
+			current = -1
+		}
+	}
+	// Matches are oldest first, stepping up goes back in the history.
+	m.search.current = ((current-step)%n + n) % n
+	return m.listCmp.ScrollToItem(m.search.matches[m.search.current])
+}
+
+// toggleToolErrors shows only the tool calls that failed, or all messages
+// again.
+func (m *messageListCmp) toggleToolErrors() tea.Cmd {
+	m.search.errorsOnly = !m.search.errorsOnly
+	m.search.current = NotFound
+	if !m.search.errorsOnly {
+		if !m.search.active() {
+			m.resetSearch()
+		}
+		return tea.Batch(m.loadMessages(), m.SetSize(m.width, m.height))
+	}
+	var failed []list.Item
+	for _, item := range m.listCmp.Items() {
+		if hasToolError(item) {
+			failed = append(failed, item)
+		}
+	}
+	return tea.Batch(m.listCmp.SetItems(failed), m.SetSize(m.width, m.height))
+}
+
+// searchView renders the search bar.
+func (m *messageListCmp) searchView() string {
+	t := styles.CurrentTheme()
+	var parts []string
+	if m.search.editing {
+		m.search.input.SetWidth(max(10, m.width/2))
+		parts = append(parts, m.search.input.View())
+	} else if m.search.query != "" {
+		parts = append(parts, t.S().Base.Render(fmt.Sprintf("/%s", m.search.query)))
+	}
+
+	if m.search.query != "" {
+		switch {
+		case len(m.search.matches) == 0:
+			parts = append(parts, t.S().Error.Render("no matches"))
+		case m.search.current >= 0:
+			// Matches are counted from the most recent one.
+			parts = append(parts, t.S().Muted.Render(fmt.Sprintf("%d/%d", len(m.search.matches)-m.search.current, len(m.search.matches))))
+		default:
+			parts = append(parts, t.S().Muted.Render(fmt.Sprintf("%d matches", len(m.search.matches))))
+		}
+	}
+	if m.search.errorsOnly {
+		parts = append(parts, t.S().Warning.Render(fmt.Sprintf("%s tool errors only", styles.WarningIcon)))
+	}
+
+	help := "n/N next/prev · / edit · esc close"
+	if m.search.editing {
+		help = "enter done · ↑↓ next/prev · esc close"
+	}
+	parts = append(parts, t.S().Subtle.Render(fmt.Sprintf("%s · ctrl+e tool errors", help)))
+	return ansi.Truncate(t.S().Base.PaddingLeft(1).Render(strings.Join(parts, "  ")), m.width, "…")
+}
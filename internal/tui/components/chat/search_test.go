@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMatches(t *testing.T) {
+	t.Parallel()
+
+	user := messages.NewMessageCmp(message.Message{
+		ID:    "user",
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "Fix the Parser please"}},
+	})
+	failed := messages.NewToolCallCmp("assistant", message.ToolCall{
+		ID:    "bash",
+		Name:  "bash",
+		Input: `{"command":"go test ./parser"}`,
+	}, nil, messages.WithToolCallResult(message.ToolResult{
+		ToolCallID: "bash",
+		Content:    "FAIL: undefined: tokenize",
+		IsError:    true,
+	}))
+	agent := messages.NewToolCallCmp("assistant", message.ToolCall{
+		ID:    "agent",
+		Name:  "agent",
+		Input: `{"prompt":"look around"}`,
+	}, nil)
+	agent.SetNestedToolCalls([]messages.ToolCallCmp{
+		messages.NewToolCallCmp("nested", message.ToolCall{ID: "view", Name: "view", Input: `{"file_path":"lexer.go"}`}, nil,
+			messages.WithToolCallResult(message.ToolResult{ToolCallID: "view", Content: "no such file", IsError: true})),
+	})
+	items := []list.Item{user, failed, agent}
+
+	require.Equal(t, []string{"user", "bash"}, findMatches(items, "parser"))
+	require.Equal(t, []string{"bash"}, findMatches(items, "TOKENIZE"))
+	require.Equal(t, []string{"agent"}, findMatches(items, "lexer.go"))
+	require.Empty(t, findMatches(items, "missing"))
+	require.Empty(t, findMatches(items, ""))
+
+	require.False(t, hasToolError(user))
+	require.True(t, hasToolError(failed))
+	require.True(t, hasToolError(agent), "errors of sub-agents count")
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
@@ -28,6 +29,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/crush/internal/version"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -44,6 +46,7 @@ const (
 	DefaultMaxFilesShown = 10
 	DefaultMaxLSPsShown  = 8
 	DefaultMaxMCPsShown  = 8
+	DefaultMaxTodosShown = 10
 	MinItemsPerSection   = 2 // Minimum items to show per section
 )
 
@@ -57,6 +60,11 @@ type SessionFilesMsg struct {
 	Files []SessionFile
 }
 
+type SessionTodosMsg struct {
+	SessionID string
+	Todos     []todo.Todo
+}
+
 type Sidebar interface {
 	util.Model
 	layout.Sizeable
@@ -73,12 +81,15 @@ type sidebarCmp struct {
 	compactMode   bool
 	history       history.Service
 	files         *csync.Map[string, SessionFile]
+	todoService   todo.Service
+	todos         []todo.Todo
 }
 
-func New(history history.Service, lspClients map[string]*lsp.Client, compact bool) Sidebar {
+func New(history history.Service, todos todo.Service, lspClients map[string]*lsp.Client, compact bool) Sidebar {
 	return &sidebarCmp{
 		lspClients:  lspClients,
 		history:     history,
+		todoService: todos,
 		compactMode: compact,
 		files:       csync.NewMap[string, SessionFile](),
 	}
@@ -96,9 +107,19 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.files.Set(file.FilePath, file)
 		}
 		return m, nil
+	case SessionTodosMsg:
+		if msg.SessionID == m.session.ID {
+			m.todos = msg.Todos
+		}
+		return m, nil
 
 	case chat.SessionClearedMsg:
 		m.session = session.Session{}
+		m.todos = nil
+	case pubsub.Event[todo.Todo]:
+		if msg.Payload.SessionID == m.session.ID {
+			return m, m.loadSessionTodos
+		}
 	case pubsub.Event[history.File]:
 		return m, m.handleFileHistoryEvent(msg)
 	case pubsub.Event[session.Session]:
@@ -159,6 +180,9 @@ func (m *sidebarCmp) View() string {
 		}
 	} else {
 		// Vertical layout (default)
+		if len(m.todos) > 0 {
+			parts = append(parts, "", m.todosBlock())
+		}
 		if m.session.ID != "" {
 			parts = append(parts, "", m.filesBlock())
 		}
@@ -265,6 +289,21 @@ func (m *sidebarCmp) loadSessionFiles() tea.Msg {
 	}
 }
 
+func (m *sidebarCmp) loadSessionTodos() tea.Msg {
+	sessionID := m.session.ID
+	todos, err := m.todoService.List(context.Background(), sessionID)
+	if err != nil {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+		}
+	}
+	return SessionTodosMsg{
+		SessionID: sessionID,
+		Todos:     todos,
+	}
+}
+
 func (m *sidebarCmp) SetSize(width, height int) tea.Cmd {
 	m.logo = m.logoBlock()
 	m.cwd = cwd()
@@ -318,6 +357,10 @@ func (m *sidebarCmp) calculateAvailableHeight() int {
 
 	usedHeight += 2 // Model info
 
+	if len(m.todos) > 0 {
+		usedHeight += 3 + min(len(m.todos), DefaultMaxTodosShown) // Section, items and empty line
+	}
+
 	usedHeight += 6 // 3 sections × 2 lines each (header + empty line)
 
 	// Base padding
@@ -477,6 +520,50 @@ func (m *sidebarCmp) filesBlock() string {
 	}, true)
 }
 
+// todosBlock renders the agent's checklist for the session, keeping the
+// items that are not done yet visible when it has to be truncated.
+func (m *sidebarCmp) todosBlock() string {
+	t := styles.CurrentTheme()
+	maxWidth := m.getMaxWidth()
+
+	done := len(m.todos) - len(todo.Open(m.todos))
+	section := core.Section(fmt.Sprintf("Todos %d/%d", done, len(m.todos)), maxWidth)
+	list := []string{t.S().Subtle.Render(section), ""}
+
+	todos := m.todos
+	if len(todos) > DefaultMaxTodosShown {
+		first := max(0, slices.IndexFunc(todos, func(item todo.Todo) bool {
+			return item.Status != todo.StatusCompleted
+		}))
+		first = min(first, len(todos)-DefaultMaxTodosShown)
+		todos = todos[first : first+DefaultMaxTodosShown]
+	}
+	for _, item := range todos {
+		icon := t.S().Base.Foreground(t.FgSubtle).Render("○")
+		titleColor := t.FgMuted
+		switch item.Status {
+		case todo.StatusInProgress:
+			icon = t.ItemBusyIcon.String()
+			titleColor = t.FgBase
+		case todo.StatusCompleted:
+			icon = t.S().Base.Foreground(t.Success).Render(styles.CheckIcon)
+			titleColor = t.FgSubtle
+		}
+		list = append(list, core.Status(core.StatusOpts{
+			Icon:       icon,
+			Title:      ansi.Truncate(item.Content, maxWidth-2, "…"),
+			TitleColor: titleColor,
+		}, maxWidth))
+	}
+	if remaining := len(m.todos) - len(todos); remaining > 0 {
+		list = append(list, t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf("…and %d more", remaining)))
+	}
+
+	return lipgloss.NewStyle().Width(maxWidth).Render(
+		lipgloss.JoinVertical(lipgloss.Left, list...),
+	)
+}
+
 func (m *sidebarCmp) lspBlock() string {
 	// Limit the number of LSPs shown
 	_, maxLSPs, _ := m.getDynamicLimits()
@@ -543,11 +630,27 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	return fmt.Sprintf("%s %s", formattedTokens, formattedCost)
 }
 
+// formatCacheUsage returns the share of the prompts of the session read from
+// the prompt cache and the money it saved, or nothing when the cache wasn't
+// used.
+func formatCacheUsage(sess session.Session) string {
+	if sess.CacheReadTokens == 0 && sess.CacheCreationTokens == 0 {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	ratio := baseStyle.Foreground(t.FgMuted).Render(fmt.Sprintf("%d%%", int(sess.CacheHitRatio()*100)))
+	label := baseStyle.Foreground(t.FgSubtle).Render("cached")
+	savings := baseStyle.Foreground(t.FgMuted).Render(fmt.Sprintf("$%.2f", sess.CacheSavings))
+	saved := baseStyle.Foreground(t.FgSubtle).Render("saved")
+	return fmt.Sprintf("%s %s %s %s", ratio, label, savings, saved)
+}
+
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
-	agentCfg := cfg.Agents["coder"]
+	agentCfg := cfg.PrimaryAgent()
 
-	selectedModel := cfg.Models[agentCfg.Model]
+	selectedModel := cfg.ModelConfig(agentCfg.Model)
 
 	model := config.Get().GetModelByType(agentCfg.Model)
 	modelProvider := config.Get().GetProviderForModel(agentCfg.Model)
@@ -560,6 +663,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 	parts := []string{
 		modelInfo,
 	}
+	if agentCfg.ID != "coder" {
+		parts = append(parts, t.S().Subtle.PaddingLeft(2).Render("Agent: "+agentCfg.Name))
+	}
 	if model.CanReason {
 		reasoningInfoStyle := t.S().Subtle.PaddingLeft(2)
 		switch modelProvider.Type {
@@ -588,6 +694,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 				s.session.Cost,
 			),
 		)
+		if cache := formatCacheUsage(s.session); cache != "" {
+			parts = append(parts, "  "+cache)
+		}
 	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -598,7 +707,8 @@ func (s *sidebarCmp) currentModelBlock() string {
 // SetSession implements Sidebar.
 func (m *sidebarCmp) SetSession(session session.Session) tea.Cmd {
 	m.session = session
-	return m.loadSessionFiles
+	m.todos = nil
+	return tea.Batch(m.loadSessionFiles, m.loadSessionTodos)
 }
 
 // SetCompactMode sets the compact mode for the sidebar.
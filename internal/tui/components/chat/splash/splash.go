@@ -691,7 +691,7 @@ func (s *splashCmp) mcpBlock() string {
 
 func (s *splashCmp) currentModelBlock() string {
 	cfg := config.Get()
-	agentCfg := cfg.Agents["coder"]
+	agentCfg := cfg.PrimaryAgent()
 	model := config.Get().GetModelByType(agentCfg.Model)
 	if model == nil {
 		return ""
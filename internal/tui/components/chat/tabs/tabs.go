@@ -0,0 +1,273 @@
+// Package tabs shows the sessions open in the chat page, so several of them
+// can run at once and be followed from the one being looked at.
+package tabs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/spinner"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxTitleWidth is the width the title of a session is truncated to.
+const maxTitleWidth = 24
+
+// Tab is a session open in the chat page.
+type Tab struct {
+	Session session.Session
+	// Unread is set when a run of the session finishes while another tab is
+	// selected, and cleared when the tab is selected.
+	Unread bool
+	// Permissions is the number of permission requests of the session
+	// waiting for an answer.
+	Permissions int
+}
+
+type Tabs interface {
+	util.Model
+	SetWidth(width int) tea.Cmd
+	// Open selects the tab of the session, adding it if it isn't open.
+	Open(session session.Session) tea.Cmd
+	// Close removes the tab of the session.
+	Close(sessionID string)
+	// Deselect leaves the tabs open without selecting any, as when a new
+	// session is being started.
+	Deselect()
+	// Selected returns the ID of the selected session, if any.
+	Selected() string
+	// Has reports whether the session has a tab.
+	Has(sessionID string) bool
+	// Tabs returns the open tabs, in the order they are shown.
+	Tabs() []Tab
+	// Next returns the session step tabs away from the selected one,
+	// wrapping around.
+	Next(step int) (session.Session, bool)
+	// At returns the session of the tab at index.
+	At(index int) (session.Session, bool)
+	// MarkUnread flags a session that finished in the background.
+	MarkUnread(sessionID string)
+	// SetPermissions sets the number of waiting permission requests of each
+	// session.
+	SetPermissions(counts map[string]int)
+	// Visible reports whether the strip is shown, which is whenever a session
+	// other than the selected one is open.
+	Visible() bool
+}
+
+type tabs struct {
+	app      *app.App
+	width    int
+	tabs     []Tab
+	selected string
+	spinner  spinner.Model
+	ticking  bool
+}
+
+func New(app *app.App) Tabs {
+	t := styles.CurrentTheme()
+	return &tabs{
+		app: app,
+		spinner: spinner.New(
+			spinner.WithSpinner(spinner.MiniDot),
+			spinner.WithStyle(t.S().Base.Foreground(t.Green)),
+		),
+	}
+}
+
+func (m *tabs) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tabs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pubsub.Event[session.Session]:
+		i := m.index(msg.Payload.ID)
+		if i < 0 {
+			return m, nil
+		}
+		switch msg.Type {
+		case pubsub.UpdatedEvent:
+			m.tabs[i].Session = msg.Payload
+		case pubsub.DeletedEvent:
+			m.Close(msg.Payload.ID)
+		}
+	case pubsub.Event[message.Message]:
+		return m, m.startSpinner()
+	case spinner.TickMsg:
+		if msg.ID != m.spinner.ID() {
+			return m, nil
+		}
+		// Stop ticking once nothing runs, the next message starts it again.
+		if !m.anyBusy() {
+			m.ticking = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *tabs) View() string {
+	if !m.Visible() {
+		return ""
+	}
+	t := styles.CurrentTheme()
+
+	parts := make([]string, 0, len(m.tabs))
+	for i, tab := range m.tabs {
+		var b strings.Builder
+		switch {
+		case m.isBusy(tab.Session.ID):
+			b.WriteString(m.spinner.View())
+		case tab.Unread:
+			b.WriteString(t.S().Base.Foreground(t.Secondary).Render(styles.ToolPending))
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(" ")
+
+		title := ansi.Truncate(tab.Session.Title, maxTitleWidth, "…")
+		if i < 9 {
+			title = fmt.Sprintf("%d %s", i+1, title)
+		}
+		style := t.S().Muted
+		if tab.Session.ID == m.selected {
+			style = t.S().Base.Bold(true)
+		}
+		b.WriteString(style.Render(title))
+
+		if tab.Permissions > 0 {
+			b.WriteString(" ")
+			b.WriteString(t.S().Warning.Render(fmt.Sprintf("%s %d", styles.WarningIcon, tab.Permissions)))
+		}
+
+		tabStyle := t.S().Base.Padding(0, 1)
+		if tab.Session.ID == m.selected {
+			tabStyle = tabStyle.Background(t.BgSubtle)
+		}
+		parts = append(parts, tabStyle.Render(b.String()))
+	}
+	line := lipgloss.JoinHorizontal(lipgloss.Left, parts...)
+	return ansi.Truncate(line, m.width, "…")
+}
+
+func (m *tabs) SetWidth(width int) tea.Cmd {
+	m.width = width
+	return nil
+}
+
+func (m *tabs) Open(session session.Session) tea.Cmd {
+	m.selected = session.ID
+	if i := m.index(session.ID); i >= 0 {
+		m.tabs[i].Session = session
+		m.tabs[i].Unread = false
+	} else {
+		m.tabs = append(m.tabs, Tab{Session: session})
+	}
+	return m.startSpinner()
+}
+
+func (m *tabs) Close(sessionID string) {
+	m.tabs = slices.DeleteFunc(m.tabs, func(tab Tab) bool {
+		return tab.Session.ID == sessionID
+	})
+	if m.selected == sessionID {
+		m.selected = ""
+	}
+}
+
+func (m *tabs) Deselect() {
+	m.selected = ""
+}
+
+func (m *tabs) Selected() string {
+	return m.selected
+}
+
+func (m *tabs) Has(sessionID string) bool {
+	return m.index(sessionID) >= 0
+}
+
+func (m *tabs) Tabs() []Tab {
+	return m.tabs
+}
+
+func (m *tabs) Next(step int) (session.Session, bool) {
+	if len(m.tabs) == 0 {
+		return session.Session{}, false
+	}
+	i := m.index(m.selected)
+	if i < 0 {
+		// Nothing is selected, the first step lands on either end.
+		if step > 0 {
+			i = -1
+		} else {
+			i = len(m.tabs)
+		}
+	}
+	n := len(m.tabs)
+	return m.tabs[((i+step)%n+n)%n].Session, true
+}
+
+func (m *tabs) At(index int) (session.Session, bool) {
+	if index < 0 || index >= len(m.tabs) {
+		return session.Session{}, false
+	}
+	return m.tabs[index].Session, true
+}
+
+func (m *tabs) MarkUnread(sessionID string) {
+	if i := m.index(sessionID); i >= 0 && sessionID != m.selected {
+		m.tabs[i].Unread = true
+	}
+}
+
+func (m *tabs) SetPermissions(counts map[string]int) {
+	for i := range m.tabs {
+		m.tabs[i].Permissions = counts[m.tabs[i].Session.ID]
+	}
+}
+
+func (m *tabs) Visible() bool {
+	return slices.ContainsFunc(m.tabs, func(tab Tab) bool {
+		return tab.Session.ID != m.selected
+	})
+}
+
+func (m *tabs) index(sessionID string) int {
+	return slices.IndexFunc(m.tabs, func(tab Tab) bool {
+		return tab.Session.ID == sessionID
+	})
+}
+
+func (m *tabs) isBusy(sessionID string) bool {
+	return m.app != nil && m.app.CoderAgent != nil && m.app.CoderAgent.IsSessionBusy(sessionID)
+}
+
+func (m *tabs) anyBusy() bool {
+	return slices.ContainsFunc(m.tabs, func(tab Tab) bool {
+		return m.isBusy(tab.Session.ID)
+	})
+}
+
+// startSpinner starts ticking the spinner when a session runs, it stops by
+// itself once none does.
+func (m *tabs) startSpinner() tea.Cmd {
+	if m.ticking || !m.anyBusy() {
+		return nil
+	}
+	m.ticking = true
+	return m.spinner.Tick
+}
@@ -0,0 +1,53 @@
+package tabs
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+
+	m := New(nil)
+	require.False(t, m.Visible())
+
+	m.Open(session.Session{ID: "a", Title: "First"})
+	require.False(t, m.Visible(), "a single selected tab has no strip")
+	m.Open(session.Session{ID: "b", Title: "Second"})
+	m.Open(session.Session{ID: "c", Title: "Third"})
+	require.True(t, m.Visible())
+	require.Equal(t, "c", m.Selected())
+
+	next, ok := m.Next(1)
+	require.True(t, ok)
+	require.Equal(t, "a", next.ID, "next wraps around")
+	prev, _ := m.Next(-1)
+	require.Equal(t, "b", prev.ID)
+
+	m.MarkUnread("a")
+	m.MarkUnread("c")
+	m.SetPermissions(map[string]int{"b": 2})
+	require.True(t, m.Tabs()[0].Unread)
+	require.False(t, m.Tabs()[2].Unread, "the selected tab is never unread")
+	require.Equal(t, 2, m.Tabs()[1].Permissions)
+
+	m.Open(session.Session{ID: "a", Title: "First"})
+	require.False(t, m.Tabs()[0].Unread, "selecting a tab reads it")
+	require.Len(t, m.Tabs(), 3)
+
+	m.Close("a")
+	require.Empty(t, m.Selected())
+	require.False(t, m.Has("a"))
+	first, ok := m.At(0)
+	require.True(t, ok)
+	require.Equal(t, "b", first.ID)
+	_, ok = m.At(2)
+	require.False(t, ok)
+
+	next, _ = m.Next(1)
+	require.Equal(t, "b", next.ID, "without a selection the first step lands on an end")
+	m.SetWidth(80)
+	require.Contains(t, m.View(), "1 Second")
+}
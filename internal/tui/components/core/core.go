@@ -195,10 +195,13 @@ func SelectableButtonsVertical(buttons []ButtonOpts, spacing int) string {
 	return lipgloss.JoinVertical(lipgloss.Center, parts...)
 }
 
+// DiffTabWidth is the number of spaces tabs take in diffs.
+const DiffTabWidth = 4
+
 func DiffFormatter() *diffview.DiffView {
 	t := styles.CurrentTheme()
 	formatDiff := diffview.New()
 	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
-	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4)
+	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(DiffTabWidth).IntraLineHighlight(true)
 	return diff
 }
@@ -1,10 +1,15 @@
 package status
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/v2/help"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/lipgloss/v2"
@@ -15,6 +20,7 @@ type StatusCmp interface {
 	util.Model
 	ToggleFullHelp()
 	SetKeyMap(keyMap help.KeyMap)
+	SetSession(session session.Session)
 }
 
 type statusCmp struct {
@@ -23,6 +29,7 @@ type statusCmp struct {
 	messageTTL time.Duration
 	help       help.Model
 	keyMap     help.KeyMap
+	session    session.Session
 }
 
 // clearMessageCmd is a command that clears status messages after a timeout
@@ -53,17 +60,69 @@ func (m *statusCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.clearMessageCmd(ttl)
 	case util.ClearStatusMsg:
 		m.info = util.InfoMsg{}
+	case pubsub.Event[session.Session]:
+		if msg.Payload.ID == m.session.ID {
+			m.session = msg.Payload
+		}
 	}
 	return m, nil
 }
 
 func (m *statusCmp) View() string {
 	t := styles.CurrentTheme()
-	status := t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
 	if m.info.Msg != "" {
-		status = m.infoMsg()
+		return m.infoMsg()
+	}
+	usage := m.contextUsage()
+	m.help.Width = m.width - 2
+	if usage != "" {
+		m.help.Width -= lipgloss.Width(usage) + 1
+	}
+	status := t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
+	if usage == "" {
+		return status
+	}
+	gap := m.width - lipgloss.Width(status) - lipgloss.Width(usage) - 1
+	if gap < 1 {
+		return status
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, status, strings.Repeat(" ", gap), usage)
+}
+
+// contextUsage renders how much of the model context window the current
+// session uses, relative to the automatic compaction threshold.
+func (m *statusCmp) contextUsage() string {
+	if m.session.ID == "" {
+		return ""
 	}
-	return status
+	cfg := config.Get()
+	agentCfg := cfg.PrimaryAgent()
+	if agentCfg.ID == "" {
+		return ""
+	}
+	model := cfg.GetModelByType(agentCfg.Model)
+	if model == nil || model.ContextWindow == 0 {
+		return ""
+	}
+
+	t := styles.CurrentTheme()
+	tokens := m.session.PromptTokens + m.session.CompletionTokens
+	percentage := float64(tokens) / float64(model.ContextWindow) * 100
+	threshold := cfg.Options.Compaction.Threshold
+
+	color := t.FgMuted
+	switch {
+	case percentage >= threshold:
+		color = t.Error
+	case percentage >= threshold-10:
+		color = t.Warning
+	}
+	usage := t.S().Base.Foreground(color).Render(fmt.Sprintf("%d%%", int(percentage)))
+	limit := t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf("context (compacts at %d%%)", int(threshold)))
+	if cfg.Options.DisableAutoSummarize {
+		limit = t.S().Base.Foreground(t.FgSubtle).Render("context")
+	}
+	return usage + " " + limit
 }
 
 func (m *statusCmp) infoMsg() string {
@@ -98,6 +157,10 @@ func (m *statusCmp) SetKeyMap(keyMap help.KeyMap) {
 	m.keyMap = keyMap
 }
 
+func (m *statusCmp) SetSession(session session.Session) {
+	m.session = session
+}
+
 func NewStatusCmp() StatusCmp {
 	t := styles.CurrentTheme()
 	help := help.New()
@@ -63,6 +63,7 @@ type (
 	SwitchSessionsMsg     struct{}
 	NewSessionsMsg        struct{}
 	SwitchModelMsg        struct{}
+	SwitchAgentMsg        struct{}
 	QuitMsg               struct{}
 	OpenFilePickerMsg     struct{}
 	ToggleHelpMsg         struct{}
@@ -70,9 +71,17 @@ type (
 	ToggleThinkingMsg     struct{}
 	OpenExternalEditorMsg struct{}
 	ToggleYoloModeMsg     struct{}
+	TogglePlanModeMsg     struct{}
+	SearchMessagesMsg     struct{}
 	CompactMsg            struct {
 		SessionID string
 	}
+	ReviewChangesMsg struct {
+		SessionID string
+	}
+	RestartMCPMsg struct {
+		Name string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -111,7 +120,7 @@ func (c *commandDialogCmp) Init() tea.Cmd {
 	if err != nil {
 		return util.ReportError(err)
 	}
-	c.userCommands = commands
+	c.userCommands = append(commands, LoadMCPPrompts()...)
 	return c.SetCommandType(c.commandType)
 }
 
@@ -286,6 +295,18 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		},
 	}
 
+	// Only show the agent switch when there are user-defined agents
+	if len(config.Get().UserAgents()) > 0 {
+		commands = append(commands, Command{
+			ID:          "switch_agent",
+			Title:       "Switch Agent",
+			Description: "Switch the agent used for the conversation",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchAgentMsg{})
+			},
+		})
+	}
+
 	// Only show compact command if there's an active session
 	if c.sessionID != "" {
 		commands = append(commands, Command{
@@ -300,14 +321,36 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 
+	// Only show the review when there's an active session
+	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "review_changes",
+			Title:       "Review Changes",
+			Description: "Review the file changes of the session and revert them",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ReviewChangesMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+		commands = append(commands, Command{
+			ID:          "search_messages",
+			Title:       "Search Messages",
+			Description: "Search the messages and tool calls of the session",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SearchMessagesMsg{})
+			},
+		})
+	}
+
 	// Only show thinking toggle for Anthropic models that can reason
 	cfg := config.Get()
-	if agentCfg, ok := cfg.Agents["coder"]; ok {
+	if agentCfg := cfg.PrimaryAgent(); agentCfg.ID != "" {
 		providerCfg := cfg.GetProviderForModel(agentCfg.Model)
 		model := cfg.GetModelByType(agentCfg.Model)
 		if providerCfg != nil && model != nil &&
 			providerCfg.Type == catwalk.TypeAnthropic && model.CanReason {
-			selectedModel := cfg.Models[agentCfg.Model]
+			selectedModel := cfg.ModelConfig(agentCfg.Model)
 			status := "Enable"
 			if selectedModel.Think {
 				status = "Disable"
@@ -334,19 +377,30 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 	if c.sessionID != "" {
-		agentCfg := config.Get().Agents["coder"]
-		model := config.Get().GetModelByType(agentCfg.Model)
-		if model.SupportsImages {
-			commands = append(commands, Command{
-				ID:          "file_picker",
-				Title:       "Open File Picker",
-				Shortcut:    "ctrl+f",
-				Description: "Open file picker",
-				Handler: func(cmd Command) tea.Cmd {
-					return util.CmdHandler(OpenFilePickerMsg{})
-				},
-			})
+		commands = append(commands, Command{
+			ID:          "file_picker",
+			Title:       "Open File Picker",
+			Shortcut:    "ctrl+f",
+			Description: "Attach an image, a PDF or a text file",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenFilePickerMsg{})
+			},
+		})
+	}
+
+	// Add a restart command for each enabled MCP server
+	for _, m := range config.Get().MCP.Sorted() {
+		if m.MCP.Disabled {
+			continue
 		}
+		commands = append(commands, Command{
+			ID:          "restart_mcp_" + m.Name,
+			Title:       "Restart MCP: " + m.Name,
+			Description: "Restart the " + m.Name + " MCP server",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(RestartMCPMsg{Name: m.Name})
+			},
+		})
 	}
 
 	// Add external editor command if $EDITOR is available
@@ -371,6 +425,14 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "toggle_plan",
+			Title:       "Toggle Plan Mode",
+			Description: "Plan changes before the agent can modify any files",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(TogglePlanModeMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
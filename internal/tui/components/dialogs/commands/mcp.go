@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/llm/agent"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	MCPCommandPrefix = "mcp:"
+
+	getPromptTimeout = 30 * time.Second
+)
+
+// LoadMCPPrompts returns the prompts of the connected MCP servers as
+// commands.
+func LoadMCPPrompts() []Command {
+	prompts := agent.ListMCPPrompts()
+	commands := make([]Command, 0, len(prompts))
+	for _, prompt := range prompts {
+		id := MCPCommandPrefix + prompt.Server + ":" + prompt.Name
+		description := prompt.Description
+		if description == "" {
+			description = fmt.Sprintf("Prompt from the %s MCP server", prompt.Server)
+		}
+		commands = append(commands, Command{
+			ID:          id,
+			Title:       id,
+			Description: description,
+			Handler:     createMCPPromptHandler(id, prompt),
+		})
+	}
+	return commands
+}
+
+func createMCPPromptHandler(id string, prompt agent.MCPPrompt) func(Command) tea.Cmd {
+	return func(cmd Command) tea.Cmd {
+		if len(prompt.Arguments) == 0 {
+			return getMCPPrompt(prompt, nil)
+		}
+
+		argNames := make([]string, len(prompt.Arguments))
+		for i, arg := range prompt.Arguments {
+			argNames[i] = arg.Name
+		}
+		return util.CmdHandler(ShowArgumentsDialogMsg{
+			CommandID: id,
+			ArgNames:  argNames,
+			Run: func(args map[string]string) tea.Cmd {
+				return getMCPPrompt(prompt, args)
+			},
+		})
+	}
+}
+
+// getMCPPrompt gets the prompt from the MCP server and sends it as the user
+// message.
+func getMCPPrompt(prompt agent.MCPPrompt, args map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		for _, arg := range prompt.Arguments {
+			if arg.Required && args[arg.Name] == "" {
+				return util.ReportWarn(fmt.Sprintf("Argument %s is required", arg.Name))()
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), getPromptTimeout)
+		defer cancel()
+		content, err := agent.GetMCPPrompt(ctx, prompt.Server, prompt.Name, args)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return CommandRunCustomMsg{
+			Content: content,
+		}
+	}
+}
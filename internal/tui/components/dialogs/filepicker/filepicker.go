@@ -1,11 +1,13 @@
 package filepicker
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/v2/filepicker"
 	"github.com/charmbracelet/bubbles/v2/help"
@@ -21,9 +23,12 @@ import (
 )
 
 const (
-	MaxAttachmentSize  = int64(5 * 1024 * 1024) // 5MB
-	FilePickerID       = "filepicker"
-	fileSelectionHight = 10
+	MaxAttachmentSize = int64(5 * 1024 * 1024) // 5MB
+	// MaxTextAttachmentSize is lower than for images and documents, as text
+	// is inlined in the prompt.
+	MaxTextAttachmentSize = int64(256 * 1024) // 256KB
+	FilePickerID          = "filepicker"
+	fileSelectionHight    = 10
 )
 
 type FilePickedMsg struct {
@@ -45,12 +50,14 @@ type model struct {
 	help            help.Model
 }
 
-var AllowedTypes = []string{".jpg", ".jpeg", ".png"}
+// ImageTypes are the extensions of the images that are previewed.
+var ImageTypes = []string{".jpg", ".jpeg", ".png"}
 
 func NewFilePickerCmp(workingDir string) FilePicker {
 	t := styles.CurrentTheme()
 	fp := filepicker.New()
-	fp.AllowedTypes = AllowedTypes
+	// Any file can be attached: images, PDFs and text files.
+	fp.AllowedTypes = nil
 
 	if workingDir != "" {
 		fp.CurrentDirectory = workingDir
@@ -129,23 +136,10 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Sequence(
 			util.CmdHandler(dialogs.CloseDialogMsg{}),
 			func() tea.Msg {
-				isFileLarge, err := IsFileTooBig(path, MaxAttachmentSize)
+				attachment, err := NewAttachment(path)
 				if err != nil {
-					return util.ReportError(fmt.Errorf("unable to read the image: %w", err))
+					return util.ReportError(err)
 				}
-				if isFileLarge {
-					return util.ReportError(fmt.Errorf("file too large, max 5MB"))
-				}
-
-				content, err := os.ReadFile(path)
-				if err != nil {
-					return util.ReportError(fmt.Errorf("unable to read the image: %w", err))
-				}
-
-				mimeBufferSize := min(512, len(content))
-				mimeType := http.DetectContentType(content[:mimeBufferSize])
-				fileName := filepath.Base(path)
-				attachment := message.Attachment{FilePath: path, FileName: fileName, MimeType: mimeType, Content: content}
 				return FilePickedMsg{
 					Attachment: attachment,
 				}
@@ -162,7 +156,7 @@ func (m *model) View() string {
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
-		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Add Image", m.width-4)),
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Add File", m.width-4)),
 		m.imagePreview(),
 		m.filePicker.View(),
 		t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
@@ -171,7 +165,7 @@ func (m *model) View() string {
 }
 
 func (m *model) currentImage() string {
-	for _, ext := range m.filePicker.AllowedTypes {
+	for _, ext := range ImageTypes {
 		if strings.HasSuffix(m.filePicker.HighlightedPath(), ext) {
 			return m.filePicker.HighlightedPath()
 		}
@@ -236,3 +230,45 @@ func IsFileTooBig(filePath string, sizeLimit int64) (bool, error) {
 
 	return false, nil
 }
+
+// NewAttachment reads a file to attach: an image, a PDF document, or a text
+// file, which is inlined in the prompt.
+func NewAttachment(path string) (message.Attachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return message.Attachment{}, fmt.Errorf("unable to read the file: %w", err)
+	}
+	if info.IsDir() {
+		return message.Attachment{}, fmt.Errorf("%s is a directory", filepath.Base(path))
+	}
+	if info.Size() > MaxAttachmentSize {
+		return message.Attachment{}, fmt.Errorf("file too large, max 5MB")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return message.Attachment{}, fmt.Errorf("unable to read the file: %w", err)
+	}
+
+	mimeType := http.DetectContentType(content[:min(512, len(content))])
+	switch {
+	case strings.HasPrefix(mimeType, "image/"), mimeType == "application/pdf":
+	case IsText(content):
+		if info.Size() > MaxTextAttachmentSize {
+			return message.Attachment{}, fmt.Errorf("text file too large, max 256KB")
+		}
+		mimeType = "text/plain; charset=utf-8"
+	default:
+		return message.Attachment{}, fmt.Errorf("unsupported file type %s, only images, PDFs and text files can be attached", mimeType)
+	}
+	return message.Attachment{
+		FilePath: path,
+		FileName: filepath.Base(path),
+		MimeType: mimeType,
+		Content:  content,
+	}, nil
+}
+
+// IsText reports whether the content of a file is text.
+func IsText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+}
@@ -114,20 +114,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionAllow)
 		case key.Matches(msg, p.keyMap.AllowSession):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionAllowForSession)
 		case key.Matches(msg, p.keyMap.Deny):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionDeny, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
 			if p.supportsDiffView() {
 				if p.diffSplitMode == nil {
@@ -230,9 +221,15 @@ func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
 		action = PermissionDeny
 	}
 
-	return tea.Batch(
-		util.CmdHandler(PermissionResponseMsg{Action: action, Permission: p.permission}),
+	return p.respond(action)
+}
+
+// respond closes the dialog before answering, so that the answer can open the
+// permission dialog of the next request.
+func (p *permissionDialogCmp) respond(action PermissionAction) tea.Cmd {
+	return tea.Sequence(
 		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(PermissionResponseMsg{Action: action, Permission: p.permission}),
 	)
 }
 
@@ -0,0 +1,83 @@
+package plan
+
+import (
+	"github.com/charmbracelet/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the plan dialog.
+type KeyMap struct {
+	LeftRight,
+	Tab,
+	Confirm,
+	Approve,
+	Edit,
+	Reject,
+	Close key.Binding
+}
+
+func DefaultKeymap() KeyMap {
+	return KeyMap{
+		LeftRight: key.NewBinding(
+			key.WithKeys("left", "right"),
+			key.WithHelp("←/→", "switch options"),
+		),
+		Tab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch options"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "confirm"),
+		),
+		Approve: key.NewBinding(
+			key.WithKeys("a", "A"),
+			key.WithHelp("a", "approve"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e", "E"),
+			key.WithHelp("e", "edit"),
+		),
+		Reject: key.NewBinding(
+			key.WithKeys("r", "R"),
+			key.WithHelp("r", "reject"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "decide later"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.LeftRight,
+		k.Tab,
+		k.Confirm,
+		k.Approve,
+		k.Edit,
+		k.Reject,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := k.KeyBindings()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Approve,
+		k.Edit,
+		k.Reject,
+		k.Close,
+	}
+}
@@ -0,0 +1,206 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	PlanDialogID dialogs.DialogID = "plan"
+
+	dialogWidth = 60
+)
+
+// ApprovedMsg is sent when the user approves a plan. The session leaves plan
+// mode and the agent starts implementing the plan.
+type ApprovedMsg struct {
+	SessionID string
+	Plan      string
+}
+
+// RejectedMsg is sent when the user rejects a plan. The session stays in plan
+// mode so the user can ask for a different plan.
+type RejectedMsg struct {
+	SessionID string
+}
+
+type action int
+
+const (
+	actionApprove action = iota
+	actionEdit
+	actionReject
+)
+
+var actions = []struct {
+	action action
+	label  string
+}{
+	{actionApprove, "Approve"},
+	{actionEdit, "Edit"},
+	{actionReject, "Reject"},
+}
+
+// PlanDialog lets the user approve, edit or reject the plan produced by an
+// agent in plan mode.
+type PlanDialog interface {
+	dialogs.DialogModel
+}
+
+type planDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	sessionID string
+	plan      string
+	edited    bool
+	selected  action
+	keymap    KeyMap
+}
+
+// NewPlanDialogCmp creates a new plan review dialog.
+func NewPlanDialogCmp(sessionID, plan string) PlanDialog {
+	return &planDialogCmp{
+		sessionID: sessionID,
+		plan:      plan,
+		keymap:    DefaultKeymap(),
+	}
+}
+
+func (p *planDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (p *planDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keymap.LeftRight, p.keymap.Tab):
+			if msg.String() == "left" {
+				p.selected = (p.selected + action(len(actions)) - 1) % action(len(actions))
+			} else {
+				p.selected = (p.selected + 1) % action(len(actions))
+			}
+		case key.Matches(msg, p.keymap.Confirm):
+			return p, p.run(p.selected)
+		case key.Matches(msg, p.keymap.Approve):
+			return p, p.run(actionApprove)
+		case key.Matches(msg, p.keymap.Edit):
+			return p, p.run(actionEdit)
+		case key.Matches(msg, p.keymap.Reject):
+			return p, p.run(actionReject)
+		case key.Matches(msg, p.keymap.Close):
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return p, nil
+}
+
+func (p *planDialogCmp) run(a action) tea.Cmd {
+	closeDialog := util.CmdHandler(dialogs.CloseDialogMsg{})
+	switch a {
+	case actionApprove:
+		return tea.Sequence(closeDialog, util.CmdHandler(ApprovedMsg{
+			SessionID: p.sessionID,
+			Plan:      p.plan,
+		}))
+	case actionEdit:
+		return tea.Sequence(closeDialog, p.openEditor())
+	default:
+		return tea.Sequence(closeDialog, util.CmdHandler(RejectedMsg{
+			SessionID: p.sessionID,
+		}))
+	}
+}
+
+// openEditor opens the plan in $EDITOR and brings the dialog back with the
+// edited plan so it can be reviewed again.
+func (p *planDialogCmp) openEditor() tea.Cmd {
+	return util.OpenEditor("plan_*.md", p.plan, func(content string) tea.Msg {
+		plan := strings.TrimSpace(content)
+		if plan == "" {
+			return util.ReportWarn("Plan is empty")()
+		}
+		edited := NewPlanDialogCmp(p.sessionID, plan).(*planDialogCmp)
+		edited.edited = true
+		return dialogs.OpenDialogMsg{Model: edited}
+	})
+}
+
+// summary returns the plan title, or its first line when the agent did not
+// follow the expected structure.
+func (p *planDialogCmp) summary() string {
+	first := ""
+	for line := range strings.SplitSeq(p.plan, "\n") {
+		line = strings.TrimSpace(line)
+		if title, ok := strings.CutPrefix(line, "## Plan:"); ok {
+			return strings.TrimSpace(title)
+		}
+		if first == "" && line != "" {
+			first = strings.TrimLeft(line, "# ")
+		}
+	}
+	return first
+}
+
+func (p *planDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	contentWidth := dialogWidth - 4
+
+	title := "Plan ready for review"
+	if p.edited {
+		title = "Edited plan ready for review"
+	}
+
+	var buttons []string
+	for _, a := range actions {
+		style := t.S().Text.PaddingLeft(2).PaddingRight(2)
+		if a.action == p.selected {
+			style = style.Foreground(t.White).Background(t.Secondary)
+		} else {
+			style = style.Background(t.BgSubtle)
+		}
+		buttons = append(buttons, style.Render(a.label), "  ")
+	}
+
+	content := baseStyle.Width(contentWidth).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Title.Render(title),
+			"",
+			t.S().Text.Render(ansi.Truncate(p.summary(), contentWidth, "…")),
+			t.S().Muted.Width(contentWidth).Render("Approve to let the agent implement it, edit it in your editor, or reject it and ask for a different plan."),
+			"",
+			baseStyle.Width(contentWidth).Align(lipgloss.Right).Render(
+				lipgloss.JoinHorizontal(lipgloss.Center, buttons[:len(buttons)-1]...),
+			),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (p *planDialogCmp) Position() (int, int) {
+	row := p.wHeight/2 - 4
+	col := p.wWidth/2 - dialogWidth/2
+	return row, col
+}
+
+func (p *planDialogCmp) ID() dialogs.DialogID {
+	return PlanDialogID
+}
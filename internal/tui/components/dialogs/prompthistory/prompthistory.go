@@ -0,0 +1,164 @@
+package prompthistory
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const PromptHistoryDialogID dialogs.DialogID = "prompt_history"
+
+// PromptSelectedMsg is sent when a prompt of the history is picked, to put it
+// back in the editor.
+type PromptSelectedMsg struct {
+	Prompt string
+}
+
+// PromptHistoryDialog searches the prompts sent in the project.
+type PromptHistoryDialog interface {
+	dialogs.DialogModel
+}
+
+type PromptsList = list.FilterableList[list.CompletionItem[string]]
+
+type promptHistoryDialogCmp struct {
+	wWidth      int
+	wHeight     int
+	width       int
+	keyMap      KeyMap
+	promptsList PromptsList
+	help        help.Model
+}
+
+// NewPromptHistoryDialogCmp creates the search of the prompts, which are
+// listed most recent first.
+func NewPromptHistoryDialogCmp(prompts []string) PromptHistoryDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	items := make([]list.CompletionItem[string], len(prompts))
+	for i, prompt := range prompts {
+		// Multi-line prompts are listed on one line.
+		title := strings.Join(strings.Fields(prompt), " ")
+		items[i] = list.NewCompletionItem(title, prompt, list.WithCompletionID(strconv.Itoa(i)))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	promptsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Search your prompts"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &promptHistoryDialogCmp{
+		keyMap:      keyMap,
+		promptsList: promptsList,
+		help:        help,
+	}
+}
+
+func (p *promptHistoryDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(p.promptsList.Init(), p.promptsList.Focus())
+}
+
+func (p *promptHistoryDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+		p.width = min(120, p.wWidth-8)
+		p.promptsList.SetInputWidth(p.listWidth() - 2)
+		return p, p.promptsList.SetSize(p.listWidth(), p.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Select):
+			selectedItem := p.promptsList.SelectedItem()
+			if selectedItem == nil {
+				return p, nil
+			}
+			return p, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(PromptSelectedMsg{Prompt: (*selectedItem).Value()}),
+			)
+		case key.Matches(msg, p.keyMap.Close):
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := p.promptsList.Update(msg)
+			p.promptsList = u.(PromptsList)
+			return p, cmd
+		}
+	}
+	return p, nil
+}
+
+func (p *promptHistoryDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Prompt History", p.width-4)),
+		p.promptsList.View(),
+		"",
+		t.S().Base.Width(p.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(p.help.View(p.keyMap)),
+	)
+	return p.style().Render(content)
+}
+
+func (p *promptHistoryDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := p.promptsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := p.Position()
+			cursor.Y += row + 3 // Border + title
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (p *promptHistoryDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(p.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (p *promptHistoryDialogCmp) listHeight() int {
+	return p.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (p *promptHistoryDialogCmp) listWidth() int {
+	return p.width - 2 // 2 for the border
+}
+
+func (p *promptHistoryDialogCmp) Position() (int, int) {
+	row := p.wHeight/4 - 2 // just a bit above the center
+	col := p.wWidth / 2
+	col -= p.width / 2
+	return row, col
+}
+
+// ID implements PromptHistoryDialog.
+func (p *promptHistoryDialogCmp) ID() dialogs.DialogID {
+	return PromptHistoryDialogID
+}
@@ -0,0 +1,278 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"math"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/disintegration/imageorient"
+	"github.com/nfnt/resize"
+)
+
+const (
+	// MaxColumns and MaxRows bound the size of the images in the chat.
+	MaxColumns = 60
+	MaxRows    = 15
+
+	// cellWidth is the width in pixels assumed for a cell, so that small
+	// images aren't blown up. Cells are assumed twice as tall as wide.
+	cellWidth = 8
+)
+
+// transmitted holds the IDs of the images sent to the terminal with the kitty
+// graphics protocol, they stay there until the terminal is closed.
+var transmitted sync.Map
+
+// Inline is an image drawn in the chat.
+//
+// With the kitty graphics protocol, the image is sent to the terminal once and
+// drawn in place of Unicode placeholders, which are regular cells. Sixel and
+// iTerm2 images are drawn at the cursor over whatever is there, so these are
+// drawn with half blocks in the chat and the image is drawn over them once
+// they stay in place, see [Placements].
+//
+// Decoding and resizing take a while, so images are laid out off the update
+// loop: [Inline.SetWidth] returns the command doing it, and the resulting
+// [LaidOutMsg] is handed back with [Inline.Update].
+type Inline struct {
+	data []byte
+
+	// The image is decoded once, by the first layout.
+	decode    sync.Once
+	img       image.Image
+	decodeErr error
+
+	// width and protocol are the layout asked for last, and requested is
+	// false until one is.
+	width     int
+	protocol  Protocol
+	requested bool
+
+	view string
+	err  error
+	// transmit is the kitty graphics sequence sending the image, and id the
+	// ID it's sent with.
+	transmit string
+	id       uint32
+	// overlay is the sixel or iTerm2 sequence drawing the image over its
+	// half blocks, which are marked with id, and rows their number.
+	overlay string
+	rows    int
+}
+
+// LaidOutMsg is sent once an image is laid out.
+type LaidOutMsg struct {
+	image    *Inline
+	width    int
+	protocol Protocol
+	view     string
+	err      error
+	transmit string
+	id       uint32
+	overlay  string
+	rows     int
+}
+
+// NewInline creates an image from its encoded data.
+func NewInline(data []byte) *Inline {
+	return &Inline{data: data}
+}
+
+// SetWidth returns the command laying the image out within width cells, or
+// nil when it already is.
+func (i *Inline) SetWidth(width int) tea.Cmd {
+	protocol, ok := Current()
+	if !ok {
+		i.view, i.requested = "", false
+		return nil
+	}
+	if i.requested && width == i.width && protocol == i.protocol {
+		return nil
+	}
+	i.width, i.protocol, i.requested = width, protocol, true
+	return func() tea.Msg {
+		return i.layout(width, protocol)
+	}
+}
+
+// Update takes the layout of the image, unless a newer one was asked for
+// since. It reports whether the view changed, and returns the command that
+// sends the image to the terminal when the protocol needs it.
+func (i *Inline) Update(msg LaidOutMsg) (bool, tea.Cmd) {
+	if msg.image != i || !i.requested || msg.width != i.width || msg.protocol != i.protocol {
+		return false, nil
+	}
+	if i.overlay != "" && i.id != msg.id {
+		overlays.CompareAndDelete(i.id, i)
+	}
+	i.view, i.err, i.transmit, i.id = msg.view, msg.err, msg.transmit, msg.id
+	i.overlay, i.rows = msg.overlay, msg.rows
+	if i.overlay != "" {
+		overlays.Store(i.id, i)
+	}
+	if i.transmit == "" {
+		return true, nil
+	}
+	if _, sent := transmitted.LoadOrStore(i.id, true); sent {
+		return true, nil
+	}
+	return true, tea.Raw(i.transmit)
+}
+
+// View returns the image, or nothing when images are turned off or it isn't
+// laid out yet.
+func (i *Inline) View() string {
+	return i.view
+}
+
+// Err returns why the image couldn't be decoded.
+func (i *Inline) Err() error {
+	return i.err
+}
+
+// layout draws the image within width cells. It only reads the data of the
+// image, so it can run off the update loop.
+func (i *Inline) layout(width int, protocol Protocol) LaidOutMsg {
+	msg := LaidOutMsg{image: i, width: width, protocol: protocol}
+	i.decode.Do(func() {
+		i.img, i.decodeErr = Decode(i.data)
+	})
+	if i.decodeErr != nil {
+		msg.err = i.decodeErr
+		return msg
+	}
+
+	b := i.img.Bounds()
+	cols, rows := Fit(b.Dx(), b.Dy(), min(width, MaxColumns), MaxRows)
+	switch protocol {
+	case HalfBlocks:
+		msg.view = halfBlocks(i.img, cols, rows)
+		return msg
+	case Sixel, ITerm2:
+		msg.view = halfBlocks(i.img, cols, rows)
+		overlay, err := encode(i.img, protocol, cols, rows)
+		if err != nil {
+			// The half blocks are enough.
+			return msg
+		}
+		msg.id = imageID(i.data, cols, rows)
+		msg.view = markRows(msg.id, msg.view)
+		msg.overlay, msg.rows = overlay, rows
+		return msg
+	}
+
+	msg.id = imageID(i.data, cols, rows)
+	var buf bytes.Buffer
+	// Send no more pixels than the cells can show.
+	img := resize.Thumbnail(uint(cols*cellWidth*2), uint(rows*cellWidth*4), i.img, resize.Lanczos3)
+	err := kitty.EncodeGraphics(&buf, img, &kitty.Options{
+		Action:           kitty.TransmitAndPut,
+		Transmission:     kitty.Direct,
+		Format:           kitty.PNG,
+		Chunk:            true,
+		Quite:            2,
+		ID:               int(msg.id),
+		Columns:          cols,
+		Rows:             rows,
+		VirtualPlacement: true,
+	})
+	if err != nil {
+		msg.err = fmt.Errorf("failed to encode image: %w", err)
+		return msg
+	}
+	msg.transmit = buf.String()
+	msg.view = kittyPlaceholders(msg.id, cols, rows)
+	return msg
+}
+
+// Decode decodes an image, following its EXIF orientation.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := imageorient.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Fit returns the cells an image of width by height pixels takes, keeping
+// its aspect ratio within maxCols by maxRows cells.
+func Fit(width, height, maxCols, maxRows int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return 1, 1
+	}
+	cols := max(1, min(maxCols, (width+cellWidth-1)/cellWidth))
+	rows := max(1, int(math.Round(float64(cols)*float64(height)/float64(width)/2)))
+	if rows > maxRows {
+		rows = maxRows
+		cols = max(1, min(maxCols, int(math.Round(float64(rows)*2*float64(width)/float64(height)))))
+	}
+	return cols, rows
+}
+
+// imageID returns the ID of an image laid out in cols by rows cells. The ID
+// is the foreground color of the kitty placeholders, so it fits in 24 bits.
+func imageID(data []byte, cols, rows int) uint32 {
+	h := fnv.New32a()
+	h.Write(data) //nolint:errcheck
+	fmt.Fprintf(h, "%dx%d", cols, rows)
+	return max(1, h.Sum32()&0xffffff)
+}
+
+// kittyPlaceholders returns the Unicode placeholders of a kitty image, the
+// diacritics give the row and column of each cell.
+func kittyPlaceholders(id uint32, cols, rows int) string {
+	fg := ansi.Style{}.ForegroundColor(color.RGBA{
+		R: uint8(id >> 16),
+		G: uint8(id >> 8),
+		B: uint8(id),
+		A: 0xff,
+	}).String()
+	lines := make([]string, rows)
+	for row := range rows {
+		var b strings.Builder
+		b.WriteString(fg)
+		for col := range cols {
+			b.WriteRune(kitty.Placeholder)
+			b.WriteRune(kitty.Diacritic(row))
+			b.WriteRune(kitty.Diacritic(col))
+		}
+		b.WriteString(ansi.ResetStyle)
+		lines[row] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// halfBlocks draws an image in cols by rows cells, each cell being two pixels
+// with the colors of "▀".
+func halfBlocks(img image.Image, cols, rows int) string {
+	img = resize.Resize(uint(cols), uint(rows*2), img, resize.Lanczos3)
+	lines := make([]string, rows)
+	for row := range rows {
+		var b strings.Builder
+		for col := range cols {
+			b.WriteString(ansi.Style{}.
+				ForegroundColor(opaque(img.At(col, row*2))).
+				BackgroundColor(opaque(img.At(col, row*2+1))).
+				String())
+			b.WriteString("▀")
+		}
+		b.WriteString(ansi.ResetStyle)
+		lines[row] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// opaque drops the transparency of a color, as cells can't be see-through.
+func opaque(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
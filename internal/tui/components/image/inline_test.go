@@ -0,0 +1,138 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		width, height int
+		cols, rows    int
+	}{
+		{"small image keeps its size", 80, 80, 10, 5},
+		{"wide image is bound by columns", 1920, 1080, 60, 17},
+		{"tall image is bound by rows", 400, 1600, 8, 15},
+		{"empty image", 0, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cols, rows := Fit(tt.width, tt.height, 60, tt.rows)
+			require.Equal(t, tt.cols, cols)
+			require.Equal(t, tt.rows, rows)
+		})
+	}
+}
+
+func TestHalfBlocks(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := range 4 {
+		img.Set(x, 0, color.RGBA{R: 0xff, A: 0xff})
+	}
+	view := halfBlocks(img, 4, 2)
+	lines := strings.Split(view, "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		require.Equal(t, 4, ansi.StringWidth(line))
+	}
+	// The top row is red over black, the bottom one all black.
+	require.Contains(t, lines[0], "\x1b[38;2;255;0;0;48;2;0;0;0m▀")
+	require.Contains(t, lines[1], "\x1b[38;2;0;0;0;48;2;0;0;0m▀")
+}
+
+func TestKittyPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	view := kittyPlaceholders(0x123456, 3, 2)
+	lines := strings.Split(view, "\n")
+	require.Len(t, lines, 2)
+	for row, line := range lines {
+		require.True(t, strings.HasPrefix(line, "\x1b[38;2;18;52;86m"))
+		cells := []rune(ansi.Strip(line))
+		require.Len(t, cells, 9)
+		for col := range 3 {
+			require.Equal(t, kitty.Placeholder, cells[col*3])
+			require.Equal(t, kitty.Diacritic(row), cells[col*3+1])
+			require.Equal(t, kitty.Diacritic(col), cells[col*3+2])
+		}
+	}
+}
+
+func TestImageID(t *testing.T) {
+	t.Parallel()
+
+	id := imageID([]byte("image"), 10, 5)
+	require.NotZero(t, id)
+	require.Less(t, id, uint32(1<<24))
+	require.Equal(t, id, imageID([]byte("image"), 10, 5))
+	require.NotEqual(t, id, imageID([]byte("image"), 20, 10))
+}
+
+func TestInline(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))))
+
+	img := NewInline(buf.Bytes())
+	laidOut := img.layout(40, HalfBlocks)
+	require.NoError(t, laidOut.err)
+	require.Equal(t, 2, ansi.StringWidth(laidOut.view))
+	require.Empty(t, laidOut.transmit)
+
+	// Layouts are only taken when they are the last one asked for.
+	changed, _ := img.Update(laidOut)
+	require.False(t, changed)
+	img.width, img.protocol, img.requested = 40, HalfBlocks, true
+	changed, cmd := img.Update(laidOut)
+	require.True(t, changed)
+	require.Nil(t, cmd)
+	require.Equal(t, laidOut.view, img.View())
+	changed, _ = img.Update(img.layout(20, HalfBlocks))
+	require.False(t, changed)
+
+	laidOut = img.layout(40, Kitty)
+	require.NoError(t, laidOut.err)
+	require.True(t, strings.HasPrefix(laidOut.transmit, "\x1b_G"))
+	require.Contains(t, laidOut.transmit, "U=1")
+
+	broken := NewInline([]byte("not an image"))
+	broken.width, broken.protocol, broken.requested = 40, HalfBlocks, true
+	changed, _ = broken.Update(broken.layout(40, HalfBlocks))
+	require.True(t, changed)
+	require.Error(t, broken.Err())
+	require.Empty(t, broken.View())
+}
+
+func TestDetect(t *testing.T) {
+	defer detected.Store(int32(HalfBlocks))
+
+	env := map[string]string{"TERM_PROGRAM": "WezTerm"}
+	DetectFromEnv(func(key string) string { return env[key] })
+	require.Equal(t, ITerm2, Protocol(detected.Load()))
+
+	// A less preferred protocol doesn't replace the detected one.
+	DetectFromDeviceAttributes([]int{62, 4, 22})
+	require.Equal(t, ITerm2, Protocol(detected.Load()))
+
+	DetectFromKittyResponse(kitty.Options{ID: 7}, []byte("OK"))
+	require.Equal(t, ITerm2, Protocol(detected.Load()))
+	DetectFromKittyResponse(kitty.Options{ID: kittyQueryID}, []byte("ENOTSUPPORTED"))
+	require.Equal(t, ITerm2, Protocol(detected.Load()))
+	DetectFromKittyResponse(kitty.Options{ID: kittyQueryID}, []byte("OK"))
+	require.Equal(t, Kitty, Protocol(detected.Load()))
+}
@@ -0,0 +1,179 @@
+package image
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// overlayDelay is how long images drawn over the chat must stay in place
+// before they are drawn, so that they aren't drawn on every frame while the
+// chat scrolls or a response streams in.
+const overlayDelay = 100 * time.Millisecond
+
+// overlayMarker starts the marker of a row of an image drawn over the chat.
+// It's an OSC sequence no terminal uses, which the renderer drops from the
+// frame, so it never reaches the terminal.
+const overlayMarker = "\x1b]5150;crush-image;"
+
+var overlayPattern = regexp.MustCompile(`\x1b\]5150;crush-image;(\d+);(\d+)\x07`)
+
+// overlays holds the images drawn over the chat by ID.
+var overlays sync.Map
+
+// markRows marks each row of the half blocks of an image with the ID of the
+// image, for [Placements] to find them in the frame.
+func markRows(id uint32, view string) string {
+	lines := strings.Split(view, "\n")
+	for row, line := range lines {
+		lines[row] = fmt.Sprintf("%s%d;%d\a%s", overlayMarker, id, row, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Placement is where an image drawn over the chat goes on the screen.
+type Placement struct {
+	ID   uint32
+	X, Y int
+}
+
+// Placements returns where the images drawn over the chat are in a frame.
+// Images cut by the edge of the chat are left out, as they would be drawn
+// over what is next to them.
+func Placements(frame string) []Placement {
+	if !strings.Contains(frame, overlayMarker) {
+		return nil
+	}
+	type candidate struct {
+		Placement
+		next int
+	}
+	var candidates []*candidate
+	for y, line := range strings.Split(frame, "\n") {
+		for _, match := range overlayPattern.FindAllStringSubmatchIndex(line, -1) {
+			id, err := strconv.ParseUint(line[match[2]:match[3]], 10, 32)
+			if err != nil {
+				continue
+			}
+			row, _ := strconv.Atoi(line[match[4]:match[5]])
+			x := ansi.StringWidth(line[:match[0]])
+			if row == 0 {
+				candidates = append(candidates, &candidate{Placement: Placement{ID: uint32(id), X: x, Y: y}, next: 1})
+				continue
+			}
+			for _, c := range candidates {
+				if c.ID == uint32(id) && c.X == x && c.Y+row == y && c.next == row {
+					c.next++
+				}
+			}
+		}
+	}
+
+	var placements []Placement
+	for _, c := range candidates {
+		if img, ok := overlays.Load(c.ID); ok && img.(*Inline).rows == c.next {
+			placements = append(placements, c.Placement)
+		}
+	}
+	return placements
+}
+
+// Draw returns the sequence drawing the images at their placements, leaving
+// the cursor where it was.
+func Draw(placements []Placement) string {
+	var b strings.Builder
+	for _, p := range placements {
+		img, ok := overlays.Load(p.ID)
+		if !ok {
+			continue
+		}
+		b.WriteString(ansi.SaveCursor)
+		b.WriteString(ansi.CursorPosition(p.X+1, p.Y+1))
+		b.WriteString(img.(*Inline).overlay)
+		b.WriteString(ansi.RestoreCursor)
+	}
+	return b.String()
+}
+
+// DrawMsg asks to draw the images over the chat when they stayed in place.
+// Redraw is set when the screen was taken over, by [Show] for instance, and
+// the images drawn before are gone.
+type DrawMsg struct {
+	Redraw bool
+}
+
+// Overlay keeps the sixel and iTerm2 images drawn over the chat in step with
+// the frames. The renderer doesn't know about these images, so when they move
+// or go away the screen is cleared, which draws the frame again without them,
+// and they are drawn again once they stay in place.
+type Overlay struct {
+	// frame holds the placements of the last frame, settled the ones seen
+	// when last checked and drawn the ones on the screen.
+	frame, settled, drawn []Placement
+	pending               bool
+}
+
+// SetFrame takes the frame about to be drawn, or nothing when the images
+// can't be drawn over it, like when a dialog is open.
+func (o *Overlay) SetFrame(frame string) {
+	o.frame = Placements(frame)
+}
+
+// Schedule returns the command checking the images after the frame is drawn,
+// or nil when there is nothing to draw or a check is already scheduled.
+func (o *Overlay) Schedule() tea.Cmd {
+	if o.pending || !overlaid() {
+		return nil
+	}
+	o.pending = true
+	return tea.Tick(overlayDelay, func(time.Time) tea.Msg {
+		return DrawMsg{}
+	})
+}
+
+// Update draws the images once they stay in place, and clears the screen of
+// the ones that moved.
+func (o *Overlay) Update(msg DrawMsg) tea.Cmd {
+	o.pending = false
+	if msg.Redraw {
+		o.drawn = nil
+	}
+	if slices.Equal(o.frame, o.drawn) {
+		return nil
+	}
+	if len(o.drawn) > 0 {
+		o.drawn, o.settled = nil, o.frame
+		return tea.Batch(tea.ClearScreen, o.Schedule())
+	}
+	if !slices.Equal(o.frame, o.settled) {
+		o.settled = o.frame
+		return o.Schedule()
+	}
+	if len(o.frame) == 0 {
+		return nil
+	}
+	o.drawn = o.frame
+	return tea.Raw(Draw(o.drawn))
+}
+
+// overlaid reports whether images are drawn over the chat, which is the case
+// with sixel and iTerm2 once an image is laid out.
+func overlaid() bool {
+	protocol, ok := Current()
+	if !ok || (protocol != Sixel && protocol != ITerm2) {
+		return false
+	}
+	var found bool
+	overlays.Range(func(any, any) bool {
+		found = true
+		return false
+	})
+	return found
+}
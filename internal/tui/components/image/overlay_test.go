@@ -0,0 +1,89 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacements(t *testing.T) {
+	t.Parallel()
+
+	img := &Inline{id: 0xabc, rows: 2, overlay: "sixel"}
+	overlays.Store(img.id, img)
+	t.Cleanup(func() { overlays.Delete(img.id) })
+
+	view := markRows(img.id, "▀▀▀\n▀▀▀")
+	frame := lipgloss.JoinVertical(lipgloss.Left,
+		"header",
+		lipgloss.JoinHorizontal(lipgloss.Top, "│ ", lipgloss.NewStyle().Padding(0, 1).Render(view), " sidebar"),
+		"footer",
+	)
+	placements := Placements(frame)
+	require.Equal(t, []Placement{{ID: 0xabc, X: 3, Y: 1}}, placements)
+	require.Equal(t, "\x1b7\x1b[2;4Hsixel\x1b8", Draw(placements))
+
+	// The markers don't reach the terminal.
+	scr := uv.NewScreenBuffer(20, 4)
+	uv.NewStyledString(frame).Draw(scr, scr.Bounds())
+	require.NotContains(t, scr.Render(), "5150")
+
+	// Images cut by the edge of the chat aren't drawn.
+	require.Empty(t, Placements(strings.Split(frame, "\n")[1]))
+	require.Empty(t, Placements(markRows(0xdef, "▀\n▀")))
+}
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+
+	img := &Inline{id: 0x123, rows: 1, overlay: "sixel"}
+	overlays.Store(img.id, img)
+	t.Cleanup(func() { overlays.Delete(img.id) })
+	frame := "\n" + markRows(img.id, "▀")
+
+	var o Overlay
+	o.SetFrame(frame)
+	// The images are only drawn once they stay in place.
+	o.Update(DrawMsg{})
+	require.Empty(t, o.drawn)
+	require.Equal(t, tea.Raw("\x1b7\x1b[2;1Hsixel\x1b8")(), o.Update(DrawMsg{})())
+	require.Nil(t, o.Update(DrawMsg{}))
+
+	// Moved images are cleared, then drawn again.
+	o.SetFrame("\n" + frame)
+	require.NotNil(t, o.Update(DrawMsg{}))
+	require.Empty(t, o.drawn)
+	o.Update(DrawMsg{})
+	require.Len(t, o.drawn, 1)
+	require.Equal(t, 2, o.drawn[0].Y)
+
+	// The viewer took the screen over.
+	require.NotNil(t, o.Update(DrawMsg{Redraw: true}))
+}
+
+func TestInlineOverlay(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))))
+
+	img := NewInline(buf.Bytes())
+	img.width, img.protocol, img.requested = 40, ITerm2, true
+	laidOut := img.layout(40, ITerm2)
+	require.NoError(t, laidOut.err)
+	require.True(t, strings.HasPrefix(laidOut.overlay, "\x1b]1337;File="))
+	require.Equal(t, 1, laidOut.rows)
+
+	changed, cmd := img.Update(laidOut)
+	require.True(t, changed)
+	require.Nil(t, cmd)
+	t.Cleanup(func() { overlays.Delete(img.id) })
+	require.Equal(t, []Placement{{ID: img.id, X: 0, Y: 0}}, Placements(img.View()))
+}
@@ -0,0 +1,117 @@
+package image
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/kitty"
+)
+
+// Protocol is a way to draw images in the terminal. Protocols are ordered
+// from the least to the most preferred.
+type Protocol int32
+
+const (
+	// HalfBlocks draws two pixels per cell with the colors of "▀", it works
+	// in any terminal with true colors.
+	HalfBlocks Protocol = iota
+	// Sixel is the sixel graphics of DEC terminals, supported by xterm, foot,
+	// mlterm and Windows Terminal among others.
+	Sixel
+	// ITerm2 is the inline images protocol of iTerm2, also supported by
+	// WezTerm.
+	ITerm2
+	// Kitty is the kitty graphics protocol, supported by kitty and Ghostty.
+	Kitty
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Sixel:
+		return "sixel"
+	case ITerm2:
+		return "iterm2"
+	case Kitty:
+		return "kitty"
+	default:
+		return "halfblocks"
+	}
+}
+
+// kittyQueryID is the ID of the image of the kitty graphics query.
+const kittyQueryID = 31
+
+// Query asks the terminal whether it supports the kitty graphics protocol and
+// sixel graphics, the answers come back as a kitty graphics response and as
+// the primary device attributes.
+var Query = ansi.KittyGraphics([]byte("AAAA"), "i=31", "s=1", "v=1", "a=q", "t=d", "f=24") +
+	ansi.RequestPrimaryDeviceAttributes
+
+var detected atomic.Int32
+
+// Detect records a protocol the terminal supports, the most preferred one
+// is kept.
+func Detect(p Protocol) {
+	for {
+		current := detected.Load()
+		if int32(p) <= current || detected.CompareAndSwap(current, int32(p)) {
+			return
+		}
+	}
+}
+
+// DetectFromEnv detects the protocols of the terminals that can be told from
+// the environment, which also covers terminals that don't answer queries.
+func DetectFromEnv(getenv func(string) string) {
+	term := getenv("TERM")
+	program := getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty",
+		term == "xterm-ghostty", program == "ghostty":
+		Detect(Kitty)
+	case program == "iTerm.app", program == "WezTerm", getenv("LC_TERMINAL") == "iTerm2":
+		Detect(ITerm2)
+	}
+}
+
+// DetectFromKittyResponse detects the kitty graphics protocol from the
+// answer to the query.
+func DetectFromKittyResponse(opts kitty.Options, payload []byte) {
+	if opts.ID == kittyQueryID && strings.HasPrefix(string(payload), "OK") {
+		Detect(Kitty)
+	}
+}
+
+// DetectFromDeviceAttributes detects sixel graphics from the primary device
+// attributes of the terminal.
+func DetectFromDeviceAttributes(attrs []int) {
+	if slices.Contains(attrs, 4) {
+		Detect(Sixel)
+	}
+}
+
+// Current returns the protocol images are drawn with, as set in the
+// configuration or else detected, and false when images are turned off.
+func Current() (Protocol, bool) {
+	mode := config.ImageModeAuto
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil && cfg.Options.TUI != nil {
+		mode = cfg.Options.TUI.Images
+	}
+	switch mode {
+	case config.ImageModeOff:
+		return HalfBlocks, false
+	case config.ImageModeKitty:
+		return Kitty, true
+	case config.ImageModeITerm2:
+		return ITerm2, true
+	case config.ImageModeSixel:
+		return Sixel, true
+	case config.ImageModeHalfBlocks:
+		return HalfBlocks, true
+	default:
+		return Protocol(detected.Load()), true
+	}
+}
@@ -0,0 +1,127 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+	"github.com/charmbracelet/x/term"
+	"github.com/nfnt/resize"
+)
+
+// viewerID is the ID of the kitty image of [Show].
+const viewerID = 32
+
+// Show shows an image full size, out of the chat, with the graphics protocol
+// of the terminal, until enter is pressed.
+func Show(data []byte) tea.Cmd {
+	protocol, ok := Current()
+	if !ok {
+		protocol = HalfBlocks
+	}
+	return tea.Exec(&viewer{data: data, protocol: protocol}, func(err error) tea.Msg {
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		// The viewer cleared the images drawn over the chat.
+		return DrawMsg{Redraw: true}
+	})
+}
+
+// viewer draws an image on the terminal released by the program.
+type viewer struct {
+	data     []byte
+	protocol Protocol
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *viewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *viewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *viewer) SetStderr(io.Writer)   {}
+
+func (v *viewer) Run() error {
+	img, err := Decode(v.data)
+	if err != nil {
+		return err
+	}
+	cols, rows := 80, 24
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		cols, rows = w, h
+	}
+	b := img.Bounds()
+	// Leave a line for the prompt, and let small images grow up to four
+	// times their size.
+	cols, rows = Fit(b.Dx()*4, b.Dy()*4, cols, rows-2)
+
+	seq, err := encode(img, v.protocol, cols, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(v.stdout, ansi.EraseEntireScreen+ansi.CursorHomePosition+seq)
+	fmt.Fprintf(v.stdout, "\r\n%dx%d, press enter to go back", b.Dx(), b.Dy())
+	_, err = bufio.NewReader(v.stdin).ReadString('\n')
+	if v.protocol == Kitty {
+		// Drop the image, the ones of the chat stay.
+		fmt.Fprint(v.stdout, ansi.KittyGraphics(nil, "a=d", "d=i", fmt.Sprintf("i=%d", viewerID)))
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil
+}
+
+// encode returns the sequence drawing an image in cols by rows cells at the
+// cursor.
+func encode(img image.Image, protocol Protocol, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	switch protocol {
+	case Kitty:
+		err := kitty.EncodeGraphics(&buf, img, &kitty.Options{
+			Action:       kitty.TransmitAndPut,
+			Transmission: kitty.Direct,
+			Format:       kitty.PNG,
+			Chunk:        true,
+			Quite:        2,
+			ID:           viewerID,
+			Columns:      cols,
+			Rows:         rows,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.String(), nil
+	case ITerm2:
+		var png bytes.Buffer
+		if err := (&kitty.Encoder{Format: kitty.PNG}).Encode(&png, img); err != nil {
+			return "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return ansi.ITerm2(iterm2.File{
+			Inline:  true,
+			Size:    int64(png.Len()),
+			Width:   iterm2.Cells(cols),
+			Height:  iterm2.Cells(rows),
+			Content: []byte(base64.StdEncoding.EncodeToString(png.Bytes())),
+		}), nil
+	case Sixel:
+		// Sixel is drawn in pixels, assume the usual cell size.
+		img = resize.Thumbnail(uint(cols*cellWidth), uint(rows*cellWidth*2), img, resize.Lanczos3)
+		if err := (&sixel.Encoder{}).Encode(&buf, img); err != nil {
+			return "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return ansi.SixelGraphics(0, 1, 0, buf.Bytes()), nil
+	default:
+		return halfBlocks(img, cols, rows), nil
+	}
+}
@@ -65,11 +65,17 @@ func RenderMCPList(opts RenderOptions) []string {
 			case agent.MCPStateStarting:
 				icon = t.ItemBusyIcon
 				description = t.S().Subtle.Render("starting...")
+			case agent.MCPStateRestarting:
+				icon = t.ItemBusyIcon
+				description = t.S().Subtle.Render("restarting...")
 			case agent.MCPStateConnected:
 				icon = t.ItemOnlineIcon
 				if state.ToolCount > 0 {
 					extraContent = t.S().Subtle.Render(fmt.Sprintf("%d tools", state.ToolCount))
 				}
+			case agent.MCPStateAuthRequired:
+				icon = t.ItemErrorIcon
+				description = t.S().Subtle.Render(fmt.Sprintf("auth required: crush mcp login %s", l.Name))
 			case agent.MCPStateError:
 				icon = t.ItemErrorIcon
 				if state.Error != nil {
@@ -17,41 +17,81 @@ var _ chroma.Formatter = chromaFormatter{}
 // foreground styling, while keeping a forced background color.
 type chromaFormatter struct {
 	bgColor color.Color
+
+	// emphasis are the ranges of the source rendered over emphasisColor
+	// instead of bgColor.
+	emphasis      []span
+	emphasisColor color.Color
 }
 
 // Format implements the chroma.Formatter interface.
 func (c chromaFormatter) Format(w io.Writer, style *chroma.Style, it chroma.Iterator) error {
+	offset := 0
 	for token := it(); token != chroma.EOF; token = it() {
+		start := offset
+		offset += len(token.Value)
 		value := strings.TrimRight(token.Value, "\n")
-		value = ansiext.Escape(value)
 
-		entry := style.Get(token.Type)
-		if entry.IsZero() {
-			if _, err := fmt.Fprint(w, value); err != nil {
+		var entry chroma.StyleEntry
+		if style != nil {
+			entry = style.Get(token.Type)
+		}
+		for len(value) > 0 {
+			part, emphasized := c.nextPart(value, start)
+			if err := c.write(w, entry, part, emphasized); err != nil {
 				return err
 			}
+			value = value[len(part):]
+			start += len(part)
+		}
+	}
+	return nil
+}
+
+// nextPart returns the beginning of value, which starts at offset in the
+// source, up to where its emphasis changes.
+func (c chromaFormatter) nextPart(value string, offset int) (string, bool) {
+	end := offset + len(value)
+	for _, s := range c.emphasis {
+		switch {
+		case s.end <= offset:
 			continue
+		case s.start <= offset:
+			return value[:min(s.end, end)-offset], true
+		case s.start < end:
+			return value[:s.start-offset], false
 		}
+		break
+	}
+	return value, false
+}
 
-		s := lipgloss.NewStyle().
-			Background(c.bgColor)
+func (c chromaFormatter) write(w io.Writer, entry chroma.StyleEntry, value string, emphasized bool) error {
+	value = ansiext.Escape(value)
+	if entry.IsZero() && !emphasized {
+		_, err := fmt.Fprint(w, value)
+		return err
+	}
 
-		if entry.Bold == chroma.Yes {
-			s = s.Bold(true)
-		}
-		if entry.Underline == chroma.Yes {
-			s = s.Underline(true)
-		}
-		if entry.Italic == chroma.Yes {
-			s = s.Italic(true)
-		}
-		if entry.Colour.IsSet() {
-			s = s.Foreground(lipgloss.Color(entry.Colour.String()))
-		}
+	s := lipgloss.NewStyle().
+		Background(c.bgColor)
+	if emphasized {
+		s = s.Background(c.emphasisColor)
+	}
 
-		if _, err := fmt.Fprint(w, s.Render(value)); err != nil {
-			return err
-		}
+	if entry.Bold == chroma.Yes {
+		s = s.Bold(true)
 	}
-	return nil
+	if entry.Underline == chroma.Yes {
+		s = s.Underline(true)
+	}
+	if entry.Italic == chroma.Yes {
+		s = s.Italic(true)
+	}
+	if entry.Colour.IsSet() {
+		s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+
+	_, err := fmt.Fprint(w, s.Render(value))
+	return err
 }
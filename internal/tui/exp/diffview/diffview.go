@@ -46,6 +46,9 @@ type DiffView struct {
 	style           Style
 	tabWidth        int
 	chromaStyle     *chroma.Style
+	intraLine       bool
+	foldUnchanged   bool
+	expandedFolds   map[int]bool
 
 	isComputed bool
 	err        error
@@ -54,6 +57,12 @@ type DiffView struct {
 
 	splitHunks []splitHunk
 
+	// folds are the numbers of unchanged lines hidden before each hunk, and
+	// after the last one.
+	folds []int
+	// intraLineSpans are the changed words of the lines of each hunk.
+	intraLineSpans []map[int][]span
+
 	totalLines      int
 	codeWidth       int
 	fullCodeWidth   int  // with leading symbols
@@ -72,11 +81,12 @@ type DiffView struct {
 // New creates a new DiffView with default settings.
 func New() *DiffView {
 	dv := &DiffView{
-		layout:       layoutUnified,
-		contextLines: udiff.DefaultContextLines,
-		lineNumbers:  true,
-		tabWidth:     8,
-		syntaxCache:  make(map[string]string),
+		layout:        layoutUnified,
+		contextLines:  udiff.DefaultContextLines,
+		lineNumbers:   true,
+		tabWidth:      8,
+		expandedFolds: make(map[int]bool),
+		syntaxCache:   make(map[string]string),
 	}
 	dv.style = DefaultDarkStyle()
 	return dv
@@ -181,6 +191,30 @@ func (dv *DiffView) ChromaStyle(style *chroma.Style) *DiffView {
 	return dv
 }
 
+// IntraLineHighlight sets whether to emphasize the words that changed
+// between a deleted line and the inserted line that replaced it.
+func (dv *DiffView) IntraLineHighlight(intraLine bool) *DiffView {
+	dv.intraLine = intraLine
+	dv.isComputed = false
+	return dv
+}
+
+// FoldUnchanged sets whether to show a fold line in place of the unchanged
+// lines between hunks, and before and after them.
+func (dv *DiffView) FoldUnchanged(foldUnchanged bool) *DiffView {
+	dv.foldUnchanged = foldUnchanged
+	return dv
+}
+
+// ExpandFold shows the unchanged lines folded before the hunk at index, as
+// context of that hunk. The index of the fold after the last hunk is the
+// number of hunks.
+func (dv *DiffView) ExpandFold(index int) *DiffView {
+	dv.expandedFolds[index] = true
+	dv.isComputed = false
+	return dv
+}
+
 // clearSyntaxCache clears the syntax highlighting cache.
 func (dv *DiffView) clearSyntaxCache() {
 	if dv.syntaxCache != nil {
@@ -228,6 +262,33 @@ func (dv *DiffView) String() string {
 	}
 }
 
+// HunkOffsets returns the line at which each hunk starts in the rendered
+// diff, to be used as the YOffset to scroll to a hunk.
+func (dv *DiffView) HunkOffsets() []int {
+	dv.normalizeLineEndings()
+	dv.replaceTabs()
+	if err := dv.computeDiff(); err != nil {
+		return nil
+	}
+	dv.convertDiffToSplit()
+
+	offsets := make([]int, len(dv.unified.Hunks))
+	line := 0
+	for i, h := range dv.unified.Hunks {
+		if dv.showsFold(i) {
+			line++
+		}
+		offsets[i] = line
+		switch dv.layout {
+		case layoutUnified:
+			line += 1 + len(h.Lines)
+		case layoutSplit:
+			line += 1 + len(dv.splitHunks[i].lines)
+		}
+	}
+	return offsets
+}
+
 // normalizeLineEndings ensures the file contents use Unix-style line endings.
 func (dv *DiffView) normalizeLineEndings() {
 	dv.before.content = strings.ReplaceAll(dv.before.content, "\r\n", "\n")
@@ -259,7 +320,86 @@ func (dv *DiffView) computeDiff() error {
 		dv.edits,
 		dv.contextLines,
 	)
-	return dv.err
+	if dv.err != nil {
+		return dv.err
+	}
+	dv.computeFolds()
+	dv.computeIntraLineSpans()
+	return nil
+}
+
+// computeFolds expands the folds that were asked for into the context of
+// their hunks, and counts the unchanged lines left in the others.
+func (dv *DiffView) computeFolds() {
+	hunks := dv.unified.Hunks
+	dv.folds = make([]int, len(hunks)+1)
+	if len(hunks) == 0 {
+		return
+	}
+
+	lines := strings.SplitAfter(dv.before.content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// previousEnd is the number of before lines up to the end of the
+	// previous hunk.
+	previousEnd := 0
+	for i, h := range hunks {
+		start := h.FromLine - 1
+		if dv.expandedFolds[i] && start > previousEnd {
+			context := make([]udiff.Line, 0, start-previousEnd+len(h.Lines))
+			for _, content := range lines[previousEnd:start] {
+				context = append(context, udiff.Line{Kind: udiff.Equal, Content: content})
+			}
+			h.Lines = append(context, h.Lines...)
+			h.ToLine -= start - previousEnd
+			h.FromLine = previousEnd + 1
+			start = previousEnd
+		}
+		dv.folds[i] = start - previousEnd
+		before, _ := dv.hunkShownLines(h)
+		previousEnd = h.FromLine - 1 + before
+	}
+
+	last := hunks[len(hunks)-1]
+	if dv.expandedFolds[len(hunks)] {
+		for _, content := range lines[min(previousEnd, len(lines)):] {
+			last.Lines = append(last.Lines, udiff.Line{Kind: udiff.Equal, Content: content})
+		}
+		previousEnd = len(lines)
+	}
+	dv.folds[len(hunks)] = max(0, len(lines)-previousEnd)
+}
+
+// computeIntraLineSpans finds the changed words of the modified lines.
+func (dv *DiffView) computeIntraLineSpans() {
+	dv.intraLineSpans = nil
+	if !dv.intraLine {
+		return
+	}
+	dv.intraLineSpans = make([]map[int][]span, len(dv.unified.Hunks))
+	for i, h := range dv.unified.Hunks {
+		dv.intraLineSpans[i] = hunkIntraLineSpans(h)
+	}
+}
+
+// lineSpans returns the changed words of a line of a hunk.
+func (dv *DiffView) lineSpans(hunk, line int) []span {
+	if hunk >= len(dv.intraLineSpans) {
+		return nil
+	}
+	return dv.intraLineSpans[hunk][line]
+}
+
+// foldLineFor formats the line shown in place of folded unchanged lines.
+func (dv *DiffView) foldLineFor(lines int) string {
+	return fmt.Sprintf("  ⋯ %d unchanged %s ", lines, ternary(lines == 1, "line", "lines"))
+}
+
+// showsFold reports whether the fold at index is rendered.
+func (dv *DiffView) showsFold(index int) bool {
+	return dv.foldUnchanged && index < len(dv.folds) && dv.folds[index] > 0
 }
 
 // convertDiffToSplit converts the unified diff to a split diff if the layout is
@@ -272,6 +412,14 @@ func (dv *DiffView) convertDiffToSplit() {
 	dv.splitHunks = make([]splitHunk, len(dv.unified.Hunks))
 	for i, h := range dv.unified.Hunks {
 		dv.splitHunks[i] = hunkToSplit(h)
+		if !dv.intraLine {
+			continue
+		}
+		for _, l := range dv.splitHunks[i].lines {
+			if l.before != nil && l.after != nil && l.before.Kind == udiff.Delete && l.after.Kind == udiff.Insert {
+				l.beforeSpans, l.afterSpans = intraLineSpans(trimNewline(l.before.Content), trimNewline(l.after.Content))
+			}
+		}
 	}
 }
 
@@ -312,6 +460,11 @@ func (dv *DiffView) detectTotalLines() {
 			dv.totalLines += 1 + len(h.lines)
 		}
 	}
+	for i := range dv.folds {
+		if dv.showsFold(i) {
+			dv.totalLines++
+		}
+	}
 }
 
 func (dv *DiffView) preventInfiniteYScroll() {
@@ -346,8 +499,8 @@ func (dv *DiffView) detectCodeWidth() {
 func (dv *DiffView) detectUnifiedCodeWidth() {
 	dv.codeWidth = 0
 
-	for _, h := range dv.unified.Hunks {
-		shownLines := ansi.StringWidth(dv.hunkLineFor(h))
+	for i, h := range dv.unified.Hunks {
+		shownLines := max(ansi.StringWidth(dv.hunkLineFor(h)), dv.foldWidth(i))
 
 		for _, l := range h.Lines {
 			lineWidth := ansi.StringWidth(strings.TrimSuffix(l.Content, "\n")) + 1
@@ -362,7 +515,7 @@ func (dv *DiffView) detectSplitCodeWidth() {
 	dv.codeWidth = 0
 
 	for i, h := range dv.splitHunks {
-		shownLines := ansi.StringWidth(dv.hunkLineFor(dv.unified.Hunks[i]))
+		shownLines := max(ansi.StringWidth(dv.hunkLineFor(dv.unified.Hunks[i])), dv.foldWidth(i))
 
 		for _, l := range h.lines {
 			if l.before != nil {
@@ -402,17 +555,25 @@ func (dv *DiffView) renderUnified() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, spans []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls.Code.GetBackground(), spans, ls.Emphasis.GetBackground())
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
 		return
 	}
 
+	truncated := false
+
 outer:
 	for i, h := range dv.unified.Hunks {
+		if dv.showsFold(i) {
+			if shouldWrite() {
+				dv.writeUnifiedFold(&b, dv.folds[i])
+			}
+			printedLines++
+		}
 		if shouldWrite() {
 			ls := dv.style.DividerLine
 			if dv.lineNumbers {
@@ -445,6 +606,7 @@ outer:
 					))
 					b.WriteRune('\n')
 				}
+				truncated = true
 				break outer
 			}
 
@@ -452,7 +614,7 @@ outer:
 			case udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -466,7 +628,7 @@ outer:
 			case udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l.Content, ls, dv.lineSpans(i, j))
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -480,7 +642,7 @@ outer:
 			case udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l.Content, ls, dv.lineSpans(i, j))
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
@@ -500,6 +662,13 @@ outer:
 		}
 	}
 
+	if last := len(dv.unified.Hunks); !truncated && dv.showsFold(last) {
+		if shouldWrite() {
+			dv.writeUnifiedFold(&b, dv.folds[last])
+		}
+		printedLines++
+	}
+
 	for printedLines < dv.height {
 		if shouldWrite() {
 			ls := dv.style.MissingLine
@@ -525,17 +694,25 @@ func (dv *DiffView) renderSplit() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, spans []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls.Code.GetBackground(), spans, ls.Emphasis.GetBackground())
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
 		return
 	}
 
+	truncated := false
+
 outer:
 	for i, h := range dv.splitHunks {
+		if dv.showsFold(i) {
+			if shouldWrite() {
+				dv.writeSplitFold(&b, dv.folds[i])
+			}
+			printedLines++
+		}
 		if shouldWrite() {
 			ls := dv.style.DividerLine
 			if dv.lineNumbers {
@@ -583,6 +760,7 @@ outer:
 					))
 					b.WriteRune('\n')
 				}
+				truncated = true
 				break outer
 			}
 
@@ -600,7 +778,7 @@ outer:
 			case l.before.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -612,7 +790,7 @@ outer:
 			case l.before.Kind == udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before.Content, ls, l.beforeSpans)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -638,7 +816,7 @@ outer:
 			case l.after.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -650,7 +828,7 @@ outer:
 			case l.after.Kind == udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after.Content, ls, l.afterSpans)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -670,6 +848,13 @@ outer:
 		}
 	}
 
+	if last := len(dv.splitHunks); !truncated && dv.showsFold(last) {
+		if shouldWrite() {
+			dv.writeSplitFold(&b, dv.folds[last])
+		}
+		printedLines++
+	}
+
 	for printedLines < dv.height {
 		if shouldWrite() {
 			ls := dv.style.MissingLine
@@ -689,6 +874,48 @@ outer:
 	return b.String()
 }
 
+// writeUnifiedFold writes the line shown in place of folded unchanged lines
+// in the unified diff view.
+func (dv *DiffView) writeUnifiedFold(b *strings.Builder, lines int) {
+	ls := dv.style.DividerLine
+	if dv.lineNumbers {
+		b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
+		b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
+	}
+	content := ansi.Truncate(dv.foldLineFor(lines), dv.fullCodeWidth, "…")
+	b.WriteString(ls.Code.Width(dv.fullCodeWidth).Render(content))
+	b.WriteRune('\n')
+}
+
+// writeSplitFold writes the line shown in place of folded unchanged lines in
+// the split diff view.
+func (dv *DiffView) writeSplitFold(b *strings.Builder, lines int) {
+	ls := dv.style.DividerLine
+	if dv.lineNumbers {
+		b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
+	}
+	content := ansi.Truncate(dv.foldLineFor(lines), dv.fullCodeWidth, "…")
+	b.WriteString(ls.Code.Width(dv.fullCodeWidth).Render(content))
+	if dv.lineNumbers {
+		b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
+	}
+	b.WriteString(ls.Code.Width(dv.fullCodeWidth + btoi(dv.extraColOnAfter)).Render(" "))
+	b.WriteRune('\n')
+}
+
+// foldWidth returns the width of the fold line before the hunk at index, or
+// zero when it isn't shown.
+func (dv *DiffView) foldWidth(index int) int {
+	width := 0
+	if dv.showsFold(index) {
+		width = ansi.StringWidth(dv.foldLineFor(dv.folds[index]))
+	}
+	if index == len(dv.unified.Hunks)-1 && dv.showsFold(index+1) {
+		width = max(width, ansi.StringWidth(dv.foldLineFor(dv.folds[index+1])))
+	}
+	return width
+}
+
 // hunkLineFor formats the header line for a hunk in the unified diff view.
 func (dv *DiffView) hunkLineFor(h *udiff.Hunk) string {
 	beforeShownLines, afterShownLines := dv.hunkShownLines(h)
@@ -732,25 +959,36 @@ func (dv *DiffView) lineStyleForType(t udiff.OpKind) LineStyle {
 	}
 }
 
-func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
-	if dv.chromaStyle == nil {
+func (dv *DiffView) hightlightCode(source string, bgColor color.Color, emphasis []span, emphasisColor color.Color) string {
+	if _, ok := emphasisColor.(lipgloss.NoColor); ok || emphasisColor == nil {
+		emphasis = nil
+	}
+	if dv.chromaStyle == nil && len(emphasis) == 0 {
 		return source
 	}
 
 	// Create cache key from content and background color
-	cacheKey := dv.createSyntaxCacheKey(source, bgColor)
+	cacheKey := dv.createSyntaxCacheKey(source, bgColor, emphasis)
 
 	// Check if we already have this highlighted
 	if cached, exists := dv.syntaxCache[cacheKey]; exists {
 		return cached
 	}
 
-	l := dv.getChromaLexer()
-	f := dv.getChromaFormatter(bgColor)
+	f := chromaFormatter{
+		bgColor:       bgColor,
+		emphasis:      emphasis,
+		emphasisColor: emphasisColor,
+	}
 
-	it, err := l.Tokenise(nil, source)
-	if err != nil {
-		return source
+	// Without syntax highlighting the whole line is a single token.
+	it := chroma.Literator(chroma.Token{Type: chroma.Text, Value: source})
+	if dv.chromaStyle != nil {
+		var err error
+		it, err = dv.getChromaLexer().Tokenise(nil, source)
+		if err != nil {
+			return source
+		}
 	}
 
 	var b strings.Builder
@@ -768,7 +1006,7 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 
 // createSyntaxCacheKey creates a cache key from source content and background color.
 // We use a simple hash to keep memory usage reasonable.
-func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) string {
+func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color, emphasis []span) string {
 	// Convert color to string representation
 	r, g, b, a := bgColor.RGBA()
 	colorStr := fmt.Sprintf("%d,%d,%d,%d", r, g, b, a)
@@ -777,6 +1015,9 @@ func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) str
 	h := xxh3.New()
 	h.Write([]byte(source))
 	h.Write([]byte(colorStr))
+	for _, s := range emphasis {
+		fmt.Fprintf(h, ",%d-%d", s.start, s.end)
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
@@ -795,9 +1036,3 @@ func (dv *DiffView) getChromaLexer() chroma.Lexer {
 	dv.cachedLexer = chroma.Coalesce(l)
 	return dv.cachedLexer
 }
-
-func (dv *DiffView) getChromaFormatter(bgColor color.Color) chroma.Formatter {
-	return chromaFormatter{
-		bgColor: bgColor,
-	}
-}
@@ -42,6 +42,18 @@ var TestLineBreakIssueBefore string
 //go:embed testdata/TestLineBreakIssue.after
 var TestLineBreakIssueAfter string
 
+//go:embed testdata/TestIntraLine.before
+var TestIntraLineBefore string
+
+//go:embed testdata/TestIntraLine.after
+var TestIntraLineAfter string
+
+//go:embed testdata/TestFolds.before
+var TestFoldsBefore string
+
+//go:embed testdata/TestFolds.after
+var TestFoldsAfter string
+
 type (
 	TestFunc  func(dv *diffview.DiffView) *diffview.DiffView
 	TestFuncs map[string]TestFunc
@@ -336,6 +348,82 @@ func TestDiffViewYOffsetInfinite(t *testing.T) {
 	}
 }
 
+func TestDiffViewIntraLine(t *testing.T) {
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			for themeName, themeFunc := range ThemeFuncs {
+				t.Run(themeName, func(t *testing.T) {
+					t.Parallel()
+
+					dv := diffview.New().
+						Before("main.go", TestIntraLineBefore).
+						After("main.go", TestIntraLineAfter).
+						IntraLineHighlight(true)
+					dv = layoutFunc(dv)
+					dv = themeFunc(dv)
+
+					golden.RequireEqual(t, []byte(dv.String()))
+				})
+			}
+			t.Run("NoSyntaxHighlight", func(t *testing.T) {
+				t.Parallel()
+
+				dv := diffview.New().
+					Before("main.go", TestIntraLineBefore).
+					After("main.go", TestIntraLineAfter).
+					Style(diffview.DefaultLightStyle()).
+					IntraLineHighlight(true)
+				dv = layoutFunc(dv)
+
+				golden.RequireEqual(t, []byte(dv.String()))
+			})
+		})
+	}
+}
+
+func TestDiffViewFolds(t *testing.T) {
+	foldFuncs := TestFuncs{
+		"Folded": func(dv *diffview.DiffView) *diffview.DiffView {
+			return dv
+		},
+		"ExpandedBetween": func(dv *diffview.DiffView) *diffview.DiffView {
+			return dv.ExpandFold(1)
+		},
+		"ExpandedAround": func(dv *diffview.DiffView) *diffview.DiffView {
+			return dv.ExpandFold(0).ExpandFold(2)
+		},
+	}
+
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			for foldName, foldFunc := range foldFuncs {
+				t.Run(foldName, func(t *testing.T) {
+					t.Parallel()
+
+					dv := diffview.New().
+						Before("main.go", TestFoldsBefore).
+						After("main.go", TestFoldsAfter).
+						Style(diffview.DefaultLightStyle()).
+						ChromaStyle(styles.Get("catppuccin-latte")).
+						FoldUnchanged(true)
+					dv = layoutFunc(dv)
+					dv = foldFunc(dv)
+
+					output := dv.String()
+					golden.RequireEqual(t, []byte(output))
+
+					lines := strings.Split(ansi.Strip(output), "\n")
+					for _, offset := range dv.HunkOffsets() {
+						if !strings.Contains(lines[offset], "@@") {
+							t.Errorf("expected a hunk header at line %d, got %q", offset, lines[offset])
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
 func assertLineWidth(t *testing.T, expected int, output string) {
 	var lineWidth int
 	for line := range strings.SplitSeq(output, "\n") {
@@ -353,3 +441,27 @@ func assertHeight(t *testing.T, expected int, output string) {
 		t.Errorf("expected output height to be == %d, got %d", expected, lines)
 	}
 }
+
+func TestDiffViewHunkOffsets(t *testing.T) {
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			dv := diffview.New().
+				Before("main.go", TestMultipleHunksBefore).
+				After("main.go", TestMultipleHunksAfter)
+			dv = layoutFunc(dv)
+
+			offsets := dv.HunkOffsets()
+			if len(offsets) != 2 {
+				t.Fatalf("expected 2 hunks, got %d", len(offsets))
+			}
+			lines := strings.Split(ansi.Strip(dv.String()), "\n")
+			for _, offset := range offsets {
+				if !strings.Contains(lines[offset], "@@") {
+					t.Errorf("expected a hunk header at line %d, got %q", offset, lines[offset])
+				}
+			}
+		})
+	}
+}
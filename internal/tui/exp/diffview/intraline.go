@@ -0,0 +1,191 @@
+package diffview
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// maxIntraLineTokens is the maximum number of words in a line for it to be
+// diffed word by word, which is quadratic.
+const maxIntraLineTokens = 500
+
+// span is a range of bytes in a line.
+type span struct {
+	start, end int
+}
+
+// token is a word, a run of spaces, or a single other character of a line.
+type token struct {
+	text  string
+	start int
+}
+
+// tokenize splits a line into tokens.
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		switch {
+		case isWordRune(r):
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isWordRune(r) {
+					break
+				}
+				end += size
+			}
+		case unicode.IsSpace(r):
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				end += size
+			}
+		}
+		tokens = append(tokens, token{text: s[i:end], start: i})
+		i = end
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// intraLineSpans returns the changed words of a deleted line and of the
+// inserted line that replaced it. Nothing is returned for lines with too
+// little in common, for which emphasizing most of the line is just noise.
+func intraLineSpans(before, after string) (beforeSpans, afterSpans []span) {
+	a, b := tokenize(before), tokenize(after)
+	if len(a) == 0 || len(b) == 0 || len(a) > maxIntraLineTokens || len(b) > maxIntraLineTokens {
+		return nil, nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changedA := make([]bool, len(a))
+	changedB := make([]bool, len(b))
+	common := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i].text == b[j].text:
+			if !isSpaceToken(a[i]) {
+				common += len(a[i].text)
+			}
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changedA[i] = true
+			i++
+		default:
+			changedB[j] = true
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		changedA[i] = true
+	}
+	for ; j < len(b); j++ {
+		changedB[j] = true
+	}
+
+	if 2*common < min(nonSpaceLen(a), nonSpaceLen(b)) {
+		return nil, nil
+	}
+	return changedSpans(a, changedA), changedSpans(b, changedB)
+}
+
+// changedSpans merges the changed tokens into spans. Spaces between two
+// changed words are part of the span, so that a changed phrase reads as one.
+func changedSpans(tokens []token, changed []bool) []span {
+	var spans []span
+	for i, t := range tokens {
+		if !changed[i] {
+			joins := isSpaceToken(t) && len(spans) > 0 && spans[len(spans)-1].end == t.start &&
+				i+1 < len(tokens) && changed[i+1]
+			if !joins {
+				continue
+			}
+		}
+		end := t.start + len(t.text)
+		if len(spans) > 0 && spans[len(spans)-1].end == t.start {
+			spans[len(spans)-1].end = end
+			continue
+		}
+		spans = append(spans, span{start: t.start, end: end})
+	}
+	return spans
+}
+
+func isSpaceToken(t token) bool {
+	r, _ := utf8.DecodeRuneInString(t.text)
+	return unicode.IsSpace(r)
+}
+
+func nonSpaceLen(tokens []token) int {
+	n := 0
+	for _, t := range tokens {
+		if !isSpaceToken(t) {
+			n += len(t.text)
+		}
+	}
+	return n
+}
+
+// hunkIntraLineSpans returns the changed words of the lines of a hunk, by
+// line index. Each run of deleted lines followed by inserted lines is paired
+// line by line, like in the split view.
+func hunkIntraLineSpans(h *udiff.Hunk) map[int][]span {
+	spans := make(map[int][]span)
+	for i := 0; i < len(h.Lines); {
+		if h.Lines[i].Kind != udiff.Delete {
+			i++
+			continue
+		}
+		deletes := i
+		for i < len(h.Lines) && h.Lines[i].Kind == udiff.Delete {
+			i++
+		}
+		inserts := i
+		for i < len(h.Lines) && h.Lines[i].Kind == udiff.Insert {
+			i++
+		}
+		for k := 0; deletes+k < inserts && inserts+k < i; k++ {
+			before, after := intraLineSpans(
+				trimNewline(h.Lines[deletes+k].Content),
+				trimNewline(h.Lines[inserts+k].Content),
+			)
+			if len(before) > 0 || len(after) > 0 {
+				spans[deletes+k] = before
+				spans[inserts+k] = after
+			}
+		}
+	}
+	return spans
+}
+
+func trimNewline(s string) string {
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		return s[:len(s)-1]
+	}
+	return s
+}
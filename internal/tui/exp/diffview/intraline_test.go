@@ -0,0 +1,65 @@
+package diffview
+
+import (
+	"testing"
+)
+
+func TestIntraLineSpans(t *testing.T) {
+	tests := []struct {
+		before, after string
+		beforeSpans   []string
+		afterSpans    []string
+	}{
+		{
+			before:      `return fmt.Sprintf("Hello, %s!", name)`,
+			after:       `return fmt.Sprintf("Hi, %s!", name)`,
+			beforeSpans: []string{"Hello"},
+			afterSpans:  []string{"Hi"},
+		},
+		{
+			before:     `func greet(name string) string {`,
+			after:      `func greet(name string, excited bool) string {`,
+			afterSpans: []string{", excited bool"},
+		},
+		{
+			before:      `total := add(1, 2)`,
+			after:       `sum := add(1, 2)`,
+			beforeSpans: []string{"total"},
+			afterSpans:  []string{"sum"},
+		},
+		{
+			// Lines with too little in common aren't emphasized.
+			before: `fmt.Println("total:", total)`,
+			after:  `fmt.Printf("sum: %d\n", sum)`,
+		},
+		{
+			before: "",
+			after:  "x := 1",
+		},
+	}
+
+	for _, tt := range tests {
+		beforeSpans, afterSpans := intraLineSpans(tt.before, tt.after)
+		assertSpans(t, tt.before, beforeSpans, tt.beforeSpans)
+		assertSpans(t, tt.after, afterSpans, tt.afterSpans)
+	}
+}
+
+func assertSpans(t *testing.T, line string, spans []span, expected []string) {
+	t.Helper()
+
+	var got []string
+	for _, s := range spans {
+		got = append(got, line[s.start:s.end])
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected spans %q of %q, got %q", expected, line, got)
+		return
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("expected spans %q of %q, got %q", expected, line, got)
+			return
+		}
+	}
+}
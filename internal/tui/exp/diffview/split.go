@@ -16,6 +16,10 @@ type splitHunk struct {
 type splitLine struct {
 	before *udiff.Line
 	after  *udiff.Line
+
+	// beforeSpans and afterSpans are the changed words of a modified line.
+	beforeSpans []span
+	afterSpans  []span
 }
 
 func hunkToSplit(h *udiff.Hunk) (sh splitHunk) {
@@ -10,6 +10,9 @@ type LineStyle struct {
 	LineNumber lipgloss.Style
 	Symbol     lipgloss.Style
 	Code       lipgloss.Style
+	// Emphasis is the style of the changed words of a modified line. Only
+	// its background is used, over the syntax highlighting.
+	Emphasis lipgloss.Style
 }
 
 // Style defines the overall style for the diff view, including styles for
@@ -58,6 +61,8 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper).
 				Background(lipgloss.Color("#e8f5e9")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#a5d6a7")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -69,6 +74,8 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper).
 				Background(lipgloss.Color("#ffebee")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#ef9a9a")),
 		},
 	}
 }
@@ -108,6 +115,8 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt).
 				Background(lipgloss.Color("#303a30")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#3f5a3f")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -119,6 +128,8 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt).
 				Background(lipgloss.Color("#3a3030")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#5a3c3c")),
 		},
 	}
 }
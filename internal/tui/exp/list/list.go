@@ -57,6 +57,8 @@ type List[T Item] interface {
 	SelectParagraph(col, line int)
 	GetSelectedText(paddingLeft int) string
 	HasSelection() bool
+	SetHighlight(text string)
+	ScrollToItem(id string) tea.Cmd
 }
 
 type direction int
@@ -112,6 +114,9 @@ type list[T Item] struct {
 	selectionEndLine   int
 
 	selectionActive bool
+
+	// highlight is the text highlighted in the view, ignoring case.
+	highlight string
 }
 
 type ListOption func(*confOptions)
@@ -464,6 +469,10 @@ func (l *list[T]) View() string {
 		Width(l.width).
 		Render(strings.Join(lines, "\n"))
 
+	if l.highlight != "" {
+		view = l.highlightView(view)
+	}
+
 	if !l.hasSelection() {
 		return view
 	}
@@ -471,6 +480,48 @@ func (l *list[T]) View() string {
 	return l.selectionView(view, false)
 }
 
+// highlightView highlights the occurrences of the highlight text in the
+// visible lines, ignoring case. Occurrences wrapped across lines are not
+// highlighted.
+func (l *list[T]) highlightView(view string) string {
+	hs := styles.CurrentTheme().SearchMatch
+	area := uv.Rect(0, 0, l.width, l.height)
+	scr := uv.NewScreenBuffer(area.Dx(), area.Dy())
+	uv.NewStyledString(view).Draw(scr, area)
+
+	var needle []string
+	graphemes := uniseg.NewGraphemes(strings.ToLower(l.highlight))
+	for graphemes.Next() {
+		needle = append(needle, graphemes.Str())
+	}
+
+	for y := range scr.Height() {
+		// The graphemes of the line along with the column of their cell.
+		var cols []int
+		var line []string
+		for x := range scr.Width() {
+			cell := scr.CellAt(x, y)
+			if cell == nil || cell.Width == 0 {
+				continue
+			}
+			cols = append(cols, x)
+			line = append(line, strings.ToLower(cell.Content))
+		}
+		for i := 0; i+len(needle) <= len(line); i++ {
+			if !slices.Equal(line[i:i+len(needle)], needle) {
+				continue
+			}
+			for _, x := range cols[i : i+len(needle)] {
+				cell := scr.CellAt(x, y).Clone()
+				cell.Style = cell.Style.Background(hs.GetBackground()).Foreground(hs.GetForeground())
+				scr.SetCell(x, y, cell)
+			}
+			i += len(needle) - 1
+		}
+	}
+	return scr.Render()
+}
+
 func (l *list[T]) viewPosition() (int, int) {
 	start, end := 0, 0
 	renderedLines := lipgloss.Height(l.rendered) - 1
@@ -1229,6 +1280,55 @@ func (l *list[T]) SetSelected(id string) tea.Cmd {
 	return l.render()
 }
 
+// SetHighlight implements List.
+func (l *list[T]) SetHighlight(text string) {
+	l.highlight = text
+}
+
+// ScrollToItem implements List. It selects the item and scrolls to its first
+// highlighted line, or to its top when it has none.
+func (l *list[T]) ScrollToItem(id string) tea.Cmd {
+	if _, ok := l.indexMap.Get(id); !ok {
+		return nil
+	}
+	l.selectedItem = id
+	cmd := l.render()
+	rItem, ok := l.renderedItems.Get(id)
+	if !ok {
+		return cmd
+	}
+	line := rItem.start
+	if l.highlight != "" {
+		needle := strings.ToLower(l.highlight)
+		lines := strings.Split(l.rendered, "\n")
+		for i := rItem.start; i <= rItem.end && i < len(lines); i++ {
+			if strings.Contains(strings.ToLower(ansi.Strip(lines[i])), needle) {
+				line = i
+				break
+			}
+		}
+	}
+	l.scrollToLine(line)
+	return cmd
+}
+
+// scrollToLine scrolls the line of the rendered items into view, a third of
+// the way down the viewport, unless it's already visible.
+func (l *list[T]) scrollToLine(line int) {
+	start, end := l.viewPosition()
+	if line >= start && line <= end {
+		return
+	}
+	renderedHeight := lipgloss.Height(l.rendered)
+	maxOffset := max(0, renderedHeight-l.height)
+	top := max(0, line-l.height/3)
+	if l.direction == DirectionForward {
+		l.offset = min(top, maxOffset)
+	} else {
+		l.offset = util.Clamp(renderedHeight-l.height-top, 0, maxOffset)
+	}
+}
+
 func (l *list[T]) reset(selectedItem string) tea.Cmd {
 	var cmds []tea.Cmd
 	l.rendered = ""
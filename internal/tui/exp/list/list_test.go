@@ -564,6 +564,38 @@ func TestListMovement(t *testing.T) {
 	})
 }
 
+func TestListSearch(t *testing.T) {
+	t.Parallel()
+	t.Run("should scroll to the highlighted line of an item", func(t *testing.T) {
+		t.Parallel()
+		items := []Item{}
+		for i := range 30 {
+			content := fmt.Sprintf("Item %d", i)
+			if i == 3 {
+				content = "Item 3\nfirst\nsecond Needle"
+			}
+			items = append(items, NewSelectableItem(content))
+		}
+		l := New(items, WithDirectionBackward(), WithSize(20, 10)).(*list[Item])
+		execCmd(l, l.Init())
+
+		l.SetHighlight("needle")
+		execCmd(l, l.ScrollToItem(items[3].ID()))
+
+		assert.Equal(t, items[3].ID(), l.selectedItem)
+		start, end := l.viewPosition()
+		assert.LessOrEqual(t, start, 5)
+		assert.GreaterOrEqual(t, end, 5)
+		assert.Contains(t, l.View(), "Needle")
+	})
+	t.Run("should ignore unknown items", func(t *testing.T) {
+		t.Parallel()
+		l := New([]Item{NewSelectableItem("Item")}, WithSize(20, 10)).(*list[Item])
+		execCmd(l, l.Init())
+		assert.Nil(t, l.ScrollToItem("missing"))
+	})
+}
+
 type SelectableItem interface {
 	Item
 	layout.Focusable
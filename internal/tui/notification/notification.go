@@ -0,0 +1,194 @@
+// Package notification tells the user about agent turns that finish, fail or
+// wait for a permission while they look elsewhere, with terminal
+// notifications, the terminal bell and an optional desktop command.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	// title is the title of the notifications.
+	title = "Crush"
+
+	commandTimeout = 10 * time.Second
+)
+
+// Notifier decides when to notify and sends the notifications.
+type Notifier struct {
+	sessions session.Service
+	// busy reports whether the agent works on a session.
+	busy func(sessionID string) bool
+	// started holds when the agent started to work on each busy session.
+	started *csync.Map[string, time.Time]
+	// focused is set while the terminal has the focus, which is assumed when
+	// the terminal doesn't report it.
+	focused atomic.Bool
+}
+
+func New(sessions session.Service, busy func(sessionID string) bool) *Notifier {
+	n := &Notifier{
+		sessions: sessions,
+		busy:     busy,
+		started:  csync.NewMap[string, time.Time](),
+	}
+	n.focused.Store(true)
+	return n
+}
+
+// SetFocused records whether the terminal has the focus.
+func (n *Notifier) SetFocused(focused bool) {
+	n.focused.Store(focused)
+}
+
+// TurnStarted records the start of a turn of the session. Prompts sent while
+// the agent works on the session are part of the same turn, so only the first
+// one is recorded. Sessions the agent doesn't work on, like the ones of
+// sub-agents, aren't recorded, and the ones that went idle are forgotten.
+func (n *Notifier) TurnStarted(sessionID string) {
+	for id := range n.started.Seq2() {
+		if id != sessionID && !n.busy(id) {
+			n.started.Del(id)
+		}
+	}
+	if !n.busy(sessionID) {
+		return
+	}
+	n.started.GetOrSet(sessionID, time.Now)
+}
+
+// TurnCancelled forgets the turn of the session, which isn't notified about.
+func (n *Notifier) TurnCancelled(sessionID string) {
+	n.started.Del(sessionID)
+}
+
+// TurnFinished notifies that the turn of the session finished, or failed when
+// err is set.
+func (n *Notifier) TurnFinished(sessionID string, err error) tea.Cmd {
+	started, ok := n.started.Take(sessionID)
+	return n.notify(sessionID, started, ok, func(name string) string {
+		if err != nil {
+			return fmt.Sprintf("“%s” failed: %v", name, err)
+		}
+		return fmt.Sprintf("“%s” finished", name)
+	})
+}
+
+// PermissionRequested notifies that a session waits for a permission to run
+// a tool.
+func (n *Notifier) PermissionRequested(sessionID, toolName string) tea.Cmd {
+	return func() tea.Msg {
+		// Requests of sub-agents belong to the turn of the session that
+		// started them.
+		root := n.rootSession(sessionID)
+		started, ok := n.started.Get(root.ID)
+		cmd := n.notify(root.ID, started, ok, func(name string) string {
+			return fmt.Sprintf("“%s” needs permission to use %s", name, toolName)
+		})
+		if cmd == nil {
+			return nil
+		}
+		return cmd()
+	}
+}
+
+// notify sends a notification when it should be.
+func (n *Notifier) notify(sessionID string, started time.Time, hasStarted bool, message func(name string) string) tea.Cmd {
+	opts := *config.Get().Options.TUI.Notifications
+	if !n.shouldNotify(opts, started, hasStarted) {
+		return nil
+	}
+	return func() tea.Msg {
+		body := message(n.rootSession(sessionID).Title)
+		if len(opts.Command) > 0 {
+			runCommand(opts.Command, title, body)
+		}
+		var seq strings.Builder
+		if !opts.DisableTerminal {
+			seq.WriteString(TerminalNotification(title, body))
+		}
+		if !opts.DisableBell {
+			seq.WriteString("\a")
+		}
+		if seq.Len() == 0 {
+			return nil
+		}
+		return tea.RawMsg{Msg: seq.String()}
+	}
+}
+
+// shouldNotify reports whether to notify about a turn that started at
+// started: always when the terminal isn't focused, else only when the turn ran
+// long enough for the user to look away.
+func (n *Notifier) shouldNotify(opts config.NotificationOptions, started time.Time, hasStarted bool) bool {
+	if opts.Disabled {
+		return false
+	}
+	if !n.focused.Load() {
+		return true
+	}
+	return hasStarted && time.Since(started) >= time.Duration(opts.MinDuration)*time.Second
+}
+
+// rootSession returns the session that started the session, going up from
+// the sessions of sub-agents.
+func (n *Notifier) rootSession(sessionID string) session.Session {
+	root := session.Session{ID: sessionID, Title: "Session"}
+	for id := sessionID; id != ""; {
+		s, err := n.sessions.Get(context.Background(), id)
+		if err != nil {
+			break
+		}
+		root = s
+		id = s.ParentSessionID
+	}
+	return root
+}
+
+// TerminalNotification returns the OSC 9 and OSC 777 sequences of a
+// notification. Terminals ignore the sequence they don't support.
+func TerminalNotification(title, body string) string {
+	title, body = sanitize(title), sanitize(body)
+	return fmt.Sprintf("\x1b]9;%s: %s\x07\x1b]777;notify;%s;%s\x07",
+		title, body, strings.ReplaceAll(title, ";", ","), body)
+}
+
+// sanitize drops the control characters that would end the sequence early.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// runCommand runs the desktop notification command, replacing {title} and
+// {message} in its arguments.
+func runCommand(command []string, title, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	replacer := strings.NewReplacer("{title}", title, "{message}", body)
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = replacer.Replace(arg)
+	}
+	if out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		slog.Warn("Notification command failed", "command", command[0], "error", err, "output", string(out))
+	}
+}
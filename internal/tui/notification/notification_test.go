@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerminalNotification(t *testing.T) {
+	t.Parallel()
+
+	seq := TerminalNotification("Crush", "“Fix; tests”\nfinished\x07\x1b")
+	require.Equal(t,
+		"\x1b]9;Crush: “Fix; tests” finished\x07\x1b]777;notify;Crush;“Fix; tests” finished\x07",
+		seq,
+	)
+}
+
+func TestShouldNotify(t *testing.T) {
+	t.Parallel()
+
+	opts := config.NotificationOptions{MinDuration: 30}
+	long := time.Now().Add(-time.Minute)
+	short := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name       string
+		opts       config.NotificationOptions
+		focused    bool
+		started    time.Time
+		hasStarted bool
+		want       bool
+	}{
+		{name: "long turn", opts: opts, focused: true, started: long, hasStarted: true, want: true},
+		{name: "short turn", opts: opts, focused: true, started: short, hasStarted: true, want: false},
+		{name: "unknown turn", opts: opts, focused: true, want: false},
+		{name: "unfocused", opts: opts, focused: false, started: short, hasStarted: true, want: true},
+		{name: "unfocused unknown turn", opts: opts, focused: false, want: true},
+		{name: "disabled", opts: config.NotificationOptions{Disabled: true, MinDuration: 30}, focused: false, started: long, hasStarted: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			n := New(nil, nil)
+			n.SetFocused(tt.focused)
+			require.Equal(t, tt.want, n.shouldNotify(tt.opts, tt.started, tt.hasStarted))
+		})
+	}
+}
+
+func TestTurnStarted(t *testing.T) {
+	t.Parallel()
+
+	busy := map[string]bool{"session": true, "other": true}
+	n := New(nil, func(sessionID string) bool { return busy[sessionID] })
+
+	// Prompts queued while the agent works are part of the same turn.
+	n.TurnStarted("session")
+	first, ok := n.started.Get("session")
+	require.True(t, ok)
+	n.TurnStarted("session")
+	queued, _ := n.started.Get("session")
+	require.Equal(t, first, queued)
+
+	// Sessions of sub-agents aren't recorded.
+	n.TurnStarted("sub-agent")
+	_, ok = n.started.Get("sub-agent")
+	require.False(t, ok)
+
+	// Cancelled turns are forgotten.
+	n.TurnCancelled("session")
+	_, ok = n.started.Get("session")
+	require.False(t, ok)
+
+	// Sessions that went idle are forgotten.
+	n.TurnStarted("session")
+	busy["session"] = false
+	n.TurnStarted("other")
+	_, ok = n.started.Get("session")
+	require.False(t, ok)
+	require.Equal(t, 1, n.started.Len())
+}
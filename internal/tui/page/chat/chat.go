@@ -2,6 +2,11 @@ package chat
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/charmbracelet/bubbles/v2/help"
@@ -11,10 +16,12 @@ import (
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/llm/agent"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/todo"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
@@ -22,12 +29,16 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/sidebar"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/splash"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/tabs"
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/plan"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/prompthistory"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -42,6 +53,11 @@ type (
 		Focused bool
 	}
 	CancelTimerExpiredMsg struct{}
+	// PendingPermissionsMsg carries the number of permission requests waiting
+	// in each session, shown as a badge on their tabs.
+	PendingPermissionsMsg struct {
+		Counts map[string]int
+	}
 )
 
 type PanelType string
@@ -59,6 +75,7 @@ const (
 	SideBarWidth                = 31  // Width of the sidebar
 	SideBarDetailsPadding       = 1   // Padding for the sidebar details section
 	HeaderHeight                = 1   // Height of the header
+	TabsHeight                  = 1   // Height of the session tabs
 
 	// Layout constants for borders and padding
 	BorderWidth        = 1 // Width of component borders
@@ -100,6 +117,7 @@ type chatPage struct {
 
 	// Components
 	header  header.Header
+	tabs    tabs.Tabs
 	sidebar sidebar.Sidebar
 	chat    chat.MessageListCmp
 	editor  editor.Editor
@@ -111,6 +129,9 @@ type chatPage struct {
 	splashFullScreen bool
 	isOnboarding     bool
 	isProjectInit    bool
+
+	// Plan mode for the session that will be created by the next message.
+	planMode bool
 }
 
 func New(app *app.App) ChatPage {
@@ -118,7 +139,8 @@ func New(app *app.App) ChatPage {
 		app:         app,
 		keyMap:      DefaultKeyMap(),
 		header:      header.New(app.LSPClients),
-		sidebar:     sidebar.New(app.History, app.LSPClients, false),
+		tabs:        tabs.New(app),
+		sidebar:     sidebar.New(app.History, app.Todos, app.LSPClients, false),
 		chat:        chat.New(app),
 		editor:      editor.New(app),
 		splash:      splash.New(),
@@ -152,6 +174,7 @@ func (p *chatPage) Init() tea.Cmd {
 
 	return tea.Batch(
 		p.header.Init(),
+		p.tabs.Init(),
 		p.sidebar.Init(),
 		p.chat.Init(),
 		p.editor.Init(),
@@ -169,6 +192,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if p.compact {
 			msg.Y -= 1
 		}
+		msg.Y -= p.tabsHeight()
 		if p.isMouseOverChat(msg.X, msg.Y) {
 			u, cmd := p.chat.Update(msg)
 			p.chat = u.(chat.MessageListCmp)
@@ -179,6 +203,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if p.compact {
 			msg.Y -= 1
 		}
+		msg.Y -= p.tabsHeight()
 		if p.isMouseOverChat(msg.X, msg.Y) {
 			p.focusedPane = PanelTypeChat
 			p.chat.Focus()
@@ -195,6 +220,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if p.compact {
 			msg.Y -= 1
 		}
+		msg.Y -= p.tabsHeight()
 		if msg.Button == tea.MouseLeft {
 			u, cmd := p.chat.Update(msg)
 			p.chat = u.(chat.MessageListCmp)
@@ -205,6 +231,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if p.compact {
 			msg.Y -= 1
 		}
+		msg.Y -= p.tabsHeight()
 		if msg.Button == tea.MouseLeft {
 			u, cmd := p.chat.Update(msg)
 			p.chat = u.(chat.MessageListCmp)
@@ -222,7 +249,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case CancelTimerExpiredMsg:
 		p.isCanceling = false
 		return p, nil
-	case editor.OpenEditorMsg:
+	case editor.OpenEditorMsg, editor.HistoryLoadedMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
@@ -256,9 +283,16 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		u, cmd := p.header.Update(msg)
 		p.header = u.(header.Header)
 		cmds = append(cmds, cmd)
+		u, cmd = p.tabs.Update(msg)
+		p.tabs = u.(tabs.Tabs)
+		cmds = append(cmds, cmd)
 		u, cmd = p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
+		if msg.Type == pubsub.DeletedEvent {
+			// A deleted session loses its tab, which can hide the strip.
+			cmds = append(cmds, p.SetSize(p.width, p.height))
+		}
 		return p, tea.Batch(cmds...)
 	case chat.SessionClearedMsg:
 		u, cmd := p.header.Update(msg)
@@ -272,6 +306,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
 	case filepicker.FilePickedMsg,
+		prompthistory.PromptSelectedMsg,
 		completions.CompletionsClosedMsg,
 		completions.SelectCompletionMsg:
 		u, cmd := p.editor.Update(msg)
@@ -289,6 +324,9 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case pubsub.Event[message.Message],
 		anim.StepMsg,
 		spinner.TickMsg:
+		u, cmd := p.tabs.Update(msg)
+		p.tabs = u.(tabs.Tabs)
+		cmds = append(cmds, cmd)
 		if p.focusedPane == PanelTypeSplash {
 			u, cmd := p.splash.Update(msg)
 			p.splash = u.(splash.Splash)
@@ -300,12 +338,50 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		return p, tea.Batch(cmds...)
+	case commands.TogglePlanModeMsg:
+		return p, p.togglePlanMode()
+	case commands.SearchMessagesMsg:
+		if p.session.ID == "" {
+			return p, nil
+		}
+		p.focusedPane = PanelTypeChat
+		p.chat.Focus()
+		p.editor.Blur()
+		return p, p.chat.OpenSearch()
+	case plan.ApprovedMsg:
+		if _, err := p.app.CoderAgent.ApprovePlan(context.Background(), msg.SessionID, msg.Plan); err != nil {
+			return p, util.ReportError(err)
+		}
+		if msg.SessionID == p.session.ID {
+			p.editor.SetPlanMode(false)
+		}
+		return p, tea.Batch(util.ReportInfo("Plan approved, plan mode off"), p.chat.GoToBottom())
+	case messages.RetryMsg:
+		return p, p.retry(msg.SessionID, msg.MessageID)
+	case image.LaidOutMsg:
+		u, cmd := p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		return p, cmd
+	case retriedMsg:
+		u, cmd := p.chat.Update(chat.MessagesDeletedMsg{SessionID: msg.sessionID})
+		p.chat = u.(chat.MessageListCmp)
+		if msg.sessionID != p.session.ID {
+			// Another session was opened in the meantime, the turn runs in
+			// the background.
+			if _, err := p.app.CoderAgent.Run(context.Background(), msg.sessionID, msg.prompt, msg.attachments...); err != nil {
+				return p, tea.Batch(cmd, util.ReportError(err))
+			}
+			return p, cmd
+		}
+		return p, tea.Batch(cmd, p.sendMessage(msg.prompt, msg.attachments))
+	case plan.RejectedMsg:
+		return p, util.ReportInfo("Plan rejected, tell the agent what to change")
 	case commands.ToggleYoloModeMsg:
 		// update the editor style
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
-	case pubsub.Event[history.File], sidebar.SessionFilesMsg:
+	case pubsub.Event[history.File], sidebar.SessionFilesMsg, pubsub.Event[todo.Todo], sidebar.SessionTodosMsg:
 		u, cmd := p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
@@ -315,9 +391,14 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.chat = u.(chat.MessageListCmp)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
+	case pubsub.Event[agent.AgentEvent]:
+		return p, p.handleAgentEvent(msg.Payload)
+	case PendingPermissionsMsg:
+		p.tabs.SetPermissions(msg.Counts)
+		return p, nil
 
 	case commands.CommandRunCustomMsg:
-		if p.app.CoderAgent.IsBusy() {
+		if p.app.CoderAgent.IsSessionBusy(p.session.ID) {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
 
@@ -341,29 +422,37 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.focusedPane = PanelTypeEditor
 		return p, p.SetSize(p.width, p.height)
 	case commands.NewSessionsMsg:
-		if p.app.CoderAgent.IsBusy() {
-			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-		}
 		return p, p.newSession()
 	case tea.KeyPressMsg:
+		// The search of the messages takes the keys while its query is typed,
+		// and esc to close it.
+		if p.focusedPane == PanelTypeChat &&
+			(p.chat.IsSearching() || p.chat.HasSearch() && key.Matches(msg, p.keyMap.Cancel)) {
+			u, cmd := p.chat.Update(msg)
+			p.chat = u.(chat.MessageListCmp)
+			return p, cmd
+		}
 		switch {
 		case key.Matches(msg, p.keyMap.NewSession):
 			// if we have no agent do nothing
 			if p.app.CoderAgent == nil {
 				return p, nil
 			}
-			if p.app.CoderAgent.IsBusy() {
-				return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-			}
 			return p, p.newSession()
-		case key.Matches(msg, p.keyMap.AddAttachment):
-			agentCfg := config.Get().Agents["coder"]
-			model := config.Get().GetModelByType(agentCfg.Model)
-			if model.SupportsImages {
-				return p, util.CmdHandler(commands.OpenFilePickerMsg{})
-			} else {
-				return p, util.ReportWarn("File attachments are not supported by the current model: " + model.Name)
+		case key.Matches(msg, p.keyMap.NextTab):
+			return p, p.switchTab(1)
+		case key.Matches(msg, p.keyMap.PrevTab):
+			return p, p.switchTab(-1)
+		case key.Matches(msg, p.keyMap.GoToTab):
+			index, _ := strconv.Atoi(msg.String()[len("alt+"):])
+			if session, ok := p.tabs.At(index - 1); ok {
+				return p, util.CmdHandler(chat.SessionSelectedMsg(session))
 			}
+			return p, nil
+		case key.Matches(msg, p.keyMap.CloseTab):
+			return p, p.closeTab()
+		case key.Matches(msg, p.keyMap.AddAttachment):
+			return p, util.CmdHandler(commands.OpenFilePickerMsg{})
 		case key.Matches(msg, p.keyMap.Tab):
 			if p.session.ID == "" {
 				u, cmd := p.splash.Update(msg)
@@ -373,7 +462,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			p.changeFocus()
 			return p, nil
 		case key.Matches(msg, p.keyMap.Cancel):
-			if p.session.ID != "" && p.app.CoderAgent.IsBusy() {
+			if p.session.ID != "" && p.app.CoderAgent.IsSessionBusy(p.session.ID) {
 				return p, p.cancel()
 			}
 		case key.Matches(msg, p.keyMap.Details):
@@ -475,6 +564,10 @@ func (p *chatPage) View() string {
 		}
 	}
 
+	if p.tabs.Visible() && !p.splashFullScreen {
+		chatView = lipgloss.JoinVertical(lipgloss.Left, p.tabs.View(), chatView)
+	}
+
 	layers := []*lipgloss.Layer{
 		lipgloss.NewLayer(chatView).X(0).Y(0),
 	}
@@ -492,7 +585,7 @@ func (p *chatPage) View() string {
 				version,
 			),
 		)
-		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1))
+		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1+p.tabsHeight()))
 	}
 	canvas := lipgloss.NewCanvas(
 		layers...,
@@ -516,12 +609,12 @@ func (p *chatPage) updateCompactConfig(compact bool) tea.Cmd {
 func (p *chatPage) toggleThinking() tea.Cmd {
 	return func() tea.Msg {
 		cfg := config.Get()
-		agentCfg := cfg.Agents["coder"]
-		currentModel := cfg.Models[agentCfg.Model]
+		agentCfg := cfg.PrimaryAgent()
+		currentModel := cfg.ModelConfig(agentCfg.Model)
 
 		// Toggle the thinking mode
 		currentModel.Think = !currentModel.Think
-		cfg.Models[agentCfg.Model] = currentModel
+		cfg.SetModelConfig(agentCfg.Model, currentModel)
 
 		// Update the agent with the new configuration
 		if err := p.app.UpdateAgentModel(); err != nil {
@@ -572,13 +665,18 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	p.height = height
 	var cmds []tea.Cmd
 
+	// The tabs take the first line, the rest is laid out below them.
+	top := p.tabsHeight()
+	height -= top
+	cmds = append(cmds, p.tabs.SetWidth(width))
+
 	if p.session.ID == "" {
 		if p.splashFullScreen {
 			cmds = append(cmds, p.splash.SetSize(width, height))
 		} else {
 			cmds = append(cmds, p.splash.SetSize(width, height-EditorHeight))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
-			cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
+			cmds = append(cmds, p.editor.SetPosition(0, top+height-EditorHeight))
 		}
 	} else {
 		if p.compact {
@@ -592,7 +690,7 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.sidebar.SetSize(SideBarWidth, height-EditorHeight))
 		}
-		cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
+		cmds = append(cmds, p.editor.SetPosition(0, top+height-EditorHeight))
 	}
 	return tea.Batch(cmds...)
 }
@@ -603,16 +701,39 @@ func (p *chatPage) newSession() tea.Cmd {
 	}
 
 	p.session = session.Session{}
+	p.tabs.Deselect()
+	p.planMode = false
+	p.editor.SetPlanMode(false)
 	p.focusedPane = PanelTypeEditor
 	p.editor.Focus()
 	p.chat.Blur()
 	p.isCanceling = false
 	return tea.Batch(
 		util.CmdHandler(chat.SessionClearedMsg{}),
+		p.editor.SetSession(session.Session{}),
 		p.SetSize(p.width, p.height),
 	)
 }
 
+func (p *chatPage) togglePlanMode() tea.Cmd {
+	if p.app.CoderAgent == nil {
+		return nil
+	}
+	var enabled bool
+	if p.session.ID == "" {
+		p.planMode = !p.planMode
+		enabled = p.planMode
+	} else {
+		enabled = !p.app.CoderAgent.IsPlanMode(p.session.ID)
+		p.app.CoderAgent.SetPlanMode(p.session.ID, enabled)
+	}
+	p.editor.SetPlanMode(enabled)
+	if enabled {
+		return util.ReportInfo("Plan mode on, the agent will answer with a plan and cannot modify files")
+	}
+	return util.ReportInfo("Plan mode off")
+}
+
 func (p *chatPage) setSession(session session.Session) tea.Cmd {
 	if p.session.ID == session.ID {
 		return nil
@@ -620,7 +741,9 @@ func (p *chatPage) setSession(session session.Session) tea.Cmd {
 
 	var cmds []tea.Cmd
 	p.session = session
+	p.isCanceling = false
 
+	cmds = append(cmds, p.tabs.Open(session))
 	cmds = append(cmds, p.SetSize(p.width, p.height))
 	cmds = append(cmds, p.chat.SetSession(session))
 	cmds = append(cmds, p.sidebar.SetSession(session))
@@ -630,6 +753,69 @@ func (p *chatPage) setSession(session session.Session) tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// switchTab selects the session step tabs away from the current one.
+func (p *chatPage) switchTab(step int) tea.Cmd {
+	session, ok := p.tabs.Next(step)
+	if !ok || session.ID == p.session.ID {
+		return nil
+	}
+	return util.CmdHandler(chat.SessionSelectedMsg(session))
+}
+
+// closeTab closes the tab of the current session and selects its neighbour,
+// or starts a new session when it was the last one. A running session keeps
+// its tab, so its progress isn't lost.
+func (p *chatPage) closeTab() tea.Cmd {
+	if p.session.ID == "" {
+		return nil
+	}
+	if p.app.CoderAgent != nil && p.app.CoderAgent.IsSessionBusy(p.session.ID) {
+		return util.ReportWarn("Session is busy, cancel it before closing its tab...")
+	}
+	index := slices.IndexFunc(p.tabs.Tabs(), func(tab tabs.Tab) bool {
+		return tab.Session.ID == p.session.ID
+	})
+	p.tabs.Close(p.session.ID)
+	if next, ok := p.tabs.At(min(index, len(p.tabs.Tabs())-1)); ok {
+		return util.CmdHandler(chat.SessionSelectedMsg(next))
+	}
+	return p.newSession()
+}
+
+// handleAgentEvent flags the tab of a session whose run finished while
+// another session was shown, and tells the user about it.
+func (p *chatPage) handleAgentEvent(event agent.AgentEvent) tea.Cmd {
+	if event.SessionID == "" || event.SessionID == p.session.ID || !p.tabs.Has(event.SessionID) {
+		return nil
+	}
+	var title string
+	for _, tab := range p.tabs.Tabs() {
+		if tab.Session.ID == event.SessionID {
+			title = tab.Session.Title
+		}
+	}
+	switch {
+	case event.Type == agent.AgentEventTypeResponse && event.Done:
+		p.tabs.MarkUnread(event.SessionID)
+		return util.ReportInfo(fmt.Sprintf("“%s” finished", title))
+	case event.Type == agent.AgentEventTypeError:
+		if errors.Is(event.Error, agent.ErrRequestCancelled) || errors.Is(event.Error, context.Canceled) {
+			return nil
+		}
+		p.tabs.MarkUnread(event.SessionID)
+		return util.ReportWarn(fmt.Sprintf("“%s” failed: %v", title, event.Error))
+	}
+	return nil
+}
+
+// tabsHeight returns the height taken by the session tabs.
+func (p *chatPage) tabsHeight() int {
+	if p.tabs.Visible() && !p.splashFullScreen {
+		return TabsHeight
+	}
+	return 0
+}
+
 func (p *chatPage) changeFocus() {
 	if p.session.ID == "" {
 		return
@@ -685,6 +871,10 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 			return util.ReportError(err)
 		}
 		session = newSession
+		if p.planMode {
+			p.app.CoderAgent.SetPlanMode(session.ID, true)
+			p.planMode = false
+		}
 		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
 	}
 	_, err := p.app.CoderAgent.Run(context.Background(), session.ID, text, attachments...)
@@ -695,12 +885,98 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 	return tea.Batch(cmds...)
 }
 
+// retriedMsg is sent once the messages of a retried turn are deleted, to
+// send its prompt again.
+type retriedMsg struct {
+	sessionID   string
+	prompt      string
+	attachments []message.Attachment
+}
+
+// retry runs the turn of a message again: the messages from the prompt that
+// started it on are deleted and the prompt is sent again, with its
+// attachments. Only the last turn of a session can be retried.
+func (p *chatPage) retry(sessionID, messageID string) tea.Cmd {
+	if sessionID != p.session.ID {
+		return nil
+	}
+	if p.app.CoderAgent.IsSessionBusy(sessionID) {
+		return util.ReportWarn("Agent is busy, please wait before retrying...")
+	}
+	summaryMessageID := p.session.SummaryMessageID
+	return func() tea.Msg {
+		ctx := context.Background()
+		msgs, err := p.app.Messages.List(ctx, sessionID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		start, warning := retryStart(msgs, messageID, summaryMessageID)
+		if warning != "" {
+			return util.ReportWarn(warning)()
+		}
+
+		prompt := msgs[start]
+		for _, msg := range slices.Backward(msgs[start:]) {
+			if err := p.app.Messages.Delete(ctx, msg.ID); err != nil {
+				return tea.BatchMsg{
+					util.CmdHandler(chat.MessagesDeletedMsg{SessionID: sessionID}),
+					util.ReportError(fmt.Errorf("failed to delete message: %w", err)),
+				}
+			}
+		}
+		var attachments []message.Attachment
+		for _, content := range prompt.BinaryContent() {
+			attachments = append(attachments, message.Attachment{
+				FilePath: content.Path,
+				FileName: filepath.Base(content.Path),
+				MimeType: content.MIMEType,
+				Content:  content.Data,
+			})
+		}
+		return retriedMsg{
+			sessionID:   sessionID,
+			prompt:      prompt.Content().Text,
+			attachments: attachments,
+		}
+	}
+}
+
+// retryStart returns the index of the prompt that started the turn of a
+// message, or why the turn can't be retried: only the last turn can be, as
+// long as it doesn't hold the summary of the session.
+func retryStart(msgs []message.Message, messageID, summaryMessageID string) (int, string) {
+	i := slices.IndexFunc(msgs, func(msg message.Message) bool {
+		return msg.ID == messageID
+	})
+	if i < 0 {
+		return 0, "Message not found"
+	}
+	start := i
+	for start >= 0 && msgs[start].Role != message.User {
+		start--
+	}
+	if start < 0 {
+		return 0, "No prompt to retry"
+	}
+	for _, msg := range msgs[start+1:] {
+		if msg.Role == message.User {
+			return 0, "Only the last turn can be retried"
+		}
+	}
+	for _, msg := range msgs[start:] {
+		if msg.ID == summaryMessageID {
+			return 0, "The session was summarized, this turn can't be retried"
+		}
+	}
+	return start, ""
+}
+
 func (p *chatPage) Bindings() []key.Binding {
 	bindings := []key.Binding{
 		p.keyMap.NewSession,
 		p.keyMap.AddAttachment,
 	}
-	if p.app.CoderAgent != nil && p.app.CoderAgent.IsBusy() {
+	if p.app.CoderAgent != nil && p.app.CoderAgent.IsSessionBusy(p.session.ID) {
 		cancelBinding := p.keyMap.Cancel
 		if p.isCanceling {
 			cancelBinding = key.NewBinding(
@@ -821,7 +1097,7 @@ func (p *chatPage) Help() help.KeyMap {
 			}
 			return core.NewSimpleHelp(shortList, fullList)
 		}
-		if p.app.CoderAgent != nil && p.app.CoderAgent.IsBusy() {
+		if p.app.CoderAgent != nil && p.app.CoderAgent.IsSessionBusy(p.session.ID) {
 			cancelBinding := key.NewBinding(
 				key.WithKeys("esc"),
 				key.WithHelp("esc", "cancel"),
@@ -883,6 +1159,14 @@ func (p *chatPage) Help() help.KeyMap {
 					key.WithHelp("ctrl+n", "new sessions"),
 				))
 		}
+		if p.tabs.Visible() {
+			globalBindings = append(globalBindings,
+				p.keyMap.NextTab,
+				p.keyMap.PrevTab,
+				p.keyMap.GoToTab,
+				p.keyMap.CloseTab,
+			)
+		}
 		shortList = append(shortList,
 			// Commands
 			commandsBinding,
@@ -897,6 +1181,8 @@ func (p *chatPage) Help() help.KeyMap {
 					key.WithHelp("↑↓", "scroll"),
 				),
 				messages.CopyKey,
+				messages.ActionsKey,
+				chat.SearchKey,
 			)
 			fullList = append(fullList,
 				[]key.Binding{
@@ -937,8 +1223,16 @@ func (p *chatPage) Help() help.KeyMap {
 				},
 				[]key.Binding{
 					messages.CopyKey,
+					messages.ActionsKey,
+					messages.ViewImagesKey,
 					messages.ClearSelectionKey,
 				},
+				[]key.Binding{
+					chat.SearchKey,
+					chat.NextMatchKey,
+					chat.PrevMatchKey,
+					chat.ToolErrorsKey,
+				},
 			)
 		case PanelTypeEditor:
 			newLineBinding := key.NewBinding(
@@ -957,16 +1251,32 @@ func (p *chatPage) Help() help.KeyMap {
 					newLineBinding,
 					key.NewBinding(
 						key.WithKeys("ctrl+f"),
-						key.WithHelp("ctrl+f", "add image"),
+						key.WithHelp("ctrl+f", "attach file"),
+					),
+					key.NewBinding(
+						key.WithKeys("ctrl+v"),
+						key.WithHelp("ctrl+v", "paste image"),
 					),
 					key.NewBinding(
 						key.WithKeys("/"),
 						key.WithHelp("/", "add file"),
 					),
+					key.NewBinding(
+						key.WithKeys("@"),
+						key.WithHelp("@", "mention"),
+					),
 					key.NewBinding(
 						key.WithKeys("ctrl+o"),
 						key.WithHelp("ctrl+o", "open editor"),
 					),
+					key.NewBinding(
+						key.WithKeys("up", "down"),
+						key.WithHelp("↑↓", "recall prompts"),
+					),
+					key.NewBinding(
+						key.WithKeys("ctrl+x"),
+						key.WithHelp("ctrl+x", "search prompts"),
+					),
 				})
 
 			if p.editor.HasAttachments() {
@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryStart(t *testing.T) {
+	t.Parallel()
+
+	failed := message.Message{ID: "failed", Role: message.Assistant}
+	failed.AddFinish(message.FinishReasonError, "Provider error", "overloaded")
+	msgs := []message.Message{
+		{ID: "prompt1", Role: message.User},
+		{ID: "answer1", Role: message.Assistant},
+		{ID: "prompt2", Role: message.User},
+		{ID: "call", Role: message.Assistant},
+		{ID: "result", Role: message.Tool},
+		failed,
+	}
+
+	tests := []struct {
+		name             string
+		messageID        string
+		summaryMessageID string
+		start            int
+		warning          string
+	}{
+		{"failed turn", "failed", "", 2, ""},
+		{"message in the last turn", "call", "", 2, ""},
+		{"prompt of the last turn", "prompt2", "", 2, ""},
+		{"turn that isn't the last", "answer1", "", 0, "Only the last turn can be retried"},
+		{"summarized turn", "failed", "call", 0, "The session was summarized, this turn can't be retried"},
+		{"summary before the turn", "failed", "answer1", 2, ""},
+		{"unknown message", "missing", "", 0, "Message not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start, warning := retryStart(msgs, tt.messageID, tt.summaryMessageID)
+			require.Equal(t, tt.start, start)
+			require.Equal(t, tt.warning, warning)
+		})
+	}
+
+	t.Run("no prompt", func(t *testing.T) {
+		t.Parallel()
+		_, warning := retryStart(msgs[1:2], "answer1", "")
+		require.Equal(t, "No prompt to retry", warning)
+	})
+}
@@ -10,6 +10,10 @@ type KeyMap struct {
 	Cancel        key.Binding
 	Tab           key.Binding
 	Details       key.Binding
+	NextTab       key.Binding
+	PrevTab       key.Binding
+	GoToTab       key.Binding
+	CloseTab      key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -34,5 +38,21 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+d"),
 			key.WithHelp("ctrl+d", "toggle details"),
 		),
+		NextTab: key.NewBinding(
+			key.WithKeys("ctrl+pgdown", "alt+."),
+			key.WithHelp("alt+.", "next session tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("ctrl+pgup", "alt+,"),
+			key.WithHelp("alt+,", "previous session tab"),
+		),
+		GoToTab: key.NewBinding(
+			key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+			key.WithHelp("alt+1-9", "go to session tab"),
+		),
+		CloseTab: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("alt+w", "close session tab"),
+		),
 	}
 }
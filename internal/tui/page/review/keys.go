@@ -0,0 +1,83 @@
+package review
+
+import (
+	"github.com/charmbracelet/bubbles/v2/key"
+)
+
+type KeyMap struct {
+	Back,
+	NextFile,
+	PreviousFile,
+	NextHunk,
+	PreviousHunk,
+	ExpandContext,
+	ToggleDiffMode,
+	RevertHunk,
+	RevertFile,
+	Reviewed,
+	ScrollDown,
+	ScrollUp,
+	ScrollLeft,
+	ScrollRight key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("esc", "back"),
+		),
+		NextFile: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓", "next file"),
+		),
+		PreviousFile: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑", "previous file"),
+		),
+		NextHunk: key.NewBinding(
+			key.WithKeys("n", "]"),
+			key.WithHelp("n", "next hunk"),
+		),
+		PreviousHunk: key.NewBinding(
+			key.WithKeys("p", "["),
+			key.WithHelp("p", "previous hunk"),
+		),
+		ExpandContext: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "expand context"),
+		),
+		ToggleDiffMode: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle diff mode"),
+		),
+		RevertHunk: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "revert hunk"),
+		),
+		RevertFile: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "revert file"),
+		),
+		Reviewed: key.NewBinding(
+			key.WithKeys("space", "v"),
+			key.WithHelp("space", "mark reviewed"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("shift+down", "J"),
+			key.WithHelp("shift+↓", "scroll down"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("shift+up", "K"),
+			key.WithHelp("shift+↑", "scroll up"),
+		),
+		ScrollLeft: key.NewBinding(
+			key.WithKeys("shift+left", "H"),
+			key.WithHelp("shift+←", "scroll left"),
+		),
+		ScrollRight: key.NewBinding(
+			key.WithKeys("shift+right", "L"),
+			key.WithHelp("shift+→", "scroll right"),
+		),
+	}
+}
@@ -0,0 +1,551 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
+	"github.com/charmbracelet/crush/internal/tui/page"
+	"github.com/charmbracelet/crush/internal/tui/page/chat"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var ReviewPageID page.PageID = "review"
+
+const (
+	FileListMaxWidth = 40 // Maximum width of the file list
+	HeaderHeight     = 2  // Height of the header including the blank line
+)
+
+// errFileChanged is returned when a file was changed outside of the session
+// since its latest version, which reverting would overwrite.
+var errFileChanged = errors.New("the file changed since the last edit of the session")
+
+// ReviewPage shows the changes a session made to files, and lets the user
+// revert them whole or hunk by hunk.
+type ReviewPage interface {
+	util.Model
+	layout.Sizeable
+	layout.Help
+}
+
+// filesLoadedMsg carries the files a session touched.
+type filesLoadedMsg struct {
+	sessionID string
+	files     []reviewFile
+}
+
+// reviewFile is a file touched by the session, with its version from before
+// the session and its latest one.
+type reviewFile struct {
+	initial   history.File
+	latest    history.File
+	additions int
+	deletions int
+}
+
+func (f reviewFile) path() string {
+	return f.initial.Path
+}
+
+// createdBySession reports whether the file didn't exist before the session.
+func (f reviewFile) createdBySession() bool {
+	return f.initial.New
+}
+
+func newReviewFile(initial, latest history.File) reviewFile {
+	before, _ := fsext.ToUnixLineEndings(initial.Content)
+	after, _ := fsext.ToUnixLineEndings(latest.Content)
+	_, additions, deletions := diff.GenerateDiff(before, after, initial.Path)
+	return reviewFile{
+		initial:   initial,
+		latest:    latest,
+		additions: additions,
+		deletions: deletions,
+	}
+}
+
+type reviewPage struct {
+	width, height int
+	history       history.Service
+	keyMap        KeyMap
+
+	sessionID string
+	files     []reviewFile
+	selected  int
+	// reviewed holds the version of each file that was marked reviewed, so
+	// that later changes need reviewing again.
+	reviewed map[string]int64
+	// expanded holds the unchanged regions of the selected file that are
+	// shown, by fold index.
+	expanded map[int]bool
+
+	split   *bool // nil means split when the page is wide enough
+	hunk    int
+	xOffset int
+	yOffset int
+}
+
+func New(history history.Service) ReviewPage {
+	return &reviewPage{
+		history:  history,
+		keyMap:   DefaultKeyMap(),
+		reviewed: make(map[string]int64),
+		expanded: make(map[int]bool),
+	}
+}
+
+func (p *reviewPage) Init() tea.Cmd {
+	return nil
+}
+
+func (p *reviewPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return p, p.SetSize(msg.Width, msg.Height)
+	case commands.ReviewChangesMsg:
+		if msg.SessionID != p.sessionID {
+			p.sessionID = msg.SessionID
+			p.files = nil
+			p.selected = 0
+			p.reviewed = make(map[string]int64)
+			p.resetScroll()
+		}
+		return p, p.loadFiles(p.sessionID)
+	case filesLoadedMsg:
+		if msg.sessionID != p.sessionID {
+			return p, nil
+		}
+		p.files = msg.files
+		p.selected = min(p.selected, max(0, len(p.files)-1))
+		p.hunk = min(p.hunk, max(0, p.hunkCount()-1))
+		return p, nil
+	case pubsub.Event[history.File]:
+		if msg.Payload.SessionID == p.sessionID {
+			p.updateFile(msg.Payload)
+		}
+		return p, nil
+	case tea.MouseWheelMsg:
+		switch msg.Button {
+		case tea.MouseWheelDown:
+			p.yOffset++
+		case tea.MouseWheelUp:
+			p.yOffset = max(0, p.yOffset-1)
+		}
+		return p, nil
+	case tea.KeyPressMsg:
+		return p, p.handleKeyPress(msg)
+	}
+	return p, nil
+}
+
+func (p *reviewPage) handleKeyPress(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, p.keyMap.Back):
+		return util.CmdHandler(page.PageChangeMsg{ID: chat.ChatPageID})
+	case key.Matches(msg, p.keyMap.NextFile):
+		if p.selected < len(p.files)-1 {
+			p.selected++
+			p.resetScroll()
+		}
+	case key.Matches(msg, p.keyMap.PreviousFile):
+		if p.selected > 0 {
+			p.selected--
+			p.resetScroll()
+		}
+	case key.Matches(msg, p.keyMap.NextHunk):
+		p.moveToHunk(p.hunk + 1)
+	case key.Matches(msg, p.keyMap.PreviousHunk):
+		p.moveToHunk(p.hunk - 1)
+	case key.Matches(msg, p.keyMap.ToggleDiffMode):
+		split := !p.useSplit()
+		p.split = &split
+		p.moveToHunk(p.hunk)
+	case key.Matches(msg, p.keyMap.ExpandContext):
+		if p.hunkCount() > 0 {
+			p.expanded[p.hunk] = true
+			p.expanded[p.hunk+1] = true
+			p.moveToHunk(p.hunk)
+		}
+	case key.Matches(msg, p.keyMap.ScrollDown):
+		p.yOffset++
+	case key.Matches(msg, p.keyMap.ScrollUp):
+		p.yOffset = max(0, p.yOffset-1)
+	case key.Matches(msg, p.keyMap.ScrollRight):
+		p.xOffset += 5
+	case key.Matches(msg, p.keyMap.ScrollLeft):
+		p.xOffset = max(0, p.xOffset-5)
+	case key.Matches(msg, p.keyMap.Reviewed):
+		if file, ok := p.selectedFile(); ok {
+			if p.isReviewed(file) {
+				delete(p.reviewed, file.path())
+			} else {
+				p.reviewed[file.path()] = file.latest.Version
+				if p.selected < len(p.files)-1 {
+					p.selected++
+					p.resetScroll()
+				}
+			}
+		}
+	case key.Matches(msg, p.keyMap.RevertHunk):
+		if file, ok := p.selectedFile(); ok && p.hunkCount() > 0 {
+			return p.revertHunk(file, p.hunk)
+		}
+	case key.Matches(msg, p.keyMap.RevertFile):
+		if file, ok := p.selectedFile(); ok && file.initial.Content != file.latest.Content {
+			return p.revertFile(file)
+		}
+	}
+	return nil
+}
+
+// loadFiles lists the files of the session with their first and latest
+// versions. The session is passed in, as the page can move to another one
+// while they load.
+func (p *reviewPage) loadFiles(sessionID string) tea.Cmd {
+	service := p.history
+	return func() tea.Msg {
+		versions, err := service.ListBySession(context.Background(), sessionID)
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+
+		initial := make(map[string]history.File)
+		latest := make(map[string]history.File)
+		for _, version := range versions {
+			if existing, ok := initial[version.Path]; !ok || version.Version < existing.Version {
+				initial[version.Path] = version
+			}
+			if existing, ok := latest[version.Path]; !ok || version.Version > existing.Version {
+				latest[version.Path] = version
+			}
+		}
+
+		files := make([]reviewFile, 0, len(initial))
+		for path, first := range initial {
+			files = append(files, newReviewFile(first, latest[path]))
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].path() < files[j].path()
+		})
+		return filesLoadedMsg{sessionID: sessionID, files: files}
+	}
+}
+
+// updateFile applies a new version of a file, which is a change made by the
+// agent or a revert.
+func (p *reviewPage) updateFile(version history.File) {
+	for i, file := range p.files {
+		if file.path() != version.Path {
+			continue
+		}
+		if version.Version > file.latest.Version {
+			p.files[i] = newReviewFile(file.initial, version)
+			if i == p.selected {
+				p.hunk = min(p.hunk, max(0, p.hunkCount()-1))
+			}
+		}
+		return
+	}
+	p.files = append(p.files, newReviewFile(version, version))
+	sort.SliceStable(p.files, func(i, j int) bool {
+		return p.files[i].path() < p.files[j].path()
+	})
+}
+
+// revertHunk undoes a hunk of the file, writing the result as a new version.
+func (p *reviewPage) revertHunk(file reviewFile, hunk int) tea.Cmd {
+	return func() tea.Msg {
+		content, err := diff.RevertHunk(file.initial.Content, file.latest.Content, hunk, core.DiffTabWidth)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		if err := p.writeVersion(file, content); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeInfo,
+			Msg:  fmt.Sprintf("Reverted hunk %d of %s", hunk+1, p.displayPath(file.path())),
+		}
+	}
+}
+
+// revertFile restores the file as it was before the session.
+func (p *reviewPage) revertFile(file reviewFile) tea.Cmd {
+	return func() tea.Msg {
+		if err := p.writeVersion(file, file.initial.Content); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeInfo,
+			Msg:  "Reverted " + p.displayPath(file.path()),
+		}
+	}
+}
+
+// writeVersion writes the content to the file and records it in the history
+// of the session. The file must not have changed since its latest version.
+// Files the session created are removed once nothing of them is left.
+func (p *reviewPage) writeVersion(file reviewFile, content string) error {
+	path := file.path()
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if string(current) != file.latest.Content {
+		return fmt.Errorf("failed to revert %s: %w", p.displayPath(path), errFileChanged)
+	}
+
+	if content == "" && file.createdBySession() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	} else {
+		mode := os.FileMode(0o644)
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	if _, err := p.history.CreateVersion(context.Background(), file.latest.SessionID, path, content); err != nil {
+		return fmt.Errorf("failed to record the reverted version of %s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *reviewPage) selectedFile() (reviewFile, bool) {
+	if p.selected < 0 || p.selected >= len(p.files) {
+		return reviewFile{}, false
+	}
+	return p.files[p.selected], true
+}
+
+func (p *reviewPage) isReviewed(file reviewFile) bool {
+	version, ok := p.reviewed[file.path()]
+	return ok && version == file.latest.Version
+}
+
+func (p *reviewPage) resetScroll() {
+	p.hunk = 0
+	p.expanded = make(map[int]bool)
+	p.xOffset = 0
+	p.yOffset = 0
+}
+
+func (p *reviewPage) useSplit() bool {
+	if p.split != nil {
+		return *p.split
+	}
+	switch config.Get().Options.TUI.DiffMode {
+	case "split":
+		return true
+	case "unified":
+		return false
+	}
+	return p.diffWidth() >= 140
+}
+
+// moveToHunk selects a hunk of the selected file and scrolls to it.
+func (p *reviewPage) moveToHunk(hunk int) {
+	file, ok := p.selectedFile()
+	if !ok {
+		return
+	}
+	offsets := p.diffView(file).HunkOffsets()
+	if len(offsets) == 0 {
+		return
+	}
+	p.hunk = max(0, min(hunk, len(offsets)-1))
+	p.yOffset = offsets[p.hunk]
+}
+
+func (p *reviewPage) hunkCount() int {
+	file, ok := p.selectedFile()
+	if !ok {
+		return 0
+	}
+	return len(p.diffView(file).HunkOffsets())
+}
+
+func (p *reviewPage) diffView(file reviewFile) *diffview.DiffView {
+	path := fsext.PrettyPath(file.path())
+	dv := core.DiffFormatter().
+		Before(path, file.initial.Content).
+		After(path, file.latest.Content).
+		Width(p.diffWidth()).
+		Height(p.height - HeaderHeight).
+		XOffset(p.xOffset).
+		YOffset(p.yOffset).
+		FoldUnchanged(true)
+	for fold := range p.expanded {
+		dv = dv.ExpandFold(fold)
+	}
+	if p.useSplit() {
+		return dv.Split()
+	}
+	return dv.Unified()
+}
+
+func (p *reviewPage) fileListWidth() int {
+	return min(FileListMaxWidth, p.width/3)
+}
+
+func (p *reviewPage) diffWidth() int {
+	return p.width - p.fileListWidth() - 1
+}
+
+func (p *reviewPage) displayPath(path string) string {
+	if cfg := config.Get(); cfg != nil {
+		if rel, err := filepath.Rel(cfg.WorkingDir(), path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return fsext.PrettyPath(path)
+}
+
+func (p *reviewPage) View() string {
+	t := styles.CurrentTheme()
+
+	reviewed := 0
+	for _, file := range p.files {
+		if p.isReviewed(file) {
+			reviewed++
+		}
+	}
+	info := fmt.Sprintf("%d/%d reviewed", reviewed, len(p.files))
+	if count := p.hunkCount(); count > 0 {
+		info = fmt.Sprintf("hunk %d/%d · %s", p.hunk+1, count, info)
+	}
+	header := core.SectionWithInfo(t.S().Title.Render("Review Changes"), p.width-2, t.S().Subtle.Render(info))
+
+	var body string
+	file, ok := p.selectedFile()
+	if !ok {
+		body = t.S().Muted.Render("No files changed in this session")
+	} else {
+		listWidth := p.fileListWidth()
+		separator := t.S().Base.Foreground(t.Border).Render(
+			strings.TrimSuffix(strings.Repeat(styles.BorderThin+"\n", max(0, p.height-HeaderHeight)), "\n"),
+		)
+		diff := p.diffView(file).String()
+		if file.initial.Content == file.latest.Content {
+			diff = t.S().Muted.Width(p.diffWidth()).Render(" No changes left in this file")
+		}
+		body = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			t.S().Base.Width(listWidth).Height(p.height-HeaderHeight).Render(p.renderFileList(listWidth)),
+			separator,
+			diff,
+		)
+	}
+
+	return t.S().Base.Width(p.width).Height(p.height).Render(
+		lipgloss.JoinVertical(lipgloss.Left, " "+header, "", body),
+	)
+}
+
+// renderFileList renders the files, keeping the selected one in view.
+func (p *reviewPage) renderFileList(width int) string {
+	t := styles.CurrentTheme()
+	height := max(1, p.height-HeaderHeight)
+	start := max(0, min(p.selected-height/2, len(p.files)-height))
+	end := min(len(p.files), start+height)
+
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		file := p.files[i]
+		mark := "  "
+		if p.isReviewed(file) {
+			mark = t.S().Base.Foreground(t.Success).Render(styles.CheckIcon) + " "
+		}
+		var stats []string
+		if file.additions > 0 {
+			stats = append(stats, t.S().Base.Foreground(t.Success).Render(fmt.Sprintf("+%d", file.additions)))
+		}
+		if file.deletions > 0 {
+			stats = append(stats, t.S().Base.Foreground(t.Error).Render(fmt.Sprintf("-%d", file.deletions)))
+		}
+		stat := strings.Join(stats, " ")
+		pathWidth := width - lipgloss.Width(mark) - lipgloss.Width(stat) - 2
+		path := ansi.Truncate(p.displayPath(file.path()), max(1, pathWidth), "…")
+
+		style := t.S().Text
+		if i == p.selected {
+			style = t.S().TextSelected
+		}
+		gap := strings.Repeat(" ", max(1, width-lipgloss.Width(mark)-lipgloss.Width(path)-lipgloss.Width(stat)-1))
+		lines = append(lines, mark+style.Render(path)+gap+stat)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *reviewPage) SetSize(width, height int) tea.Cmd {
+	p.width = width
+	p.height = height
+	return nil
+}
+
+func (p *reviewPage) GetSize() (int, int) {
+	return p.width, p.height
+}
+
+func (p *reviewPage) Bindings() []key.Binding {
+	return []key.Binding{
+		p.keyMap.NextFile,
+		p.keyMap.PreviousFile,
+		p.keyMap.NextHunk,
+		p.keyMap.PreviousHunk,
+		p.keyMap.ExpandContext,
+		p.keyMap.Reviewed,
+		p.keyMap.RevertHunk,
+		p.keyMap.RevertFile,
+		p.keyMap.ToggleDiffMode,
+		p.keyMap.Back,
+	}
+}
+
+func (p *reviewPage) Help() help.KeyMap {
+	shortList := []key.Binding{
+		p.keyMap.NextHunk,
+		p.keyMap.Reviewed,
+		p.keyMap.RevertHunk,
+		p.keyMap.RevertFile,
+		p.keyMap.ToggleDiffMode,
+		p.keyMap.Back,
+	}
+	fullList := [][]key.Binding{
+		{p.keyMap.NextFile, p.keyMap.PreviousFile},
+		{p.keyMap.NextHunk, p.keyMap.PreviousHunk, p.keyMap.ExpandContext},
+		{p.keyMap.Reviewed, p.keyMap.RevertHunk, p.keyMap.RevertFile},
+		{p.keyMap.ScrollDown, p.keyMap.ScrollUp, p.keyMap.ScrollLeft, p.keyMap.ScrollRight},
+		{p.keyMap.ToggleDiffMode, p.keyMap.Back},
+	}
+	return core.NewSimpleHelp(shortList, fullList)
+}
@@ -0,0 +1,143 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPage returns a review page along with the history of a new session.
+func newTestPage(t *testing.T) (*reviewPage, history.Service, string) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sess, err := session.NewService(q).Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	files := history.NewService(q, conn)
+	p := New(files).(*reviewPage)
+	p.sessionID = sess.ID
+	// The diff mode comes from the configuration otherwise.
+	split := false
+	p.split = &split
+	return p, files, sess.ID
+}
+
+// editFile records an edit of the session, the file being created by it
+// when before is nil.
+func editFile(t *testing.T, files history.Service, sessionID, path string, before *string, after string) {
+	t.Helper()
+	if before == nil {
+		_, err := files.CreateNew(t.Context(), sessionID, path)
+		require.NoError(t, err)
+	} else {
+		require.NoError(t, os.WriteFile(path, []byte(*before), 0o644))
+		_, err := files.Create(t.Context(), sessionID, path, *before)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(after), 0o644))
+	_, err := files.CreateVersion(t.Context(), sessionID, path, after)
+	require.NoError(t, err)
+}
+
+// load loads the files of the session in the page and returns the one at
+// path.
+func load(t *testing.T, p *reviewPage, path string) reviewFile {
+	t.Helper()
+	p.Update(p.loadFiles(p.sessionID)())
+	for _, file := range p.files {
+		if file.path() == path {
+			return file
+		}
+	}
+	t.Fatalf("%s not loaded", path)
+	return reviewFile{}
+}
+
+func TestRevertFile(t *testing.T) {
+	t.Parallel()
+
+	p, files, sessionID := newTestPage(t)
+	dir := t.TempDir()
+
+	t.Run("file created by the session is removed", func(t *testing.T) {
+		path := filepath.Join(dir, "created.txt")
+		editFile(t, files, sessionID, path, nil, "new\n")
+
+		msg := p.revertFile(load(t, p, path))()
+		require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type)
+		require.NoFileExists(t, path)
+	})
+
+	t.Run("empty file is emptied", func(t *testing.T) {
+		path := filepath.Join(dir, "empty.txt")
+		empty := ""
+		editFile(t, files, sessionID, path, &empty, "content\n")
+
+		msg := p.revertFile(load(t, p, path))()
+		require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Empty(t, content)
+
+		latest, err := files.GetByPathAndSession(t.Context(), path, sessionID)
+		require.NoError(t, err)
+		require.Empty(t, latest.Content)
+	})
+
+	t.Run("file changed since is left alone", func(t *testing.T) {
+		path := filepath.Join(dir, "changed.txt")
+		before := "before\n"
+		editFile(t, files, sessionID, path, &before, "after\n")
+		require.NoError(t, os.WriteFile(path, []byte("by hand\n"), 0o644))
+
+		msg := p.revertFile(load(t, p, path))()
+		require.Equal(t, util.InfoTypeError, msg.(util.InfoMsg).Type)
+		require.Contains(t, msg.(util.InfoMsg).Msg, errFileChanged.Error())
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "by hand\n", string(content))
+	})
+}
+
+func TestRevertHunk(t *testing.T) {
+	t.Parallel()
+
+	p, files, sessionID := newTestPage(t)
+	path := filepath.Join(t.TempDir(), "lines.txt")
+
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	before := strings.Join(lines, "\n") + "\n"
+	lines[1], lines[17] = "changed 2", "changed 18"
+	after := strings.Join(lines, "\n") + "\n"
+	editFile(t, files, sessionID, path, &before, after)
+
+	file := load(t, p, path)
+	p.selected = 0
+	require.Equal(t, 2, p.hunkCount())
+
+	msg := p.revertHunk(file, 1)()
+	require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines[17] = "line 18"
+	require.Equal(t, strings.Join(lines, "\n")+"\n", string(content))
+
+	// The reverted version is the latest of the session.
+	latest, err := files.GetByPathAndSession(t.Context(), path, sessionID)
+	require.NoError(t, err)
+	require.Equal(t, string(content), latest.Content)
+}
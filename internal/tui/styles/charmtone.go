@@ -60,6 +60,9 @@ func NewCharmtoneTheme() *Theme {
 	// Text selection.
 	t.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(charmtone.Charple)
 
+	// Search matches.
+	t.SearchMatch = lipgloss.NewStyle().Foreground(charmtone.Pepper).Background(charmtone.Citron)
+
 	// LSP and MCP status.
 	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(charmtone.Squid).SetString("●")
 	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(charmtone.Citron)
@@ -71,5 +74,10 @@ func NewCharmtoneTheme() *Theme {
 	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(charmtone.Zest).SetString(":::")
 	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(charmtone.Squid)
 
+	t.PlanIconFocused = lipgloss.NewStyle().Foreground(charmtone.Pepper).Background(charmtone.Malibu).Bold(true).SetString(" P ")
+	t.PlanIconBlurred = t.PlanIconFocused.Background(charmtone.Squid)
+	t.PlanDotsFocused = lipgloss.NewStyle().Foreground(charmtone.Malibu).SetString(":::")
+	t.PlanDotsBlurred = t.PlanDotsFocused.Foreground(charmtone.Squid)
+
 	return t
 }
@@ -1,5 +1,7 @@
 package styles
 
+import "strings"
+
 const (
 	CheckIcon    string = "✓"
 	ErrorIcon    string = "×"
@@ -9,6 +11,7 @@ const (
 	SpinnerIcon  string = "..."
 	LoadingIcon  string = "⟳"
 	DocumentIcon string = "🖼"
+	FileIcon     string = "📄"
 	ModelIcon    string = "◇"
 
 	// Tool call icons
@@ -39,3 +42,12 @@ var SelectionIgnoreIcons = []string{
 	BorderThin,
 	BorderThick,
 }
+
+// AttachmentIcon returns the icon of an attachment: DocumentIcon for images
+// and FileIcon for PDFs and text files.
+func AttachmentIcon(mimeType string) string {
+	if strings.HasPrefix(mimeType, "image/") {
+		return DocumentIcon
+	}
+	return FileIcon
+}
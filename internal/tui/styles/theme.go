@@ -78,6 +78,9 @@ type Theme struct {
 	// Text selection.
 	TextSelection lipgloss.Style
 
+	// Search matches.
+	SearchMatch lipgloss.Style
+
 	// LSP and MCP status indicators.
 	ItemOfflineIcon lipgloss.Style
 	ItemBusyIcon    lipgloss.Style
@@ -90,6 +93,12 @@ type Theme struct {
 	YoloDotsFocused lipgloss.Style
 	YoloDotsBlurred lipgloss.Style
 
+	// Editor: Plan Mode
+	PlanIconFocused lipgloss.Style
+	PlanIconBlurred lipgloss.Style
+	PlanDotsFocused lipgloss.Style
+	PlanDotsBlurred lipgloss.Style
+
 	styles *Styles
 }
 
@@ -460,6 +469,8 @@ func (t *Theme) buildStyles() *Styles {
 					Background(lipgloss.Color("#323931")),
 				Code: lipgloss.NewStyle().
 					Background(lipgloss.Color("#323931")),
+				Emphasis: lipgloss.NewStyle().
+					Background(lipgloss.Color("#3e5239")),
 			},
 			DeleteLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
@@ -470,6 +481,8 @@ func (t *Theme) buildStyles() *Styles {
 					Background(lipgloss.Color("#383030")),
 				Code: lipgloss.NewStyle().
 					Background(lipgloss.Color("#383030")),
+				Emphasis: lipgloss.NewStyle().
+					Background(lipgloss.Color("#573939")),
 			},
 		},
 		FilePicker: filepicker.Styles{
@@ -2,7 +2,10 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,9 +13,12 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/llm/agent"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
 	cmpChat "github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/splash"
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
@@ -20,18 +26,24 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/core/status"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/agents"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/compact"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/plan"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
+	"github.com/charmbracelet/crush/internal/tui/notification"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/page/chat"
+	"github.com/charmbracelet/crush/internal/tui/page/review"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
 )
 
 var lastMouseEvent time.Time
@@ -72,6 +84,24 @@ type appModel struct {
 
 	// Chat Page Specific
 	selectedSessionID string // The ID of the currently selected session
+
+	// Permission requests of sessions running in the background wait here
+	// until their session is selected, only one dialog is shown at a time.
+	pendingPermissions []sessionPermission
+	shownPermission    string // The ID of the request in the permission dialog
+
+	notifier *notification.Notifier
+
+	// images draws sixel and iTerm2 images over the chat.
+	images image.Overlay
+}
+
+// sessionPermission is a permission request along with the session it's shown
+// in, which is the parent session for the requests of sub-agents.
+type sessionPermission struct {
+	request   permission.PermissionRequest
+	sessionID string
+	title     string
 }
 
 // Init initializes the application model and returns initial commands.
@@ -85,12 +115,27 @@ func (a appModel) Init() tea.Cmd {
 	cmds = append(cmds, cmd)
 
 	cmds = append(cmds, tea.EnableMouseAllMotion)
+	// Notifications are sent right away while the terminal is unfocused.
+	cmds = append(cmds, tea.EnableReportFocus)
+	// Ask the terminal how it draws images, unless it's set.
+	if cfg := config.Get(); cfg.Options.TUI.Images == config.ImageModeAuto {
+		cmds = append(cmds, tea.Raw(image.Query))
+	}
 
 	return tea.Batch(cmds...)
 }
 
 // Update handles incoming messages and updates the application state.
 func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(image.DrawMsg); ok {
+		return a, a.images.Update(msg)
+	}
+	m, cmd := a.update(msg)
+	// Images drawn over the chat are checked once the new frame is drawn.
+	return m, tea.Batch(cmd, a.images.Schedule())
+}
+
+func (a *appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 	a.isConfigured = config.HasInitialDataConfig()
@@ -105,6 +150,18 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		return a, tea.Batch(cmds...)
+	case tea.FocusMsg:
+		a.notifier.SetFocused(true)
+		return a, nil
+	case tea.BlurMsg:
+		a.notifier.SetFocused(false)
+		return a, nil
+	case uv.KittyGraphicsEvent:
+		image.DetectFromKittyResponse(msg.Options, msg.Payload)
+		return a, nil
+	case uv.PrimaryDeviceAttributesEvent:
+		image.DetectFromDeviceAttributes(msg)
+		return a, nil
 	case tea.WindowSizeMsg:
 		a.wWidth, a.wHeight = msg.Width, msg.Height
 		a.completions.Update(msg)
@@ -131,6 +188,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					msg.CommandID,
 					msg.Content,
 					msg.ArgNames,
+					msg.Run,
 				),
 			},
 		)
@@ -148,8 +206,11 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Session
 	case cmpChat.SessionSelectedMsg:
 		a.selectedSessionID = msg.ID
+		a.status.SetSession(msg)
+		cmds = append(cmds, a.showNextPermission())
 	case cmpChat.SessionClearedMsg:
 		a.selectedSessionID = ""
+		a.status.SetSession(session.Session{})
 	// Commands
 	case commands.SwitchSessionsMsg:
 		return a, func() tea.Msg {
@@ -165,11 +226,66 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Model: models.NewModelDialogCmp(),
 			},
 		)
+	case commands.SwitchAgentMsg:
+		cfg := config.Get()
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: agents.NewAgentDialogCmp(
+					append([]config.Agent{cfg.Agents["coder"]}, cfg.UserAgents()...),
+					cfg.PrimaryAgent().ID,
+				),
+			},
+		)
+	// Agent Switch
+	case agents.AgentSelectedMsg:
+		if err := a.app.SetPrimaryAgent(msg.Agent.ID); err != nil {
+			if errors.Is(err, agent.ErrSessionBusy) {
+				return a, util.ReportWarn("Agent is busy, please wait...")
+			}
+			return a, util.ReportError(err)
+		}
+		return a, util.ReportInfo(fmt.Sprintf("Switched to the %s agent", msg.Agent.Name))
 	// Compact
 	case commands.CompactMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: compact.NewCompactDialogCmp(a.app.CoderAgent, msg.SessionID, true),
 		})
+	// Review
+	case commands.ReviewChangesMsg:
+		cmd := a.moveToPage(review.ReviewPageID)
+		if a.currentPage != review.ReviewPageID {
+			return a, cmd
+		}
+		updated, pageCmd := a.pages[a.currentPage].Update(msg)
+		a.pages[a.currentPage] = updated.(util.Model)
+		return a, tea.Batch(cmd, pageCmd)
+	// Images are only drawn by the chat page, which lays them out even while
+	// another page is shown.
+	case image.LaidOutMsg:
+		updated, pageCmd := a.pages[chat.ChatPageID].Update(msg)
+		a.pages[chat.ChatPageID] = updated.(util.Model)
+		return a, pageCmd
+	// File history is shown by both the chat and the review pages.
+	case pubsub.Event[history.File]:
+		for id, page := range a.pages {
+			m, pageCmd := page.Update(msg)
+			a.pages[id] = m.(util.Model)
+			if pageCmd != nil {
+				cmds = append(cmds, pageCmd)
+			}
+		}
+		return a, tea.Batch(cmds...)
+	// MCP Restart
+	case commands.RestartMCPMsg:
+		return a, tea.Sequence(
+			util.ReportInfo(fmt.Sprintf("Restarting the %s MCP server...", msg.Name)),
+			func() tea.Msg {
+				if err := agent.RestartMCPClient(context.Background(), msg.Name); err != nil {
+					return util.ReportError(fmt.Errorf("failed to restart the %s MCP server: %w", msg.Name, err))()
+				}
+				return util.ReportInfo(fmt.Sprintf("Restarted the %s MCP server", msg.Name))()
+			},
+		)
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
@@ -219,11 +335,21 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.pages[a.currentPage] = updated.(util.Model)
 		return a, itemCmd
 	case pubsub.Event[permission.PermissionRequest]:
-		return a, util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
-				DiffMode: config.Get().Options.TUI.DiffMode,
-			}),
-		})
+		return a, tea.Batch(
+			a.routePermission(msg.Payload),
+			a.notifier.PermissionRequested(msg.Payload.SessionID, msg.Payload.ToolName),
+		)
+	case sessionPermission:
+		a.pendingPermissions = append(a.pendingPermissions, msg)
+		cmd := a.showNextPermission()
+		if a.shownPermission == msg.request.ID {
+			return a, cmd
+		}
+		warning := "A session in the background is waiting for a permission"
+		if msg.title != "" {
+			warning = fmt.Sprintf("“%s” is waiting for a permission", msg.title)
+		}
+		return a, tea.Batch(cmd, util.ReportWarn(warning))
 	case permissions.PermissionResponseMsg:
 		switch msg.Action {
 		case permissions.PermissionAllow:
@@ -233,7 +359,10 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
-		return a, nil
+		if a.shownPermission == msg.Permission.ID {
+			a.shownPermission = ""
+		}
+		return a, a.showNextPermission()
 	// Agent Events
 	case pubsub.Event[agent.AgentEvent]:
 		payload := msg.Payload
@@ -245,19 +374,37 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, dialogCmd)
 		}
 
-		// Handle auto-compact logic
-		if payload.Done && payload.Type == agent.AgentEventTypeResponse && a.selectedSessionID != "" {
-			// Get current session to check token usage
-			session, err := a.app.Sessions.Get(context.Background(), a.selectedSessionID)
-			if err == nil {
-				model := a.app.CoderAgent.Model()
-				contextWindow := model.ContextWindow
-				tokens := session.CompletionTokens + session.PromptTokens
-				if (tokens >= int64(float64(contextWindow)*0.95)) && !config.Get().Options.DisableAutoSummarize { // Show compact confirmation dialog
-					cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
-						Model: compact.NewCompactDialogCmp(a.app.CoderAgent, a.selectedSessionID, false),
-					}))
-				}
+		// Automatic compaction is driven by the agent, surface its progress
+		// when the compact dialog is not there to show it.
+		if payload.Type == agent.AgentEventTypeSummarize && payload.SessionID == a.selectedSessionID &&
+			a.dialog.ActiveDialogID() != compact.CompactDialogID {
+			cmds = append(cmds, util.ReportInfo(payload.Progress))
+		}
+
+		// The chat page follows the runs of the sessions in the background.
+		updated, pageCmd := a.pages[chat.ChatPageID].Update(msg)
+		a.pages[chat.ChatPageID] = updated.(util.Model)
+		cmds = append(cmds, pageCmd)
+
+		switch {
+		case payload.SessionID == "":
+		case payload.Type == agent.AgentEventTypeResponse && payload.Done:
+			cmds = append(cmds, a.notifier.TurnFinished(payload.SessionID, nil))
+		case payload.Type == agent.AgentEventTypeError &&
+			(errors.Is(payload.Error, agent.ErrRequestCancelled) || errors.Is(payload.Error, context.Canceled)):
+			a.notifier.TurnCancelled(payload.SessionID)
+		case payload.Type == agent.AgentEventTypeError:
+			cmds = append(cmds, a.notifier.TurnFinished(payload.SessionID, payload.Error))
+		}
+
+		// A finished turn in plan mode is the plan, ask the user to review it.
+		if payload.Type == agent.AgentEventTypeResponse && payload.Done &&
+			payload.Message.SessionID == a.selectedSessionID &&
+			a.app.CoderAgent.IsPlanMode(payload.Message.SessionID) {
+			if content := strings.TrimSpace(payload.Message.Content().Text); content != "" {
+				cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
+					Model: plan.NewPlanDialogCmp(payload.Message.SessionID, content),
+				}))
 			}
 		}
 
@@ -310,6 +457,14 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, tea.Batch(cmds...)
 	}
+
+	// A message of the user starts a turn, which is notified about when it
+	// finishes.
+	if event, ok := msg.(pubsub.Event[message.Message]); ok &&
+		event.Type == pubsub.CreatedEvent && event.Payload.Role == message.User {
+		a.notifier.TurnStarted(event.Payload.SessionID)
+	}
+
 	s, _ := a.status.Update(msg)
 	a.status = s.(status.StatusCmp)
 
@@ -329,6 +484,52 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
+// routePermission finds the session a permission request is shown in, going
+// up from the sessions of sub-agents to the session that started them.
+func (a *appModel) routePermission(request permission.PermissionRequest) tea.Cmd {
+	return func() tea.Msg {
+		routed := sessionPermission{request: request, sessionID: request.SessionID}
+		for id := request.SessionID; id != ""; {
+			s, err := a.app.Sessions.Get(context.Background(), id)
+			if err != nil {
+				break
+			}
+			routed.sessionID, routed.title = s.ID, s.Title
+			id = s.ParentSessionID
+		}
+		return routed
+	}
+}
+
+// showNextPermission opens the dialog of the first waiting permission request
+// of the selected session, and updates the badges of the other sessions.
+func (a *appModel) showNextPermission() tea.Cmd {
+	var cmd tea.Cmd
+	if a.shownPermission == "" {
+		for i, pending := range a.pendingPermissions {
+			if pending.sessionID != a.selectedSessionID {
+				continue
+			}
+			a.pendingPermissions = slices.Delete(a.pendingPermissions, i, i+1)
+			a.shownPermission = pending.request.ID
+			cmd = util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: permissions.NewPermissionDialogCmp(pending.request, &permissions.Options{
+					DiffMode: config.Get().Options.TUI.DiffMode,
+				}),
+			})
+			break
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, pending := range a.pendingPermissions {
+		counts[pending.sessionID]++
+	}
+	updated, pageCmd := a.pages[chat.ChatPageID].Update(chat.PendingPermissionsMsg{Counts: counts})
+	a.pages[chat.ChatPageID] = updated.(util.Model)
+	return tea.Batch(cmd, pageCmd)
+}
+
 // handleWindowResize processes window resize events and updates all components.
 func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 	var cmds []tea.Cmd
@@ -478,6 +679,7 @@ func (a *appModel) View() tea.View {
 	t := styles.CurrentTheme()
 	view.BackgroundColor = t.BgBase
 	if a.wWidth < 25 || a.wHeight < 15 {
+		a.images.SetFrame("")
 		view.Layer = lipgloss.NewCanvas(
 			lipgloss.NewLayer(
 				t.S().Base.Width(a.wWidth).Height(a.wHeight).
@@ -506,6 +708,12 @@ func (a *appModel) View() tea.View {
 	components = append(components, a.status.View())
 
 	appView := lipgloss.JoinVertical(lipgloss.Top, components...)
+	if a.dialog.HasDialogs() || a.completions.Open() {
+		// Images would be drawn over the dialog.
+		a.images.SetFrame("")
+	} else {
+		a.images.SetFrame(appView)
+	}
 	layers := []*lipgloss.Layer{
 		lipgloss.NewLayer(appView),
 	}
@@ -553,6 +761,7 @@ func (a *appModel) View() tea.View {
 
 // New creates and initializes a new TUI application model.
 func New(app *app.App) tea.Model {
+	image.DetectFromEnv(os.Getenv)
 	chatPage := chat.New(app)
 	keyMap := DefaultKeyMap()
 	keyMap.pageBindings = chatPage.Bindings()
@@ -565,11 +774,15 @@ func New(app *app.App) tea.Model {
 		keyMap:      keyMap,
 
 		pages: map[page.PageID]util.Model{
-			chat.ChatPageID: chatPage,
+			chat.ChatPageID:     chatPage,
+			review.ReviewPageID: review.New(app.History),
 		},
 
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
+		notifier: notification.New(app.Sessions, func(sessionID string) bool {
+			return app.CoderAgent != nil && app.CoderAgent.IsSessionBusy(sessionID)
+		}),
 	}
 
 	return model
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// OpenEditor opens the content in $EDITOR, in a temporary file named after
+// pattern like os.CreateTemp does. Once the editor exits, done is called
+// with the content of the file, which is then removed. A nil done doesn't
+// read the content back.
+func OpenEditor(pattern, content string, done func(edited string) tea.Msg) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		// Use platform-appropriate default editor
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "nvim"
+		}
+	}
+
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(content); err != nil {
+		return ReportError(err)
+	}
+	c := exec.CommandContext(context.TODO(), editor, tmpfile.Name())
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name())
+		if err != nil {
+			return ReportError(err)()
+		}
+		if done == nil {
+			return nil
+		}
+		edited, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return ReportError(err)()
+		}
+		return done(string(edited))
+	})
+}